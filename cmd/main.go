@@ -2,24 +2,103 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/digest"
+	"github.com/akazantzidis/gwi-ass/internal/app/relay"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
 	"github.com/akazantzidis/gwi-ass/internal/infra"
+	"github.com/akazantzidis/gwi-ass/internal/infra/janitor"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/config"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
 	"log"
+	_ "time/tzdata" // quiet hours are in the users' time zones, the container image has no zoneinfo
 )
 
 func main() {
-	infraProviders := infra.NewInfraProviders()
+	cfg := config.Load()
+	infraProviders := infra.NewInfraProviders(cfg)
 	tp := time.NewTimeProvider()
 	up := uuid.NewUUIDProvider()
 
 	seedInitialUsers(infraProviders)
 
-	appServices := app.NewServices(infraProviders.FavoriteRepository, infraProviders.NotificationService, infraProviders.UserRepository, infraProviders.RefreshTokenRepository, up, tp)
+	appServices := app.NewServices(app.Dependencies{
+		FavoriteRepository:     infraProviders.FavoriteRepository,
+		NotificationService:    infraProviders.NotificationService,
+		NotificationChannel:    cfg.NotificationChannel,
+		UserRepository:         infraProviders.UserRepository,
+		RefreshTokenRepository: infraProviders.RefreshTokenRepository,
+		ResetTokenRepository:   infraProviders.ResetTokenRepository,
+		LoginAttemptRepository: infraProviders.LoginAttemptRepository,
+		APIKeyRepository:       infraProviders.APIKeyRepository,
+		ClientRepository:       infraProviders.ClientRepository,
+		StatsRepository:        infraProviders.StatsRepository,
+		AuditRepository:        infraProviders.AuditRepository,
+		DeadLetterRepository:   infraProviders.DeadLetterRepository,
+		PreferenceRepository:   infraProviders.PreferenceRepository,
+		InboxRepository:        infraProviders.InboxRepository,
+		OutboxRepository:       infraProviders.OutboxRepository,
+		WebhookRepository:      infraProviders.WebhookRepository,
+		WebhookPoster:          infraProviders.WebhookPoster,
+		DigestRepository:       infraProviders.DigestRepository,
+		UUIDProvider:           up,
+		TimeProvider:           tp,
+		PasswordPolicy:         infraProviders.PasswordPolicy,
+		PasswordHasher:         infraProviders.PasswordHasher,
+		PasswordResetTTL:       cfg.PasswordResetTTL,
+		UsernameLockoutPolicy:  infraProviders.UsernameLockoutPolicy,
+		IPLockoutPolicy:        infraProviders.IPLockoutPolicy,
+		SecretCipher:           infraProviders.SecretCipher,
+		SecretHasher:           infraProviders.SecretHasher,
+		TOTPIssuer:             cfg.TOTPIssuer,
+		OIDCStateRepository:    infraProviders.OIDCStateRepository,
+		OIDCProvider:           infraProviders.OIDCProvider,
+		OIDCRoleMapping:        cfg.OIDCRoleMapping,
+		OIDCStateTTL:           cfg.OIDCStateTTL,
+		OutboxRelay: relay.Config{
+			Interval:  cfg.OutboxPollInterval,
+			BatchSize: cfg.OutboxBatchSize,
+			Retry:     backoff.Exponential{Base: cfg.OutboxRetryBase, Max: cfg.OutboxRetryMax},
+			Retention: cfg.OutboxRetention,
+		},
+		WebhookDelivery: webhooks.Config{
+			Interval:     cfg.WebhookPollInterval,
+			BatchSize:    cfg.WebhookBatchSize,
+			MaxAttempts:  cfg.WebhookMaxAttempts,
+			Retry:        backoff.Exponential{Base: cfg.WebhookRetryBase, Max: cfg.WebhookRetryMax},
+			DisableAfter: cfg.WebhookDisableAfter,
+			Retention:    cfg.WebhookDeliveryRetention,
+		},
+		Digests: digest.Config{
+			Interval:  cfg.DigestInterval,
+			Retention: cfg.DigestRetention,
+		},
+		FavoriteStream: stream.Config{
+			BufferSize: cfg.StreamBufferSize,
+			Heartbeat:  cfg.StreamHeartbeat,
+			Retention:  cfg.StreamBufferRetention,
+		},
+	})
 
-	infraHTTPServer := infra.NewHTTPServer(appServices)
+	go janitor.New("refresh tokens", cfg.JanitorInterval, appServices.AuthServices.Commands.PurgeExpiredSessionsHandler.Handle).Run(context.Background())
+	go janitor.New("password reset tokens", cfg.JanitorInterval, appServices.UserServices.Commands.PurgeExpiredResetTokensHandler.Handle).Run(context.Background())
+	go janitor.New("oidc states", cfg.JanitorInterval, appServices.AuthServices.Commands.PurgeExpiredOIDCStatesHandler.Handle).Run(context.Background())
+	go janitor.New("login attempts", cfg.JanitorInterval, appServices.AuthServices.Commands.PurgeStaleLoginAttemptsHandler.Handle).Run(context.Background())
+	go janitor.New("outbox events", cfg.JanitorInterval, appServices.OutboxRelay.PurgePublished).Run(context.Background())
+	go janitor.New("webhook deliveries", cfg.JanitorInterval, appServices.WebhookDeliverer.PurgeDeliveries).Run(context.Background())
+	go janitor.New("sent digests", cfg.JanitorInterval, appServices.DigestScheduler.PurgeSent).Run(context.Background())
+	go janitor.New("stream buffers", cfg.JanitorInterval, appServices.FavoriteServices.Stream.PurgeIdle).Run(context.Background())
+	go appServices.OutboxRelay.Run(context.Background())
+	go appServices.WebhookDeliverer.Run(context.Background())
+	go appServices.DigestScheduler.Run(context.Background())
+	go appServices.UserServices.Commands.ForgotPasswordHandler.Run(context.Background())
+
+	infraHTTPServer := infra.NewHTTPServer(appServices, cfg)
 	infraHTTPServer.ListenAndServe(":8080")
 }
 
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+)
+
+// CreateWebhookRequest describes a new webhook subscription. Events empty subscribes to every
+// webhook event, Secret empty has one generated.
+type CreateWebhookRequest struct {
+	URL    string
+	Events []string
+	Secret string
+	Source audit.Source
+}
+
+// CreateWebhookResult contains the subscription and its signing secret, which is only ever returned here
+type CreateWebhookResult struct {
+	webhooks.Summary
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookHandler interface
+type CreateWebhookHandler interface {
+	Handle(req CreateWebhookRequest) (*CreateWebhookResult, error)
+}
+
+type createWebhookHandler struct {
+	repo         webhook.Repository
+	cipher       helper.SecretCipher
+	audit        auditlog.Recorder
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// NewCreateWebhookHandler constructor
+func NewCreateWebhookHandler(repo webhook.Repository, cipher helper.SecretCipher, recorder auditlog.Recorder, up uuid.Provider, tp time.Provider) CreateWebhookHandler {
+	return &createWebhookHandler{repo: repo, cipher: cipher, audit: recorder, uuidProvider: up, timeProvider: tp}
+}
+
+// Handle stores the subscription with its secret encrypted
+func (h *createWebhookHandler) Handle(req CreateWebhookRequest) (*CreateWebhookResult, error) {
+	url := strings.TrimSpace(req.URL)
+	if err := webhook.ValidateURL(url); err != nil {
+		return nil, err
+	}
+	if err := webhooks.ValidateEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := helper.GenerateOpaqueToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = generated
+	} else if len(secret) < webhook.SecretMinLength {
+		return nil, webhook.ErrInvalidSecret
+	}
+	encrypted, err := h.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	now := h.timeProvider.Now().UTC()
+	s := webhook.Subscription{
+		ID:        h.uuidProvider.NewUUID(),
+		URL:       url,
+		Events:    req.Events,
+		Secret:    encrypted,
+		Status:    webhook.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.repo.Add(s); err != nil {
+		return nil, fmt.Errorf("failed to store webhook subscription: %w", err)
+	}
+
+	entry := audit.NewEntry(req.Source, audit.ActionWebhookCreate, audit.Target{Type: audit.TargetWebhook, ID: s.ID.String()})
+	entry.Changes = audit.Diff(nil, auditedWebhook(s))
+	if err := h.audit.Record(entry); err != nil {
+		return nil, fmt.Errorf("webhook created but %w", err)
+	}
+
+	return &CreateWebhookResult{Summary: webhooks.Summarize(s), Secret: secret}, nil
+}
+
+// auditedWebhook is what the audit log keeps of a subscription, never its secret
+func auditedWebhook(s webhook.Subscription) interface{} {
+	return struct {
+		URL    string         `json:"url"`
+		Events []string       `json:"events"`
+		Status webhook.Status `json:"status"`
+	}{URL: s.URL, Events: s.Events, Status: s.Status}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/google/uuid"
+)
+
+// DeleteWebhookRequest represents an admin deleting a webhook subscription
+type DeleteWebhookRequest struct {
+	ID     uuid.UUID
+	Source audit.Source
+}
+
+// DeleteWebhookHandler interface
+type DeleteWebhookHandler interface {
+	Handle(req DeleteWebhookRequest) error
+}
+
+type deleteWebhookHandler struct {
+	repo  webhook.Repository
+	audit auditlog.Recorder
+}
+
+// NewDeleteWebhookHandler constructor
+func NewDeleteWebhookHandler(repo webhook.Repository, recorder auditlog.Recorder) DeleteWebhookHandler {
+	return &deleteWebhookHandler{repo: repo, audit: recorder}
+}
+
+// Handle deletes the subscription with its pending deliveries and delivery log
+func (h *deleteWebhookHandler) Handle(req DeleteWebhookRequest) error {
+	s, err := h.repo.Get(req.ID)
+	if err != nil {
+		return err
+	}
+	if err := h.repo.Delete(req.ID); err != nil {
+		return err
+	}
+
+	entry := audit.NewEntry(req.Source, audit.ActionWebhookDelete, audit.Target{Type: audit.TargetWebhook, ID: req.ID.String()})
+	entry.Changes = audit.Diff(auditedWebhook(s), nil)
+	if err := h.audit.Record(entry); err != nil {
+		return fmt.Errorf("webhook deleted but %w", err)
+	}
+	return nil
+}
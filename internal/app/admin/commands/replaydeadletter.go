@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/google/uuid"
+)
+
+// ReplayDeadLetterRequest represents an admin sending a dead letter again
+type ReplayDeadLetterRequest struct {
+	ID     uuid.UUID
+	Source audit.Source
+}
+
+// ReplayDeadLetterResult tells whether the notification was queued again. Skipped is why the
+// recipient's preferences held it back, in which case the dead letter is kept.
+type ReplayDeadLetterResult struct {
+	Replayed bool   `json:"replayed"`
+	Skipped  string `json:"skipped,omitempty"`
+}
+
+// ReplayDeadLetterHandler interface
+type ReplayDeadLetterHandler interface {
+	Handle(req ReplayDeadLetterRequest) (*ReplayDeadLetterResult, error)
+}
+
+type replayDeadLetterHandler struct {
+	repo                notification.DeadLetterRepository
+	notificationService notification.Filter
+	audit               auditlog.Recorder
+}
+
+// NewReplayDeadLetterHandler constructor, notificationService applies the preferences of the recipient
+func NewReplayDeadLetterHandler(repo notification.DeadLetterRepository, notificationService notification.Filter, recorder auditlog.Recorder) ReplayDeadLetterHandler {
+	return &replayDeadLetterHandler{repo: repo, notificationService: notificationService, audit: recorder}
+}
+
+// Handle takes the dead letter out of the store and notifies it again. Taking it first means
+// concurrent replays send it once, and a delivery failing again becomes a new dead letter.
+// A notification the recipient's preferences hold back, e.g. in quiet hours, is put back.
+func (h *replayDeadLetterHandler) Handle(req ReplayDeadLetterRequest) (*ReplayDeadLetterResult, error) {
+	// redaction never changes, so it can be checked before the dead letter is taken
+	deadLetter, err := h.repo.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter.Redacted {
+		return nil, notification.ErrDeadLetterRedacted
+	}
+	deadLetter, err = h.repo.Take(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := audit.NewEntry(req.Source, audit.ActionNotificationReplay, audit.Target{Type: audit.TargetDeadLetter, ID: req.ID.String()})
+	if err := h.audit.Record(entry); err != nil {
+		// put it back, the replay did not happen
+		if addErr := h.repo.Add(deadLetter); addErr != nil {
+			return nil, fmt.Errorf("%w, and the dead letter was lost: %v", err, addErr)
+		}
+		return nil, err
+	}
+
+	skipped, err := h.notificationService.Deliver(deadLetter.Notification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay notification: %w", err)
+	}
+	if skipped != "" {
+		if err := h.repo.Add(deadLetter); err != nil {
+			return nil, fmt.Errorf("notification skipped (%s), and the dead letter was lost: %w", skipped, err)
+		}
+		return &ReplayDeadLetterResult{Skipped: skipped}, nil
+	}
+	return &ReplayDeadLetterResult{Replayed: true}, nil
+}
@@ -0,0 +1,142 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeadLetterRepository struct {
+	mock.Mock
+}
+
+func (m *MockDeadLetterRepository) Add(dl notification.DeadLetter) error {
+	return m.Called(dl).Error(0)
+}
+
+func (m *MockDeadLetterRepository) List() ([]notification.DeadLetter, error) {
+	args := m.Called()
+	return args.Get(0).([]notification.DeadLetter), args.Error(1)
+}
+
+func (m *MockDeadLetterRepository) Get(id uuid.UUID) (notification.DeadLetter, error) {
+	args := m.Called(id)
+	return args.Get(0).(notification.DeadLetter), args.Error(1)
+}
+
+func (m *MockDeadLetterRepository) Take(id uuid.UUID) (notification.DeadLetter, error) {
+	args := m.Called(id)
+	return args.Get(0).(notification.DeadLetter), args.Error(1)
+}
+
+type MockFilter struct {
+	mock.Mock
+}
+
+func (m *MockFilter) Notify(n notification.Notification) error {
+	return m.Called(n).Error(0)
+}
+
+func (m *MockFilter) Deliver(n notification.Notification) (string, error) {
+	args := m.Called(n)
+	return args.String(0), args.Error(1)
+}
+
+func TestReplayDeadLetterHandler_Handle(t *testing.T) {
+	id := uuid.New()
+	deadLetter := notification.DeadLetter{ID: id, Notification: notification.Notification{Subject: "hello"}, Attempts: 5}
+	auditErr := errors.New("audit failed")
+
+	tests := []struct {
+		name           string
+		auditError     error
+		setupMocks     func(repo *MockDeadLetterRepository, ns *MockFilter)
+		expectedResult *commands.ReplayDeadLetterResult
+		expectedError  error
+	}{
+		{
+			name: "replayed",
+			setupMocks: func(repo *MockDeadLetterRepository, ns *MockFilter) {
+				repo.On("Get", id).Return(deadLetter, nil)
+				repo.On("Take", id).Return(deadLetter, nil)
+				ns.On("Deliver", deadLetter.Notification).Return("", nil)
+			},
+			expectedResult: &commands.ReplayDeadLetterResult{Replayed: true},
+		},
+		{
+			name: "held back by the preferences",
+			setupMocks: func(repo *MockDeadLetterRepository, ns *MockFilter) {
+				repo.On("Get", id).Return(deadLetter, nil)
+				repo.On("Take", id).Return(deadLetter, nil)
+				ns.On("Deliver", deadLetter.Notification).Return("quiet hours", nil)
+				repo.On("Add", deadLetter).Return(nil)
+			},
+			expectedResult: &commands.ReplayDeadLetterResult{Skipped: "quiet hours"},
+		},
+		{
+			name: "unknown dead letter",
+			setupMocks: func(repo *MockDeadLetterRepository, ns *MockFilter) {
+				repo.On("Get", id).Return(notification.DeadLetter{}, notification.ErrDeadLetterNotFound)
+			},
+			expectedError: notification.ErrDeadLetterNotFound,
+		},
+		{
+			name: "redacted dead letter",
+			setupMocks: func(repo *MockDeadLetterRepository, ns *MockFilter) {
+				repo.On("Get", id).Return(notification.DeadLetter{ID: id, Redacted: true}, nil)
+			},
+			expectedError: notification.ErrDeadLetterRedacted,
+		},
+		{
+			name: "replayed concurrently",
+			setupMocks: func(repo *MockDeadLetterRepository, ns *MockFilter) {
+				repo.On("Get", id).Return(deadLetter, nil)
+				repo.On("Take", id).Return(notification.DeadLetter{}, notification.ErrDeadLetterNotFound)
+			},
+			expectedError: notification.ErrDeadLetterNotFound,
+		},
+		{
+			name:       "audit failure keeps the dead letter",
+			auditError: auditErr,
+			setupMocks: func(repo *MockDeadLetterRepository, ns *MockFilter) {
+				repo.On("Get", id).Return(deadLetter, nil)
+				repo.On("Take", id).Return(deadLetter, nil)
+				repo.On("Add", deadLetter).Return(nil)
+			},
+			expectedError: auditErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockDeadLetterRepository{}
+			ns := &MockFilter{}
+			tt.setupMocks(repo, ns)
+			trail := &auditlogtest.Trail{Err: tt.auditError}
+
+			result, err := commands.NewReplayDeadLetterHandler(repo, ns, trail).Handle(commands.ReplayDeadLetterRequest{ID: id})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, trail.Entries)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+				if assert.Len(t, trail.Entries, 1) {
+					assert.Equal(t, audit.ActionNotificationReplay, trail.Entries[0].Action)
+					assert.Equal(t, id.String(), trail.Entries[0].Target.ID)
+				}
+			}
+
+			repo.AssertExpectations(t)
+			ns.AssertExpectations(t)
+		})
+	}
+}
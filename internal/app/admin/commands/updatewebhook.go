@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// UpdateWebhookRequest changes the fields of a subscription that are set
+type UpdateWebhookRequest struct {
+	ID     uuid.UUID
+	URL    *string
+	Events *[]string
+	// Enabled re-enables a disabled subscription, clearing its failures, or disables it
+	Enabled *bool
+	Source  audit.Source
+}
+
+// UpdateWebhookHandler interface
+type UpdateWebhookHandler interface {
+	Handle(req UpdateWebhookRequest) (*webhooks.Summary, error)
+}
+
+type updateWebhookHandler struct {
+	repo         webhook.Repository
+	audit        auditlog.Recorder
+	timeProvider time.Provider
+}
+
+// NewUpdateWebhookHandler constructor
+func NewUpdateWebhookHandler(repo webhook.Repository, recorder auditlog.Recorder, tp time.Provider) UpdateWebhookHandler {
+	return &updateWebhookHandler{repo: repo, audit: recorder, timeProvider: tp}
+}
+
+// Handle applies the changes. Deliveries already failed stay failed when a subscription is enabled again.
+func (h *updateWebhookHandler) Handle(req UpdateWebhookRequest) (*webhooks.Summary, error) {
+	s, err := h.repo.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	before := s
+
+	if req.URL != nil {
+		url := strings.TrimSpace(*req.URL)
+		if err := webhook.ValidateURL(url); err != nil {
+			return nil, err
+		}
+		s.URL = url
+	}
+	if req.Events != nil {
+		if err := webhooks.ValidateEvents(*req.Events); err != nil {
+			return nil, err
+		}
+		s.Events = *req.Events
+	}
+	if req.Enabled != nil {
+		switch {
+		case *req.Enabled && s.Status != webhook.StatusActive:
+			s.Status, s.ConsecutiveFailures, s.DisabledReason = webhook.StatusActive, 0, ""
+		case !*req.Enabled && s.Status != webhook.StatusDisabled:
+			s.Status, s.DisabledReason = webhook.StatusDisabled, "disabled by an admin"
+		}
+	}
+
+	changes := audit.Diff(auditedWebhook(before), auditedWebhook(s))
+	if len(changes) == 0 {
+		summary := webhooks.Summarize(s)
+		return &summary, nil
+	}
+	s.UpdatedAt = h.timeProvider.Now().UTC()
+	if err := h.repo.Update(s); err != nil {
+		return nil, err
+	}
+
+	entry := audit.NewEntry(req.Source, audit.ActionWebhookUpdate, audit.Target{Type: audit.TargetWebhook, ID: s.ID.String()})
+	entry.Changes = changes
+	if err := h.audit.Record(entry); err != nil {
+		return nil, fmt.Errorf("webhook updated but %w", err)
+	}
+
+	summary := webhooks.Summarize(s)
+	return &summary, nil
+}
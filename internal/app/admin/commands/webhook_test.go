@@ -0,0 +1,169 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookStore keeps the subscriptions, the commands never touch the deliveries
+type webhookStore struct {
+	webhook.Repository
+	subscriptions map[uuid.UUID]webhook.Subscription
+}
+
+func (s *webhookStore) Add(sub webhook.Subscription) error {
+	s.subscriptions[sub.ID] = sub
+	return nil
+}
+
+func (s *webhookStore) Get(id uuid.UUID) (webhook.Subscription, error) {
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return webhook.Subscription{}, webhook.ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *webhookStore) Update(sub webhook.Subscription) error {
+	s.subscriptions[sub.ID] = sub
+	return nil
+}
+
+func (s *webhookStore) Delete(id uuid.UUID) error {
+	delete(s.subscriptions, id)
+	return nil
+}
+
+func newWebhookFixture(t *testing.T) (*webhookStore, helper.SecretCipher, *auditlogtest.Trail, *timeprovider.MockProvider) {
+	cipher, err := helper.NewSecretCipher([]byte(strings.Repeat("k", 32)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC))
+	return &webhookStore{subscriptions: map[uuid.UUID]webhook.Subscription{}}, cipher, &auditlogtest.Trail{}, tp
+}
+
+func TestCreateWebhookHandler_Handle(t *testing.T) {
+	repo, cipher, trail, tp := newWebhookFixture(t)
+	handler := commands.NewCreateWebhookHandler(repo, cipher, trail, uuidprovider.NewUUIDProvider(), tp)
+
+	result, err := handler.Handle(commands.CreateWebhookRequest{URL: " https://partner.example.com/hooks ", Events: []string{"favourite.created"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "https://partner.example.com/hooks", result.URL)
+	assert.Equal(t, webhook.StatusActive, result.Status)
+	assert.NotEmpty(t, result.Secret, "a secret is generated")
+
+	stored, err := repo.Get(result.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEqual(t, result.Secret, stored.Secret, "stored encrypted")
+	decrypted, err := cipher.Decrypt(stored.Secret)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, result.Secret, decrypted)
+
+	if !assert.Len(t, trail.Entries, 1) {
+		return
+	}
+	assert.Equal(t, audit.ActionWebhookCreate, trail.Entries[0].Action)
+	assert.Equal(t, audit.Target{Type: audit.TargetWebhook, ID: result.ID.String()}, trail.Entries[0].Target)
+	assert.NotContains(t, trail.Entries[0].Changes, "secret")
+
+	chosen, err := handler.Handle(commands.CreateWebhookRequest{URL: "http://localhost:9000", Secret: "a-secret-of-my-own"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "a-secret-of-my-own", chosen.Secret)
+	assert.Equal(t, []string{}, chosen.Events, "every event")
+}
+
+func TestCreateWebhookHandler_Validation(t *testing.T) {
+	repo, cipher, trail, tp := newWebhookFixture(t)
+	handler := commands.NewCreateWebhookHandler(repo, cipher, trail, uuidprovider.NewUUIDProvider(), tp)
+
+	_, err := handler.Handle(commands.CreateWebhookRequest{URL: "partner.example.com"})
+	assert.ErrorIs(t, err, webhook.ErrInvalidURL)
+	_, err = handler.Handle(commands.CreateWebhookRequest{URL: "https://partner.example.com", Events: []string{"user.logged_in"}})
+	assert.ErrorIs(t, err, webhook.ErrInvalidEvent)
+	_, err = handler.Handle(commands.CreateWebhookRequest{URL: "https://partner.example.com", Secret: "short"})
+	assert.ErrorIs(t, err, webhook.ErrInvalidSecret)
+	assert.Empty(t, repo.subscriptions)
+	assert.Empty(t, trail.Entries)
+}
+
+func TestUpdateWebhookHandler_Handle(t *testing.T) {
+	repo, _, trail, tp := newWebhookFixture(t)
+	id := uuid.New()
+	if !assert.NoError(t, repo.Add(webhook.Subscription{
+		ID: id, URL: "https://partner.example.com", Status: webhook.StatusDisabled,
+		ConsecutiveFailures: 20, DisabledReason: "20 consecutive failed deliveries",
+	})) {
+		return
+	}
+	handler := commands.NewUpdateWebhookHandler(repo, trail, tp)
+
+	enabled, events := true, []string{"favourite.deleted"}
+	summary, err := handler.Handle(commands.UpdateWebhookRequest{ID: id, Enabled: &enabled, Events: &events})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, webhook.StatusActive, summary.Status)
+	assert.Equal(t, 0, summary.ConsecutiveFailures, "enabling clears the failures")
+	assert.Empty(t, summary.DisabledReason)
+	assert.Equal(t, events, summary.Events)
+	if !assert.Len(t, trail.Entries, 1) {
+		return
+	}
+	assert.Equal(t, audit.ActionWebhookUpdate, trail.Entries[0].Action)
+	assert.Contains(t, trail.Entries[0].Changes, "status")
+	assert.Contains(t, trail.Entries[0].Changes, "events")
+
+	_, err = handler.Handle(commands.UpdateWebhookRequest{ID: id, Enabled: &enabled})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, trail.Entries, 1, "nothing changed, nothing audited")
+
+	badURL := "ftp://partner.example.com"
+	_, err = handler.Handle(commands.UpdateWebhookRequest{ID: id, URL: &badURL})
+	assert.ErrorIs(t, err, webhook.ErrInvalidURL)
+	_, err = handler.Handle(commands.UpdateWebhookRequest{ID: uuid.New(), Enabled: &enabled})
+	assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+}
+
+func TestDeleteWebhookHandler_Handle(t *testing.T) {
+	repo, _, trail, _ := newWebhookFixture(t)
+	id := uuid.New()
+	if !assert.NoError(t, repo.Add(webhook.Subscription{ID: id, URL: "https://partner.example.com"})) {
+		return
+	}
+	handler := commands.NewDeleteWebhookHandler(repo, trail)
+
+	if !assert.NoError(t, handler.Handle(commands.DeleteWebhookRequest{ID: id})) {
+		return
+	}
+	assert.Empty(t, repo.subscriptions)
+	if !assert.Len(t, trail.Entries, 1) {
+		return
+	}
+	assert.Equal(t, audit.ActionWebhookDelete, trail.Entries[0].Action)
+
+	assert.ErrorIs(t, handler.Handle(commands.DeleteWebhookRequest{ID: id}), webhook.ErrSubscriptionNotFound)
+}
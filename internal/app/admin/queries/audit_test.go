@@ -0,0 +1,92 @@
+package queries_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Append(entry audit.Entry) error {
+	return m.Called(entry).Error(0)
+}
+
+func (m *MockAuditRepository) List(query audit.Query) ([]audit.Entry, int, error) {
+	args := m.Called(query)
+	return args.Get(0).([]audit.Entry), args.Int(1), args.Error(2)
+}
+
+func TestListAuditHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           queries.ListAuditRequest
+		expectedQuery audit.Query
+	}{
+		{
+			name:          "defaults",
+			req:           queries.ListAuditRequest{Filter: queries.AuditFilter{Action: "auth"}},
+			expectedQuery: audit.Query{Action: "auth", Limit: queries.DefaultAuditLimit},
+		},
+		{
+			name:          "limit is capped and offset is not negative",
+			req:           queries.ListAuditRequest{Offset: -5, Limit: 10000},
+			expectedQuery: audit.Query{Limit: queries.MaxAuditLimit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockAuditRepository{}
+			repo.On("List", tt.expectedQuery).Return([]audit.Entry{{Action: audit.ActionLogin}}, 7, nil)
+
+			result, err := queries.NewListAuditHandler(repo).Handle(tt.req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, 7, result.Total)
+			assert.Equal(t, tt.expectedQuery.Limit, result.Limit)
+			assert.Len(t, result.Entries, 1)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportAuditHandler_Handle(t *testing.T) {
+	full := make([]audit.Entry, 500)
+	repo := &MockAuditRepository{}
+	repo.On("List", audit.Query{ActorID: "u1", OldestFirst: true, Limit: 500}).Return(full, 501, nil)
+	repo.On("List", audit.Query{ActorID: "u1", OldestFirst: true, Offset: 500, Limit: 500}).Return([]audit.Entry{{Action: audit.ActionLogout}}, 501, nil)
+
+	written := 0
+	err := queries.NewExportAuditHandler(repo).Handle(queries.AuditFilter{ActorID: "u1"}, func(audit.Entry) error {
+		written++
+		return nil
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 501, written)
+	repo.AssertExpectations(t)
+}
+
+func TestExportAuditHandler_StopsOnWriteError(t *testing.T) {
+	repo := &MockAuditRepository{}
+	repo.On("List", mock.Anything).Return([]audit.Entry{{}, {}}, 2, nil).Once()
+	writeErr := errors.New("client gone")
+
+	written := 0
+	err := queries.NewExportAuditHandler(repo).Handle(queries.AuditFilter{}, func(audit.Entry) error {
+		written++
+		return writeErr
+	})
+	assert.ErrorIs(t, err, writeErr)
+	assert.Equal(t, 1, written)
+}
@@ -0,0 +1,48 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+)
+
+// exportBatchSize is how many entries are read from the repository at a time
+const exportBatchSize = 500
+
+// ExportAuditHandler streams the whole matching audit log, oldest entries first
+type ExportAuditHandler interface {
+	Handle(filter AuditFilter, write func(entry audit.Entry) error) error
+}
+
+type exportAuditHandler struct {
+	repo audit.Repository
+}
+
+// NewExportAuditHandler constructor
+func NewExportAuditHandler(repo audit.Repository) ExportAuditHandler {
+	return &exportAuditHandler{repo: repo}
+}
+
+// Handle passes each matching entry to write, stopping at the first error. Entries are read in
+// chronological batches, so entries appended meanwhile only ever extend the end of the export.
+func (h *exportAuditHandler) Handle(filter AuditFilter, write func(entry audit.Entry) error) error {
+	q := filter.query()
+	q.OldestFirst = true
+	q.Limit = exportBatchSize
+
+	for {
+		entries, _, err := h.repo.List(q)
+		if err != nil {
+			return fmt.Errorf("failed to export audit entries: %w", err)
+		}
+		for _, e := range entries {
+			if err := write(e); err != nil {
+				return err
+			}
+		}
+		if len(entries) < exportBatchSize {
+			return nil
+		}
+		q.Offset += len(entries)
+	}
+}
@@ -0,0 +1,75 @@
+package queries
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/stats"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+const (
+	// DefaultStatsDays is the window of favourites counted per day when none is requested
+	DefaultStatsDays = 30
+	// MaxStatsDays caps the requested window
+	MaxStatsDays = 365
+	// DefaultTopUsers is how many top users are returned when no number is requested
+	DefaultTopUsers = 10
+	// MaxTopUsers caps the requested number of top users
+	MaxTopUsers = 100
+)
+
+// GetStatsRequest represents a stats request
+type GetStatsRequest struct {
+	// Days is the window of favourites counted per day, ending today
+	Days int
+	// Top is how many of the users with the most favourites to return
+	Top int
+}
+
+// GetStatsResult are the stats, with a count for every day of the window
+type GetStatsResult struct {
+	stats.Stats
+	Days int `json:"days"`
+}
+
+// GetStatsHandler interface
+type GetStatsHandler interface {
+	Handle(req GetStatsRequest) (*GetStatsResult, error)
+}
+
+type getStatsHandler struct {
+	repo         stats.Repository
+	timeProvider timeprovider.Provider
+}
+
+// NewGetStatsHandler constructor
+func NewGetStatsHandler(repo stats.Repository, tp timeprovider.Provider) GetStatsHandler {
+	return &getStatsHandler{repo: repo, timeProvider: tp}
+}
+
+// Handle returns the operational stats of the service
+func (h *getStatsHandler) Handle(req GetStatsRequest) (*GetStatsResult, error) {
+	if req.Days <= 0 {
+		req.Days = DefaultStatsDays
+	}
+	if req.Days > MaxStatsDays {
+		req.Days = MaxStatsDays
+	}
+	if req.Top <= 0 {
+		req.Top = DefaultTopUsers
+	}
+	if req.Top > MaxTopUsers {
+		req.Top = MaxTopUsers
+	}
+
+	now := h.timeProvider.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	since := today.AddDate(0, 0, -(req.Days - 1))
+
+	s, err := h.repo.Get(stats.Query{Since: since, Now: now, TopUsers: req.Top})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute stats: %w", err)
+	}
+	return &GetStatsResult{Stats: *s, Days: req.Days}, nil
+}
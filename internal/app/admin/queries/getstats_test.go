@@ -0,0 +1,104 @@
+package queries_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/stats"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) Get(query stats.Query) (*stats.Stats, error) {
+	args := m.Called(query)
+	s := args.Get(0)
+	if s == nil {
+		return nil, args.Error(1)
+	}
+	return s.(*stats.Stats), args.Error(1)
+}
+
+func TestGetStatsHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		req           queries.GetStatsRequest
+		expectedQuery stats.Query
+		expectedDays  []string
+	}{
+		{
+			name: "window ends today",
+			req:  queries.GetStatsRequest{Days: 3, Top: 5},
+			expectedQuery: stats.Query{
+				Since:    time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+				Now:      now,
+				TopUsers: 5,
+			},
+			expectedDays: []string{"2025-03-08", "2025-03-09", "2025-03-10"},
+		},
+		{
+			name: "defaults",
+			req:  queries.GetStatsRequest{},
+			expectedQuery: stats.Query{
+				Since:    time.Date(2025, 2, 9, 0, 0, 0, 0, time.UTC),
+				Now:      now,
+				TopUsers: queries.DefaultTopUsers,
+			},
+		},
+		{
+			name: "limits are capped",
+			req:  queries.GetStatsRequest{Days: 10000, Top: 10000},
+			expectedQuery: stats.Query{
+				Since:    now.Truncate(24*time.Hour).AddDate(0, 0, -(queries.MaxStatsDays - 1)),
+				Now:      now,
+				TopUsers: queries.MaxTopUsers,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockStatsRepository{}
+			perDay := []stats.DayFavourites{}
+			for _, day := range tt.expectedQuery.Days() {
+				n := 0
+				if day == "2025-03-09" {
+					n = 2
+				}
+				perDay = append(perDay, stats.DayFavourites{Day: day, Favourites: n})
+			}
+			repo.On("Get", tt.expectedQuery).Return(&stats.Stats{Favourites: 2, FavouritesPerDay: perDay}, nil)
+			tp := &timeprovider.MockProvider{}
+			tp.On("Now").Return(now)
+
+			handler := queries.NewGetStatsHandler(repo, tp)
+
+			result, err := handler.Handle(tt.req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, 2, result.Favourites)
+
+			total := 0
+			days := make([]string, 0, len(result.FavouritesPerDay))
+			for _, d := range result.FavouritesPerDay {
+				days = append(days, d.Day)
+				total += d.Favourites
+			}
+			assert.Len(t, days, result.Days)
+			assert.Equal(t, 2, total)
+			if tt.expectedDays != nil {
+				assert.Equal(t, tt.expectedDays, days)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package queries
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+)
+
+const (
+	// DefaultAuditLimit is the page size of the audit log when none is requested
+	DefaultAuditLimit = 50
+	// MaxAuditLimit caps the page size of the audit log
+	MaxAuditLimit = 500
+)
+
+// AuditFilter selects entries of the audit log, see audit.Query
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	Since      time.Time
+	Until      time.Time
+}
+
+func (f AuditFilter) query() audit.Query {
+	return audit.Query{
+		ActorID:    f.ActorID,
+		Action:     f.Action,
+		TargetType: f.TargetType,
+		TargetID:   f.TargetID,
+		RequestID:  f.RequestID,
+		Since:      f.Since,
+		Until:      f.Until,
+	}
+}
+
+// ListAuditRequest represents a search of the audit log, newest entries first
+type ListAuditRequest struct {
+	Filter AuditFilter
+	Offset int
+	Limit  int
+}
+
+// ListAuditResult represents one page of the audit log
+type ListAuditResult struct {
+	Entries []audit.Entry `json:"entries"`
+	Total   int           `json:"total"`
+	Offset  int           `json:"offset"`
+	Limit   int           `json:"limit"`
+}
+
+// ListAuditHandler interface
+type ListAuditHandler interface {
+	Handle(req ListAuditRequest) (*ListAuditResult, error)
+}
+
+type listAuditHandler struct {
+	repo audit.Repository
+}
+
+// NewListAuditHandler constructor
+func NewListAuditHandler(repo audit.Repository) ListAuditHandler {
+	return &listAuditHandler{repo: repo}
+}
+
+// Handle returns the requested page of the matching entries
+func (h *listAuditHandler) Handle(req ListAuditRequest) (*ListAuditResult, error) {
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+	if req.Limit <= 0 {
+		req.Limit = DefaultAuditLimit
+	}
+	if req.Limit > MaxAuditLimit {
+		req.Limit = MaxAuditLimit
+	}
+
+	q := req.Filter.query()
+	q.Offset, q.Limit = req.Offset, req.Limit
+	entries, total, err := h.repo.List(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return &ListAuditResult{Entries: entries, Total: total, Offset: req.Offset, Limit: req.Limit}, nil
+}
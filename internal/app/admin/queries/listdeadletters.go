@@ -0,0 +1,36 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+)
+
+// ListDeadLettersResult represents the notifications waiting to be replayed, oldest first
+type ListDeadLettersResult struct {
+	DeadLetters []notification.DeadLetter `json:"dead_letters"`
+	Total       int                       `json:"total"`
+}
+
+// ListDeadLettersHandler interface
+type ListDeadLettersHandler interface {
+	Handle() (*ListDeadLettersResult, error)
+}
+
+type listDeadLettersHandler struct {
+	repo notification.DeadLetterRepository
+}
+
+// NewListDeadLettersHandler constructor
+func NewListDeadLettersHandler(repo notification.DeadLetterRepository) ListDeadLettersHandler {
+	return &listDeadLettersHandler{repo: repo}
+}
+
+// Handle returns every dead letter
+func (h *listDeadLettersHandler) Handle() (*ListDeadLettersResult, error) {
+	deadLetters, err := h.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return &ListDeadLettersResult{DeadLetters: deadLetters, Total: len(deadLetters)}, nil
+}
@@ -0,0 +1,61 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultDeliveryLimit is how many deliveries are listed when no limit is given
+	DefaultDeliveryLimit = 50
+	// MaxDeliveryLimit caps the deliveries listed at once
+	MaxDeliveryLimit = 500
+)
+
+// ListWebhookDeliveriesRequest asks for the latest deliveries of a subscription
+type ListWebhookDeliveriesRequest struct {
+	SubscriptionID uuid.UUID
+	Limit          int
+}
+
+// ListWebhookDeliveriesResult is the delivery log of a subscription, newest first
+type ListWebhookDeliveriesResult struct {
+	Deliveries []webhook.Delivery `json:"deliveries"`
+	Total      int                `json:"total"`
+}
+
+// ListWebhookDeliveriesHandler interface
+type ListWebhookDeliveriesHandler interface {
+	Handle(req ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResult, error)
+}
+
+type listWebhookDeliveriesHandler struct {
+	repo webhook.Repository
+}
+
+// NewListWebhookDeliveriesHandler constructor
+func NewListWebhookDeliveriesHandler(repo webhook.Repository) ListWebhookDeliveriesHandler {
+	return &listWebhookDeliveriesHandler{repo: repo}
+}
+
+// Handle returns the latest deliveries of an existing subscription
+func (h *listWebhookDeliveriesHandler) Handle(req ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResult, error) {
+	if _, err := h.repo.Get(req.SubscriptionID); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultDeliveryLimit
+	}
+	if limit > MaxDeliveryLimit {
+		limit = MaxDeliveryLimit
+	}
+
+	deliveries, err := h.repo.Deliveries(req.SubscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return &ListWebhookDeliveriesResult{Deliveries: deliveries, Total: len(deliveries)}, nil
+}
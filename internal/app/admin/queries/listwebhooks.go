@@ -0,0 +1,66 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/google/uuid"
+)
+
+// ListWebhooksResult represents the webhook subscriptions, oldest first
+type ListWebhooksResult struct {
+	Webhooks []webhooks.Summary `json:"webhooks"`
+	Total    int                `json:"total"`
+}
+
+// ListWebhooksHandler interface
+type ListWebhooksHandler interface {
+	Handle() (*ListWebhooksResult, error)
+}
+
+type listWebhooksHandler struct {
+	repo webhook.Repository
+}
+
+// NewListWebhooksHandler constructor
+func NewListWebhooksHandler(repo webhook.Repository) ListWebhooksHandler {
+	return &listWebhooksHandler{repo: repo}
+}
+
+// Handle returns every subscription
+func (h *listWebhooksHandler) Handle() (*ListWebhooksResult, error) {
+	subscriptions, err := h.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	summaries := make([]webhooks.Summary, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		summaries = append(summaries, webhooks.Summarize(s))
+	}
+	return &ListWebhooksResult{Webhooks: summaries, Total: len(summaries)}, nil
+}
+
+// GetWebhookHandler interface
+type GetWebhookHandler interface {
+	Handle(id uuid.UUID) (*webhooks.Summary, error)
+}
+
+type getWebhookHandler struct {
+	repo webhook.Repository
+}
+
+// NewGetWebhookHandler constructor
+func NewGetWebhookHandler(repo webhook.Repository) GetWebhookHandler {
+	return &getWebhookHandler{repo: repo}
+}
+
+// Handle returns the subscription
+func (h *getWebhookHandler) Handle(id uuid.UUID) (*webhooks.Summary, error) {
+	s, err := h.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	summary := webhooks.Summarize(s)
+	return &summary, nil
+}
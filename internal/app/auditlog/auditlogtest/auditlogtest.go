@@ -0,0 +1,24 @@
+// Package auditlogtest is an in-memory audit recorder for tests. It keeps the recorded entries so
+// tests can assert on them, and can refuse every entry to exercise the paths of a failing audit log.
+package auditlogtest
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+)
+
+// Trail is an auditlog.Recorder collecting the recorded entries
+type Trail struct {
+	// Entries are the recorded entries, in the order they were recorded
+	Entries []audit.Entry
+	// Err, when set, is returned by Record and the entry is dropped
+	Err error
+}
+
+// Record appends the entry, or returns Err when set
+func (t *Trail) Record(entry audit.Entry) error {
+	if t.Err != nil {
+		return t.Err
+	}
+	t.Entries = append(t.Entries, entry)
+	return nil
+}
@@ -0,0 +1,75 @@
+// Package auditlog records the actions of the application in the audit log.
+package auditlog
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+)
+
+// Recorder appends entries to the audit log. Commands record before notifying anyone,
+// so a failing notification never loses an entry.
+type Recorder interface {
+	Record(entry audit.Entry) error
+}
+
+// RecorderFunc adapts a function to a Recorder
+type RecorderFunc func(entry audit.Entry) error
+
+// Record calls f(entry)
+func (f RecorderFunc) Record(entry audit.Entry) error {
+	return f(entry)
+}
+
+// RecordImpersonatedRead records a read made by an admin impersonating a user. Other reads are not
+// recorded: users reading their own data would flood the log, but what an admin saw as someone else
+// must be accounted for.
+func RecordImpersonatedRead(r Recorder, source audit.Source, action string, target audit.Target) error {
+	if source.Actor.Kind != audit.ActorImpersonation {
+		return nil
+	}
+	return r.Record(audit.NewEntry(source, action, target))
+}
+
+// Stamper gives entries their id and time. Commands whose repository appends the entry in the same
+// write as the change it describes stamp it, instead of recording it once the change is stored.
+type Stamper interface {
+	Stamp(entry audit.Entry) audit.Entry
+}
+
+type stamper struct {
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// NewStamper constructor
+func NewStamper(up uuid.Provider, tp time.Provider) Stamper {
+	return stamper{uuidProvider: up, timeProvider: tp}
+}
+
+// Stamp sets the id and the current time of the entry
+func (s stamper) Stamp(entry audit.Entry) audit.Entry {
+	entry.ID = s.uuidProvider.NewUUID()
+	entry.Time = s.timeProvider.Now().UTC()
+	return entry
+}
+
+type recorder struct {
+	repo    audit.Repository
+	stamper Stamper
+}
+
+// NewRecorder constructor
+func NewRecorder(repo audit.Repository, up uuid.Provider, tp time.Provider) Recorder {
+	return &recorder{repo: repo, stamper: NewStamper(up, tp)}
+}
+
+// Record stamps the entry with an id and the current time and appends it
+func (r *recorder) Record(entry audit.Entry) error {
+	if err := r.repo.Append(r.stamper.Stamp(entry)); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
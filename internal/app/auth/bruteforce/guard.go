@@ -0,0 +1,147 @@
+// Package bruteforce throttles failed login attempts per username and per client IP.
+package bruteforce
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// ErrTooManyAttempts is returned while a username or IP is throttled or locked
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// ThrottledError carries how long the client has to wait before retrying
+type ThrottledError struct {
+	RetryAfter gotime.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts, e.RetryAfter.Round(gotime.Second))
+}
+
+// Unwrap allows errors.Is(err, ErrTooManyAttempts)
+func (e *ThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// Guard tracks failed login attempts and decides whether a new attempt may proceed
+type Guard interface {
+	// Check returns a *ThrottledError if the username or the IP must wait
+	Check(username, ip string) error
+	// Failure records a failed attempt for both the username and the IP
+	Failure(username, ip string)
+	// Success clears the failed attempts of the username
+	Success(username string)
+}
+
+type guard struct {
+	mu                  sync.Mutex
+	repo                lockout.Repository
+	usernamePolicy      lockout.Policy
+	ipPolicy            lockout.Policy
+	notificationService notification.Service
+	timeProvider        time.Provider
+}
+
+// NewGuard constructor
+func NewGuard(
+	repo lockout.Repository,
+	usernamePolicy lockout.Policy,
+	ipPolicy lockout.Policy,
+	ns notification.Service,
+	tp time.Provider,
+) Guard {
+	return &guard{
+		repo:                repo,
+		usernamePolicy:      usernamePolicy,
+		ipPolicy:            ipPolicy,
+		notificationService: ns,
+		timeProvider:        tp,
+	}
+}
+
+// UsernameKey returns the lockout key of a username. Usernames are tracked whether
+// they exist or not, so the lockout state reveals nothing about existing accounts.
+func UsernameKey(username string) lockout.Key {
+	return lockout.Key{Scope: lockout.ScopeUsername, Value: user.NormalizeUsername(username)}
+}
+
+// IPKey returns the lockout key of a client IP
+func IPKey(ip string) lockout.Key {
+	return lockout.Key{Scope: lockout.ScopeIP, Value: ip}
+}
+
+func (g *guard) Check(username, ip string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.timeProvider.Now()
+	wait := g.retryAfter(UsernameKey(username), g.usernamePolicy, now)
+	if ipWait := g.retryAfter(IPKey(ip), g.ipPolicy, now); ipWait > wait {
+		wait = ipWait
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (g *guard) Failure(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.timeProvider.Now()
+	if g.recordFailure(UsernameKey(username), g.usernamePolicy, now) {
+		g.notifyLocked(notification.EventAccountLocked, map[string]string{
+			"username": user.NormalizeUsername(username),
+			"until":    now.Add(g.usernamePolicy.LockoutDuration).UTC().Format(gotime.RFC3339),
+		})
+	}
+	if ip != "" && g.recordFailure(IPKey(ip), g.ipPolicy, now) {
+		g.notifyLocked(notification.EventIPBlocked, map[string]string{
+			"ip":    ip,
+			"until": now.Add(g.ipPolicy.LockoutDuration).UTC().Format(gotime.RFC3339),
+		})
+	}
+}
+
+func (g *guard) Success(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.repo.Delete(UsernameKey(username))
+}
+
+func (g *guard) retryAfter(key lockout.Key, policy lockout.Policy, now gotime.Time) gotime.Duration {
+	a, ok := g.repo.Get(key)
+	if !ok {
+		return 0
+	}
+	return policy.RetryAfter(a, now)
+}
+
+// recordFailure stores one more failure for the key and reports whether it locked the key
+func (g *guard) recordFailure(key lockout.Key, policy lockout.Policy, now gotime.Time) bool {
+	a, ok := g.repo.Get(key)
+	if !ok {
+		a = lockout.Attempts{Key: key}
+	}
+	a, locked := policy.RecordFailure(a, now)
+	g.repo.Save(a)
+	return locked
+}
+
+// notifyLocked emits a security notification. Delivery failures must not affect the login outcome.
+func (g *guard) notifyLocked(eventName string, data map[string]string) {
+	_ = g.notificationService.Notify(notification.Notification{
+		Kind:  notification.KindSecurity,
+		Event: eventName,
+		Data:  data,
+	})
+}
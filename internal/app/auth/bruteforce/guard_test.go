@@ -0,0 +1,109 @@
+package bruteforce_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeRepository is a map backed lockout.Repository
+type fakeRepository map[string]lockout.Attempts
+
+func (f fakeRepository) Get(key lockout.Key) (lockout.Attempts, bool) {
+	a, ok := f[key.String()]
+	return a, ok
+}
+
+func (f fakeRepository) Save(a lockout.Attempts) {
+	f[a.Key.String()] = a
+}
+
+func (f fakeRepository) Delete(key lockout.Key) bool {
+	_, ok := f[key.String()]
+	delete(f, key.String())
+	return ok
+}
+
+func (f fakeRepository) List() []lockout.Attempts {
+	var values []lockout.Attempts
+	for _, a := range f {
+		values = append(values, a)
+	}
+	return values
+}
+
+func (f fakeRepository) DeleteStale(before time.Time, now time.Time) int {
+	return 0
+}
+
+var (
+	usernamePolicy = lockout.Policy{FreeAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Minute, LockoutThreshold: 3, LockoutDuration: time.Hour}
+	ipPolicy       = lockout.Policy{FreeAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute, LockoutThreshold: 10, LockoutDuration: time.Hour}
+)
+
+func TestGuard_BackoffAndLockout(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockTime := &timeprovider.MockProvider{}
+	mockTime.On("Now").Return(now)
+	mockNotification := &notification.MockNotificationService{}
+	mockNotification.On("Notify", mock.MatchedBy(func(n notification.Notification) bool {
+		return n.Kind == notification.KindSecurity && n.Event == notification.EventAccountLocked &&
+			n.Data["username"] == "alice" && n.Data["until"] == "2025-01-01T13:00:00Z"
+	})).Return(nil).Once()
+
+	guard := bruteforce.NewGuard(fakeRepository{}, usernamePolicy, ipPolicy, mockNotification, mockTime)
+
+	// first failure is free
+	guard.Failure("Alice", "10.0.0.1")
+	assert.NoError(t, guard.Check("alice", "10.0.0.1"))
+
+	// second failure backs off, for any casing of the username and from any IP
+	guard.Failure("alice", "10.0.0.1")
+	err := guard.Check("ALICE", "10.0.0.2")
+	var throttled *bruteforce.ThrottledError
+	assert.True(t, errors.As(err, &throttled))
+	assert.Equal(t, time.Second, throttled.RetryAfter)
+	assert.True(t, errors.Is(err, bruteforce.ErrTooManyAttempts))
+
+	// other usernames from the same IP are not affected yet
+	assert.NoError(t, guard.Check("bob", "10.0.0.1"))
+
+	// third failure locks the username and notifies once
+	guard.Failure("alice", "10.0.0.1")
+	err = guard.Check("alice", "10.0.0.3")
+	assert.True(t, errors.As(err, &throttled))
+	assert.Equal(t, time.Hour, throttled.RetryAfter)
+
+	// success clears the username
+	guard.Success("alice")
+	assert.NoError(t, guard.Check("alice", "10.0.0.3"))
+
+	mockNotification.AssertExpectations(t)
+}
+
+func TestGuard_IPThrottling(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockTime := &timeprovider.MockProvider{}
+	mockTime.On("Now").Return(now)
+	mockNotification := &notification.MockNotificationService{}
+	mockNotification.On("Notify", mock.Anything).Maybe().Return(nil)
+
+	guard := bruteforce.NewGuard(fakeRepository{}, usernamePolicy, ipPolicy, mockNotification, mockTime)
+
+	// spraying a different username per attempt still trips the IP policy
+	users := []string{"u1", "u2", "u3", "u4", "u5", "u6"}
+	for _, u := range users {
+		guard.Failure(u, "10.0.0.9")
+	}
+
+	assert.Error(t, guard.Check("someone-else", "10.0.0.9"))
+	assert.NoError(t, guard.Check("someone-else", "10.0.0.10"))
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"errors"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidAPIKey is returned for unknown, revoked or expired API keys
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyPrincipal is the identity an API key acts as
+type APIKeyPrincipal struct {
+	KeyID  uuid.UUID
+	UserID uuid.UUID
+	Roles  []string
+	Scopes []string
+}
+
+// AuthenticateAPIKeyHandler resolves an API key into the principal it acts as
+type AuthenticateAPIKeyHandler interface {
+	Handle(key string) (*APIKeyPrincipal, error)
+}
+
+type authenticateAPIKeyHandler struct {
+	repo         token.APIKeyRepository
+	userRepo     user.Repository
+	timeProvider time.Provider
+}
+
+// NewAuthenticateAPIKeyHandler constructor
+func NewAuthenticateAPIKeyHandler(repo token.APIKeyRepository, userRepo user.Repository, tp time.Provider) AuthenticateAPIKeyHandler {
+	return &authenticateAPIKeyHandler{repo: repo, userRepo: userRepo, timeProvider: tp}
+}
+
+// Handle checks the key and records its use. Roles are read from the user on every
+// request so a role removed from the user is removed from its keys too.
+func (h *authenticateAPIKeyHandler) Handle(raw string) (*APIKeyPrincipal, error) {
+	key, ok := h.repo.Get(raw)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	now := h.timeProvider.Now().UTC()
+	if key.Expired(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	u, err := h.userRepo.GetByID(key.UserID)
+	if err != nil || !u.Active() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	h.repo.Touch(key.ID, now)
+
+	return &APIKeyPrincipal{
+		KeyID:  key.ID,
+		UserID: u.ID,
+		Roles:  u.Roles,
+		Scopes: key.Scopes,
+	}, nil
+}
@@ -0,0 +1,204 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for api keys
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Save(t string, key token.APIKey) {
+	m.Called(t, key)
+}
+
+func (m *MockAPIKeyRepository) Get(t string) (token.APIKey, bool) {
+	args := m.Called(t)
+	return args.Get(0).(token.APIKey), args.Bool(1)
+}
+
+func (m *MockAPIKeyRepository) ListByUser(userID uuid.UUID) []token.APIKey {
+	args := m.Called(userID)
+	return args.Get(0).([]token.APIKey)
+}
+
+func (m *MockAPIKeyRepository) DeleteByID(userID uuid.UUID, keyID uuid.UUID) error {
+	args := m.Called(userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) DeleteByUser(userID uuid.UUID) int {
+	args := m.Called(userID)
+	return args.Int(0)
+}
+
+func (m *MockAPIKeyRepository) Touch(keyID uuid.UUID, at time.Time) {
+	m.Called(keyID, at)
+}
+
+// Mock repository for users
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetByID(id uuid.UUID) (*user.User, error) {
+	args := m.Called(id)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(username string) (*user.User, error) {
+	args := m.Called(username)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Add(username, plainPassword string, roles []string) (*user.User, error) {
+	args := m.Called(username, plainPassword, roles)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uuid.UUID, plainPassword string) error {
+	args := m.Called(id, plainPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(query user.ListQuery) ([]*user.User, int, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*user.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) SetStatus(id uuid.UUID, status user.Status) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ReplacePasswordHash(id uuid.UUID, oldHash, newHash string) error {
+	args := m.Called(id, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateTOTP(id uuid.UUID, totp user.TOTP) error {
+	args := m.Called(id, totp)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByIdentity(identity user.Identity) (*user.User, error) {
+	args := m.Called(identity)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) LinkIdentity(id uuid.UUID, identity user.Identity) error {
+	args := m.Called(id, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateRoles(id uuid.UUID, roles []string) error {
+	args := m.Called(id, roles)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateEmail(id uuid.UUID, email string) error {
+	args := m.Called(id, email)
+	return args.Error(0)
+}
+
+func TestAuthenticateAPIKeyHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	owner := &user.User{ID: uuid.New(), Username: "alice", Roles: []string{"user"}}
+	key := token.APIKey{ID: uuid.New(), UserID: owner.ID, Scopes: []string{token.ScopeFavoritesRead}}
+	expired := key
+	expired.ExpiresAt = now.Add(-time.Second)
+
+	tests := []struct {
+		name          string
+		setupMocks    func(k *MockAPIKeyRepository, u *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			setupMocks: func(k *MockAPIKeyRepository, u *MockUserRepository) {
+				k.On("Get", "gwi_key").Return(key, true)
+				u.On("GetByID", owner.ID).Return(owner, nil)
+				k.On("Touch", key.ID, now).Return()
+			},
+			expectedError: nil,
+		},
+		{
+			name: "unknown key",
+			setupMocks: func(k *MockAPIKeyRepository, u *MockUserRepository) {
+				k.On("Get", "gwi_key").Return(token.APIKey{}, false)
+			},
+			expectedError: command.ErrInvalidAPIKey,
+		},
+		{
+			name: "expired key",
+			setupMocks: func(k *MockAPIKeyRepository, u *MockUserRepository) {
+				k.On("Get", "gwi_key").Return(expired, true)
+			},
+			expectedError: command.ErrInvalidAPIKey,
+		},
+		{
+			name: "owner no longer exists",
+			setupMocks: func(k *MockAPIKeyRepository, u *MockUserRepository) {
+				k.On("Get", "gwi_key").Return(key, true)
+				u.On("GetByID", owner.ID).Return(nil, errors.New("not found"))
+			},
+			expectedError: command.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockKeys := &MockAPIKeyRepository{}
+			mockUsers := &MockUserRepository{}
+			tt.setupMocks(mockKeys, mockUsers)
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Return(now)
+
+			handler := command.NewAuthenticateAPIKeyHandler(mockKeys, mockUsers, mockTime)
+
+			principal, err := handler.Handle("gwi_key")
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, owner.ID, principal.UserID)
+				assert.Equal(t, owner.Roles, principal.Roles)
+				assert.Equal(t, key.Scopes, principal.Scopes)
+			}
+
+			mockKeys.AssertExpectations(t)
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
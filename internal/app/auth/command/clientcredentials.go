@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+)
+
+// ClientCredentialsRequest is a token request of the client_credentials grant
+type ClientCredentialsRequest struct {
+	ClientID     string
+	ClientSecret string
+	Scopes       []string // empty requests every scope the client is allowed
+}
+
+// ClientTokenResult is the access token issued to a client
+type ClientTokenResult struct {
+	AccessToken string
+	ExpiresAt   gotime.Time
+	Scopes      []string
+}
+
+// ClientCredentialsHandler implements the client_credentials grant
+type ClientCredentialsHandler interface {
+	Handle(req ClientCredentialsRequest) (*ClientTokenResult, error)
+}
+
+type clientCredentialsHandler struct {
+	clients oauth.ClientAuthenticator
+	events  eventbus.Publisher
+}
+
+// NewClientCredentialsHandler constructor
+func NewClientCredentialsHandler(clients oauth.ClientAuthenticator, publisher eventbus.Publisher) ClientCredentialsHandler {
+	return &clientCredentialsHandler{clients: clients, events: publisher}
+}
+
+// Handle authenticates the client and issues a token limited to the requested scopes
+func (h *clientCredentialsHandler) Handle(req ClientCredentialsRequest) (*ClientTokenResult, error) {
+	c, err := h.clients.Authenticate(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = c.Scopes
+	}
+	if err := token.ValidateScopes(scopes, c.Scopes); err != nil {
+		return nil, err
+	}
+
+	access, exp, err := helper.GenerateClientToken(c.ID.String(), scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client token: %w", err)
+	}
+	h.events.Publish(event.ClientTokenIssued{ClientID: c.ID, Scopes: scopes, ExpiresAt: exp})
+
+	return &ClientTokenResult{AccessToken: access, ExpiresAt: exp, Scopes: scopes}, nil
+}
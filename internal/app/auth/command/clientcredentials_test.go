@@ -0,0 +1,94 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock client authenticator
+type MockClientAuthenticator struct {
+	mock.Mock
+}
+
+func (m *MockClientAuthenticator) Authenticate(clientID, secret string) (client.Client, error) {
+	args := m.Called(clientID, secret)
+	return args.Get(0).(client.Client), args.Error(1)
+}
+
+func TestClientCredentialsHandler_Handle(t *testing.T) {
+	registered := client.Client{ID: uuid.New(), Scopes: []string{token.ScopeFavoritesRead, token.ScopeUsersOnBehalf}}
+
+	tests := []struct {
+		name           string
+		scopes         []string
+		authError      error
+		expectedScopes []string
+		expectedError  error
+	}{
+		{
+			name:           "defaults to every allowed scope",
+			expectedScopes: registered.Scopes,
+		},
+		{
+			name:           "narrowed scope",
+			scopes:         []string{token.ScopeFavoritesRead},
+			expectedScopes: []string{token.ScopeFavoritesRead},
+		},
+		{
+			name:          "scope not granted to the client",
+			scopes:        []string{token.ScopeFavoritesWrite},
+			expectedError: token.ErrInvalidScope,
+		},
+		{
+			name:          "invalid credentials",
+			authError:     oauth.ErrInvalidClient,
+			expectedError: oauth.ErrInvalidClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClients := &MockClientAuthenticator{}
+			mockClients.On("Authenticate", registered.ID.String(), "secret").Return(registered, tt.authError)
+
+			events := &eventLog{}
+			handler := command.NewClientCredentialsHandler(mockClients, events)
+
+			result, err := handler.Handle(command.ClientCredentialsRequest{
+				ClientID:     registered.ID.String(),
+				ClientSecret: "secret",
+				Scopes:       tt.scopes,
+			})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedScopes, result.Scopes)
+
+				claims, err := helper.ParseAndValidateToken(result.AccessToken)
+				assert.NoError(t, err)
+				assert.Equal(t, helper.TokenUseClient, claims.TokenUse)
+				assert.Equal(t, registered.ID.String(), claims.ClientID)
+				assert.Empty(t, claims.UserID)
+
+				if assert.Len(t, events.events, 1) {
+					assert.Equal(t, event.ClientTokenIssued{ClientID: registered.ID, Scopes: tt.expectedScopes, ExpiresAt: result.ExpiresAt}, events.events[0])
+				}
+			} else {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Empty(t, events.events)
+			}
+
+			mockClients.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+	googleuuid "github.com/google/uuid"
+)
+
+// maxAPIKeyNameLength bounds the label of an API key
+const maxAPIKeyNameLength = 64
+
+var (
+	// ErrInvalidAPIKeyName is returned when the key label is empty or too long
+	ErrInvalidAPIKeyName = errors.New("api key name must be 1 to 64 characters")
+	// ErrInvalidAPIKeyExpiry is returned when the requested expiry is not in the future
+	ErrInvalidAPIKeyExpiry = errors.New("api key expiry must be in the future")
+)
+
+// CreateAPIKeyRequest describes a new personal access token
+type CreateAPIKeyRequest struct {
+	UserID    googleuuid.UUID
+	Name      string
+	Scopes    []string
+	ExpiresAt gotime.Time // zero for a key that never expires
+}
+
+// CreateAPIKeyResult contains the new key. Token is only ever returned here.
+type CreateAPIKeyResult struct {
+	ID        googleuuid.UUID `json:"id"`
+	Name      string          `json:"name"`
+	Token     string          `json:"token"`
+	Prefix    string          `json:"prefix"`
+	Scopes    []string        `json:"scopes"`
+	CreatedAt gotime.Time     `json:"created_at"`
+	ExpiresAt *gotime.Time    `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyHandler interface
+type CreateAPIKeyHandler interface {
+	Handle(req CreateAPIKeyRequest) (*CreateAPIKeyResult, error)
+}
+
+type createAPIKeyHandler struct {
+	repo         token.APIKeyRepository
+	events       eventbus.Publisher
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// NewCreateAPIKeyHandler constructor
+func NewCreateAPIKeyHandler(repo token.APIKeyRepository, publisher eventbus.Publisher, up uuid.Provider, tp time.Provider) CreateAPIKeyHandler {
+	return &createAPIKeyHandler{repo: repo, events: publisher, uuidProvider: up, timeProvider: tp}
+}
+
+// Handle validates the request and stores the hash of a new key
+func (h *createAPIKeyHandler) Handle(req CreateAPIKeyRequest) (*CreateAPIKeyResult, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, ErrInvalidAPIKeyName
+	}
+	if err := token.ValidateScopes(req.Scopes, token.APIKeyScopes); err != nil {
+		return nil, err
+	}
+	now := h.timeProvider.Now().UTC()
+	if !req.ExpiresAt.IsZero() && !req.ExpiresAt.After(now) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+
+	raw, prefix, err := helper.GenerateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := token.APIKey{
+		ID:        h.uuidProvider.NewUUID(),
+		UserID:    req.UserID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+		ExpiresAt: req.ExpiresAt.UTC(),
+	}
+	h.repo.Save(raw, key)
+	h.events.Publish(event.APIKeyCreated{UserID: key.UserID, KeyID: key.ID, Name: key.Name, Scopes: key.Scopes})
+
+	result := &CreateAPIKeyResult{
+		ID:        key.ID,
+		Name:      key.Name,
+		Token:     raw,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+	if !key.ExpiresAt.IsZero() {
+		result.ExpiresAt = &key.ExpiresAt
+	}
+	return result, nil
+}
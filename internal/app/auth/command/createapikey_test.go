@@ -0,0 +1,93 @@
+package command_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateAPIKeyHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	keyID := uuid.New()
+
+	tests := []struct {
+		name          string
+		req           command.CreateAPIKeyRequest
+		expectedError error
+	}{
+		{
+			name:          "happy path",
+			req:           command.CreateAPIKeyRequest{UserID: userID, Name: "etl", Scopes: []string{token.ScopeFavoritesRead}},
+			expectedError: nil,
+		},
+		{
+			name:          "with expiry",
+			req:           command.CreateAPIKeyRequest{UserID: userID, Name: "etl", Scopes: []string{token.ScopeFavoritesRead}, ExpiresAt: now.Add(time.Hour)},
+			expectedError: nil,
+		},
+		{
+			name:          "missing name",
+			req:           command.CreateAPIKeyRequest{UserID: userID, Name: "  ", Scopes: []string{token.ScopeFavoritesRead}},
+			expectedError: command.ErrInvalidAPIKeyName,
+		},
+		{
+			name:          "unknown scope",
+			req:           command.CreateAPIKeyRequest{UserID: userID, Name: "etl", Scopes: []string{"admin"}},
+			expectedError: token.ErrInvalidScope,
+		},
+		{
+			name:          "no scope",
+			req:           command.CreateAPIKeyRequest{UserID: userID, Name: "etl"},
+			expectedError: token.ErrInvalidScope,
+		},
+		{
+			name:          "expiry in the past",
+			req:           command.CreateAPIKeyRequest{UserID: userID, Name: "etl", Scopes: []string{token.ScopeFavoritesRead}, ExpiresAt: now.Add(-time.Hour)},
+			expectedError: command.ErrInvalidAPIKeyExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockAPIKeyRepository{}
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Return(now)
+			mockUUID := &uuidprovider.MockProvider{}
+			mockUUID.On("NewUUID").Return(keyID)
+			if tt.expectedError == nil {
+				mockRepo.On("Save", mock.MatchedBy(func(raw string) bool {
+					return strings.HasPrefix(raw, helper.APIKeyPrefix)
+				}), mock.MatchedBy(func(k token.APIKey) bool {
+					return k.ID == keyID && k.UserID == userID && k.CreatedAt.Equal(now) && k.ExpiresAt.Equal(tt.req.ExpiresAt)
+				})).Return()
+			}
+
+			events := &eventLog{}
+			handler := command.NewCreateAPIKeyHandler(mockRepo, events, mockUUID, mockTime)
+
+			result, err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, strings.HasPrefix(result.Token, result.Prefix))
+				assert.Equal(t, []event.Event{event.APIKeyCreated{UserID: userID, KeyID: keyID, Name: result.Name, Scopes: result.Scopes}}, events.events)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/google/uuid"
+)
+
+// DeleteClientRequest represents an admin deleting a client
+type DeleteClientRequest struct {
+	ClientID uuid.UUID
+	Source   audit.Source
+}
+
+// DeleteClientHandler removes a registered client. Its issued tokens stop passing introspection
+// at once and expire with the access token lifetime.
+type DeleteClientHandler interface {
+	Handle(req DeleteClientRequest) error
+}
+
+type deleteClientHandler struct {
+	repo   client.Repository
+	audit  auditlog.Recorder
+	events eventbus.Publisher
+}
+
+// NewDeleteClientHandler constructor
+func NewDeleteClientHandler(repo client.Repository, recorder auditlog.Recorder, publisher eventbus.Publisher) DeleteClientHandler {
+	return &deleteClientHandler{repo: repo, audit: recorder, events: publisher}
+}
+
+func (h *deleteClientHandler) Handle(req DeleteClientRequest) error {
+	c, err := h.repo.GetByID(req.ClientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete client %s: %w", req.ClientID, err)
+	}
+	if err := h.repo.Delete(req.ClientID); err != nil {
+		return fmt.Errorf("failed to delete client %s: %w", req.ClientID, err)
+	}
+
+	entry := audit.NewEntry(req.Source, audit.ActionClientDelete, audit.Target{Type: audit.TargetClient, ID: req.ClientID.String()})
+	entry.Changes = audit.Diff(auditedClient(c), nil)
+	if err := h.audit.Record(entry); err != nil {
+		return err
+	}
+	h.events.Publish(event.ClientDeleted{ClientID: c.ID, Name: c.Name})
+	return nil
+}
@@ -0,0 +1,121 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonateHandler_Handle(t *testing.T) {
+	adminID := uuid.New()
+	userID := uuid.New()
+	active := &user.User{ID: userID, Username: "carol", Roles: []string{"admin"}, Status: user.StatusActive}
+	disabled := &user.User{ID: userID, Username: "carol", Status: user.StatusDisabled}
+
+	tests := []struct {
+		name           string
+		req            command.ImpersonateRequest
+		setupMock      func(m *MockUserRepository)
+		expectedError  error
+		expectedScopes []string
+	}{
+		{
+			name:           "read-only by default",
+			req:            command.ImpersonateRequest{ActorID: adminID, UserID: userID},
+			setupMock:      func(m *MockUserRepository) { m.On("GetByID", userID).Return(active, nil) },
+			expectedScopes: []string{token.ScopeFavoritesRead},
+		},
+		{
+			name:           "read-write",
+			req:            command.ImpersonateRequest{ActorID: adminID, UserID: userID, Mode: token.ImpersonationReadWrite},
+			setupMock:      func(m *MockUserRepository) { m.On("GetByID", userID).Return(active, nil) },
+			expectedScopes: []string{token.ScopeFavoritesRead, token.ScopeFavoritesWrite},
+		},
+		{
+			name:          "unknown mode",
+			req:           command.ImpersonateRequest{ActorID: adminID, UserID: userID, Mode: "admin"},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: token.ErrInvalidImpersonationMode,
+		},
+		{
+			name:          "admin cannot impersonate themselves",
+			req:           command.ImpersonateRequest{ActorID: adminID, UserID: adminID},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: command.ErrSelfImpersonation,
+		},
+		{
+			name:          "disabled user",
+			req:           command.ImpersonateRequest{ActorID: adminID, UserID: userID},
+			setupMock:     func(m *MockUserRepository) { m.On("GetByID", userID).Return(disabled, nil) },
+			expectedError: user.ErrUserDisabled,
+		},
+		{
+			name:          "unknown user",
+			req:           command.ImpersonateRequest{ActorID: adminID, UserID: userID},
+			setupMock:     func(m *MockUserRepository) { m.On("GetByID", userID).Return(nil, user.ErrUserNotFound) },
+			expectedError: user.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+			trail := &auditlogtest.Trail{}
+			events := &eventLog{}
+
+			result, err := command.NewImpersonateHandler(mockRepo, trail, events).Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Nil(t, result)
+				assert.Empty(t, trail.Entries)
+				assert.Empty(t, events.events)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.expectedScopes, result.Scopes)
+
+			claims, err := helper.ParseAndValidateToken(result.AccessToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, helper.TokenUseImpersonation, claims.TokenUse)
+			assert.Equal(t, userID.String(), claims.UserID)
+			assert.Equal(t, adminID.String(), claims.Act.Subject)
+			assert.Empty(t, claims.Roles, "the roles of the user are never granted")
+			assert.Equal(t, tt.expectedScopes, claims.Scopes)
+
+			if !assert.Len(t, trail.Entries, 1) {
+				return
+			}
+			assert.Equal(t, audit.ActionUserImpersonate, trail.Entries[0].Action)
+			assert.Equal(t, userID.String(), trail.Entries[0].Target.ID)
+			assert.Contains(t, trail.Entries[0].Changes, "mode")
+			assert.Equal(t, []event.Event{event.UserImpersonated{ActorID: adminID, UserID: userID, Mode: string(result.Mode), ExpiresAt: result.ExpiresAt.UTC()}}, events.events)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImpersonateHandler_NoTokenWhenAuditFails(t *testing.T) {
+	userID := uuid.New()
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("GetByID", userID).Return(&user.User{ID: userID, Status: user.StatusActive}, nil)
+	auditErr := errors.New("audit store down")
+
+	result, err := command.NewImpersonateHandler(mockRepo, &auditlogtest.Trail{Err: auditErr}, &eventLog{}).Handle(command.ImpersonateRequest{ActorID: uuid.New(), UserID: userID})
+	assert.ErrorIs(t, err, auditErr)
+	assert.Nil(t, result)
+}
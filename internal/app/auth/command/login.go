@@ -2,57 +2,106 @@ package command
 
 import (
 	"fmt"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
 	"github.com/akazantzidis/gwi-ass/internal/domain/token"
 	"github.com/akazantzidis/gwi-ass/internal/domain/user"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
 )
 
-// LoginRequest encapsulates username/password
+// LoginRequest encapsulates username/password and the client metadata of the new session
 type LoginRequest struct {
-	Username string
-	Password string
+	Username  string
+	Password  string
+	UserAgent string
+	IP        string
+	RequestID string
 }
 
 // LoginHandler interface
 type LoginHandler interface {
-	Handle(req LoginRequest) (accessToken string, refreshToken string, err error)
+	Handle(req LoginRequest) (LoginResult, error)
 }
 
 type loginHandler struct {
-	userRepo    user.Repository // you can define a UserRepo interface
-	refreshRepo token.RefreshRepository
+	userRepo user.Repository // you can define a UserRepo interface
+	hasher   user.PasswordHasher
+	guard    bruteforce.Guard
+	audit    auditlog.Recorder
+	events   eventbus.Publisher
+	sessions sessionIssuer
 }
 
-func NewLoginHandler(userRepo user.Repository, refreshRepo token.RefreshRepository) LoginHandler {
-	return &loginHandler{userRepo: userRepo, refreshRepo: refreshRepo}
+func NewLoginHandler(userRepo user.Repository, refreshRepo token.RefreshRepository, hasher user.PasswordHasher, guard bruteforce.Guard, recorder auditlog.Recorder, publisher eventbus.Publisher, up uuid.Provider, tp time.Provider) LoginHandler {
+	return &loginHandler{
+		userRepo: userRepo,
+		hasher:   hasher,
+		guard:    guard,
+		audit:    recorder,
+		events:   publisher,
+		sessions: sessionIssuer{refreshRepo: refreshRepo, audit: recorder, events: publisher, uuidProvider: up, timeProvider: tp},
+	}
 }
 
-func (h *loginHandler) Handle(req LoginRequest) (string, string, error) {
+// Handle verifies the credentials. Every attempt costs the same password verification, whatever the
+// account, the algorithm of its hash or the lockout state, so the timing cannot be used to discover accounts.
+func (h *loginHandler) Handle(req LoginRequest) (LoginResult, error) {
+	if err := h.guard.Check(req.Username, req.IP); err != nil {
+		_ = h.hasher.VerifyUniform("", req.Password)
+		return LoginResult{}, err
+	}
+
 	u, err := h.userRepo.GetByUsername(req.Username)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid credentials")
+		_ = h.hasher.VerifyUniform("", req.Password)
+		h.guard.Failure(req.Username, req.IP)
+		return LoginResult{}, h.fail(nil, req, fmt.Errorf("invalid credentials"))
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(req.Password)); err != nil {
-		return "", "", fmt.Errorf("invalid credentials")
+	if err := h.hasher.VerifyUniform(u.Password, req.Password); err != nil {
+		h.guard.Failure(req.Username, req.IP)
+		return LoginResult{}, h.fail(u, req, fmt.Errorf("invalid credentials"))
 	}
+	h.rehash(u, req.Password)
 
-	access, err := helper.GenerateAccessToken(u.ID.String(), u.Roles)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to generate access token: %w", err)
+	// only told once the password is proven, so it cannot be used to probe accounts
+	if !u.Active() {
+		return LoginResult{}, h.fail(u, req, user.ErrUserDisabled)
 	}
 
-	refresh, exp, err := helper.GenerateRefreshToken()
-	if err != nil {
-		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	// the failed attempts are only cleared once the second factor has been verified as well
+	if u.TOTP.Enabled {
+		mfaToken, exp, err := helper.GenerateMFAToken(u.ID.String())
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("failed to generate mfa token: %w", err)
+		}
+		return LoginResult{MFARequired: true, MFAToken: mfaToken, MFAExpiresAt: exp}, nil
 	}
 
-	h.refreshRepo.Save(refresh, token.RefreshRecord{
-		UserID: u.ID,
-		Expiry: exp,
-		Roles:  u.Roles,
-	})
+	h.guard.Success(req.Username)
+
+	return h.sessions.issue(u, audit.ActionLogin, req.RequestID, req.UserAgent, req.IP)
+}
 
-	return access, refresh, nil
+func (h *loginHandler) fail(u *user.User, req LoginRequest, err error) error {
+	return recordLoginFailure(h.audit, h.events, audit.ActionLogin, u, req.Username, req.RequestID, req.IP, err)
+}
+
+// rehash upgrades a verified password hash made with an outdated algorithm or parameters.
+// It is best effort: on failure the old hash keeps working and the upgrade is retried on the next login.
+func (h *loginHandler) rehash(u *user.User, password string) {
+	if !h.hasher.NeedsRehash(u.Password) {
+		return
+	}
+	hashed, err := h.hasher.Hash(password)
+	if err != nil {
+		return
+	}
+	if err := h.userRepo.ReplacePasswordHash(u.ID, u.Password, hashed); err == nil {
+		u.Password = hashed
+	}
 }
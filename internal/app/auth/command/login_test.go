@@ -0,0 +1,261 @@
+package command_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// noopGuard never throttles
+type noopGuard struct{}
+
+func (noopGuard) Check(username, ip string) error { return nil }
+func (noopGuard) Failure(username, ip string)     {}
+func (noopGuard) Success(username string)         {}
+
+// blockingGuard throttles every attempt
+type blockingGuard struct{ noopGuard }
+
+func (blockingGuard) Check(username, ip string) error {
+	return &bruteforce.ThrottledError{RetryAfter: time.Minute}
+}
+
+// eventLog collects the published events
+type eventLog struct {
+	events []event.Event
+}
+
+func (l *eventLog) Publish(e event.Event) {
+	l.events = append(l.events, e)
+}
+
+func TestLoginHandler_RehashesOutdatedHash(t *testing.T) {
+	bcryptHasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: 4})
+	if !assert.NoError(t, err) {
+		return
+	}
+	argonHasher, err := passwordhash.New(passwordhash.Config{
+		Algorithm: passwordhash.Argon2id,
+		Argon2id:  passwordhash.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	bcryptHash, err := bcryptHasher.Hash("password1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	argonHash, err := argonHasher.Hash("password1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name      string
+		hash      string
+		password  string
+		setupMock func(m *MockUserRepository, id uuid.UUID)
+		wantErr   bool
+	}{
+		{
+			name:     "outdated hash is upgraded",
+			hash:     bcryptHash,
+			password: "password1",
+			setupMock: func(m *MockUserRepository, id uuid.UUID) {
+				m.On("ReplacePasswordHash", id, bcryptHash, mock.MatchedBy(func(h string) bool {
+					return strings.HasPrefix(h, "$argon2id$") && argonHasher.Verify(h, "password1") == nil
+				})).Return(nil)
+			},
+		},
+		{
+			name:      "current hash is kept",
+			hash:      argonHash,
+			password:  "password1",
+			setupMock: func(m *MockUserRepository, id uuid.UUID) {},
+		},
+		{
+			name:      "outdated hash is kept on a wrong password",
+			hash:      bcryptHash,
+			password:  "wrong",
+			setupMock: func(m *MockUserRepository, id uuid.UUID) {},
+			wantErr:   true,
+		},
+		{
+			name:     "concurrent password change wins",
+			hash:     bcryptHash,
+			password: "password1",
+			setupMock: func(m *MockUserRepository, id uuid.UUID) {
+				m.On("ReplacePasswordHash", id, bcryptHash, mock.Anything).Return(user.ErrPasswordHashChanged)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &user.User{ID: uuid.New(), Username: "alice", Password: tt.hash, Roles: []string{"user"}}
+			userRepo := &MockUserRepository{}
+			userRepo.On("GetByUsername", "alice").Return(u, nil)
+			tt.setupMock(userRepo, u.ID)
+
+			refreshRepo := &MockRefreshRepository{}
+			refreshRepo.On("Save", mock.Anything, mock.Anything).Maybe()
+
+			up := &uuidprovider.MockProvider{}
+			up.On("NewUUID").Return(uuid.New()).Maybe()
+			tp := &timeprovider.MockProvider{}
+			tp.On("Now").Return(time.Now()).Maybe()
+
+			trail := &auditlogtest.Trail{}
+			events := &eventLog{}
+
+			handler := command.NewLoginHandler(userRepo, refreshRepo, argonHasher, noopGuard{}, trail, events, up, tp)
+
+			result, err := handler.Handle(command.LoginRequest{Username: "alice", Password: tt.password, RequestID: "req-1"})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.NotEmpty(t, result.AccessToken)
+			}
+			if !assert.Len(t, trail.Entries, 1) {
+				return
+			}
+			assert.Equal(t, audit.ActionLogin, trail.Entries[0].Action)
+			assert.Equal(t, u.ID.String(), trail.Entries[0].Target.ID)
+			assert.Equal(t, "req-1", trail.Entries[0].RequestID)
+			if !assert.Len(t, events.events, 1) {
+				return
+			}
+			if tt.wantErr {
+				assert.Equal(t, audit.OutcomeFailure, trail.Entries[0].Outcome)
+				assert.Equal(t, event.UserLoginFailed{UserID: u.ID, Username: "alice", Method: event.LoginPassword, Reason: err.Error()}, events.events[0])
+			} else {
+				assert.Equal(t, audit.OutcomeSuccess, trail.Entries[0].Outcome)
+				loggedIn, ok := events.events[0].(event.UserLoggedIn)
+				if !assert.True(t, ok) {
+					return
+				}
+				assert.Equal(t, u.ID, loggedIn.UserID)
+				assert.Equal(t, event.LoginPassword, loggedIn.Method)
+			}
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// uniformHasher records how the login handler verifies passwords
+type uniformHasher struct {
+	user.PasswordHasher
+	verified []string
+}
+
+func (h *uniformHasher) Verify(hash, plainPassword string) error {
+	h.verified = append(h.verified, "Verify")
+	return h.PasswordHasher.Verify(hash, plainPassword)
+}
+
+func (h *uniformHasher) VerifyUniform(hash, plainPassword string) error {
+	h.verified = append(h.verified, "VerifyUniform")
+	return h.PasswordHasher.VerifyUniform(hash, plainPassword)
+}
+
+func TestLoginHandler_UniformVerification(t *testing.T) {
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: 4})
+	if !assert.NoError(t, err) {
+		return
+	}
+	hash, err := hasher.Hash("password1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	userRepo := &MockUserRepository{}
+	userRepo.On("GetByUsername", "alice").Return(&user.User{ID: uuid.New(), Username: "alice", Password: hash}, nil)
+	userRepo.On("GetByUsername", "nobody").Return(nil, errors.New("user not found"))
+
+	// the unknown user, the known one and the throttled attempt all cost one uniform verification
+	for _, tt := range []struct {
+		username string
+		guard    bruteforce.Guard
+	}{
+		{username: "nobody", guard: noopGuard{}},
+		{username: "alice", guard: noopGuard{}},
+		{username: "alice", guard: blockingGuard{}},
+	} {
+		recorder := &uniformHasher{PasswordHasher: hasher}
+		handler := command.NewLoginHandler(userRepo, &MockRefreshRepository{}, recorder, tt.guard, &auditlogtest.Trail{}, &eventLog{}, &uuidprovider.MockProvider{}, &timeprovider.MockProvider{})
+		_, err := handler.Handle(command.LoginRequest{Username: tt.username, Password: "wrong"})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"VerifyUniform"}, recorder.verified, tt.username)
+	}
+}
+
+func TestLoginHandler_RejectsDisabledUser(t *testing.T) {
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: 4})
+	if !assert.NoError(t, err) {
+		return
+	}
+	hash, err := hasher.Hash("password1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u := &user.User{ID: uuid.New(), Username: "alice", Password: hash, Roles: []string{"user"}, Status: user.StatusDisabled}
+	userRepo := &MockUserRepository{}
+	userRepo.On("GetByUsername", "alice").Return(u, nil)
+	refreshRepo := &MockRefreshRepository{}
+
+	trail := &auditlogtest.Trail{}
+
+	handler := command.NewLoginHandler(userRepo, refreshRepo, hasher, noopGuard{}, trail, &eventLog{}, &uuidprovider.MockProvider{}, &timeprovider.MockProvider{})
+
+	result, err := handler.Handle(command.LoginRequest{Username: "alice", Password: "password1"})
+	assert.ErrorIs(t, err, user.ErrUserDisabled)
+	assert.Empty(t, result.AccessToken)
+	refreshRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	if !assert.Len(t, trail.Entries, 1) {
+		return
+	}
+	assert.Equal(t, audit.OutcomeFailure, trail.Entries[0].Outcome)
+}
+
+func TestLoginHandler_FailsWhenAuditFails(t *testing.T) {
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: 4})
+	if !assert.NoError(t, err) {
+		return
+	}
+	hash, err := hasher.Hash("password1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u := &user.User{ID: uuid.New(), Username: "alice", Password: hash, Roles: []string{"user"}}
+	userRepo := &MockUserRepository{}
+	userRepo.On("GetByUsername", "alice").Return(u, nil)
+	refreshRepo := &MockRefreshRepository{}
+	auditErr := errors.New("audit store down")
+
+	handler := command.NewLoginHandler(userRepo, refreshRepo, hasher, noopGuard{}, &auditlogtest.Trail{Err: auditErr}, &eventLog{}, &uuidprovider.MockProvider{}, &timeprovider.MockProvider{})
+
+	result, err := handler.Handle(command.LoginRequest{Username: "alice", Password: "password1"})
+	assert.ErrorIs(t, err, auditErr)
+	assert.Empty(t, result.AccessToken)
+	refreshRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,90 @@
+package command
+
+import (
+	"errors"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+	googleuuid "github.com/google/uuid"
+)
+
+// ErrInvalidMFAToken is returned when the challenge token of a two-factor login is invalid or expired
+var ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+
+// MFALoginRequest completes a login with the second factor
+type MFALoginRequest struct {
+	MFAToken  string
+	Code      string // TOTP code or recovery code
+	UserAgent string
+	IP        string
+	RequestID string
+}
+
+// MFALoginHandler interface
+type MFALoginHandler interface {
+	Handle(req MFALoginRequest) (LoginResult, error)
+}
+
+type mfaLoginHandler struct {
+	userRepo user.Repository
+	guard    bruteforce.Guard
+	verifier mfa.Verifier
+	audit    auditlog.Recorder
+	events   eventbus.Publisher
+	sessions sessionIssuer
+}
+
+// NewMFALoginHandler constructor
+func NewMFALoginHandler(userRepo user.Repository, refreshRepo token.RefreshRepository, guard bruteforce.Guard, verifier mfa.Verifier, recorder auditlog.Recorder, publisher eventbus.Publisher, up uuid.Provider, tp time.Provider) MFALoginHandler {
+	return &mfaLoginHandler{
+		userRepo: userRepo,
+		guard:    guard,
+		verifier: verifier,
+		audit:    recorder,
+		events:   publisher,
+		sessions: sessionIssuer{refreshRepo: refreshRepo, audit: recorder, events: publisher, uuidProvider: up, timeProvider: tp},
+	}
+}
+
+// Handle verifies the challenge token and the second factor, then issues the token pair
+func (h *mfaLoginHandler) Handle(req MFALoginRequest) (LoginResult, error) {
+	subject, err := helper.ParseMFAToken(req.MFAToken)
+	if err != nil {
+		return LoginResult{}, ErrInvalidMFAToken
+	}
+	userID, err := googleuuid.Parse(subject)
+	if err != nil {
+		return LoginResult{}, ErrInvalidMFAToken
+	}
+
+	u, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		return LoginResult{}, ErrInvalidMFAToken
+	}
+
+	if err := h.guard.Check(u.Username, req.IP); err != nil {
+		return LoginResult{}, err
+	}
+
+	if err := h.verifier.Verify(*u, req.Code); err != nil {
+		h.guard.Failure(u.Username, req.IP)
+		return LoginResult{}, recordLoginFailure(h.audit, h.events, audit.ActionLoginMFA, u, u.Username, req.RequestID, req.IP, err)
+	}
+
+	h.guard.Success(u.Username)
+
+	// the user may have been disabled while the challenge was pending
+	if !u.Active() {
+		return LoginResult{}, recordLoginFailure(h.audit, h.events, audit.ActionLoginMFA, u, u.Username, req.RequestID, req.IP, user.ErrUserDisabled)
+	}
+
+	return h.sessions.issue(u, audit.ActionLoginMFA, req.RequestID, req.UserAgent, req.IP)
+}
@@ -1,22 +1,45 @@
 package command
 
 import (
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/token"
 )
 
+// LogoutRequest ends the session of a refresh token
+type LogoutRequest struct {
+	RefreshToken string
+	IP           string
+	RequestID    string
+}
+
 type LogoutHandler interface {
-	Handle(refreshToken string) error
+	Handle(req LogoutRequest) error
 }
 
 type logoutHandler struct {
 	refreshRepo token.RefreshRepository
+	audit       auditlog.Recorder
+	events      eventbus.Publisher
 }
 
-func NewLogoutHandler(rr token.RefreshRepository) LogoutHandler {
-	return &logoutHandler{refreshRepo: rr}
+func NewLogoutHandler(rr token.RefreshRepository, recorder auditlog.Recorder, publisher eventbus.Publisher) LogoutHandler {
+	return &logoutHandler{refreshRepo: rr, audit: recorder, events: publisher}
 }
 
-func (h *logoutHandler) Handle(refreshToken string) error {
-	h.refreshRepo.Delete(refreshToken)
+func (h *logoutHandler) Handle(req LogoutRequest) error {
+	rec, ok := h.refreshRepo.Get(req.RefreshToken)
+	if !ok {
+		return nil
+	}
+	h.refreshRepo.Delete(req.RefreshToken)
+
+	source := audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: rec.UserID.String()}, RequestID: req.RequestID, IP: req.IP}
+	if err := h.audit.Record(audit.NewEntry(source, audit.ActionLogout, audit.Target{Type: audit.TargetSession, ID: rec.ID.String()})); err != nil {
+		return err
+	}
+	h.events.Publish(event.UserLoggedOut{UserID: rec.UserID, SessionID: rec.ID})
 	return nil
 }
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/google/uuid"
+)
+
+// LogoutAllRequest revokes every session of the user making the request
+type LogoutAllRequest struct {
+	UserID uuid.UUID
+	Source audit.Source
+}
+
+// LogoutAllHandler revokes every session of a user
+type LogoutAllHandler interface {
+	Handle(req LogoutAllRequest) (int, error)
+}
+
+type logoutAllHandler struct {
+	refreshRepo token.RefreshRepository
+	audit       auditlog.Recorder
+	events      eventbus.Publisher
+}
+
+// NewLogoutAllHandler constructor
+func NewLogoutAllHandler(rr token.RefreshRepository, recorder auditlog.Recorder, publisher eventbus.Publisher) LogoutAllHandler {
+	return &logoutAllHandler{refreshRepo: rr, audit: recorder, events: publisher}
+}
+
+// Handle removes all refresh tokens of the user and returns how many sessions were revoked
+func (h *logoutAllHandler) Handle(req LogoutAllRequest) (int, error) {
+	revoked := h.refreshRepo.DeleteByUser(req.UserID)
+	if err := h.audit.Record(audit.NewEntry(req.Source, audit.ActionLogoutAll, audit.Target{Type: audit.TargetUser, ID: req.UserID.String()})); err != nil {
+		return revoked, err
+	}
+	h.events.Publish(event.UserSessionsRevoked{UserID: req.UserID, Revoked: revoked})
+	return revoked, nil
+}
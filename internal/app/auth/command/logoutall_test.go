@@ -0,0 +1,38 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogoutAllHandler_Handle(t *testing.T) {
+	mockUserID := uuid.New()
+
+	mockRepo := &MockRefreshRepository{}
+	mockRepo.On("DeleteByUser", mockUserID).Return(3)
+
+	trail := &auditlogtest.Trail{}
+	source := audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: mockUserID.String()}, RequestID: "req-1"}
+
+	events := &eventLog{}
+	handler := command.NewLogoutAllHandler(mockRepo, trail, events)
+
+	revoked, err := handler.Handle(command.LogoutAllRequest{UserID: mockUserID, Source: source})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, revoked)
+	if assert.Len(t, trail.Entries, 1) {
+		assert.Equal(t, audit.ActionLogoutAll, trail.Entries[0].Action)
+		assert.Equal(t, source.Actor, trail.Entries[0].Actor)
+		assert.Equal(t, mockUserID.String(), trail.Entries[0].Target.ID)
+	}
+	assert.Equal(t, []event.Event{event.UserSessionsRevoked{UserID: mockUserID, Revoked: 3}}, events.events)
+
+	mockRepo.AssertExpectations(t)
+}
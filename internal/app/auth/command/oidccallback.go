@@ -0,0 +1,162 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+)
+
+var (
+	// ErrInvalidOIDCState is returned when the callback state is unknown, used or expired
+	ErrInvalidOIDCState = errors.New("invalid or expired oidc state")
+	// ErrOIDCLoginFailed is returned when the provider does not confirm the identity
+	ErrOIDCLoginFailed = errors.New("oidc login failed")
+)
+
+// OIDCCallbackRequest is the provider redirect back to the service
+type OIDCCallbackRequest struct {
+	State     string
+	Code      string
+	UserAgent string
+	IP        string
+	RequestID string
+}
+
+// OIDCCallbackHandler completes an OpenID Connect login
+type OIDCCallbackHandler interface {
+	Handle(req OIDCCallbackRequest) (LoginResult, error)
+}
+
+type oidcCallbackHandler struct {
+	provider     oidc.Provider
+	stateRepo    token.OIDCStateRepository
+	userRepo     user.Repository
+	roleMapping  oidc.RoleMapping
+	defaultRoles []string
+	audit        auditlog.Recorder
+	events       eventbus.Publisher
+	timeProvider time.Provider
+	sessions     sessionIssuer
+}
+
+// NewOIDCCallbackHandler constructor. Users are given defaultRoles plus the roles mapped from their provider groups.
+func NewOIDCCallbackHandler(provider oidc.Provider, stateRepo token.OIDCStateRepository, userRepo user.Repository, refreshRepo token.RefreshRepository,
+	roleMapping oidc.RoleMapping, defaultRoles []string, recorder auditlog.Recorder, publisher eventbus.Publisher, up uuid.Provider, tp time.Provider) OIDCCallbackHandler {
+	return &oidcCallbackHandler{
+		provider:     provider,
+		stateRepo:    stateRepo,
+		userRepo:     userRepo,
+		roleMapping:  roleMapping,
+		defaultRoles: defaultRoles,
+		audit:        recorder,
+		events:       publisher,
+		timeProvider: tp,
+		sessions:     sessionIssuer{refreshRepo: refreshRepo, audit: recorder, events: publisher, uuidProvider: up, timeProvider: tp},
+	}
+}
+
+// Handle redeems the code, provisions or updates the linked local user and issues the token pair,
+// or the MFA challenge of a user enrolled in two-factor authentication
+func (h *oidcCallbackHandler) Handle(req OIDCCallbackRequest) (LoginResult, error) {
+	state, ok := h.stateRepo.Consume(req.State)
+	if !ok || h.timeProvider.Now().After(state.Expiry) {
+		return LoginResult{}, ErrInvalidOIDCState
+	}
+
+	identity, err := h.provider.Exchange(req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	u, err := h.resolveUser(identity)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !u.Active() {
+		return LoginResult{}, recordLoginFailure(h.audit, h.events, audit.ActionLoginOIDC, u, u.Username, req.RequestID, req.IP, user.ErrUserDisabled)
+	}
+
+	// the provider owns the group memberships, so roles follow them on every login
+	roles := h.roleMapping.Roles(identity.Groups, h.defaultRoles)
+	if !sameRoles(u.Roles, roles) {
+		if err := h.userRepo.UpdateRoles(u.ID, roles); err != nil {
+			return LoginResult{}, fmt.Errorf("failed to update roles: %w", err)
+		}
+		u.Roles = roles
+	}
+
+	// so does the email address, when the provider shares a valid one
+	if email := user.NormalizeEmail(identity.Email); email != "" && email != u.Email && user.ValidateEmail(email) == nil {
+		if err := h.userRepo.UpdateEmail(u.ID, email); err != nil {
+			return LoginResult{}, fmt.Errorf("failed to update email: %w", err)
+		}
+		u.Email = email
+	}
+
+	// the provider only proves the first factor, enrolled users still complete the login with POST /login/mfa
+	if u.TOTP.Enabled {
+		mfaToken, exp, err := helper.GenerateMFAToken(u.ID.String())
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("failed to generate mfa token: %w", err)
+		}
+		return LoginResult{MFARequired: true, MFAToken: mfaToken, MFAExpiresAt: exp}, nil
+	}
+
+	return h.sessions.issue(u, audit.ActionLoginOIDC, req.RequestID, req.UserAgent, req.IP)
+}
+
+// resolveUser returns the user linked to identity, provisioning one on first login.
+// An existing local user with the same username is never linked automatically.
+func (h *oidcCallbackHandler) resolveUser(identity oidc.Identity) (*user.User, error) {
+	link := user.Identity{Issuer: identity.Issuer, Subject: identity.Subject}
+	if u, err := h.userRepo.GetByIdentity(link); err == nil {
+		return u, nil
+	}
+
+	// federated users never log in with a password, they get one nobody knows
+	password, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	var u *user.User
+	for _, username := range oidc.UsernameCandidates(identity) {
+		u, err = h.userRepo.Add(username, password, h.defaultRoles)
+		if !errors.Is(err, user.ErrUsernameTaken) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+
+	if err := h.userRepo.LinkIdentity(u.ID, link); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return u, nil
+}
+
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]struct{}, len(a))
+	for _, r := range a {
+		seen[r] = struct{}{}
+	}
+	for _, r := range b {
+		if _, ok := seen[r]; !ok {
+			return false
+		}
+	}
+	return true
+}
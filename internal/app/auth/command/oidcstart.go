@@ -0,0 +1,68 @@
+package command
+
+import (
+	"fmt"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// StartOIDCLoginResult contains where to send the user agent. State must be bound to the
+// user agent, so that the callback only completes the login in the browser that started it.
+type StartOIDCLoginResult struct {
+	AuthURL string
+	State   string
+	Expiry  gotime.Time
+}
+
+// StartOIDCLoginHandler starts an OpenID Connect login
+type StartOIDCLoginHandler interface {
+	Handle() (*StartOIDCLoginResult, error)
+}
+
+type startOIDCLoginHandler struct {
+	provider     oidc.Provider
+	stateRepo    token.OIDCStateRepository
+	stateTTL     gotime.Duration
+	timeProvider time.Provider
+}
+
+// NewStartOIDCLoginHandler constructor, stateTTL bounds how long the user may take at the provider
+func NewStartOIDCLoginHandler(provider oidc.Provider, stateRepo token.OIDCStateRepository, stateTTL gotime.Duration, tp time.Provider) StartOIDCLoginHandler {
+	return &startOIDCLoginHandler{provider: provider, stateRepo: stateRepo, stateTTL: stateTTL, timeProvider: tp}
+}
+
+// Handle stores a fresh state, nonce and PKCE verifier and returns the authorization URL
+func (h *startOIDCLoginHandler) Handle() (*StartOIDCLoginResult, error) {
+	state, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+	nonce, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier, challenge, err := helper.GeneratePKCE()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+
+	authURL, err := h.provider.AuthCodeURL(state, nonce, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build authorization url: %w", err)
+	}
+
+	now := h.timeProvider.Now().UTC()
+	expiry := now.Add(h.stateTTL)
+	h.stateRepo.Save(state, token.OIDCStateRecord{
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		CreatedAt:    now,
+		Expiry:       expiry,
+	})
+
+	return &StartOIDCLoginResult{AuthURL: authURL, State: state, Expiry: expiry}, nil
+}
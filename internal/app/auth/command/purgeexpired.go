@@ -0,0 +1,26 @@
+package command
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// PurgeExpiredSessionsHandler removes refresh tokens that are past their expiry
+type PurgeExpiredSessionsHandler interface {
+	Handle() (int, error)
+}
+
+type purgeExpiredSessionsHandler struct {
+	refreshRepo  token.RefreshRepository
+	timeProvider time.Provider
+}
+
+// NewPurgeExpiredSessionsHandler constructor
+func NewPurgeExpiredSessionsHandler(rr token.RefreshRepository, tp time.Provider) PurgeExpiredSessionsHandler {
+	return &purgeExpiredSessionsHandler{refreshRepo: rr, timeProvider: tp}
+}
+
+// Handle purges the expired sessions and returns how many were removed
+func (h *purgeExpiredSessionsHandler) Handle() (int, error) {
+	return h.refreshRepo.DeleteExpired(h.timeProvider.Now().UTC()), nil
+}
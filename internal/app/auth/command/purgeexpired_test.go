@@ -0,0 +1,28 @@
+package command_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeExpiredSessionsHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo := &MockRefreshRepository{}
+	mockRepo.On("DeleteExpired", now).Return(2)
+	mockTime := &timeprovider.MockProvider{}
+	mockTime.On("Now").Return(now)
+
+	handler := command.NewPurgeExpiredSessionsHandler(mockRepo, mockTime)
+
+	removed, err := handler.Handle()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	mockRepo.AssertExpectations(t)
+}
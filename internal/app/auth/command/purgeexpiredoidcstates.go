@@ -0,0 +1,26 @@
+package command
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// PurgeExpiredOIDCStatesHandler removes OpenID Connect logins abandoned at the provider
+type PurgeExpiredOIDCStatesHandler interface {
+	Handle() (int, error)
+}
+
+type purgeExpiredOIDCStatesHandler struct {
+	stateRepo    token.OIDCStateRepository
+	timeProvider time.Provider
+}
+
+// NewPurgeExpiredOIDCStatesHandler constructor
+func NewPurgeExpiredOIDCStatesHandler(sr token.OIDCStateRepository, tp time.Provider) PurgeExpiredOIDCStatesHandler {
+	return &purgeExpiredOIDCStatesHandler{stateRepo: sr, timeProvider: tp}
+}
+
+// Handle purges the expired states and returns how many were removed
+func (h *purgeExpiredOIDCStatesHandler) Handle() (int, error) {
+	return h.stateRepo.DeleteExpired(h.timeProvider.Now().UTC()), nil
+}
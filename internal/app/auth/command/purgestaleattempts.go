@@ -0,0 +1,30 @@
+package command
+
+import (
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// PurgeStaleLoginAttemptsHandler removes failed login attempts that no longer count
+type PurgeStaleLoginAttemptsHandler interface {
+	Handle() (int, error)
+}
+
+type purgeStaleLoginAttemptsHandler struct {
+	repo         lockout.Repository
+	retention    gotime.Duration
+	timeProvider time.Provider
+}
+
+// NewPurgeStaleLoginAttemptsHandler constructor, retention is how long failures are remembered
+func NewPurgeStaleLoginAttemptsHandler(repo lockout.Repository, retention gotime.Duration, tp time.Provider) PurgeStaleLoginAttemptsHandler {
+	return &purgeStaleLoginAttemptsHandler{repo: repo, retention: retention, timeProvider: tp}
+}
+
+// Handle purges the stale records and returns how many were removed
+func (h *purgeStaleLoginAttemptsHandler) Handle() (int, error) {
+	now := h.timeProvider.Now()
+	return h.repo.DeleteStale(now.Add(-h.retention), now), nil
+}
@@ -2,14 +2,22 @@ package command
 
 import (
 	"fmt"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
-	"time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
 
 	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
 )
 
 type RefreshRequest struct {
 	RefreshToken string
+	UserAgent    string
+	IP           string
+	RequestID    string
 }
 
 type RefreshHandler interface {
@@ -17,11 +25,15 @@ type RefreshHandler interface {
 }
 
 type refreshHandler struct {
-	refreshRepo token.RefreshRepository
+	refreshRepo  token.RefreshRepository
+	userRepo     user.Repository
+	audit        auditlog.Recorder
+	events       eventbus.Publisher
+	timeProvider time.Provider
 }
 
-func NewRefreshHandler(refreshRepo token.RefreshRepository) RefreshHandler {
-	return &refreshHandler{refreshRepo: refreshRepo}
+func NewRefreshHandler(refreshRepo token.RefreshRepository, userRepo user.Repository, recorder auditlog.Recorder, publisher eventbus.Publisher, tp time.Provider) RefreshHandler {
+	return &refreshHandler{refreshRepo: refreshRepo, userRepo: userRepo, audit: recorder, events: publisher, timeProvider: tp}
 }
 
 func (h *refreshHandler) Handle(req RefreshRequest) (string, string, error) {
@@ -34,7 +46,8 @@ func (h *refreshHandler) Handle(req RefreshRequest) (string, string, error) {
 		return "", "", fmt.Errorf("invalid refresh token")
 	}
 
-	if time.Now().After(rec.Expiry) {
+	now := h.timeProvider.Now().UTC()
+	if now.After(rec.Expiry) {
 		h.refreshRepo.Delete(req.RefreshToken)
 		return "", "", fmt.Errorf("refresh token expired")
 	}
@@ -42,6 +55,22 @@ func (h *refreshHandler) Handle(req RefreshRequest) (string, string, error) {
 	// ROTATE TOKEN
 	h.refreshRepo.Delete(req.RefreshToken)
 
+	// the user is read again so a disabled user loses the session and role changes apply
+	u, err := h.userRepo.GetByID(rec.UserID)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+	if !u.Active() {
+		return "", "", user.ErrUserDisabled
+	}
+	rec.Roles = u.Roles
+
+	source := audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: u.ID.String()}, RequestID: req.RequestID, IP: req.IP}
+	entry := audit.NewEntry(source, audit.ActionRefresh, audit.Target{Type: audit.TargetSession, ID: rec.ID.String()})
+	if err := h.audit.Record(entry); err != nil {
+		return "", "", err
+	}
+
 	access, err := helper.GenerateAccessToken(rec.UserID.String(), rec.Roles)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate access token: %w", err)
@@ -52,11 +81,13 @@ func (h *refreshHandler) Handle(req RefreshRequest) (string, string, error) {
 		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	h.refreshRepo.Save(newRefresh, token.RefreshRecord{
-		UserID: rec.UserID,
-		Expiry: exp,
-		Roles:  rec.Roles,
-	})
+	// the rotated token keeps the session identity, only its activity metadata moves forward
+	rec.Expiry = exp
+	rec.LastRefreshedAt = now
+	rec.UserAgent = req.UserAgent
+	rec.IP = req.IP
+	h.refreshRepo.Save(newRefresh, rec)
+	h.events.Publish(event.SessionRefreshed{UserID: rec.UserID, SessionID: rec.ID})
 
 	return access, newRefresh, nil
 }
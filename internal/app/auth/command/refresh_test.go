@@ -0,0 +1,129 @@
+package command_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRefreshHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	record := token.RefreshRecord{
+		ID:              uuid.New(),
+		UserID:          uuid.New(),
+		Expiry:          now.Add(time.Hour),
+		Roles:           []string{"user"},
+		CreatedAt:       now.Add(-time.Hour),
+		LastRefreshedAt: now.Add(-time.Hour),
+		UserAgent:       "old-agent",
+		IP:              "10.0.0.1",
+	}
+	owner := &user.User{ID: record.UserID, Username: "alice", Roles: []string{"admin", "user"}, Status: user.StatusActive}
+	disabled := &user.User{ID: record.UserID, Username: "alice", Roles: []string{"user"}, Status: user.StatusDisabled}
+
+	tests := []struct {
+		name          string
+		req           command.RefreshRequest
+		setupMock     func(m *MockRefreshRepository, u *MockUserRepository)
+		expectedError string
+	}{
+		{
+			name: "happy path - rotation keeps the session",
+			req:  command.RefreshRequest{RefreshToken: "old", UserAgent: "new-agent", IP: "10.0.0.2"},
+			setupMock: func(m *MockRefreshRepository, u *MockUserRepository) {
+				m.On("Get", "old").Return(record, true)
+				m.On("Delete", "old").Return()
+				u.On("GetByID", record.UserID).Return(owner, nil)
+				m.On("Save", mock.Anything, mock.MatchedBy(func(rec token.RefreshRecord) bool {
+					return rec.ID == record.ID &&
+						assert.ObjectsAreEqual(owner.Roles, rec.Roles) &&
+						rec.CreatedAt.Equal(record.CreatedAt) &&
+						rec.LastRefreshedAt.Equal(now) &&
+						rec.UserAgent == "new-agent" &&
+						rec.IP == "10.0.0.2"
+				})).Return()
+			},
+			expectedError: "",
+		},
+		{
+			name:          "missing token",
+			req:           command.RefreshRequest{},
+			setupMock:     func(m *MockRefreshRepository, u *MockUserRepository) {},
+			expectedError: "missing refresh token",
+		},
+		{
+			name: "unknown token",
+			req:  command.RefreshRequest{RefreshToken: "unknown"},
+			setupMock: func(m *MockRefreshRepository, u *MockUserRepository) {
+				m.On("Get", "unknown").Return(token.RefreshRecord{}, false)
+			},
+			expectedError: "invalid refresh token",
+		},
+		{
+			name: "expired token",
+			req:  command.RefreshRequest{RefreshToken: "expired"},
+			setupMock: func(m *MockRefreshRepository, u *MockUserRepository) {
+				expired := record
+				expired.Expiry = now.Add(-time.Minute)
+				m.On("Get", "expired").Return(expired, true)
+				m.On("Delete", "expired").Return()
+			},
+			expectedError: "refresh token expired",
+		},
+		{
+			name: "disabled user",
+			req:  command.RefreshRequest{RefreshToken: "old"},
+			setupMock: func(m *MockRefreshRepository, u *MockUserRepository) {
+				m.On("Get", "old").Return(record, true)
+				m.On("Delete", "old").Return()
+				u.On("GetByID", record.UserID).Return(disabled, nil)
+			},
+			expectedError: user.ErrUserDisabled.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRefreshRepository{}
+			mockUsers := &MockUserRepository{}
+			tt.setupMock(mockRepo, mockUsers)
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Maybe().Return(now)
+
+			trail := &auditlogtest.Trail{}
+
+			events := &eventLog{}
+			handler := command.NewRefreshHandler(mockRepo, mockUsers, trail, events, mockTime)
+
+			access, refresh, err := handler.Handle(tt.req)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, trail.Entries)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, access)
+				assert.NotEmpty(t, refresh)
+				if assert.Len(t, trail.Entries, 1) {
+					assert.Equal(t, audit.ActionRefresh, trail.Entries[0].Action)
+					assert.Equal(t, record.ID.String(), trail.Entries[0].Target.ID)
+				}
+				assert.Equal(t, []event.Event{event.SessionRefreshed{UserID: record.UserID, SessionID: record.ID}}, events.events)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
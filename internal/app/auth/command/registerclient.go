@@ -0,0 +1,105 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+	googleuuid "github.com/google/uuid"
+)
+
+// ErrInvalidClientName is returned when the client name is empty or too long
+var ErrInvalidClientName = errors.New("client name must be 1 to 64 characters")
+
+// RegisterClientRequest describes a new OAuth2 client
+type RegisterClientRequest struct {
+	Name   string
+	Scopes []string
+	Source audit.Source
+}
+
+// RegisterClientResult contains the client credentials. ClientSecret is only ever returned here.
+type RegisterClientResult struct {
+	ClientID     googleuuid.UUID `json:"client_id"`
+	ClientSecret string          `json:"client_secret"`
+	Name         string          `json:"name"`
+	Scopes       []string        `json:"scopes"`
+	CreatedAt    gotime.Time     `json:"created_at"`
+}
+
+// RegisterClientHandler interface
+type RegisterClientHandler interface {
+	Handle(req RegisterClientRequest) (*RegisterClientResult, error)
+}
+
+type registerClientHandler struct {
+	repo         client.Repository
+	hasher       helper.TokenHasher
+	audit        auditlog.Recorder
+	events       eventbus.Publisher
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// NewRegisterClientHandler constructor
+func NewRegisterClientHandler(repo client.Repository, hasher helper.TokenHasher, recorder auditlog.Recorder, publisher eventbus.Publisher, up uuid.Provider, tp time.Provider) RegisterClientHandler {
+	return &registerClientHandler{repo: repo, hasher: hasher, audit: recorder, events: publisher, uuidProvider: up, timeProvider: tp}
+}
+
+// Handle registers the client and stores the hash of a new secret
+func (h *registerClientHandler) Handle(req RegisterClientRequest) (*RegisterClientResult, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 64 {
+		return nil, ErrInvalidClientName
+	}
+	if err := token.ValidateScopes(req.Scopes, token.ClientScopes); err != nil {
+		return nil, err
+	}
+
+	secret, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client secret: %w", err)
+	}
+
+	c := client.Client{
+		ID:         h.uuidProvider.NewUUID(),
+		Name:       name,
+		SecretHash: h.hasher.Hash(secret),
+		Scopes:     req.Scopes,
+		CreatedAt:  h.timeProvider.Now().UTC(),
+	}
+	h.repo.Save(c)
+
+	entry := audit.NewEntry(req.Source, audit.ActionClientRegister, audit.Target{Type: audit.TargetClient, ID: c.ID.String()})
+	entry.Changes = audit.Diff(nil, auditedClient(c))
+	if err := h.audit.Record(entry); err != nil {
+		return nil, fmt.Errorf("client registered but %w", err)
+	}
+	h.events.Publish(event.ClientRegistered{ClientID: c.ID, Name: c.Name, Scopes: c.Scopes})
+
+	return &RegisterClientResult{
+		ClientID:     c.ID,
+		ClientSecret: secret,
+		Name:         c.Name,
+		Scopes:       c.Scopes,
+		CreatedAt:    c.CreatedAt,
+	}, nil
+}
+
+// auditedClient is what the audit log keeps of a client, never its secret hash
+func auditedClient(c client.Client) interface{} {
+	return struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}{Name: c.Name, Scopes: c.Scopes}
+}
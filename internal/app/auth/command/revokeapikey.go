@@ -0,0 +1,40 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/google/uuid"
+)
+
+// RevokeAPIKeyRequest identifies a single API key of a user
+type RevokeAPIKeyRequest struct {
+	UserID uuid.UUID
+	KeyID  uuid.UUID
+}
+
+// RevokeAPIKeyHandler revokes a single API key of a user
+type RevokeAPIKeyHandler interface {
+	Handle(req RevokeAPIKeyRequest) error
+}
+
+type revokeAPIKeyHandler struct {
+	repo   token.APIKeyRepository
+	events eventbus.Publisher
+}
+
+// NewRevokeAPIKeyHandler constructor
+func NewRevokeAPIKeyHandler(repo token.APIKeyRepository, publisher eventbus.Publisher) RevokeAPIKeyHandler {
+	return &revokeAPIKeyHandler{repo: repo, events: publisher}
+}
+
+// Handle removes the key, only if it belongs to the requesting user
+func (h *revokeAPIKeyHandler) Handle(req RevokeAPIKeyRequest) error {
+	if err := h.repo.DeleteByID(req.UserID, req.KeyID); err != nil {
+		return fmt.Errorf("failed to revoke api key %s: %w", req.KeyID, err)
+	}
+	h.events.Publish(event.APIKeyRevoked{UserID: req.UserID, KeyID: req.KeyID})
+	return nil
+}
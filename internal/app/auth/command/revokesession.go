@@ -0,0 +1,40 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/google/uuid"
+)
+
+// RevokeSessionRequest identifies a single session of a user
+type RevokeSessionRequest struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+}
+
+// RevokeSessionHandler revokes a single session of a user
+type RevokeSessionHandler interface {
+	Handle(req RevokeSessionRequest) error
+}
+
+type revokeSessionHandler struct {
+	refreshRepo token.RefreshRepository
+	events      eventbus.Publisher
+}
+
+// NewRevokeSessionHandler constructor
+func NewRevokeSessionHandler(rr token.RefreshRepository, publisher eventbus.Publisher) RevokeSessionHandler {
+	return &revokeSessionHandler{refreshRepo: rr, events: publisher}
+}
+
+// Handle removes the session, only if it belongs to the requesting user
+func (h *revokeSessionHandler) Handle(req RevokeSessionRequest) error {
+	if err := h.refreshRepo.DeleteByID(req.UserID, req.SessionID); err != nil {
+		return fmt.Errorf("failed to revoke session %s: %w", req.SessionID, err)
+	}
+	h.events.Publish(event.SessionRevoked{UserID: req.UserID, SessionID: req.SessionID})
+	return nil
+}
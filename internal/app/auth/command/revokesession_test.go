@@ -0,0 +1,99 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for refresh tokens
+type MockRefreshRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshRepository) Save(t string, rec token.RefreshRecord) {
+	m.Called(t, rec)
+}
+
+func (m *MockRefreshRepository) Get(t string) (token.RefreshRecord, bool) {
+	args := m.Called(t)
+	return args.Get(0).(token.RefreshRecord), args.Bool(1)
+}
+
+func (m *MockRefreshRepository) Delete(t string) {
+	m.Called(t)
+}
+
+func (m *MockRefreshRepository) ListByUser(userID uuid.UUID) []token.RefreshRecord {
+	args := m.Called(userID)
+	return args.Get(0).([]token.RefreshRecord)
+}
+
+func (m *MockRefreshRepository) DeleteByID(userID uuid.UUID, sessionID uuid.UUID) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshRepository) DeleteByUser(userID uuid.UUID) int {
+	args := m.Called(userID)
+	return args.Int(0)
+}
+
+func (m *MockRefreshRepository) DeleteExpired(now time.Time) int {
+	args := m.Called(now)
+	return args.Int(0)
+}
+
+func TestRevokeSessionHandler_Handle(t *testing.T) {
+	mockUserID := uuid.New()
+	mockSessionID := uuid.New()
+
+	tests := []struct {
+		name          string
+		repoError     error
+		expectedError error
+	}{
+		{
+			name:          "happy path",
+			repoError:     nil,
+			expectedError: nil,
+		},
+		{
+			name:          "session not found",
+			repoError:     token.ErrSessionNotFound,
+			expectedError: token.ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRefreshRepository{}
+			mockRepo.On("DeleteByID", mockUserID, mockSessionID).Return(tt.repoError)
+
+			events := &eventLog{}
+			handler := command.NewRevokeSessionHandler(mockRepo, events)
+
+			err := handler.Handle(command.RevokeSessionRequest{
+				UserID:    mockUserID,
+				SessionID: mockSessionID,
+			})
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Empty(t, events.events)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []event.Event{event.SessionRevoked{UserID: mockUserID, SessionID: mockSessionID}}, events.events)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
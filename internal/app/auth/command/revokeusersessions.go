@@ -0,0 +1,47 @@
+package command
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// RevokeUserSessionsRequest represents an admin forcing the logout of a user
+type RevokeUserSessionsRequest struct {
+	UserID uuid.UUID
+	Source audit.Source
+}
+
+// RevokeUserSessionsHandler lets an admin force the logout of a user
+type RevokeUserSessionsHandler interface {
+	Handle(req RevokeUserSessionsRequest) (int, error)
+}
+
+type revokeUserSessionsHandler struct {
+	userRepo    user.Repository
+	refreshRepo token.RefreshRepository
+	audit       auditlog.Recorder
+	events      eventbus.Publisher
+}
+
+// NewRevokeUserSessionsHandler constructor
+func NewRevokeUserSessionsHandler(userRepo user.Repository, refreshRepo token.RefreshRepository, recorder auditlog.Recorder, publisher eventbus.Publisher) RevokeUserSessionsHandler {
+	return &revokeUserSessionsHandler{userRepo: userRepo, refreshRepo: refreshRepo, audit: recorder, events: publisher}
+}
+
+// Handle revokes every session of the user and returns how many were revoked
+func (h *revokeUserSessionsHandler) Handle(req RevokeUserSessionsRequest) (int, error) {
+	if _, err := h.userRepo.GetByID(req.UserID); err != nil {
+		return 0, err
+	}
+	revoked := h.refreshRepo.DeleteByUser(req.UserID)
+	if err := h.audit.Record(audit.NewEntry(req.Source, audit.ActionUserRevokeSessions, audit.Target{Type: audit.TargetUser, ID: req.UserID.String()})); err != nil {
+		return revoked, err
+	}
+	h.events.Publish(event.UserSessionsRevoked{UserID: req.UserID, Revoked: revoked, ByAdmin: true})
+	return revoked, nil
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"fmt"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+)
+
+// LoginResult is the outcome of a login step. Either the token pair is set, or
+// MFARequired is true and MFAToken must be exchanged together with a second factor.
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+
+	MFARequired  bool
+	MFAToken     string
+	MFAExpiresAt gotime.Time
+}
+
+// sessionIssuer issues the access/refresh pair of a fully authenticated user
+type sessionIssuer struct {
+	refreshRepo  token.RefreshRepository
+	audit        auditlog.Recorder
+	events       eventbus.Publisher
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// issue records the successful login of action, then starts the session
+func (s sessionIssuer) issue(u *user.User, action, requestID, userAgent, ip string) (LoginResult, error) {
+	source := audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: u.ID.String()}, RequestID: requestID, IP: ip}
+	if err := s.audit.Record(audit.NewEntry(source, action, audit.Target{Type: audit.TargetUser, ID: u.ID.String()})); err != nil {
+		return LoginResult{}, err
+	}
+
+	access, err := helper.GenerateAccessToken(u.ID.String(), u.Roles)
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refresh, exp, err := helper.GenerateRefreshToken()
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := s.timeProvider.Now().UTC()
+	sessionID := s.uuidProvider.NewUUID()
+	s.refreshRepo.Save(refresh, token.RefreshRecord{
+		ID:              sessionID,
+		UserID:          u.ID,
+		Expiry:          exp,
+		Roles:           u.Roles,
+		CreatedAt:       now,
+		LastRefreshedAt: now,
+		UserAgent:       userAgent,
+		IP:              ip,
+	})
+
+	s.events.Publish(event.UserLoggedIn{UserID: u.ID, SessionID: sessionID, Method: loginMethod(action), IP: ip, UserAgent: userAgent})
+
+	return LoginResult{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// recordLoginFailure records and publishes a failed login of username, by the user u when one has
+// that username, and returns loginErr unless the entry could not be recorded
+func recordLoginFailure(recorder auditlog.Recorder, publisher eventbus.Publisher, action string, u *user.User, username, requestID, ip string, loginErr error) error {
+	target := audit.Target{Type: audit.TargetUser, ID: username}
+	failed := event.UserLoginFailed{Username: username, Method: loginMethod(action), IP: ip, Reason: loginErr.Error()}
+	if u != nil {
+		target.ID = u.ID.String()
+		failed.UserID = u.ID
+	}
+	entry := audit.NewEntry(audit.Source{Actor: audit.Actor{Kind: audit.ActorAnonymous}, RequestID: requestID, IP: ip}, action, target)
+	entry.Outcome = audit.OutcomeFailure
+	if err := recorder.Record(entry); err != nil {
+		return err
+	}
+	publisher.Publish(failed)
+	return loginErr
+}
+
+// loginMethod is the login method of the audit action of a login
+func loginMethod(action string) string {
+	switch action {
+	case audit.ActionLoginMFA:
+		return event.LoginMFA
+	case audit.ActionLoginOIDC:
+		return event.LoginOIDC
+	default:
+		return event.LoginPassword
+	}
+}
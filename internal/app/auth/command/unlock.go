@@ -0,0 +1,64 @@
+package command
+
+import (
+	"errors"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+)
+
+// ErrLockoutNotFound is returned when there is nothing to unlock
+var ErrLockoutNotFound = errors.New("no failed login attempts recorded")
+
+// UnlockRequest identifies the username and/or client IP to unlock
+type UnlockRequest struct {
+	Username string
+	IP       string
+	Source   audit.Source
+}
+
+// UnlockHandler clears the failed login attempts of a username and/or IP
+type UnlockHandler interface {
+	Handle(req UnlockRequest) error
+}
+
+type unlockHandler struct {
+	repo   lockout.Repository
+	audit  auditlog.Recorder
+	events eventbus.Publisher
+}
+
+// NewUnlockHandler constructor
+func NewUnlockHandler(repo lockout.Repository, recorder auditlog.Recorder, publisher eventbus.Publisher) UnlockHandler {
+	return &unlockHandler{repo: repo, audit: recorder, events: publisher}
+}
+
+// Handle removes the lockout state, returning ErrLockoutNotFound if neither key was tracked
+func (h *unlockHandler) Handle(req UnlockRequest) error {
+	var unlocked []lockout.Key
+	var cleared event.LockoutCleared
+	if req.Username != "" && h.repo.Delete(bruteforce.UsernameKey(req.Username)) {
+		unlocked = append(unlocked, bruteforce.UsernameKey(req.Username))
+		cleared.Username = req.Username
+	}
+	if req.IP != "" && h.repo.Delete(bruteforce.IPKey(req.IP)) {
+		unlocked = append(unlocked, bruteforce.IPKey(req.IP))
+		cleared.IP = req.IP
+	}
+	if len(unlocked) == 0 {
+		return ErrLockoutNotFound
+	}
+
+	for _, key := range unlocked {
+		target := audit.Target{Type: audit.TargetLockout, ID: key.String()}
+		if err := h.audit.Record(audit.NewEntry(req.Source, audit.ActionLockoutUnlock, target)); err != nil {
+			return err
+		}
+	}
+	h.events.Publish(cleared)
+	return nil
+}
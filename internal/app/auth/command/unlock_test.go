@@ -0,0 +1,112 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for login attempts
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Get(key lockout.Key) (lockout.Attempts, bool) {
+	args := m.Called(key)
+	return args.Get(0).(lockout.Attempts), args.Bool(1)
+}
+
+func (m *MockLoginAttemptRepository) Save(a lockout.Attempts) {
+	m.Called(a)
+}
+
+func (m *MockLoginAttemptRepository) Delete(key lockout.Key) bool {
+	args := m.Called(key)
+	return args.Bool(0)
+}
+
+func (m *MockLoginAttemptRepository) List() []lockout.Attempts {
+	args := m.Called()
+	return args.Get(0).([]lockout.Attempts)
+}
+
+func (m *MockLoginAttemptRepository) DeleteStale(before time.Time, now time.Time) int {
+	args := m.Called(before, now)
+	return args.Int(0)
+}
+
+func TestUnlockHandler_Handle(t *testing.T) {
+	usernameKey := lockout.Key{Scope: lockout.ScopeUsername, Value: "alice"}
+	ipKey := lockout.Key{Scope: lockout.ScopeIP, Value: "10.0.0.1"}
+
+	tests := []struct {
+		name            string
+		req             command.UnlockRequest
+		setupMock       func(m *MockLoginAttemptRepository)
+		expectedError   error
+		expectedEntries int
+	}{
+		{
+			name: "unlock username, normalized",
+			req:  command.UnlockRequest{Username: "Alice"},
+			setupMock: func(m *MockLoginAttemptRepository) {
+				m.On("Delete", usernameKey).Return(true)
+			},
+			expectedError:   nil,
+			expectedEntries: 1,
+		},
+		{
+			name: "unlock ip only",
+			req:  command.UnlockRequest{Username: "alice", IP: "10.0.0.1"},
+			setupMock: func(m *MockLoginAttemptRepository) {
+				m.On("Delete", usernameKey).Return(false)
+				m.On("Delete", ipKey).Return(true)
+			},
+			expectedError:   nil,
+			expectedEntries: 1,
+		},
+		{
+			name: "nothing to unlock",
+			req:  command.UnlockRequest{Username: "alice"},
+			setupMock: func(m *MockLoginAttemptRepository) {
+				m.On("Delete", usernameKey).Return(false)
+			},
+			expectedError: command.ErrLockoutNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockLoginAttemptRepository{}
+			tt.setupMock(mockRepo)
+
+			trail := &auditlogtest.Trail{}
+			events := &eventLog{}
+
+			handler := command.NewUnlockHandler(mockRepo, trail, events)
+
+			err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, trail.Entries, tt.expectedEntries)
+			if tt.expectedError != nil {
+				assert.Empty(t, events.events)
+			} else if assert.Len(t, events.events, 1) {
+				assert.IsType(t, event.LockoutCleared{}, events.events[0])
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
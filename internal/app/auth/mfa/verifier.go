@@ -0,0 +1,130 @@
+// Package mfa verifies the second factor of users enrolled in TOTP two-factor authentication.
+package mfa
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/totp"
+)
+
+var (
+	// ErrInvalidCode is returned when neither a valid TOTP code nor an unused recovery code was given
+	ErrInvalidCode = errors.New("invalid two-factor code")
+	// ErrNotEnrolled is returned when the user has not enabled two-factor authentication
+	ErrNotEnrolled = errors.New("two-factor authentication is not enabled")
+)
+
+const (
+	// allowedSkew is the number of 30s steps of clock drift tolerated in each direction
+	allowedSkew = 1
+	// recoveryCodeCount is the number of recovery codes issued on enrolment
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet avoids characters that are easily confused when read back
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Verifier checks the second factor of a user
+type Verifier interface {
+	// Verify accepts a current TOTP code or an unused recovery code and persists the consumed state
+	Verify(u user.User, code string) error
+}
+
+type verifier struct {
+	mu           sync.Mutex
+	repo         user.Repository
+	cipher       helper.SecretCipher
+	hasher       helper.TokenHasher
+	timeProvider time.Provider
+}
+
+// NewVerifier constructor
+func NewVerifier(repo user.Repository, cipher helper.SecretCipher, hasher helper.TokenHasher, tp time.Provider) Verifier {
+	return &verifier{repo: repo, cipher: cipher, hasher: hasher, timeProvider: tp}
+}
+
+func (v *verifier) Verify(u user.User, code string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// re-read under the lock so concurrent verifications see each other's consumed codes
+	current, err := v.repo.GetByID(u.ID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	state := current.TOTP
+	if !state.Enabled {
+		return ErrNotEnrolled
+	}
+
+	secret, err := v.cipher.Decrypt(state.Secret)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := totp.Validate(secret, code, v.timeProvider.Now(), allowedSkew); ok {
+		if step <= state.LastUsedStep {
+			return ErrInvalidCode
+		}
+		state.LastUsedStep = step
+		return v.repo.UpdateTOTP(u.ID, state)
+	}
+
+	hash := v.hasher.Hash(NormalizeRecoveryCode(code))
+	for i, stored := range state.RecoveryCodes {
+		if v.hasher.Equal(stored, hash) {
+			remaining := make([]string, 0, len(state.RecoveryCodes)-1)
+			remaining = append(remaining, state.RecoveryCodes[:i]...)
+			state.RecoveryCodes = append(remaining, state.RecoveryCodes[i+1:]...)
+			return v.repo.UpdateTOTP(u.ID, state)
+		}
+	}
+
+	return ErrInvalidCode
+}
+
+// GenerateRecoveryCodes returns new recovery codes in plain text, to show once, and their hashes, to store
+func GenerateRecoveryCodes(hasher helper.TokenHasher) ([]string, []string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b, err := randomString(10)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := b[:5] + "-" + b[5:]
+		plain = append(plain, code)
+		hashes = append(hashes, hasher.Hash(NormalizeRecoveryCode(code)))
+	}
+	return plain, hashes, nil
+}
+
+// randomString returns n characters of recoveryCodeAlphabet, using rejection sampling to avoid modulo bias
+func randomString(n int) (string, error) {
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, 1)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		if int(buf[0]) >= limit {
+			continue
+		}
+		out = append(out, recoveryCodeAlphabet[int(buf[0])%len(recoveryCodeAlphabet)])
+	}
+	return string(out), nil
+}
+
+// NormalizeRecoveryCode lowercases the code and drops separators and spaces
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
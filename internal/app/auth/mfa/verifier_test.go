@@ -0,0 +1,136 @@
+package mfa_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/totp"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository is a user.Repository holding a single user
+type fakeRepository struct {
+	u user.User
+}
+
+func (f *fakeRepository) GetByID(id uuid.UUID) (*user.User, error) {
+	if id != f.u.ID {
+		return nil, errors.New("not found")
+	}
+	u := f.u
+	return &u, nil
+}
+
+func (f *fakeRepository) GetByUsername(username string) (*user.User, error) {
+	return f.GetByID(f.u.ID)
+}
+
+func (f *fakeRepository) Add(username, plainPassword string, roles []string) (*user.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeRepository) UpdatePassword(id uuid.UUID, plainPassword string) error {
+	return nil
+}
+
+func (f *fakeRepository) List(query user.ListQuery) ([]*user.User, int, error) {
+	return nil, 0, nil
+}
+
+func (f *fakeRepository) SetStatus(id uuid.UUID, status user.Status) error {
+	return nil
+}
+
+func (f *fakeRepository) ReplacePasswordHash(id uuid.UUID, oldHash, newHash string) error {
+	return nil
+}
+
+func (f *fakeRepository) UpdateTOTP(id uuid.UUID, state user.TOTP) error {
+	f.u.TOTP = state
+	return nil
+}
+
+func (f *fakeRepository) GetByIdentity(identity user.Identity) (*user.User, error) {
+	return nil, errors.New("not found")
+}
+
+func (f *fakeRepository) LinkIdentity(id uuid.UUID, identity user.Identity) error {
+	return nil
+}
+
+func (f *fakeRepository) UpdateRoles(id uuid.UUID, roles []string) error {
+	return nil
+}
+
+func (f *fakeRepository) UpdateEmail(id uuid.UUID, email string) error {
+	return nil
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockTime := &timeprovider.MockProvider{}
+	mockTime.On("Now").Return(now)
+
+	cipher, err := helper.NewSecretCipher([]byte(strings.Repeat("k", 32)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	hasher := helper.NewTokenHasher([]byte("hash key"))
+
+	secret, err := totp.GenerateSecret()
+	if !assert.NoError(t, err) {
+		return
+	}
+	encrypted, err := cipher.Encrypt(secret)
+	if !assert.NoError(t, err) {
+		return
+	}
+	recovery, hashes, err := mfa.GenerateRecoveryCodes(hasher)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	repo := &fakeRepository{u: user.User{
+		ID:       uuid.New(),
+		Username: "alice",
+		TOTP:     user.TOTP{Enabled: true, Secret: encrypted, RecoveryCodes: hashes},
+	}}
+	v := mfa.NewVerifier(repo, cipher, hasher, mockTime)
+
+	code, err := totp.Code(secret, totp.Step(now))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("valid code", func(t *testing.T) {
+		assert.NoError(t, v.Verify(repo.u, code))
+		assert.Equal(t, totp.Step(now), repo.u.TOTP.LastUsedStep)
+	})
+
+	t.Run("replayed code", func(t *testing.T) {
+		assert.ErrorIs(t, v.Verify(repo.u, code), mfa.ErrInvalidCode)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		assert.ErrorIs(t, v.Verify(repo.u, "000000x"), mfa.ErrInvalidCode)
+	})
+
+	t.Run("recovery code is single use", func(t *testing.T) {
+		assert.NoError(t, v.Verify(repo.u, strings.ToUpper(recovery[0])))
+		assert.Len(t, repo.u.TOTP.RecoveryCodes, len(recovery)-1)
+		assert.ErrorIs(t, v.Verify(repo.u, recovery[0]), mfa.ErrInvalidCode)
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		repo.u.TOTP = user.TOTP{}
+		assert.ErrorIs(t, v.Verify(repo.u, code), mfa.ErrNotEnrolled)
+	})
+}
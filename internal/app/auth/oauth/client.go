@@ -0,0 +1,44 @@
+// Package oauth authenticates the registered OAuth2 clients.
+package oauth
+
+import (
+	"errors"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidClient is returned when the client is unknown or the secret does not match
+var ErrInvalidClient = errors.New("invalid client credentials")
+
+// ClientAuthenticator checks the credentials of a client
+type ClientAuthenticator interface {
+	Authenticate(clientID, secret string) (client.Client, error)
+}
+
+type clientAuthenticator struct {
+	repo   client.Repository
+	hasher helper.TokenHasher
+}
+
+// NewClientAuthenticator constructor
+func NewClientAuthenticator(repo client.Repository, hasher helper.TokenHasher) ClientAuthenticator {
+	return &clientAuthenticator{repo: repo, hasher: hasher}
+}
+
+// Authenticate returns the client when the secret matches its stored hash
+func (a *clientAuthenticator) Authenticate(clientID, secret string) (client.Client, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return client.Client{}, ErrInvalidClient
+	}
+	c, err := a.repo.GetByID(id)
+	if err != nil {
+		return client.Client{}, ErrInvalidClient
+	}
+	if secret == "" || !a.hasher.Equal(c.SecretHash, a.hasher.Hash(secret)) {
+		return client.Client{}, ErrInvalidClient
+	}
+	return c, nil
+}
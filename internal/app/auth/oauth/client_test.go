@@ -0,0 +1,52 @@
+package oauth_test
+
+import (
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository is a map backed client.Repository
+type fakeRepository map[uuid.UUID]client.Client
+
+func (f fakeRepository) Save(c client.Client) { f[c.ID] = c }
+
+func (f fakeRepository) GetByID(id uuid.UUID) (client.Client, error) {
+	c, ok := f[id]
+	if !ok {
+		return client.Client{}, client.ErrClientNotFound
+	}
+	return c, nil
+}
+
+func (f fakeRepository) List() []client.Client { return nil }
+
+func (f fakeRepository) Delete(id uuid.UUID) error { return nil }
+
+func TestClientAuthenticator_Authenticate(t *testing.T) {
+	hasher := helper.NewTokenHasher([]byte("key"))
+	registered := client.Client{ID: uuid.New(), SecretHash: hasher.Hash("secret")}
+	repo := fakeRepository{registered.ID: registered}
+	a := oauth.NewClientAuthenticator(repo, hasher)
+
+	got, err := a.Authenticate(registered.ID.String(), "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, registered.ID, got.ID)
+
+	_, err = a.Authenticate(registered.ID.String(), "wrong")
+	assert.ErrorIs(t, err, oauth.ErrInvalidClient)
+
+	_, err = a.Authenticate(registered.ID.String(), "")
+	assert.ErrorIs(t, err, oauth.ErrInvalidClient)
+
+	_, err = a.Authenticate(uuid.NewString(), "secret")
+	assert.ErrorIs(t, err, oauth.ErrInvalidClient)
+
+	_, err = a.Authenticate("not-a-uuid", "secret")
+	assert.ErrorIs(t, err, oauth.ErrInvalidClient)
+}
@@ -0,0 +1,94 @@
+// Package oidc contains the application side of OpenID Connect federated login: the
+// identity provider port and the mapping of external identities to local users.
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+)
+
+// Identity is the verified identity of a user at the provider, read from the ID token
+type Identity struct {
+	Issuer            string
+	Subject           string
+	PreferredUsername string
+	Email             string
+	Groups            []string
+}
+
+// Provider is an OpenID Connect provider supporting the authorization code flow with PKCE
+type Provider interface {
+	// AuthCodeURL returns the URL the user agent is sent to for authentication
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems an authorization code and returns the identity of its verified ID token.
+	// The ID token must carry the given nonce.
+	Exchange(code, codeVerifier, nonce string) (Identity, error)
+}
+
+// RoleMapping maps provider groups to local roles
+type RoleMapping map[string][]string
+
+// Roles returns the default roles plus the roles mapped from groups, sorted and without duplicates
+func (m RoleMapping) Roles(groups []string, defaults []string) []string {
+	set := make(map[string]struct{})
+	for _, r := range defaults {
+		set[r] = struct{}{}
+	}
+	for _, g := range groups {
+		for _, r := range m[g] {
+			set[r] = struct{}{}
+		}
+	}
+
+	roles := make([]string, 0, len(set))
+	for r := range set {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// UsernameCandidates returns the usernames to try, in order, when provisioning a user for identity.
+// The last candidate is derived from the subject so provisioning never fails on a taken username.
+func UsernameCandidates(identity Identity) []string {
+	var candidates []string
+	base := sanitizeUsername(identity.PreferredUsername)
+	if base == "" {
+		base = sanitizeUsername(strings.SplitN(identity.Email, "@", 2)[0])
+	}
+	if base != "" && user.ValidateUsername(base) == nil {
+		candidates = append(candidates, base)
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 23 {
+		base = base[:23]
+	}
+
+	sum := sha256.Sum256([]byte(identity.Issuer + "\x00" + identity.Subject))
+	return append(candidates, base+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// sanitizeUsername normalizes a claim and replaces the characters a username does not allow
+func sanitizeUsername(claim string) string {
+	claim = user.NormalizeUsername(claim)
+	var b strings.Builder
+	for _, c := range claim {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+			b.WriteRune(c)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	s := strings.Trim(b.String(), "-")
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package oidc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleMapping_Roles(t *testing.T) {
+	mapping := oidc.RoleMapping{
+		"admins":  {"admin", "user"},
+		"support": {"support"},
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   []string
+	}{
+		{name: "no groups keeps the defaults", groups: nil, want: []string{"user"}},
+		{name: "unmapped groups are ignored", groups: []string{"everyone"}, want: []string{"user"}},
+		{name: "mapped groups add roles once", groups: []string{"support", "admins"}, want: []string{"admin", "support", "user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mapping.Roles(tt.groups, []string{"user"}))
+		})
+	}
+}
+
+func TestUsernameCandidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		identity  oidc.Identity
+		preferred string
+	}{
+		{name: "preferred username", identity: oidc.Identity{Issuer: "https://idp", Subject: "1", PreferredUsername: "Carol.Smith"}, preferred: "carol.smith"},
+		{name: "email local part", identity: oidc.Identity{Issuer: "https://idp", Subject: "2", Email: "dave+work@example.com"}, preferred: "dave-work"},
+		{name: "no usable claim", identity: oidc.Identity{Issuer: "https://idp", Subject: "3", PreferredUsername: "é"}, preferred: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := oidc.UsernameCandidates(tt.identity)
+			if tt.preferred != "" {
+				assert.Len(t, candidates, 2)
+				assert.Equal(t, tt.preferred, candidates[0])
+			} else {
+				assert.Len(t, candidates, 1)
+			}
+
+			fallback := candidates[len(candidates)-1]
+			assert.NoError(t, user.ValidateUsername(fallback))
+			assert.Equal(t, fallback, oidc.UsernameCandidates(tt.identity)[len(candidates)-1], "fallback must be stable")
+		})
+	}
+
+	long := oidc.Identity{Issuer: "https://idp", Subject: "4", PreferredUsername: strings.Repeat("a", 40)}
+	for _, c := range oidc.UsernameCandidates(long) {
+		assert.NoError(t, user.ValidateUsername(c))
+	}
+}
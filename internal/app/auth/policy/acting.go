@@ -0,0 +1,59 @@
+// Package policy decides what an authenticated principal may do.
+package policy
+
+import (
+	"errors"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/google/uuid"
+)
+
+// ErrForbidden is returned when a principal may not act on the data of a user
+var ErrForbidden = errors.New("forbidden: cannot access another user's data")
+
+// Kind is the kind of credentials a principal authenticated with
+type Kind string
+
+const (
+	KindUser   Kind = "user"
+	KindAPIKey Kind = "api_key"
+	KindClient Kind = "client"
+	// KindImpersonation is an admin acting as UserID with an impersonation token
+	KindImpersonation Kind = "impersonation"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Kind     Kind
+	UserID   uuid.UUID // zero for clients
+	ClientID string    // empty for users and API keys
+	ActorID  uuid.UUID // the impersonating admin, zero unless impersonating
+	Scopes   []string
+}
+
+// ActingPolicy decides whether a principal may act on the data of a user
+type ActingPolicy interface {
+	CanActFor(p Principal, userID uuid.UUID) error
+}
+
+type actingPolicy struct{}
+
+// NewActingPolicy returns the default policy: users, their API keys and admins impersonating them
+// only act for themselves, clients act for any user only when granted the users:on_behalf scope
+func NewActingPolicy() ActingPolicy {
+	return actingPolicy{}
+}
+
+func (actingPolicy) CanActFor(p Principal, userID uuid.UUID) error {
+	switch p.Kind {
+	case KindUser, KindAPIKey, KindImpersonation:
+		if p.UserID == userID {
+			return nil
+		}
+	case KindClient:
+		if token.ContainsScope(p.Scopes, token.ScopeUsersOnBehalf) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,44 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/policy"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActingPolicy_CanActFor(t *testing.T) {
+	self := uuid.New()
+	other := uuid.New()
+
+	tests := []struct {
+		name      string
+		principal policy.Principal
+		userID    uuid.UUID
+		allowed   bool
+	}{
+		{name: "user acts for itself", principal: policy.Principal{Kind: policy.KindUser, UserID: self}, userID: self, allowed: true},
+		{name: "user acts for another user", principal: policy.Principal{Kind: policy.KindUser, UserID: self}, userID: other, allowed: false},
+		{name: "api key acts for its owner", principal: policy.Principal{Kind: policy.KindAPIKey, UserID: self}, userID: self, allowed: true},
+		{name: "api key acts for another user", principal: policy.Principal{Kind: policy.KindAPIKey, UserID: self, Scopes: []string{token.ScopeUsersOnBehalf}}, userID: other, allowed: false},
+		{name: "impersonation acts for the impersonated user", principal: policy.Principal{Kind: policy.KindImpersonation, UserID: other, ActorID: self}, userID: other, allowed: true},
+		{name: "impersonation acts for the admin", principal: policy.Principal{Kind: policy.KindImpersonation, UserID: other, ActorID: self}, userID: self, allowed: false},
+		{name: "client with on behalf scope", principal: policy.Principal{Kind: policy.KindClient, ClientID: "c", Scopes: []string{token.ScopeUsersOnBehalf}}, userID: other, allowed: true},
+		{name: "client without on behalf scope", principal: policy.Principal{Kind: policy.KindClient, ClientID: "c", Scopes: []string{token.ScopeFavoritesRead}}, userID: other, allowed: false},
+	}
+
+	p := policy.NewActingPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CanActFor(tt.principal, tt.userID)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, policy.ErrForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package query
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// ErrIntrospectionNotAllowed is returned when the calling client lacks the tokens:introspect scope
+var ErrIntrospectionNotAllowed = errors.New("client is not allowed to introspect tokens")
+
+// IntrospectRequest is an RFC 7662 introspection request, authenticated by the calling client
+type IntrospectRequest struct {
+	ClientID     string
+	ClientSecret string
+	Token        string
+}
+
+// IntrospectionResult is the RFC 7662 introspection response. Only Active is set for inactive tokens.
+type IntrospectionResult struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	// Act names the admin acting with an impersonation token
+	Act *helper.ActClaim `json:"act,omitempty"`
+}
+
+// IntrospectHandler interface
+type IntrospectHandler interface {
+	Handle(req IntrospectRequest) (*IntrospectionResult, error)
+}
+
+type introspectHandler struct {
+	clients      oauth.ClientAuthenticator
+	clientRepo   client.Repository
+	apiKeyRepo   token.APIKeyRepository
+	userRepo     user.Repository
+	timeProvider timeprovider.Provider
+}
+
+// NewIntrospectHandler constructor
+func NewIntrospectHandler(clients oauth.ClientAuthenticator, clientRepo client.Repository, apiKeyRepo token.APIKeyRepository, userRepo user.Repository, tp timeprovider.Provider) IntrospectHandler {
+	return &introspectHandler{clients: clients, clientRepo: clientRepo, apiKeyRepo: apiKeyRepo, userRepo: userRepo, timeProvider: tp}
+}
+
+// Handle describes an access token, client token or API key
+func (h *introspectHandler) Handle(req IntrospectRequest) (*IntrospectionResult, error) {
+	caller, err := h.clients.Authenticate(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !token.ContainsScope(caller.Scopes, token.ScopeTokensIntrospect) {
+		return nil, ErrIntrospectionNotAllowed
+	}
+
+	if strings.HasPrefix(req.Token, helper.APIKeyPrefix) {
+		return h.introspectAPIKey(req.Token), nil
+	}
+	return h.introspectJWT(req.Token), nil
+}
+
+func (h *introspectHandler) introspectAPIKey(raw string) *IntrospectionResult {
+	key, ok := h.apiKeyRepo.Get(raw)
+	if !ok || key.Expired(h.timeProvider.Now()) {
+		return &IntrospectionResult{}
+	}
+	u, err := h.userRepo.GetByID(key.UserID)
+	if err != nil || !u.Active() {
+		return &IntrospectionResult{}
+	}
+
+	result := &IntrospectionResult{
+		Active:    true,
+		Scope:     strings.Join(key.Scopes, " "),
+		Username:  u.Username,
+		TokenType: helper.TokenUseAPIKey,
+		Iat:       key.CreatedAt.Unix(),
+		Sub:       u.ID.String(),
+		Jti:       key.ID.String(),
+	}
+	if !key.ExpiresAt.IsZero() {
+		result.Exp = key.ExpiresAt.Unix()
+	}
+	return result
+}
+
+func (h *introspectHandler) introspectJWT(raw string) *IntrospectionResult {
+	claims, err := helper.ParseAndValidateToken(raw)
+	if err != nil {
+		return &IntrospectionResult{}
+	}
+
+	result := &IntrospectionResult{
+		Active:    true,
+		Scope:     strings.Join(claims.Scopes, " "),
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Jti:       claims.ID,
+	}
+	if claims.ExpiresAt != nil {
+		result.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		result.Iat = claims.IssuedAt.Unix()
+	}
+
+	// the signature proves the token was issued, the principal must also still exist
+	if claims.TokenUse == helper.TokenUseClient {
+		id, err := uuid.Parse(claims.ClientID)
+		if err != nil {
+			return &IntrospectionResult{}
+		}
+		if _, err := h.clientRepo.GetByID(id); err != nil {
+			return &IntrospectionResult{}
+		}
+		result.ClientID = claims.ClientID
+		return result
+	}
+
+	id, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return &IntrospectionResult{}
+	}
+	u, err := h.userRepo.GetByID(id)
+	if err != nil || !u.Active() {
+		return &IntrospectionResult{}
+	}
+	result.Username = u.Username
+	result.Act = claims.Act
+	return result
+}
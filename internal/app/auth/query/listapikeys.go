@@ -0,0 +1,70 @@
+package query
+
+import (
+	"sort"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/google/uuid"
+)
+
+// ListAPIKeysRequest represents a query for the API keys of a user
+type ListAPIKeysRequest struct {
+	UserID uuid.UUID
+}
+
+// APIKeyResult represents a single API key, without its secret
+type APIKeyResult struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// ListAPIKeysHandler interface
+type ListAPIKeysHandler interface {
+	Handle(query ListAPIKeysRequest) ([]APIKeyResult, error)
+}
+
+type listAPIKeysHandler struct {
+	repo token.APIKeyRepository
+}
+
+// NewListAPIKeysHandler constructor
+func NewListAPIKeysHandler(repo token.APIKeyRepository) ListAPIKeysHandler {
+	return &listAPIKeysHandler{repo: repo}
+}
+
+// Handle returns the keys of the user, newest first
+func (h *listAPIKeysHandler) Handle(query ListAPIKeysRequest) ([]APIKeyResult, error) {
+	keys := h.repo.ListByUser(query.UserID)
+
+	result := make([]APIKeyResult, 0, len(keys))
+	for _, k := range keys {
+		r := APIKeyResult{
+			ID:        k.ID,
+			Name:      k.Name,
+			Prefix:    k.Prefix,
+			Scopes:    k.Scopes,
+			CreatedAt: k.CreatedAt,
+		}
+		if !k.ExpiresAt.IsZero() {
+			expiresAt := k.ExpiresAt
+			r.ExpiresAt = &expiresAt
+		}
+		if !k.LastUsedAt.IsZero() {
+			lastUsedAt := k.LastUsedAt
+			r.LastUsedAt = &lastUsedAt
+		}
+		result = append(result, r)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package query
+
+import (
+	"sort"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/google/uuid"
+)
+
+// ClientResult represents a registered client, without its secret
+type ClientResult struct {
+	ClientID  uuid.UUID `json:"client_id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListClientsHandler interface
+type ListClientsHandler interface {
+	Handle() ([]ClientResult, error)
+}
+
+type listClientsHandler struct {
+	repo client.Repository
+}
+
+// NewListClientsHandler constructor
+func NewListClientsHandler(repo client.Repository) ListClientsHandler {
+	return &listClientsHandler{repo: repo}
+}
+
+// Handle returns the registered clients, oldest first
+func (h *listClientsHandler) Handle() ([]ClientResult, error) {
+	clients := h.repo.List()
+
+	result := make([]ClientResult, 0, len(clients))
+	for _, c := range clients {
+		result = append(result, ClientResult{
+			ClientID:  c.ID,
+			Name:      c.Name,
+			Scopes:    c.Scopes,
+			CreatedAt: c.CreatedAt,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"sort"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// LockoutResult represents a currently throttled or locked username or IP
+type LockoutResult struct {
+	Scope        lockout.Scope `json:"scope"`
+	Value        string        `json:"value"`
+	Failures     int           `json:"failures"`
+	Locked       bool          `json:"locked"`
+	LastFailure  time.Time     `json:"last_failure"`
+	BlockedUntil time.Time     `json:"blocked_until"`
+}
+
+// ListLockoutsHandler interface
+type ListLockoutsHandler interface {
+	Handle() ([]LockoutResult, error)
+}
+
+type listLockoutsHandler struct {
+	repo         lockout.Repository
+	timeProvider timeprovider.Provider
+}
+
+// NewListLockoutsHandler constructor
+func NewListLockoutsHandler(repo lockout.Repository, tp timeprovider.Provider) ListLockoutsHandler {
+	return &listLockoutsHandler{repo: repo, timeProvider: tp}
+}
+
+// Handle returns every key that is blocked right now, longest block first
+func (h *listLockoutsHandler) Handle() ([]LockoutResult, error) {
+	now := h.timeProvider.Now()
+
+	result := []LockoutResult{}
+	for _, a := range h.repo.List() {
+		if !now.Before(a.BlockedUntil) {
+			continue
+		}
+		result = append(result, LockoutResult{
+			Scope:        a.Key.Scope,
+			Value:        a.Key.Value,
+			Failures:     a.Failures,
+			Locked:       a.Locked,
+			LastFailure:  a.LastFailure,
+			BlockedUntil: a.BlockedUntil,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].BlockedUntil.After(result[j].BlockedUntil)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"sort"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// ListSessionsRequest represents a query for the active sessions of a user
+type ListSessionsRequest struct {
+	UserID uuid.UUID
+}
+
+// SessionResult represents a single active session
+type SessionResult struct {
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	UserAgent       string    `json:"user_agent"`
+	IP              string    `json:"ip"`
+}
+
+// ListSessionsHandler interface
+type ListSessionsHandler interface {
+	Handle(query ListSessionsRequest) ([]SessionResult, error)
+}
+
+type listSessionsHandler struct {
+	refreshRepo  token.RefreshRepository
+	timeProvider timeprovider.Provider
+}
+
+// NewListSessionsHandler constructor
+func NewListSessionsHandler(rr token.RefreshRepository, tp timeprovider.Provider) ListSessionsHandler {
+	return &listSessionsHandler{refreshRepo: rr, timeProvider: tp}
+}
+
+// Handle returns the unexpired sessions of the user, most recently used first
+func (h *listSessionsHandler) Handle(query ListSessionsRequest) ([]SessionResult, error) {
+	records := h.refreshRepo.ListByUser(query.UserID)
+	now := h.timeProvider.Now()
+
+	result := make([]SessionResult, 0, len(records))
+	for _, rec := range records {
+		if now.After(rec.Expiry) {
+			continue
+		}
+		result = append(result, SessionResult{
+			ID:              rec.ID,
+			CreatedAt:       rec.CreatedAt,
+			LastRefreshedAt: rec.LastRefreshedAt,
+			ExpiresAt:       rec.Expiry,
+			UserAgent:       rec.UserAgent,
+			IP:              rec.IP,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastRefreshedAt.After(result[j].LastRefreshedAt)
+	})
+
+	return result, nil
+}
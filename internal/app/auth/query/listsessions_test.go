@@ -0,0 +1,105 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/query"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for refresh tokens
+type MockRefreshRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshRepository) Save(t string, rec token.RefreshRecord) {
+	m.Called(t, rec)
+}
+
+func (m *MockRefreshRepository) Get(t string) (token.RefreshRecord, bool) {
+	args := m.Called(t)
+	return args.Get(0).(token.RefreshRecord), args.Bool(1)
+}
+
+func (m *MockRefreshRepository) Delete(t string) {
+	m.Called(t)
+}
+
+func (m *MockRefreshRepository) ListByUser(userID uuid.UUID) []token.RefreshRecord {
+	args := m.Called(userID)
+	return args.Get(0).([]token.RefreshRecord)
+}
+
+func (m *MockRefreshRepository) DeleteByID(userID uuid.UUID, sessionID uuid.UUID) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshRepository) DeleteByUser(userID uuid.UUID) int {
+	args := m.Called(userID)
+	return args.Int(0)
+}
+
+func (m *MockRefreshRepository) DeleteExpired(now time.Time) int {
+	args := m.Called(now)
+	return args.Int(0)
+}
+
+func TestListSessionsHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockUserID := uuid.New()
+
+	older := token.RefreshRecord{ID: uuid.New(), UserID: mockUserID, Expiry: now.Add(time.Hour), LastRefreshedAt: now.Add(-2 * time.Hour)}
+	newer := token.RefreshRecord{ID: uuid.New(), UserID: mockUserID, Expiry: now.Add(time.Hour), LastRefreshedAt: now.Add(-time.Hour), UserAgent: "curl"}
+	expired := token.RefreshRecord{ID: uuid.New(), UserID: mockUserID, Expiry: now.Add(-time.Minute)}
+
+	tests := []struct {
+		name        string
+		records     []token.RefreshRecord
+		expectedIDs []uuid.UUID
+	}{
+		{
+			name:        "sorted by last activity",
+			records:     []token.RefreshRecord{older, newer},
+			expectedIDs: []uuid.UUID{newer.ID, older.ID},
+		},
+		{
+			name:        "expired sessions are hidden",
+			records:     []token.RefreshRecord{expired, older},
+			expectedIDs: []uuid.UUID{older.ID},
+		},
+		{
+			name:        "no sessions",
+			records:     []token.RefreshRecord{},
+			expectedIDs: []uuid.UUID{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRefreshRepository{}
+			mockRepo.On("ListByUser", mockUserID).Return(tt.records)
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Return(now)
+
+			handler := query.NewListSessionsHandler(mockRepo, mockTime)
+
+			result, err := handler.Handle(query.ListSessionsRequest{UserID: mockUserID})
+			assert.NoError(t, err)
+
+			ids := make([]uuid.UUID, 0, len(result))
+			for _, s := range result {
+				ids = append(ids, s.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Package digest sums up the favourite events of the users who chose a digest cadence in one
+// notification per period.
+package digest
+
+import (
+	"errors"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/google/uuid"
+)
+
+// ErrDigestSent is returned when an entry is added to a digest that was already sent
+var ErrDigestSent = errors.New("digest already sent")
+
+// Actions of the entries
+const (
+	ActionAdded   = "added"
+	ActionRemoved = "removed"
+)
+
+// namespace derives the digest IDs, it must never change
+var namespace = uuid.MustParse("6f1c8a52-3d0e-4c1b-9a8e-2b7f5d4e9c10")
+
+// Entry is a favourite event waiting in the digest of its period
+type Entry struct {
+	// EventID identifies the event, a redelivered event is accumulated once
+	EventID uuid.UUID
+	// DigestID identifies the digest of the user and period, it is the ID of its notification
+	DigestID    uuid.UUID
+	UserID      uuid.UUID
+	Cadence     string
+	PeriodStart gotime.Time
+	PeriodEnd   gotime.Time
+	Action      string
+	FavouriteID uuid.UUID
+	Type        favourite.AssetType
+	Description string
+	OccurredAt  gotime.Time
+}
+
+// Repository accumulates the entries until their digest is sent, and remembers the sent digests
+type Repository interface {
+	// Add accumulates an entry, ignoring an event already in its digest.
+	// ErrDigestSent is returned when the digest of the entry was already sent.
+	Add(e Entry) error
+	// Due returns the entries of the digests whose period ended by the given time, oldest first
+	Due(until gotime.Time) ([]Entry, error)
+	// Complete records that a digest was sent and removes its entries, in one write
+	Complete(digestID uuid.UUID, at gotime.Time) error
+	// DeleteSent forgets the digests sent before the given time, returning how many were removed
+	DeleteSent(before gotime.Time) (int, error)
+}
+
+// Period returns the period of a cadence containing t, in UTC. Days start at midnight, weeks on Monday.
+func Period(cadence string, t gotime.Time) (gotime.Time, gotime.Time) {
+	t = t.UTC()
+	start := gotime.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, gotime.UTC)
+	if cadence != notification.DigestWeekly {
+		return start, start.AddDate(0, 0, 1)
+	}
+	start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// ID returns the ID of the digest of a user for the period starting at start. It is derived from
+// them, so the digest keeps its ID when it is sent again after a restart.
+func ID(userID uuid.UUID, cadence string, start gotime.Time) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(userID.String()+"/"+cadence+"/"+start.UTC().Format(gotime.RFC3339)))
+}
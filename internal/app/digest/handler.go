@@ -0,0 +1,72 @@
+package digest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// Events are the events summed up in the digests
+var Events = []string{event.FavoriteAddedName, event.FavoriteDeletedName}
+
+// NewEventHandler accumulates the favourite events of the users with a digest cadence in the digest
+// of the current period. The period is the one of the handling, not of the event, so an event
+// redelivered late joins the next digest rather than one already sent.
+func NewEventHandler(repo Repository, preferences notification.PreferenceRepository, tp time.Provider) eventbus.Handler {
+	return eventbus.HandlerFunc(func(envelope eventbus.Envelope) error {
+		var userID uuid.UUID
+		var fav favourite.Favorite
+		var action string
+		switch e := envelope.Event.(type) {
+		case event.FavoriteAdded:
+			userID, fav, action = e.UserID, e.Favorite, ActionAdded
+		case event.FavoriteDeleted:
+			userID, fav, action = e.UserID, e.Favorite, ActionRemoved
+		default:
+			return nil
+		}
+
+		prefs, err := preferences.Get(userID)
+		if errors.Is(err, notification.ErrPreferencesNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read notification preferences: %w", err)
+		}
+		if prefs.Digest == "" || prefs.Digest == notification.DigestOff {
+			return nil
+		}
+
+		entry := Entry{
+			EventID:     envelope.ID,
+			UserID:      userID,
+			Cadence:     prefs.Digest,
+			Action:      action,
+			FavouriteID: fav.ID,
+			Type:        fav.Type,
+			Description: fav.Description,
+			OccurredAt:  envelope.OccurredAt,
+		}
+		at := tp.Now()
+		for range 2 {
+			entry.PeriodStart, entry.PeriodEnd = Period(entry.Cadence, at)
+			entry.DigestID = ID(userID, entry.Cadence, entry.PeriodStart)
+			err = repo.Add(entry)
+			if !errors.Is(err, ErrDigestSent) {
+				break
+			}
+			// the digest was sent while the period was ending
+			at = entry.PeriodEnd
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add to the digest: %w", err)
+		}
+		return nil
+	})
+}
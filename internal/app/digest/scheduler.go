@@ -0,0 +1,151 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// Config paces the digests
+type Config struct {
+	// Interval is how often the digests of the ended periods are sent
+	Interval gotime.Duration
+	// Retention is how long sent digests are remembered
+	Retention gotime.Duration
+}
+
+// Scheduler sends the digests of the periods that ended, one notification per user and period
+type Scheduler struct {
+	repo         Repository
+	preferences  notification.PreferenceRepository
+	notifier     notification.Service
+	cfg          Config
+	timeProvider time.Provider
+}
+
+// NewScheduler constructor
+func NewScheduler(repo Repository, preferences notification.PreferenceRepository, ns notification.Service, cfg Config, tp time.Provider) *Scheduler {
+	return &Scheduler{repo: repo, preferences: preferences, notifier: ns, cfg: cfg, timeProvider: tp}
+}
+
+// Run sends the due digests on every tick until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := gotime.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Send(); err != nil {
+				log.Printf("digest scheduler: %v", err)
+			}
+		}
+	}
+}
+
+// Send notifies the digests whose period ended, returning how many were sent. A digest is
+// completed once notified, so after a restart in between it is sent again with the same ID.
+// Digests are held back during the quiet hours of their user.
+func (s *Scheduler) Send() (int, error) {
+	now := s.timeProvider.Now().UTC()
+	entries, err := s.repo.Due(now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the due digests: %w", err)
+	}
+
+	var order []uuid.UUID
+	digests := make(map[uuid.UUID][]Entry)
+	for _, e := range entries {
+		if _, ok := digests[e.DigestID]; !ok {
+			order = append(order, e.DigestID)
+		}
+		digests[e.DigestID] = append(digests[e.DigestID], e)
+	}
+
+	sent := 0
+	for _, id := range order {
+		entries := digests[id]
+		if s.quiet(entries[0].UserID, now) {
+			continue
+		}
+		if err := s.notifier.Notify(render(entries)); err != nil {
+			return sent, fmt.Errorf("failed to notify digest %s: %w", id, err)
+		}
+		if err := s.repo.Complete(id, now); err != nil {
+			return sent, fmt.Errorf("failed to complete digest %s: %w", id, err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// PurgeSent forgets the digests sent longer than the retention ago
+func (s *Scheduler) PurgeSent() (int, error) {
+	return s.repo.DeleteSent(s.timeProvider.Now().UTC().Add(-s.cfg.Retention))
+}
+
+// quiet reports whether the user is in their quiet hours, which the notification pipeline would
+// drop the digest in
+func (s *Scheduler) quiet(userID uuid.UUID, now gotime.Time) bool {
+	prefs, err := s.preferences.Get(userID)
+	if err != nil || prefs.QuietHours == nil {
+		return false
+	}
+	quiet, err := prefs.QuietHours.Contains(now)
+	return err == nil && quiet
+}
+
+// render returns the notification of a digest, listing the added and removed favourites by type
+func render(entries []Entry) notification.Notification {
+	first := entries[0]
+	return notification.Notification{
+		ID:     first.DigestID.String(),
+		UserID: first.UserID,
+		Kind:   notification.KindFavourite,
+		Event:  notification.EventFavoriteDigest,
+		Data: map[string]string{
+			"cadence": first.Cadence,
+			"from":    first.PeriodStart.Format(gotime.DateOnly),
+			"to":      first.PeriodEnd.AddDate(0, 0, -1).Format(gotime.DateOnly),
+			"added":   list(entries, ActionAdded),
+			"removed": list(entries, ActionRemoved),
+		},
+	}
+}
+
+// list returns a line per asset type, with the descriptions of the favourites of the action in
+// the order they happened, or "" when there are none
+func list(entries []Entry, action string) string {
+	byType := make(map[string][]string)
+	for _, e := range entries {
+		if e.Action != action {
+			continue
+		}
+		name := e.Description
+		if name == "" {
+			name = e.FavouriteID.String()
+		}
+		byType[string(e.Type)] = append(byType[string(e.Type)], name)
+	}
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	lines := make([]string, 0, len(types))
+	for _, t := range types {
+		lines = append(lines, "- "+t+": "+strings.Join(byType[t], ", "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,241 @@
+package digest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/digest"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a time provider the tests move forward
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+// store keeps the entries and sent digests like the memory repository
+type store struct {
+	entries []digest.Entry
+	sent    map[uuid.UUID]time.Time
+}
+
+func (s *store) Add(e digest.Entry) error {
+	if _, ok := s.sent[e.DigestID]; ok {
+		return digest.ErrDigestSent
+	}
+	for _, existing := range s.entries {
+		if existing.DigestID == e.DigestID && existing.EventID == e.EventID {
+			return nil
+		}
+	}
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func (s *store) Due(until time.Time) ([]digest.Entry, error) {
+	var due []digest.Entry
+	for _, e := range s.entries {
+		if !e.PeriodEnd.After(until) {
+			due = append(due, e)
+		}
+	}
+	return due, nil
+}
+
+func (s *store) Complete(id uuid.UUID, at time.Time) error {
+	var kept []digest.Entry
+	for _, e := range s.entries {
+		if e.DigestID != id {
+			kept = append(kept, e)
+		}
+	}
+	s.entries, s.sent[id] = kept, at
+	return nil
+}
+
+func (s *store) DeleteSent(before time.Time) (int, error) {
+	removed := 0
+	for id, at := range s.sent {
+		if at.Before(before) {
+			delete(s.sent, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+type preferenceStore map[uuid.UUID]notification.Preferences
+
+func (s preferenceStore) Get(userID uuid.UUID) (notification.Preferences, error) {
+	p, ok := s[userID]
+	if !ok {
+		return notification.Preferences{}, notification.ErrPreferencesNotFound
+	}
+	return p, nil
+}
+
+func (s preferenceStore) Save(p notification.Preferences) error {
+	s[p.UserID] = p
+	return nil
+}
+
+type recorder struct {
+	notifications []notification.Notification
+}
+
+func (r *recorder) Notify(n notification.Notification) error {
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func TestPeriod(t *testing.T) {
+	thursday := time.Date(2026, 1, 8, 15, 30, 0, 0, time.UTC)
+
+	start, end := digest.Period(notification.DigestDaily, thursday)
+	assert.Equal(t, time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), end)
+
+	start, end = digest.Period(notification.DigestWeekly, thursday)
+	assert.Equal(t, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), start, "weeks start on Monday")
+	assert.Equal(t, time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC), end)
+
+	start, _ = digest.Period(notification.DigestWeekly, time.Date(2026, 1, 11, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), start, "Sunday ends the week")
+}
+
+func TestScheduler_Send(t *testing.T) {
+	tp := &clock{now: time.Date(2026, 1, 8, 10, 0, 0, 0, time.UTC)}
+	repo := &store{sent: map[uuid.UUID]time.Time{}}
+	daily, weekly, immediate := uuid.New(), uuid.New(), uuid.New()
+	prefs := preferenceStore{
+		daily:     {UserID: daily, Digest: notification.DigestDaily},
+		weekly:    {UserID: weekly, Digest: notification.DigestWeekly},
+		immediate: {UserID: immediate, Digest: notification.DigestOff},
+	}
+	handler := digest.NewEventHandler(repo, prefs, tp)
+	ns := &recorder{}
+	scheduler := digest.NewScheduler(repo, prefs, ns, digest.Config{Retention: time.Hour * 24 * 30}, tp)
+
+	handle := func(e event.Event) eventbus.Envelope {
+		envelope := eventbus.Envelope{ID: uuid.New(), OccurredAt: tp.now, Event: e}
+		if !assert.NoError(t, handler.Handle(envelope)) {
+			t.FailNow()
+		}
+		return envelope
+	}
+	chart := favourite.Favorite{ID: uuid.New(), Type: favourite.AssetChart, Description: "Q1 sales"}
+	insight := favourite.Favorite{ID: uuid.New(), Type: favourite.AssetInsight, Description: "Gen Z"}
+	added := handle(event.FavoriteAdded{UserID: daily, Favorite: chart})
+	handle(event.FavoriteAdded{UserID: daily, Favorite: insight})
+	handle(event.FavoriteAdded{UserID: daily, Favorite: favourite.Favorite{ID: uuid.New(), Type: favourite.AssetChart, Description: "Revenue"}})
+	handle(event.FavoriteDeleted{UserID: daily, Favorite: insight})
+	handle(event.FavoriteAdded{UserID: weekly, Favorite: chart})
+	handle(event.FavoriteAdded{UserID: immediate, Favorite: chart})
+	if !assert.NoError(t, handler.Handle(added), "a redelivered event") {
+		return
+	}
+	if !assert.Len(t, repo.entries, 5) {
+		return
+	}
+
+	sent, err := scheduler.Send()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, sent, "the day is not over")
+
+	tp.now = time.Date(2026, 1, 9, 0, 1, 0, 0, time.UTC)
+	sent, err = scheduler.Send()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Equal(t, 1, sent) {
+		return
+	}
+	n := ns.notifications[0]
+	assert.Equal(t, daily, n.UserID)
+	assert.Equal(t, notification.EventFavoriteDigest, n.Event)
+	assert.Equal(t, digest.ID(daily, notification.DigestDaily, time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)).String(), n.ID)
+	assert.Equal(t, map[string]string{
+		"cadence": "daily",
+		"from":    "2026-01-08",
+		"to":      "2026-01-08",
+		"added":   "- chart: Q1 sales, Revenue\n- insight: Gen Z",
+		"removed": "- insight: Gen Z",
+	}, n.Data)
+
+	sent, err = scheduler.Send()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, sent, "sent once")
+
+	tp.now = time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+	sent, err = scheduler.Send()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, weekly, ns.notifications[1].UserID)
+	assert.Equal(t, "2026-01-11", ns.notifications[1].Data["to"])
+	assert.Equal(t, "", ns.notifications[1].Data["removed"])
+
+	_, _, err = notification.DefaultTemplates().Render(n.Event, "en", n.Data)
+	assert.NoError(t, err)
+}
+
+func TestScheduler_LateAndQuiet(t *testing.T) {
+	tp := &clock{now: time.Date(2026, 1, 8, 23, 59, 0, 0, time.UTC)}
+	repo := &store{sent: map[uuid.UUID]time.Time{}}
+	userID := uuid.New()
+	prefs := preferenceStore{userID: {
+		UserID:     userID,
+		Digest:     notification.DigestDaily,
+		QuietHours: &notification.QuietHours{Start: "00:00", End: "07:00"},
+	}}
+	handler := digest.NewEventHandler(repo, prefs, tp)
+	ns := &recorder{}
+	scheduler := digest.NewScheduler(repo, prefs, ns, digest.Config{Retention: time.Hour}, tp)
+
+	chart := favourite.Favorite{ID: uuid.New(), Type: favourite.AssetChart, Description: "Q1 sales"}
+	if !assert.NoError(t, handler.Handle(eventbus.Envelope{ID: uuid.New(), Event: event.FavoriteAdded{UserID: userID, Favorite: chart}})) {
+		return
+	}
+
+	tp.now = time.Date(2026, 1, 9, 3, 0, 0, 0, time.UTC)
+	sent, err := scheduler.Send()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, sent, "held back during the quiet hours")
+
+	tp.now = time.Date(2026, 1, 9, 7, 0, 0, 0, time.UTC)
+	sent, err = scheduler.Send()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, sent)
+
+	// an event handled as the day ends, after its digest was sent, joins the next one
+	tp.now = time.Date(2026, 1, 8, 23, 59, 59, 0, time.UTC)
+	if !assert.NoError(t, handler.Handle(eventbus.Envelope{ID: uuid.New(), Event: event.FavoriteDeleted{UserID: userID, Favorite: chart}})) {
+		return
+	}
+	if !assert.Len(t, repo.entries, 1) {
+		return
+	}
+	assert.Equal(t, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), repo.entries[0].PeriodStart)
+
+	tp.now = time.Date(2026, 1, 9, 8, 1, 0, 0, time.UTC)
+	purged, err := scheduler.PurgeSent()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, purged)
+}
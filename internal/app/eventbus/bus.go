@@ -0,0 +1,144 @@
+// Package eventbus delivers the domain events to the subscribers interested in them, in process.
+package eventbus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+	googleuuid "github.com/google/uuid"
+)
+
+// Envelope carries an event with the id subscribers deduplicate redeliveries by
+type Envelope struct {
+	ID         googleuuid.UUID
+	OccurredAt gotime.Time
+	Event      event.Event
+}
+
+// Handler handles the events of a subscription
+type Handler interface {
+	Handle(envelope Envelope) error
+}
+
+// HandlerFunc adapts a function to a Handler
+type HandlerFunc func(envelope Envelope) error
+
+// Handle calls f(envelope)
+func (f HandlerFunc) Handle(envelope Envelope) error {
+	return f(envelope)
+}
+
+// Publisher publishes the events of the commands, once their change is done.
+// Publishing never fails the command, failing subscribers are only logged.
+type Publisher interface {
+	Publish(e event.Event)
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(e event.Event)
+
+// Publish calls f(e)
+func (f PublisherFunc) Publish(e event.Event) {
+	f(e)
+}
+
+type subscription struct {
+	name    string
+	events  map[string]bool // nil for every event
+	handler Handler
+}
+
+func (s subscription) wants(e event.Event) bool {
+	return s.events == nil || s.events[e.EventName()]
+}
+
+// Bus is a synchronous publish/subscribe bus. Every subscription is handed the events it
+// subscribed to in the order they are published, independently of the other subscriptions.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// NewBus constructor
+func NewBus(up uuid.Provider, tp time.Provider) *Bus {
+	return &Bus{uuidProvider: up, timeProvider: tp}
+}
+
+// Subscribe registers handler for the events of the given names, or every event when none is given.
+// The name identifies the subscription, for instance in the delivery records of the outbox.
+func (b *Bus) Subscribe(name string, handler Handler, eventNames ...string) {
+	s := subscription{name: name, handler: handler}
+	if len(eventNames) > 0 {
+		s.events = make(map[string]bool, len(eventNames))
+		for _, n := range eventNames {
+			s.events[n] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, s)
+}
+
+// Subscriptions returns the names of the subscriptions, in the order they subscribed
+func (b *Bus) Subscriptions() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	names := make([]string, 0, len(b.subscriptions))
+	for _, s := range b.subscriptions {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// Publish stamps the event with a new id and delivers it to every subscription, logging failures
+func (b *Bus) Publish(e event.Event) {
+	envelope := Envelope{ID: b.uuidProvider.NewUUID(), OccurredAt: b.timeProvider.Now().UTC(), Event: e}
+	if err := b.Deliver(envelope); err != nil {
+		log.Printf("event %s (%s): %v", envelope.ID, e.EventName(), err)
+	}
+}
+
+// Deliver hands the envelope to every subscription interested in it, returning their errors
+func (b *Bus) Deliver(envelope Envelope) error {
+	var errs []error
+	for _, s := range b.snapshot() {
+		if err := deliver(s, envelope); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeliverTo hands the envelope to the named subscription only, if it is interested in it,
+// returning the error of its handler as is
+func (b *Bus) DeliverTo(name string, envelope Envelope) error {
+	for _, s := range b.snapshot() {
+		if s.name == name {
+			return deliver(s, envelope)
+		}
+	}
+	return fmt.Errorf("no subscription %q", name)
+}
+
+func (b *Bus) snapshot() []subscription {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]subscription(nil), b.subscriptions...)
+}
+
+func deliver(s subscription, envelope Envelope) error {
+	if !s.wants(envelope.Event) {
+		return nil
+	}
+	return s.handler.Handle(envelope)
+}
@@ -0,0 +1,102 @@
+package eventbus_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recorder keeps the envelopes it handled, failing while err is set
+type recorder struct {
+	err     error
+	handled []eventbus.Envelope
+}
+
+func (r *recorder) Handle(envelope eventbus.Envelope) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.handled = append(r.handled, envelope)
+	return nil
+}
+
+func TestBus_Publish(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	up := &uuidprovider.MockProvider{}
+	up.On("NewUUID").Return(id)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+
+	bus := eventbus.NewBus(up, tp)
+	favourites, all, failing := &recorder{}, &recorder{}, &recorder{err: errors.New("down")}
+	bus.Subscribe("favourites", favourites, event.FavoriteAddedName, event.FavoriteDeletedName)
+	bus.Subscribe("all", all)
+	bus.Subscribe("failing", failing)
+	assert.Equal(t, []string{"favourites", "all", "failing"}, bus.Subscriptions())
+
+	added := event.FavoriteAdded{UserID: uuid.New(), Favorite: favourite.Favorite{ID: uuid.New()}}
+	bus.Publish(added)
+	bus.Publish(event.UserLoggedOut{UserID: uuid.New()})
+
+	if !assert.Len(t, favourites.handled, 1, "only the subscribed events are delivered") {
+		return
+	}
+	assert.Equal(t, eventbus.Envelope{ID: id, OccurredAt: now, Event: added}, favourites.handled[0])
+	assert.Len(t, all.handled, 2, "a failing subscription does not stop the others")
+}
+
+func TestBus_Deliver(t *testing.T) {
+	bus := eventbus.NewBus(&uuidprovider.MockProvider{}, &timeprovider.MockProvider{})
+	ok, first, second := &recorder{}, &recorder{err: errors.New("down")}, &recorder{err: errors.New("full")}
+	bus.Subscribe("ok", ok)
+	bus.Subscribe("first", first)
+	bus.Subscribe("second", second)
+
+	envelope := eventbus.Envelope{ID: uuid.New(), Event: event.SessionRevoked{}}
+	assert.EqualError(t, bus.Deliver(envelope), "first: down\nsecond: full")
+	assert.Len(t, ok.handled, 1)
+
+	assert.EqualError(t, bus.DeliverTo("first", envelope), "down")
+	assert.NoError(t, bus.DeliverTo("ok", envelope))
+	assert.Len(t, ok.handled, 2)
+	assert.EqualError(t, bus.DeliverTo("missing", envelope), `no subscription "missing"`)
+}
+
+func TestNotificationHandler(t *testing.T) {
+	ns := &notification.MockNotificationService{}
+	h := eventbus.NewNotificationHandler(ns)
+	userID := uuid.New()
+	envelope := eventbus.Envelope{
+		ID:    uuid.New(),
+		Event: event.FavoriteAdded{UserID: userID, Favorite: favourite.Favorite{Description: "My chart"}},
+	}
+
+	ns.On("Notify", mock.MatchedBy(func(n notification.Notification) bool {
+		return n.ID == envelope.ID.String() && n.UserID == userID && n.Kind == notification.KindFavourite &&
+			n.Event == notification.EventFavoriteAdded && n.Data["description"] == "My chart"
+	})).Return(nil).Once()
+
+	if !assert.NoError(t, h.Handle(envelope)) {
+		return
+	}
+	if !assert.NoError(t, h.Handle(eventbus.Envelope{ID: uuid.New(), Event: event.FavoriteDeleted{}}), "other events are ignored") {
+		return
+	}
+	ns.AssertExpectations(t)
+
+	ns = &notification.MockNotificationService{}
+	ns.On("Notify", mock.Anything).Return(errors.New("down"))
+	assert.EqualError(t, eventbus.NewNotificationHandler(ns).Handle(envelope), "down")
+}
@@ -0,0 +1,27 @@
+package eventbus
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+)
+
+// NotificationEvents are the events the notification handler is interested in
+var NotificationEvents = []string{event.FavoriteAddedName}
+
+// NewNotificationHandler notifies users about their new favourites, using the envelope ID as the
+// notification ID so a redelivered event can be recognised
+func NewNotificationHandler(ns notification.Service) Handler {
+	return HandlerFunc(func(envelope Envelope) error {
+		added, ok := envelope.Event.(event.FavoriteAdded)
+		if !ok {
+			return nil
+		}
+		return ns.Notify(notification.Notification{
+			ID:     envelope.ID.String(),
+			UserID: added.UserID,
+			Kind:   notification.KindFavourite,
+			Event:  notification.EventFavoriteAdded,
+			Data:   map[string]string{"description": added.Favorite.Description},
+		})
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 )
@@ -17,6 +19,7 @@ type AddFavoriteRequest struct {
 	Type        favourite.AssetType
 	Description string
 	Data        json.RawMessage
+	Source      audit.Source
 }
 
 // CreateFavoriteRequestHandler interface for handling add favorite
@@ -25,49 +28,48 @@ type CreateFavoriteRequestHandler interface {
 }
 
 type addFavoriteRequestHandler struct {
-	repo                favourite.Repository
-	notificationService notification.Service
+	repo    favourite.Repository
+	stamper auditlog.Stamper
 }
 
 // NewAddFavoriteRequestHandler constructor
-func NewAddFavoriteRequestHandler(
-	repo favourite.Repository,
-	notificationService notification.Service,
-) CreateFavoriteRequestHandler {
-	return addFavoriteRequestHandler{
-		repo:                repo,
-		notificationService: notificationService,
-	}
+func NewAddFavoriteRequestHandler(repo favourite.Repository, stamper auditlog.Stamper) CreateFavoriteRequestHandler {
+	return addFavoriteRequestHandler{repo: repo, stamper: stamper}
 }
 
-// Handle adds a new favorite
+// Handle adds a new favorite after the others of the user. Subscribers, like the notifications, learn
+// about it from the outbox event.
 func (h addFavoriteRequestHandler) Handle(req AddFavoriteRequest) error {
+	existing, err := h.repo.GetAll(req.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch favorites: %w", err)
+	}
+	position := 0
+	if len(existing) > 0 {
+		position = existing[len(existing)-1].Position + 1
+	}
+
 	fav := favourite.Favorite{
 		ID:          req.ID,
 		Type:        req.Type,
 		Description: req.Description,
 		Data:        req.Data,
+		Position:    position,
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
 	}
 
-	// Store under the correct user
-	if err := h.repo.Add(req.UserID, fav); err != nil {
-		return fmt.Errorf("failed to add favorite: %w", err)
+	added, err := newOutboxEvent(event.FavoriteAdded{UserID: req.UserID, Favorite: fav}, req.UserID, fav.ID)
+	if err != nil {
+		return err
 	}
 
-	// Send notification
-	n := notification.Notification{
-		Subject: "New Favorite added",
-		Message: fmt.Sprintf(
-			"A new favorite with description '%s' was added for user %s",
-			fav.Description,
-			req.UserID.String(),
-		),
-	}
+	entry := audit.NewEntry(req.Source, audit.ActionFavouriteCreate, audit.Target{Type: audit.TargetFavourite, ID: fav.ID.String()})
+	entry.Changes = audit.Diff(nil, fav)
 
-	if err := h.notificationService.Notify(n); err != nil {
-		return fmt.Errorf("favorite added but failed to send notification: %w", err)
+	// Store under the correct user, with its audit entry
+	if err := h.repo.Add(req.UserID, fav, h.stamper.Stamp(entry), added); err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
 	}
 
 	return nil
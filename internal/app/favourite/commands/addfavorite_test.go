@@ -6,8 +6,13 @@ import (
 	"testing"
 	_ "time"
 
-	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -16,14 +21,24 @@ import (
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
 )
 
-// Mock repository for favorites
+// Mock repository for favorites, keeping the audit entries and outbox events of the successful writes
 type MockRepositoryF struct {
 	mock.Mock
+	entries []audit.Entry
+	events  []outbox.Event
 }
 
-func (m *MockRepositoryF) Add(userID uuid.UUID, fav favourite.Favorite) error {
+func (m *MockRepositoryF) record(err error, entry audit.Entry, events []outbox.Event) error {
+	if err == nil {
+		m.entries = append(m.entries, entry)
+		m.events = append(m.events, events...)
+	}
+	return err
+}
+
+func (m *MockRepositoryF) Add(userID uuid.UUID, fav favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
 	args := m.Called(userID, fav)
-	return args.Error(0)
+	return m.record(args.Error(0), entry, events)
 }
 
 func (m *MockRepositoryF) GetByID(userID uuid.UUID, favoriteID uuid.UUID) (*favourite.Favorite, error) {
@@ -44,73 +59,66 @@ func (m *MockRepositoryF) GetAll(userID uuid.UUID) ([]favourite.Favorite, error)
 	return favs.([]favourite.Favorite), args.Error(1)
 }
 
-func (m *MockRepositoryF) Update(userID uuid.UUID, favorite favourite.Favorite) error {
+func (m *MockRepositoryF) Update(userID uuid.UUID, favorite favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
 	args := m.Called(userID, favorite)
-	return args.Error(0)
+	return m.record(args.Error(0), entry, events)
 }
 
 // Delete mock implementation
-func (m *MockRepositoryF) Delete(userID uuid.UUID, favoriteID uuid.UUID) error {
+func (m *MockRepositoryF) Delete(userID uuid.UUID, favoriteID uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
 	args := m.Called(userID, favoriteID)
-	return args.Error(0)
+	return m.record(args.Error(0), entry, events)
 }
 
-// Mock notification service
-type MockNotificationService struct {
-	mock.Mock
+func (m *MockRepositoryF) Reorder(userID uuid.UUID, order []uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
+	args := m.Called(userID, order)
+	return m.record(args.Error(0), entry, events)
 }
 
-func (m *MockNotificationService) Notify(n notification.Notification) error {
-	args := m.Called(n)
-	return args.Error(0)
+func newStamper() auditlog.Stamper {
+	return auditlog.NewStamper(uuidprovider.NewUUIDProvider(), timeprovider.NewTimeProvider())
 }
 
 func TestAddFavoriteRequestHandler_Handle(t *testing.T) {
 	mockUserID := uuid.New()
 	tests := []struct {
-		name              string
-		repoError         error
-		notificationError error
-		expectedError     string
+		name            string
+		repoError       error
+		expectedError   string
+		expectedEntries int
+		expectedEvents  int
 	}{
 		{
-			name:              "happy path",
-			repoError:         nil,
-			notificationError: nil,
-			expectedError:     "",
-		},
-		{
-			name:              "repo returns error",
-			repoError:         errors.New("repo failed"),
-			notificationError: nil,
-			expectedError:     "failed to add favorite: repo failed",
+			name:            "happy path",
+			repoError:       nil,
+			expectedError:   "",
+			expectedEntries: 1,
+			expectedEvents:  1,
 		},
 		{
-			name:              "notification returns error",
-			repoError:         nil,
-			notificationError: errors.New("notify failed"),
-			expectedError:     "favorite added but failed to send notification: notify failed",
+			name:          "repo returns error",
+			repoError:     errors.New("repo failed"),
+			expectedError: "failed to add favorite: repo failed",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := &MockRepositoryF{}
-			mockNotification := &MockNotificationService{}
 
-			// Repo Add always expected
-			mockRepo.On("Add", mock.Anything, mock.Anything).Return(tt.repoError)
+			// the new favourite goes after the others
+			mockRepo.On("GetAll", mockUserID).Return([]favourite.Favorite{{ID: uuid.New(), Position: 0}, {ID: uuid.New(), Position: 3}}, nil)
+			mockRepo.On("Add", mockUserID, mock.MatchedBy(func(fav favourite.Favorite) bool { return fav.Position == 4 })).Return(tt.repoError)
 
-			// Notify may be called, depending on repo result
-			mockNotification.On("Notify", mock.Anything).Maybe().Return(tt.notificationError)
-
-			handler := commands.NewAddFavoriteRequestHandler(mockRepo, mockNotification)
+			handler := commands.NewAddFavoriteRequestHandler(mockRepo, newStamper())
 
 			req := commands.AddFavoriteRequest{
+				ID:          uuid.New(),
 				UserID:      mockUserID,
 				Type:        "Chart",
 				Description: "My Favorite Chart",
 				Data:        json.RawMessage(`{"x":1,"y":2}`),
+				Source:      audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: mockUserID.String()}, RequestID: "req-1"},
 			}
 
 			err := handler.Handle(req)
@@ -121,8 +129,29 @@ func TestAddFavoriteRequestHandler_Handle(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
+			// the entry is stored in the same write as the favourite, or not at all
+			assert.Len(t, mockRepo.entries, tt.expectedEntries)
+			for _, e := range mockRepo.entries {
+				assert.NotEqual(t, uuid.Nil, e.ID, "stamped before the write")
+				assert.False(t, e.Time.IsZero())
+				assert.Equal(t, audit.ActionFavouriteCreate, e.Action)
+				assert.Equal(t, req.ID.String(), e.Target.ID)
+				assert.Equal(t, "req-1", e.RequestID)
+				assert.JSONEq(t, `{"x":1,"y":2}`, string(e.Changes["data"].After))
+			}
+
+			assert.Len(t, mockRepo.events, tt.expectedEvents)
+			for _, e := range mockRepo.events {
+				assert.Equal(t, event.FavoriteAddedName, e.Type)
+				assert.Equal(t, req.ID, e.AggregateID)
+				assert.Equal(t, mockUserID, e.UserID)
+
+				var payload event.FavoriteAdded
+				assert.NoError(t, json.Unmarshal(e.Payload, &payload))
+				assert.Equal(t, "My Favorite Chart", payload.Favorite.Description)
+			}
+
 			mockRepo.AssertExpectations(t)
-			mockNotification.AssertExpectations(t)
 		})
 	}
 }
@@ -3,6 +3,9 @@ package commands
 import (
 	"fmt"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 )
@@ -11,6 +14,7 @@ import (
 type DeleteFavoriteRequest struct {
 	UserID     uuid.UUID
 	FavoriteID uuid.UUID
+	Source     audit.Source
 }
 
 // DeleteFavoriteRequestHandler interface
@@ -19,12 +23,13 @@ type DeleteFavoriteRequestHandler interface {
 }
 
 type deleteFavoriteRequestHandler struct {
-	repo favourite.Repository
+	repo    favourite.Repository
+	stamper auditlog.Stamper
 }
 
 // NewDeleteFavoriteRequestHandler constructor
-func NewDeleteFavoriteRequestHandler(repo favourite.Repository) DeleteFavoriteRequestHandler {
-	return deleteFavoriteRequestHandler{repo: repo}
+func NewDeleteFavoriteRequestHandler(repo favourite.Repository, stamper auditlog.Stamper) DeleteFavoriteRequestHandler {
+	return deleteFavoriteRequestHandler{repo: repo, stamper: stamper}
 }
 
 // Handle deletes a favorite for a specific user
@@ -38,8 +43,16 @@ func (h deleteFavoriteRequestHandler) Handle(command DeleteFavoriteRequest) erro
 		return fmt.Errorf("favorite with ID %s does not exist for user %s", command.FavoriteID, command.UserID)
 	}
 
-	// Delete the favorite
-	if err := h.repo.Delete(command.UserID, command.FavoriteID); err != nil {
+	deleted, err := newOutboxEvent(event.FavoriteDeleted{UserID: command.UserID, Favorite: *fav}, command.UserID, command.FavoriteID)
+	if err != nil {
+		return err
+	}
+
+	entry := audit.NewEntry(command.Source, audit.ActionFavouriteDelete, audit.Target{Type: audit.TargetFavourite, ID: command.FavoriteID.String()})
+	entry.Changes = audit.Diff(*fav, nil)
+
+	// Delete the favorite, with its audit entry
+	if err := h.repo.Delete(command.UserID, command.FavoriteID, h.stamper.Stamp(entry), deleted); err != nil {
 		return fmt.Errorf("failed to delete favorite: %w", err)
 	}
 
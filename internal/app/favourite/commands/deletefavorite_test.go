@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -60,7 +61,7 @@ func TestDeleteFavoriteRequestHandler_Handle(t *testing.T) {
 			mockRepo := &MockRepositoryF{}
 			tt.setupMock(mockRepo)
 
-			handler := commands.NewDeleteFavoriteRequestHandler(mockRepo)
+			handler := commands.NewDeleteFavoriteRequestHandler(mockRepo, newStamper())
 
 			err := handler.Handle(commands.DeleteFavoriteRequest{
 				UserID:     mockUserID,
@@ -70,8 +71,14 @@ func TestDeleteFavoriteRequestHandler_Handle(t *testing.T) {
 			if tt.expectedError != "" {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, mockRepo.entries, "no audit entry without the change")
 			} else {
 				assert.NoError(t, err)
+				assert.Len(t, mockRepo.entries, 1)
+				if assert.Len(t, mockRepo.events, 1) {
+					assert.Equal(t, event.FavoriteDeletedName, mockRepo.events[0].Type)
+					assert.Equal(t, mockFavoriteID, mockRepo.events[0].AggregateID)
+				}
 			}
 
 			mockRepo.AssertExpectations(t)
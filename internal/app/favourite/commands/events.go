@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+)
+
+// newOutboxEvent builds the outbox event stored together with the change of the favourite e is about
+func newOutboxEvent(e event.Event, userID, favoriteID uuid.UUID) (outbox.Event, error) {
+	stored, err := outbox.NewEvent(uuid.New(), e.EventName(), favoriteID, userID, e, time.Now().UTC())
+	if err != nil {
+		return outbox.Event{}, fmt.Errorf("failed to build %s event: %w", e.EventName(), err)
+	}
+	return stored, nil
+}
+
+// changedFields returns the sorted names of the fields of changes
+func changedFields(changes map[string]audit.Change) []string {
+	fields := make([]string, 0, len(changes))
+	for name := range changes {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/google/uuid"
+)
+
+// ReorderFavoritesRequest orders the favourites of a user, Order lists all their ids first to last
+type ReorderFavoritesRequest struct {
+	UserID uuid.UUID
+	Order  []uuid.UUID
+	Source audit.Source
+}
+
+// ReorderFavoritesRequestHandler interface
+type ReorderFavoritesRequestHandler interface {
+	Handle(command ReorderFavoritesRequest) error
+}
+
+type reorderFavoritesRequestHandler struct {
+	repo    favourite.Repository
+	stamper auditlog.Stamper
+}
+
+// NewReorderFavoritesRequestHandler constructor
+func NewReorderFavoritesRequestHandler(repo favourite.Repository, stamper auditlog.Stamper) ReorderFavoritesRequestHandler {
+	return reorderFavoritesRequestHandler{repo: repo, stamper: stamper}
+}
+
+// Handle stores the new order of the favourites. An order missing a favourite or naming another one fails
+// with favourite.ErrInvalidOrder, the order they already have changes nothing.
+func (h reorderFavoritesRequestHandler) Handle(command ReorderFavoritesRequest) error {
+	favorites, err := h.repo.GetAll(command.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch favorites: %w", err)
+	}
+	current := make([]uuid.UUID, 0, len(favorites))
+	for _, fav := range favorites {
+		current = append(current, fav.ID)
+	}
+	if slices.Equal(current, command.Order) {
+		return nil
+	}
+
+	reordered, err := newOutboxEvent(event.FavoritesReordered{UserID: command.UserID, Order: command.Order}, command.UserID, command.UserID)
+	if err != nil {
+		return err
+	}
+
+	entry := audit.NewEntry(command.Source, audit.ActionFavouriteReorder, audit.Target{Type: audit.TargetUser, ID: command.UserID.String()})
+	entry.Changes = audit.Diff(map[string][]uuid.UUID{"order": current}, map[string][]uuid.UUID{"order": command.Order})
+
+	// The repository checks the order against the favourites it holds when writing it
+	if err := h.repo.Reorder(command.UserID, command.Order, h.stamper.Stamp(entry), reordered); err != nil {
+		return fmt.Errorf("failed to reorder favorites: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderFavoritesRequestHandler_Handle(t *testing.T) {
+	mockUserID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	stored := []favourite.Favorite{{ID: first, Position: 0}, {ID: second, Position: 1}}
+
+	tests := []struct {
+		name          string
+		order         []uuid.UUID
+		setupMock     func(m *MockRepositoryF)
+		expectedError error
+		reordered     bool
+	}{
+		{
+			name:  "happy path - the new order is stored",
+			order: []uuid.UUID{second, first},
+			setupMock: func(m *MockRepositoryF) {
+				m.On("GetAll", mockUserID).Return(stored, nil)
+				m.On("Reorder", mockUserID, []uuid.UUID{second, first}).Return(nil)
+			},
+			reordered: true,
+		},
+		{
+			name:  "the current order changes nothing",
+			order: []uuid.UUID{first, second},
+			setupMock: func(m *MockRepositoryF) {
+				m.On("GetAll", mockUserID).Return(stored, nil)
+			},
+		},
+		{
+			name:  "an order missing a favourite is refused by the repository",
+			order: []uuid.UUID{second},
+			setupMock: func(m *MockRepositoryF) {
+				m.On("GetAll", mockUserID).Return(stored, nil)
+				m.On("Reorder", mockUserID, []uuid.UUID{second}).Return(favourite.ErrInvalidOrder)
+			},
+			expectedError: favourite.ErrInvalidOrder,
+		},
+		{
+			name:  "GetAll returns error",
+			order: []uuid.UUID{second, first},
+			setupMock: func(m *MockRepositoryF) {
+				m.On("GetAll", mockUserID).Return(nil, errors.New("repo error"))
+			},
+			expectedError: errors.New("repo error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRepositoryF{}
+			tt.setupMock(mockRepo)
+
+			handler := commands.NewReorderFavoritesRequestHandler(mockRepo, newStamper())
+			err := handler.Handle(commands.ReorderFavoritesRequest{
+				UserID: mockUserID,
+				Order:  tt.order,
+				Source: audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: mockUserID.String()}},
+			})
+
+			switch {
+			case errors.Is(tt.expectedError, favourite.ErrInvalidOrder):
+				assert.ErrorIs(t, err, favourite.ErrInvalidOrder)
+			case tt.expectedError != nil:
+				assert.ErrorContains(t, err, tt.expectedError.Error())
+			default:
+				assert.NoError(t, err)
+			}
+
+			if !tt.reordered {
+				assert.Empty(t, mockRepo.entries)
+				assert.Empty(t, mockRepo.events)
+			} else if assert.Len(t, mockRepo.entries, 1) && assert.Len(t, mockRepo.events, 1) {
+				entry := mockRepo.entries[0]
+				assert.Equal(t, audit.ActionFavouriteReorder, entry.Action)
+				assert.Equal(t, audit.Target{Type: audit.TargetUser, ID: mockUserID.String()}, entry.Target)
+				assert.JSONEq(t, fmt.Sprintf(`[%q, %q]`, first, second), string(entry.Changes["order"].Before))
+				assert.JSONEq(t, fmt.Sprintf(`[%q, %q]`, second, first), string(entry.Changes["order"].After))
+
+				e := mockRepo.events[0]
+				assert.Equal(t, event.FavoritesReorderedName, e.Type)
+				assert.Equal(t, mockUserID, e.AggregateID)
+				var payload event.FavoritesReordered
+				assert.NoError(t, json.Unmarshal(e.Payload, &payload))
+				assert.Equal(t, []uuid.UUID{second, first}, payload.Order)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 )
@@ -15,6 +18,7 @@ type UpdateFavoriteRequest struct {
 	Type        favourite.AssetType
 	Description string
 	Data        json.RawMessage
+	Source      audit.Source
 }
 
 // UpdateFavoriteRequestHandler interface
@@ -23,12 +27,13 @@ type UpdateFavoriteRequestHandler interface {
 }
 
 type updateFavoriteRequestHandler struct {
-	repo favourite.Repository
+	repo    favourite.Repository
+	stamper auditlog.Stamper
 }
 
 // NewUpdateFavoriteRequestHandler constructor
-func NewUpdateFavoriteRequestHandler(repo favourite.Repository) UpdateFavoriteRequestHandler {
-	return updateFavoriteRequestHandler{repo: repo}
+func NewUpdateFavoriteRequestHandler(repo favourite.Repository, stamper auditlog.Stamper) UpdateFavoriteRequestHandler {
+	return updateFavoriteRequestHandler{repo: repo, stamper: stamper}
 }
 
 // Handle updates a favorite for a specific user
@@ -42,13 +47,24 @@ func (h updateFavoriteRequestHandler) Handle(command UpdateFavoriteRequest) erro
 		return fmt.Errorf("favorite with ID %s does not exist for user %s", command.ID, command.UserID)
 	}
 
+	before := *favorite
+
 	// Update fields
 	favorite.Type = command.Type
 	favorite.Description = command.Description
 	favorite.Data = command.Data
 
-	// Persist the update
-	if err := h.repo.Update(command.UserID, *favorite); err != nil {
+	changes := audit.Diff(before, *favorite)
+	updated, err := newOutboxEvent(event.FavoriteUpdated{UserID: command.UserID, Favorite: *favorite, Changed: changedFields(changes)}, command.UserID, command.ID)
+	if err != nil {
+		return err
+	}
+
+	entry := audit.NewEntry(command.Source, audit.ActionFavouriteUpdate, audit.Target{Type: audit.TargetFavourite, ID: command.ID.String()})
+	entry.Changes = changes
+
+	// Persist the update, with its audit entry
+	if err := h.repo.Update(command.UserID, *favorite, h.stamper.Stamp(entry), updated); err != nil {
 		return fmt.Errorf("failed to update favorite: %w", err)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -100,14 +101,23 @@ func TestUpdateFavoriteRequestHandler_Handle(t *testing.T) {
 			mockRepo := &MockRepositoryF{}
 			tt.setupMock(mockRepo)
 
-			handler := commands.NewUpdateFavoriteRequestHandler(mockRepo)
+			handler := commands.NewUpdateFavoriteRequestHandler(mockRepo, newStamper())
 			err := handler.Handle(tt.command)
 
 			if tt.expectedError != "" {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, mockRepo.entries, "no audit entry without the change")
 			} else {
 				assert.NoError(t, err)
+				assert.Len(t, mockRepo.entries, 1)
+				if assert.Len(t, mockRepo.events, 1) {
+					assert.Equal(t, event.FavoriteUpdatedName, mockRepo.events[0].Type)
+
+					var payload event.FavoriteUpdated
+					assert.NoError(t, json.Unmarshal(mockRepo.events[0].Payload, &payload))
+					assert.Equal(t, []string{"data", "description", "type"}, payload.Changed)
+				}
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 )
@@ -13,6 +16,7 @@ type PatchFavoriteRequest struct {
 	Type        *favourite.AssetType `json:"type,omitempty"`
 	Description *string              `json:"description,omitempty"`
 	Data        *json.RawMessage     `json:"data,omitempty"`
+	Source      audit.Source         `json:"-"`
 }
 
 // UpdatePartialFavoriteRequestHandler interface for PATCH
@@ -21,12 +25,13 @@ type UpdatePartialFavoriteRequestHandler interface {
 }
 
 type updatePartialFavoriteRequestHandler struct {
-	repo favourite.Repository
+	repo    favourite.Repository
+	stamper auditlog.Stamper
 }
 
 // NewUpdatePartialFavoriteRequestHandler constructor
-func NewUpdatePartialFavoriteRequestHandler(repo favourite.Repository) UpdatePartialFavoriteRequestHandler {
-	return &updatePartialFavoriteRequestHandler{repo: repo}
+func NewUpdatePartialFavoriteRequestHandler(repo favourite.Repository, stamper auditlog.Stamper) UpdatePartialFavoriteRequestHandler {
+	return &updatePartialFavoriteRequestHandler{repo: repo, stamper: stamper}
 }
 
 // HandlePartial applies only the provided fields to an existing favorite
@@ -40,6 +45,8 @@ func (h *updatePartialFavoriteRequestHandler) HandlePartial(userID uuid.UUID, fa
 		return nil, fmt.Errorf("favorite with ID %s not found for user %s", favoriteID, userID)
 	}
 
+	before := *fav
+
 	// Apply only provided updates
 	if req.Type != nil {
 		fav.Type = *req.Type
@@ -51,8 +58,17 @@ func (h *updatePartialFavoriteRequestHandler) HandlePartial(userID uuid.UUID, fa
 		fav.Data = *req.Data
 	}
 
-	// Persist the update
-	if err := h.repo.Update(userID, *fav); err != nil {
+	changes := audit.Diff(before, *fav)
+	updated, err := newOutboxEvent(event.FavoriteUpdated{UserID: userID, Favorite: *fav, Changed: changedFields(changes)}, userID, favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := audit.NewEntry(req.Source, audit.ActionFavouritePatch, audit.Target{Type: audit.TargetFavourite, ID: favoriteID.String()})
+	entry.Changes = changes
+
+	// Persist the update, with its audit entry
+	if err := h.repo.Update(userID, *fav, h.stamper.Stamp(entry), updated); err != nil {
 		return nil, fmt.Errorf("failed to update favorite: %w", err)
 	}
 
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 
 	"github.com/google/uuid"
@@ -110,18 +112,26 @@ func TestUpdatePartialFavoriteRequestHandler_HandlePartial(t *testing.T) {
 
 			tt.setupMock(mockRepo, fav)
 
-			handler := commands.NewUpdatePartialFavoriteRequestHandler(mockRepo)
+			handler := commands.NewUpdatePartialFavoriteRequestHandler(mockRepo, newStamper())
 			result, err := handler.HandlePartial(mockUserID, mockFavoriteID, tt.req)
 
 			if tt.expectedError != "" {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, mockRepo.entries, "no audit entry without the change")
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedFav.ID, result.ID)
 				assert.Equal(t, tt.expectedFav.Type, result.Type)
 				assert.Equal(t, tt.expectedFav.Description, result.Description)
 				assert.JSONEq(t, string(tt.expectedFav.Data), string(result.Data))
+
+				assert.Len(t, mockRepo.entries, 1)
+				assert.Equal(t, audit.ActionFavouritePatch, mockRepo.entries[0].Action)
+				assert.NotContains(t, mockRepo.entries[0].Changes, "id", "unchanged fields are not recorded")
+				if assert.Len(t, mockRepo.events, 1) {
+					assert.Equal(t, event.FavoriteUpdatedName, mockRepo.events[0].Type)
+				}
 			}
 
 			mockRepo.AssertExpectations(t)
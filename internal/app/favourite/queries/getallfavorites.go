@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 )
@@ -12,6 +14,7 @@ import (
 // GetAllFavoritesRequest represents a query to fetch all favorites for a user
 type GetAllFavoritesRequest struct {
 	UserID uuid.UUID
+	Source audit.Source
 }
 
 // GetAllFavoritesResult represents the data returned for each favorite
@@ -20,6 +23,7 @@ type GetAllFavoritesResult struct {
 	Type        favourite.AssetType `json:"type"`
 	Description string              `json:"description"`
 	Data        json.RawMessage     `json:"data"`
+	Position    int                 `json:"position"`
 	CreatedAt   time.Time           `json:"createdAt"`
 	UpdatedAt   time.Time           `json:"updatedAt"`
 }
@@ -30,20 +34,24 @@ type GetAllFavoritesRequestHandler interface {
 }
 
 type getAllFavoritesRequestHandler struct {
-	repo favourite.Repository
+	repo  favourite.Repository
+	audit auditlog.Recorder
 }
 
 // NewGetAllFavoritesRequestHandler constructor
-func NewGetAllFavoritesRequestHandler(repo favourite.Repository) GetAllFavoritesRequestHandler {
-	return getAllFavoritesRequestHandler{repo: repo}
+func NewGetAllFavoritesRequestHandler(repo favourite.Repository, recorder auditlog.Recorder) GetAllFavoritesRequestHandler {
+	return getAllFavoritesRequestHandler{repo: repo, audit: recorder}
 }
 
-// Handle fetches all favorites for a specific user
+// Handle fetches all favorites for a specific user. The read is audited when an admin impersonates the user.
 func (h getAllFavoritesRequestHandler) Handle(query GetAllFavoritesRequest) ([]GetAllFavoritesResult, error) {
 	items, err := h.repo.GetAll(query.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch favorites for user %s: %w", query.UserID, err)
 	}
+	if err := auditlog.RecordImpersonatedRead(h.audit, query.Source, audit.ActionFavouriteRead, audit.Target{Type: audit.TargetUser, ID: query.UserID.String()}); err != nil {
+		return nil, err
+	}
 
 	var result []GetAllFavoritesResult
 	for _, fav := range items {
@@ -52,6 +60,7 @@ func (h getAllFavoritesRequestHandler) Handle(query GetAllFavoritesRequest) ([]G
 			Type:        fav.Type,
 			Description: fav.Description,
 			Data:        fav.Data,
+			Position:    fav.Position,
 			CreatedAt:   fav.CreatedAt,
 			UpdatedAt:   fav.UpdatedAt,
 		})
@@ -6,8 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +22,7 @@ type MockRepositoryF struct {
 	mock.Mock
 }
 
-func (m *MockRepositoryF) Add(userID uuid.UUID, fav favourite.Favorite) error {
+func (m *MockRepositoryF) Add(userID uuid.UUID, fav favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
 	args := m.Called(userID, fav)
 	return args.Error(0)
 }
@@ -42,17 +45,22 @@ func (m *MockRepositoryF) GetAll(userID uuid.UUID) ([]favourite.Favorite, error)
 	return favs.([]favourite.Favorite), args.Error(1)
 }
 
-func (m *MockRepositoryF) Update(userID uuid.UUID, favorite favourite.Favorite) error {
+func (m *MockRepositoryF) Update(userID uuid.UUID, favorite favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
 	args := m.Called(userID, favorite)
 	return args.Error(0)
 }
 
 // Delete mock implementation
-func (m *MockRepositoryF) Delete(userID uuid.UUID, favoriteID uuid.UUID) error {
+func (m *MockRepositoryF) Delete(userID uuid.UUID, favoriteID uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
 	args := m.Called(userID, favoriteID)
 	return args.Error(0)
 }
 
+func (m *MockRepositoryF) Reorder(userID uuid.UUID, order []uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
+	args := m.Called(userID, order)
+	return args.Error(0)
+}
+
 func TestGetAllFavoritesRequestHandler_Handle(t *testing.T) {
 	mockUserID := uuid.New()
 	mockFavoriteID := uuid.New()
@@ -102,7 +110,7 @@ func TestGetAllFavoritesRequestHandler_Handle(t *testing.T) {
 			mockRepo := &MockRepositoryF{}
 			mockRepo.On("GetAll", mockUserID).Return(tt.mockReturn, tt.mockError)
 
-			handler := queries.NewGetAllFavoritesRequestHandler(mockRepo)
+			handler := queries.NewGetAllFavoritesRequestHandler(mockRepo, &auditlogtest.Trail{})
 
 			result, err := handler.Handle(queries.GetAllFavoritesRequest{
 				UserID: mockUserID,
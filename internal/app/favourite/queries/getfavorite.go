@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 	"github.com/google/uuid"
 )
@@ -13,6 +15,7 @@ import (
 type GetFavoriteRequest struct {
 	UserID     uuid.UUID
 	FavoriteID uuid.UUID
+	Source     audit.Source
 }
 
 // GetFavoriteResult is the return model of Favorite Query Handlers
@@ -21,6 +24,7 @@ type GetFavoriteResult struct {
 	Type        favourite.AssetType `json:"type"`
 	Description string              `json:"description"`
 	Data        json.RawMessage     `json:"data"`
+	Position    int                 `json:"position"`
 	CreatedAt   time.Time           `json:"createdAt"`
 }
 
@@ -30,15 +34,16 @@ type GetFavoriteRequestHandler interface {
 }
 
 type getFavoriteRequestHandler struct {
-	repo favourite.Repository
+	repo  favourite.Repository
+	audit auditlog.Recorder
 }
 
 // NewGetFavoriteRequestHandler constructor
-func NewGetFavoriteRequestHandler(repo favourite.Repository) GetFavoriteRequestHandler {
-	return getFavoriteRequestHandler{repo: repo}
+func NewGetFavoriteRequestHandler(repo favourite.Repository, recorder auditlog.Recorder) GetFavoriteRequestHandler {
+	return getFavoriteRequestHandler{repo: repo, audit: recorder}
 }
 
-// Handle fetches a specific favorite for a given user
+// Handle fetches a specific favorite for a given user. The read is audited when an admin impersonates the user.
 func (h getFavoriteRequestHandler) Handle(query GetFavoriteRequest) (*GetFavoriteResult, error) {
 	fav, err := h.repo.GetByID(query.UserID, query.FavoriteID)
 	if err != nil {
@@ -47,12 +52,16 @@ func (h getFavoriteRequestHandler) Handle(query GetFavoriteRequest) (*GetFavorit
 	if fav == nil {
 		return nil, fmt.Errorf("favorite %s not found for user %s", query.FavoriteID, query.UserID)
 	}
+	if err := auditlog.RecordImpersonatedRead(h.audit, query.Source, audit.ActionFavouriteRead, audit.Target{Type: audit.TargetFavourite, ID: fav.ID.String()}); err != nil {
+		return nil, err
+	}
 
 	return &GetFavoriteResult{
 		ID:          fav.ID,
 		Type:        fav.Type,
 		Description: fav.Description,
 		Data:        fav.Data,
+		Position:    fav.Position,
 		CreatedAt:   fav.CreatedAt,
 	}, nil
 }
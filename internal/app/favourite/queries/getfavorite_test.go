@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
 
@@ -55,7 +56,7 @@ func TestGetFavoriteRequestHandler_Handle(t *testing.T) {
 			mockRepo := &MockRepositoryF{}
 			mockRepo.On("GetByID", mockUserID, mockFavoriteID).Return(tt.mockReturn, tt.mockError)
 
-			handler := queries.NewGetFavoriteRequestHandler(mockRepo)
+			handler := queries.NewGetFavoriteRequestHandler(mockRepo, &auditlogtest.Trail{})
 
 			result, err := handler.Handle(queries.GetFavoriteRequest{
 				UserID:     mockUserID,
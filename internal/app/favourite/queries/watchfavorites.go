@@ -0,0 +1,49 @@
+package queries
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/google/uuid"
+)
+
+// WatchFavoritesRequest represents a client following the favourite events of a user
+type WatchFavoritesRequest struct {
+	UserID uuid.UUID
+	// LastEventID is the last event the client saw, empty for a new stream
+	LastEventID string
+	Source      audit.Source
+}
+
+// WatchFavoritesResult is the subscription and the buffered events following LastEventID. When
+// Resumed is false the events since LastEventID are lost, and the client reloads the favourites.
+type WatchFavoritesResult struct {
+	Subscription *stream.Subscription
+	Missed       []stream.Event
+	Resumed      bool
+}
+
+// WatchFavoritesHandler interface
+type WatchFavoritesHandler interface {
+	Handle(query WatchFavoritesRequest) (*WatchFavoritesResult, error)
+}
+
+type watchFavoritesHandler struct {
+	hub   *stream.Hub
+	audit auditlog.Recorder
+}
+
+// NewWatchFavoritesHandler constructor
+func NewWatchFavoritesHandler(hub *stream.Hub, recorder auditlog.Recorder) WatchFavoritesHandler {
+	return watchFavoritesHandler{hub: hub, audit: recorder}
+}
+
+// Handle subscribes to the events of the user, the caller unsubscribes from the hub when done.
+// The subscription is audited when an admin impersonates the user.
+func (h watchFavoritesHandler) Handle(query WatchFavoritesRequest) (*WatchFavoritesResult, error) {
+	if err := auditlog.RecordImpersonatedRead(h.audit, query.Source, audit.ActionFavouriteWatch, audit.Target{Type: audit.TargetUser, ID: query.UserID.String()}); err != nil {
+		return nil, err
+	}
+	sub, missed, resumed := h.hub.Subscribe(query.UserID, query.LastEventID)
+	return &WatchFavoritesResult{Subscription: sub, Missed: missed, Resumed: resumed}, nil
+}
@@ -0,0 +1,84 @@
+package queries_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFavoriteReads_AuditImpersonation(t *testing.T) {
+	userID, favoriteID := uuid.New(), uuid.New()
+	self := audit.Source{Actor: audit.Actor{Kind: audit.ActorUser, ID: userID.String()}}
+	impersonated := audit.Source{Actor: audit.Actor{Kind: audit.ActorImpersonation, ID: uuid.NewString(), OnBehalfOf: userID.String()}, RequestID: "req-1"}
+
+	repo := &MockRepositoryF{}
+	repo.On("GetAll", userID).Return([]favourite.Favorite{{ID: favoriteID}}, nil)
+	repo.On("GetByID", userID, favoriteID).Return(&favourite.Favorite{ID: favoriteID}, nil)
+	hub := stream.NewHub(stream.Config{BufferSize: 10}, timeprovider.NewTimeProvider())
+
+	tests := []struct {
+		name   string
+		action string
+		read   func(trail *auditlogtest.Trail, source audit.Source) error
+	}{
+		{
+			name:   "all favourites",
+			action: audit.ActionFavouriteRead,
+			read: func(trail *auditlogtest.Trail, source audit.Source) error {
+				_, err := queries.NewGetAllFavoritesRequestHandler(repo, trail).Handle(queries.GetAllFavoritesRequest{UserID: userID, Source: source})
+				return err
+			},
+		},
+		{
+			name:   "one favourite",
+			action: audit.ActionFavouriteRead,
+			read: func(trail *auditlogtest.Trail, source audit.Source) error {
+				_, err := queries.NewGetFavoriteRequestHandler(repo, trail).Handle(queries.GetFavoriteRequest{UserID: userID, FavoriteID: favoriteID, Source: source})
+				return err
+			},
+		},
+		{
+			name:   "stream",
+			action: audit.ActionFavouriteWatch,
+			read: func(trail *auditlogtest.Trail, source audit.Source) error {
+				result, err := queries.NewWatchFavoritesHandler(hub, trail).Handle(queries.WatchFavoritesRequest{UserID: userID, Source: source})
+				if err == nil {
+					hub.Unsubscribe(result.Subscription)
+				}
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trail := &auditlogtest.Trail{}
+			if !assert.NoError(t, tt.read(trail, self)) {
+				return
+			}
+			assert.Empty(t, trail.Entries, "users reading their own favourites are not audited")
+
+			if !assert.NoError(t, tt.read(trail, impersonated)) {
+				return
+			}
+			if !assert.Len(t, trail.Entries, 1) {
+				return
+			}
+			assert.Equal(t, tt.action, trail.Entries[0].Action)
+			assert.Equal(t, impersonated.Actor, trail.Entries[0].Actor)
+			assert.Equal(t, "req-1", trail.Entries[0].RequestID)
+
+			// a read that cannot be accounted for is refused
+			trail.Err = errors.New("audit log unavailable")
+			assert.ErrorIs(t, tt.read(trail, impersonated), trail.Err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrDeadLetterNotFound is returned when a dead letter does not exist, or was already replayed
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrDeadLetterRedacted is returned when replaying a dead letter whose secrets were dropped
+	ErrDeadLetterRedacted = errors.New("dead letter was redacted and cannot be replayed")
+)
+
+// secretEvents are the events whose notifications carry a credential, like a password reset token
+var secretEvents = map[string]bool{
+	EventPasswordResetRequest: true,
+}
+
+// DeadLetter is a notification the dispatcher gave up delivering
+type DeadLetter struct {
+	ID           uuid.UUID    `json:"id"`
+	Notification Notification `json:"notification"`
+	// Redacted is set when the data and message of the notification were dropped, as they carried
+	// a secret. Such a dead letter is only kept for the record and cannot be replayed.
+	Redacted  bool      `json:"redacted,omitempty"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// redact drops the data and rendered message of a notification carrying a secret, so that the
+// secret is not kept where admins can list it. It reports whether anything was dropped.
+func redact(n Notification) (Notification, bool) {
+	if !secretEvents[n.Event] {
+		return n, false
+	}
+	n.Data, n.Message = nil, ""
+	return n, true
+}
+
+// DeadLetterRepository keeps the notifications that could not be delivered until they are replayed
+type DeadLetterRepository interface {
+	Add(deadLetter DeadLetter) error
+	// List returns the dead letters, oldest first
+	List() ([]DeadLetter, error)
+	Get(id uuid.UUID) (DeadLetter, error)
+	// Take removes and returns a dead letter in one step, so that it is only ever taken once.
+	// It returns ErrDeadLetterNotFound when it does not exist.
+	Take(id uuid.UUID) (DeadLetter, error)
+}
@@ -0,0 +1,175 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+)
+
+var (
+	// ErrQueueFull is recorded on notifications dead-lettered because the queue was full
+	ErrQueueFull = errors.New("notification queue is full")
+	// ErrDispatcherClosed is recorded on notifications dead-lettered because the dispatcher was shutting down
+	ErrDispatcherClosed = errors.New("notification dispatcher is closed")
+)
+
+// DispatcherConfig sizes the dispatcher and its retries
+type DispatcherConfig struct {
+	// QueueSize is how many notifications may wait for a worker
+	QueueSize int
+	// Workers is how many notifications are delivered concurrently
+	Workers int
+	// MaxAttempts is how many times a notification is tried before it is dead-lettered
+	MaxAttempts int
+	// Retry is the delay between the attempts of a notification
+	Retry backoff.Exponential
+}
+
+// Dispatcher is a Service delivering notifications asynchronously through another Service.
+// Notify only queues the notification, so callers never wait for or fail on delivery. Failed
+// deliveries are retried with exponential backoff, then kept as dead letters for an admin to replay.
+type Dispatcher struct {
+	delivery     Service
+	deadLetters  DeadLetterRepository
+	cfg          DispatcherConfig
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+
+	mu     sync.RWMutex // guards closed against sends on the closed queue
+	closed bool
+	queue  chan Notification
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher starts the workers of a dispatcher delivering through delivery
+func NewDispatcher(delivery Service, deadLetters DeadLetterRepository, cfg DispatcherConfig, up uuid.Provider, tp time.Provider) *Dispatcher {
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = 1
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+
+	d := &Dispatcher{
+		delivery:     delivery,
+		deadLetters:  deadLetters,
+		cfg:          cfg,
+		uuidProvider: up,
+		timeProvider: tp,
+		queue:        make(chan Notification, cfg.QueueSize),
+		stop:         make(chan struct{}),
+	}
+	d.wg.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+// Notify queues the notification. When the queue is full, or the dispatcher closed, the
+// notification is dead-lettered right away. It only fails when the dead letter cannot be stored.
+func (d *Dispatcher) Notify(n Notification) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return d.deadLetter(n, 0, ErrDispatcherClosed)
+	}
+	select {
+	case d.queue <- n:
+		return nil
+	default:
+		return d.deadLetter(n, 0, ErrQueueFull)
+	}
+}
+
+// Close stops accepting notifications and waits for the queued ones to be delivered. When ctx
+// ends first, pending retries are abandoned and their notifications dead-lettered.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		close(d.stop)
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for n := range d.queue {
+		d.deliver(n)
+	}
+}
+
+// deliver tries the notification until it is delivered or out of attempts
+func (d *Dispatcher) deliver(n Notification) {
+	attempt := 1
+	for {
+		err := d.delivery.Notify(n)
+		if err == nil {
+			return
+		}
+		if attempt >= d.cfg.MaxAttempts {
+			d.logDeadLetter(n, attempt, err)
+			return
+		}
+
+		select {
+		case <-gotime.After(d.cfg.Retry.Delay(attempt)):
+			attempt++
+		case <-d.stop:
+			d.logDeadLetter(n, attempt, err)
+			return
+		}
+	}
+}
+
+// logDeadLetter dead-letters a notification of a worker, which has nobody to return the error to
+func (d *Dispatcher) logDeadLetter(n Notification, attempts int, cause error) {
+	if err := d.deadLetter(n, attempts, cause); err != nil {
+		log.Printf("notification %q lost: %v", n.Subject, err)
+	}
+}
+
+func (d *Dispatcher) deadLetter(n Notification, attempts int, cause error) error {
+	log.Printf("notification %q dead-lettered after %d attempts: %v", n.Subject, attempts, cause)
+	n, redacted := redact(n)
+	err := d.deadLetters.Add(DeadLetter{
+		ID:           d.uuidProvider.NewUUID(),
+		Notification: n,
+		Redacted:     redacted,
+		Attempts:     attempts,
+		LastError:    cause.Error(),
+		FailedAt:     d.timeProvider.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	return nil
+}
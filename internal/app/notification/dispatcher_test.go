@@ -0,0 +1,221 @@
+package notification_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// deadLetterStore collects dead letters
+type deadLetterStore struct {
+	mu          sync.Mutex
+	deadLetters []notification.DeadLetter
+}
+
+func (s *deadLetterStore) Add(dl notification.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters = append(s.deadLetters, dl)
+	return nil
+}
+
+func (s *deadLetterStore) List() ([]notification.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]notification.DeadLetter{}, s.deadLetters...), nil
+}
+
+func (s *deadLetterStore) Get(id uuid.UUID) (notification.DeadLetter, error) {
+	return notification.DeadLetter{}, notification.ErrDeadLetterNotFound
+}
+
+func (s *deadLetterStore) Take(id uuid.UUID) (notification.DeadLetter, error) {
+	return notification.DeadLetter{}, notification.ErrDeadLetterNotFound
+}
+
+// flakyService fails the first failures deliveries, and blocks each delivery until release is closed when set
+type flakyService struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	delivered []notification.Notification
+	started   chan struct{}
+	release   chan struct{}
+}
+
+func (s *flakyService) Notify(n notification.Notification) error {
+	if s.started != nil {
+		s.started <- struct{}{}
+	}
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("smtp down")
+	}
+	s.delivered = append(s.delivered, n)
+	return nil
+}
+
+func newTestDispatcher(delivery notification.Service, store *deadLetterStore, cfg notification.DispatcherConfig) *notification.Dispatcher {
+	return notification.NewDispatcher(delivery, store, cfg, uuidprovider.NewUUIDProvider(), timeprovider.NewTimeProvider())
+}
+
+func TestDispatcher_RetriesUntilDelivered(t *testing.T) {
+	delivery := &flakyService{failures: 2}
+	store := &deadLetterStore{}
+	d := newTestDispatcher(delivery, store, notification.DispatcherConfig{QueueSize: 10, Workers: 2, MaxAttempts: 3, Retry: backoff.Exponential{Base: time.Millisecond}})
+
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "hello"})) {
+		return
+	}
+	if !assert.NoError(t, d.Close(context.Background())) {
+		return
+	}
+
+	assert.Equal(t, 3, delivery.attempts)
+	assert.Len(t, delivery.delivered, 1)
+	assert.Empty(t, store.deadLetters)
+}
+
+func TestDispatcher_DeadLettersAfterMaxAttempts(t *testing.T) {
+	delivery := &flakyService{failures: 100}
+	store := &deadLetterStore{}
+	d := newTestDispatcher(delivery, store, notification.DispatcherConfig{QueueSize: 10, Workers: 1, MaxAttempts: 3, Retry: backoff.Exponential{Base: time.Millisecond, Max: 2 * time.Millisecond}})
+
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "hello"})) {
+		return
+	}
+	if !assert.NoError(t, d.Close(context.Background())) {
+		return
+	}
+
+	assert.Equal(t, 3, delivery.attempts)
+	if !assert.Len(t, store.deadLetters, 1) {
+		return
+	}
+	dl := store.deadLetters[0]
+	assert.Equal(t, "hello", dl.Notification.Subject)
+	assert.Equal(t, 3, dl.Attempts)
+	assert.Equal(t, "smtp down", dl.LastError)
+	assert.NotEqual(t, uuid.Nil, dl.ID)
+}
+
+func TestDispatcher_RedactsSecretsFromDeadLetters(t *testing.T) {
+	store := &deadLetterStore{}
+	d := newTestDispatcher(&flakyService{failures: 100}, store, notification.DispatcherConfig{QueueSize: 10, Workers: 1, MaxAttempts: 1})
+
+	const resetToken = "s3cret-reset-token"
+	reset := notification.Notification{
+		ID:      "reset",
+		UserID:  uuid.New(),
+		Kind:    notification.KindSecurity,
+		Event:   notification.EventPasswordResetRequest,
+		Data:    map[string]string{"username": "alice", "token": resetToken},
+		Subject: "Password reset requested",
+		Message: "Use the token '" + resetToken + "' to choose a new password.",
+	}
+	locked := notification.Notification{
+		Event:   notification.EventAccountLocked,
+		Data:    map[string]string{"username": "alice"},
+		Subject: "Account locked",
+		Message: "The account alice was locked",
+	}
+	if !assert.NoError(t, d.Notify(reset)) {
+		return
+	}
+	if !assert.NoError(t, d.Notify(locked)) {
+		return
+	}
+	if !assert.NoError(t, d.Close(context.Background())) {
+		return
+	}
+
+	deadLetters, err := queries.NewListDeadLettersHandler(store).Handle()
+	if !assert.NoError(t, err) {
+		return
+	}
+	listing, err := json.Marshal(deadLetters)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, string(listing), resetToken)
+
+	if !assert.Len(t, store.deadLetters, 2) {
+		return
+	}
+	assert.True(t, store.deadLetters[0].Redacted)
+	assert.Equal(t, reset.Subject, store.deadLetters[0].Notification.Subject, "still tells what was lost")
+	assert.Equal(t, reset.UserID, store.deadLetters[0].Notification.UserID)
+	assert.False(t, store.deadLetters[1].Redacted)
+	assert.Equal(t, locked, store.deadLetters[1].Notification)
+}
+
+func TestDispatcher_FullQueueDeadLettersWithoutBlocking(t *testing.T) {
+	delivery := &flakyService{started: make(chan struct{}, 10), release: make(chan struct{})}
+	store := &deadLetterStore{}
+	d := newTestDispatcher(delivery, store, notification.DispatcherConfig{QueueSize: 1, Workers: 1, MaxAttempts: 1})
+
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "first"})) {
+		return
+	}
+	<-delivery.started // the worker holds the first one
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "second"})) {
+		return
+	}
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "third"})) {
+		return
+	}
+
+	if !assert.Len(t, store.deadLetters, 1) {
+		return
+	}
+	assert.Equal(t, "third", store.deadLetters[0].Notification.Subject)
+	assert.Equal(t, notification.ErrQueueFull.Error(), store.deadLetters[0].LastError)
+	assert.Zero(t, store.deadLetters[0].Attempts)
+
+	close(delivery.release)
+	if !assert.NoError(t, d.Close(context.Background())) {
+		return
+	}
+	assert.Len(t, delivery.delivered, 2)
+
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "late"})) {
+		return
+	}
+	assert.Equal(t, notification.ErrDispatcherClosed.Error(), store.deadLetters[1].LastError)
+}
+
+func TestDispatcher_CloseAbandonsRetriesOnDeadline(t *testing.T) {
+	delivery := &flakyService{failures: 100}
+	store := &deadLetterStore{}
+	d := newTestDispatcher(delivery, store, notification.DispatcherConfig{QueueSize: 10, Workers: 1, MaxAttempts: 5, Retry: backoff.Exponential{Base: time.Hour}})
+
+	if !assert.NoError(t, d.Notify(notification.Notification{Subject: "hello"})) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Close(ctx), context.DeadlineExceeded)
+
+	if !assert.Len(t, store.deadLetters, 1) {
+		return
+	}
+	assert.Equal(t, 1, store.deadLetters[0].Attempts)
+}
@@ -0,0 +1,18 @@
+package notification
+
+import "errors"
+
+// FanOut is a Service passing every notification to several services. A failing service does not
+// keep the notification from the others, its error is returned once they all had it.
+type FanOut []Service
+
+// Notify passes the notification to every service
+func (f FanOut) Notify(n Notification) error {
+	var errs []error
+	for _, s := range f {
+		if err := s.Notify(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+	googleuuid "github.com/google/uuid"
+)
+
+// ErrInboxItemNotFound is returned when a notification is not in the inbox of the user
+var ErrInboxItemNotFound = errors.New("notification not found")
+
+// InboxItem is a notification kept in the inbox of its recipient
+type InboxItem struct {
+	ID     googleuuid.UUID `json:"id"`
+	UserID googleuuid.UUID `json:"-"`
+	// NotificationID is the ID of the notification, an inbox keeps a notification once
+	NotificationID string       `json:"-"`
+	Kind           Kind         `json:"kind,omitempty"`
+	Event          string       `json:"event,omitempty"`
+	Subject        string       `json:"subject"`
+	Message        string       `json:"message"`
+	CreatedAt      gotime.Time  `json:"created_at"`
+	ReadAt         *gotime.Time `json:"read_at,omitempty"`
+}
+
+// InboxQuery selects a page of the inbox of a user, newest notifications first
+type InboxQuery struct {
+	UserID     googleuuid.UUID
+	UnreadOnly bool
+	Offset     int
+	Limit      int
+}
+
+// InboxRepository keeps the inboxes of the users
+type InboxRepository interface {
+	// Add stores an item, returning false when its notification is already in the inbox
+	Add(item InboxItem) (bool, error)
+	// List returns a page of an inbox and the number of items matching the query
+	List(q InboxQuery) ([]InboxItem, int, error)
+	// Unread returns how many items of an inbox are unread
+	Unread(userID googleuuid.UUID) (int, error)
+	// MarkRead marks an item read, ErrInboxItemNotFound when it is not in the inbox of the user
+	MarkRead(userID, id googleuuid.UUID, at gotime.Time) error
+	// MarkAllRead marks every unread item of an inbox read, returning how many there were
+	MarkAllRead(userID googleuuid.UUID, at gotime.Time) (int, error)
+}
+
+// Inbox is a Service keeping the notifications in the inbox of their recipient, for the dashboard
+// to show. Notifications without a recipient are meant for the operators and are not kept.
+type Inbox struct {
+	repo         InboxRepository
+	uuidProvider uuid.Provider
+	timeProvider time.Provider
+}
+
+// NewInbox constructor
+func NewInbox(repo InboxRepository, up uuid.Provider, tp time.Provider) *Inbox {
+	return &Inbox{repo: repo, uuidProvider: up, timeProvider: tp}
+}
+
+// Notify adds the notification to the inbox of its recipient
+func (i *Inbox) Notify(n Notification) error {
+	// reset tokens only go to the address of the user, an inbox is read by whoever holds a session
+	if n.UserID == googleuuid.Nil || n.Event == EventPasswordResetRequest {
+		return nil
+	}
+
+	_, err := i.repo.Add(InboxItem{
+		ID:             i.uuidProvider.NewUUID(),
+		UserID:         n.UserID,
+		NotificationID: n.ID,
+		Kind:           n.Kind,
+		Event:          n.Event,
+		Subject:        n.Subject,
+		Message:        n.Message,
+		CreatedAt:      i.timeProvider.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add the notification to the inbox: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package notification_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// inboxStore records the added items, the inbox only ever adds
+type inboxStore struct {
+	notification.InboxRepository
+	items []notification.InboxItem
+}
+
+func (s *inboxStore) Add(item notification.InboxItem) (bool, error) {
+	s.items = append(s.items, item)
+	return true, nil
+}
+
+// failing is a service that always fails
+type failing struct{}
+
+func (failing) Notify(notification.Notification) error { return errors.New("smtp down") }
+
+func TestInbox_Notify(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+	repo := &inboxStore{}
+	inbox := notification.NewInbox(repo, uuidprovider.NewUUIDProvider(), tp)
+	userID := uuid.New()
+
+	if !assert.NoError(t, inbox.Notify(notification.Notification{
+		ID: "event-1", UserID: userID, Kind: notification.KindFavourite, Event: notification.EventFavoriteAdded,
+		Subject: "New Favorite added", Message: "A new favorite with description 'Q1' was added",
+	})) {
+		return
+	}
+	if !assert.NoError(t, inbox.Notify(notification.Notification{Subject: "Client IP blocked"}), "operator notifications") {
+		return
+	}
+	if !assert.NoError(t, inbox.Notify(notification.Notification{UserID: userID, Event: notification.EventPasswordResetRequest, Subject: "Password reset requested"})) {
+		return
+	}
+
+	if !assert.Len(t, repo.items, 1) {
+		return
+	}
+	item := repo.items[0]
+	assert.NotEqual(t, uuid.Nil, item.ID)
+	assert.Equal(t, userID, item.UserID)
+	assert.Equal(t, "event-1", item.NotificationID)
+	assert.Equal(t, "New Favorite added", item.Subject)
+	assert.Equal(t, now, item.CreatedAt)
+	assert.Nil(t, item.ReadAt)
+}
+
+func TestFanOut_Notify(t *testing.T) {
+	first, last := &recorder{}, &recorder{}
+	err := notification.FanOut{first, failing{}, last}.Notify(notification.Notification{Subject: "hello"})
+
+	assert.ErrorContains(t, err, "smtp down")
+	assert.Len(t, first.notifications, 1)
+	assert.Len(t, last.notifications, 1, "a failing service does not stop the others")
+}
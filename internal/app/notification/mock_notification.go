@@ -9,7 +9,7 @@ type MockNotificationService struct {
 }
 
 // Notify sends mock Notifications
-func (m MockNotificationService) Notify(notification Notification) error {
+func (m *MockNotificationService) Notify(notification Notification) error {
 	args := m.Called(notification)
 	return args.Error(0)
 }
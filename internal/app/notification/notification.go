@@ -1,9 +1,30 @@
 package notification
 
+import "github.com/google/uuid"
+
+// Kind classifies a Notification
+type Kind string
+
+const (
+	// KindFavourite notifications are about changes to a user's favourites
+	KindFavourite Kind = "favourite"
+	// KindSecurity notifications are about the security of a user's account
+	KindSecurity Kind = "security"
+)
+
 // Notification provides a struct to send messages via the Service
 type Notification struct {
-	Subject string `json:"subject"`
-	Message string `json:"message"`
+	// ID identifies the notification across redeliveries, so receivers can drop duplicates
+	ID string `json:"id,omitempty"`
+	// UserID is the recipient, channels find the user's address by it. It is empty for the
+	// notifications meant for the operators, like lockouts of unknown usernames.
+	UserID uuid.UUID `json:"user_id,omitzero"`
+	Kind   Kind      `json:"kind,omitempty"`
+	// Event selects the template rendering Subject and Message from Data, and is what users mute
+	Event   string            `json:"event,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+	Subject string            `json:"subject"`
+	Message string            `json:"message"`
 }
 
 // Service sends Notification
@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// PreferenceFilter is a Service applying the preferences of the recipient before the next Service.
+// It drops the notifications the recipient opted out of on the channel, and renders the others
+// from their template in the recipient's locale. Security notifications are never dropped. Quiet
+// hours and digests only hold back the push channels, not the in-app inbox.
+type PreferenceFilter struct {
+	next         Service
+	channel      string
+	preferences  PreferenceRepository
+	templates    *Templates
+	timeProvider time.Provider
+}
+
+// Filter is a Service that may skip the notifications it is given
+type Filter interface {
+	Service
+	// Deliver is Notify, also returning why the notification was skipped, empty when it was passed on
+	Deliver(n Notification) (skipped string, err error)
+}
+
+// NewPreferenceFilter constructor, channel is the channel next delivers through
+func NewPreferenceFilter(next Service, channel string, preferences PreferenceRepository, templates *Templates, tp time.Provider) *PreferenceFilter {
+	return &PreferenceFilter{next: next, channel: channel, preferences: preferences, templates: templates, timeProvider: tp}
+}
+
+// Notify renders and passes on the notification, unless the recipient opted out of it
+func (f *PreferenceFilter) Notify(n Notification) error {
+	_, err := f.Deliver(n)
+	return err
+}
+
+// Deliver renders and passes on the notification, or returns why the recipient opted out of it
+func (f *PreferenceFilter) Deliver(n Notification) (string, error) {
+	prefs := DefaultPreferences(n.UserID)
+	if n.UserID != uuid.Nil {
+		saved, err := f.preferences.Get(n.UserID)
+		switch {
+		case err == nil:
+			prefs = saved
+		case !errors.Is(err, ErrPreferencesNotFound):
+			return "", fmt.Errorf("failed to read notification preferences: %w", err)
+		}
+
+		if reason := f.optedOut(n, prefs); reason != "" {
+			log.Printf("notification %s (%s) for user %s skipped: %s", n.ID, n.Event, n.UserID, reason)
+			return reason, nil
+		}
+	}
+
+	if n.Subject == "" && n.Event != "" {
+		subject, message, err := f.templates.Render(n.Event, prefs.Locale, n.Data)
+		if err != nil {
+			return "", err
+		}
+		n.Subject, n.Message = subject, message
+	}
+	return "", f.next.Notify(n)
+}
+
+// optedOut returns why the recipient does not want the notification, empty when they do
+func (f *PreferenceFilter) optedOut(n Notification, prefs Preferences) string {
+	if n.Kind == KindSecurity {
+		return ""
+	}
+	if !contains(prefs.Channels, f.channel) {
+		return "channel " + f.channel + " disabled"
+	}
+	if contains(prefs.MutedEvents, n.Event) {
+		return "event muted"
+	}
+	// quiet hours and digests are about not being interrupted, the inbox is only read when the user
+	// looks at it, and what it drops is lost for good
+	if f.channel == ChannelInApp {
+		return ""
+	}
+	if n.Event == EventFavoriteAdded && prefs.Digest != "" && prefs.Digest != DigestOff {
+		return "sent in the " + prefs.Digest + " digest"
+	}
+	if prefs.QuietHours != nil {
+		quiet, err := prefs.QuietHours.Contains(f.timeProvider.Now())
+		if err != nil {
+			log.Printf("notification preferences of user %s: %v", n.UserID, err)
+		}
+		if quiet {
+			return "quiet hours"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package notification_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// preferenceStore keeps the preferences by user
+type preferenceStore map[uuid.UUID]notification.Preferences
+
+func (s preferenceStore) Get(userID uuid.UUID) (notification.Preferences, error) {
+	p, ok := s[userID]
+	if !ok {
+		return notification.Preferences{}, notification.ErrPreferencesNotFound
+	}
+	return p, nil
+}
+
+func (s preferenceStore) Save(p notification.Preferences) error {
+	s[p.UserID] = p
+	return nil
+}
+
+// recorder is the next service, collecting what passes the filter
+type recorder struct {
+	notifications []notification.Notification
+}
+
+func (r *recorder) Notify(n notification.Notification) error {
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates := notification.DefaultTemplates()
+	data := map[string]string{"description": "Q1 sales"}
+
+	subject, message, err := templates.Render(notification.EventFavoriteAdded, "en", data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "New Favorite added", subject)
+	assert.Equal(t, "A new favorite with description 'Q1 sales' was added", message)
+
+	subject, _, err = templates.Render(notification.EventFavoriteAdded, "el-GR", data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Νέο αγαπημένο", subject, "falls back to the language")
+
+	subject, _, err = templates.Render(notification.EventAccountLocked, "el", map[string]string{"username": "alice", "until": "soon"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Account locked", subject, "falls back to the default locale")
+
+	_, _, err = templates.Render("favourite.exploded", "en", data)
+	assert.ErrorIs(t, err, notification.ErrTemplateNotFound)
+	_, _, err = templates.Render(notification.EventFavoriteAdded, "en", map[string]string{})
+	assert.Error(t, err, "missing data")
+
+	assert.Error(t, templates.Register("broken", "en", notification.Template{Subject: "{{.oops"}))
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	overnight := notification.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Athens"}
+	for utc, quiet := range map[string]bool{
+		"2026-01-02T19:59:00Z": false, // 21:59 in Athens
+		"2026-01-02T20:00:00Z": true,
+		"2026-01-03T04:59:00Z": true,
+		"2026-01-03T05:00:00Z": false,
+	} {
+		at, _ := time.Parse(time.RFC3339, utc)
+		got, err := overnight.Contains(at)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, quiet, got, utc)
+	}
+
+	lunch := notification.QuietHours{Start: "12:00", End: "13:00"}
+	got, err := lunch.Contains(time.Date(2026, 1, 2, 12, 30, 0, 0, time.UTC))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, got, "UTC without a time zone")
+}
+
+func TestPreferences_Validate(t *testing.T) {
+	assert.NoError(t, notification.DefaultPreferences(uuid.New()).Validate())
+
+	for name, p := range map[string]notification.Preferences{
+		"locale":             {Locale: "english"},
+		"digest cadence":     {Locale: "en", Digest: "hourly"},
+		"channel":            {Locale: "en", Channels: []string{"pigeon"}},
+		"security event":     {Locale: "en", MutedEvents: []string{notification.EventPasswordResetRequest}},
+		"quiet hours format": {Locale: "en", QuietHours: &notification.QuietHours{Start: "10pm", End: "07:00"}},
+		"quiet hours empty":  {Locale: "en", QuietHours: &notification.QuietHours{Start: "07:00", End: "07:00"}},
+		"quiet hours zone":   {Locale: "en", QuietHours: &notification.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}},
+	} {
+		assert.ErrorIs(t, p.Validate(), notification.ErrInvalidPreferences, name)
+	}
+}
+
+func TestPreferenceFilter_Notify(t *testing.T) {
+	now := time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+
+	greek, muted, noEmail, sleeping, digested, unknown := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	prefs := preferenceStore{
+		digested: {UserID: digested, Locale: "en", Channels: []string{"email"}, Digest: notification.DigestDaily},
+		greek:    {UserID: greek, Locale: "el", Channels: []string{"email"}},
+		muted:    {UserID: muted, Locale: "en", Channels: []string{"email"}, MutedEvents: []string{notification.EventFavoriteAdded}},
+		noEmail:  {UserID: noEmail, Locale: "en", Channels: []string{}},
+		sleeping: {UserID: sleeping, Locale: "en", Channels: []string{"email"}, QuietHours: &notification.QuietHours{Start: "22:00", End: "07:00"}},
+	}
+	next := &recorder{}
+	filter := notification.NewPreferenceFilter(next, notification.ChannelEmail, prefs, notification.DefaultTemplates(), tp)
+
+	added := func(userID uuid.UUID) notification.Notification {
+		return notification.Notification{
+			UserID: userID,
+			Kind:   notification.KindFavourite,
+			Event:  notification.EventFavoriteAdded,
+			Data:   map[string]string{"description": "chart"},
+		}
+	}
+	for _, userID := range []uuid.UUID{greek, muted, noEmail, sleeping, digested, unknown} {
+		if !assert.NoError(t, filter.Notify(added(userID))) {
+			return
+		}
+	}
+
+	if !assert.Len(t, next.notifications, 2, "muted, channel disabled, quiet hours and digests are skipped") {
+		return
+	}
+	assert.Equal(t, greek, next.notifications[0].UserID)
+	assert.Equal(t, "Νέο αγαπημένο", next.notifications[0].Subject)
+	assert.Equal(t, unknown, next.notifications[1].UserID, "the defaults apply")
+	assert.Equal(t, "New Favorite added", next.notifications[1].Subject)
+
+	skipped, err := filter.Deliver(added(sleeping))
+	assert.NoError(t, err)
+	assert.Equal(t, "quiet hours", skipped)
+	skipped, err = filter.Deliver(added(greek))
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+	next.notifications = nil
+
+	// security notifications always go through
+	for _, userID := range []uuid.UUID{muted, noEmail, sleeping} {
+		if !assert.NoError(t, filter.Notify(notification.Notification{
+			UserID: userID,
+			Kind:   notification.KindSecurity,
+			Event:  notification.EventPasswordResetRequest,
+			Data:   map[string]string{"username": "alice", "token": "t0k3n", "ttl": "15m0s"},
+		})) {
+			return
+		}
+	}
+	if !assert.Len(t, next.notifications, 3) {
+		return
+	}
+	assert.Contains(t, next.notifications[0].Message, "t0k3n")
+
+	// the inbox only applies the channel and muted events, quiet hours and digests are for push channels
+	inAppPrefs := preferenceStore{
+		digested: {UserID: digested, Locale: "en", Channels: []string{"in_app"}, Digest: notification.DigestDaily},
+		muted:    {UserID: muted, Locale: "en", Channels: []string{"in_app"}, MutedEvents: []string{notification.EventFavoriteAdded}},
+		noEmail:  {UserID: noEmail, Locale: "en", Channels: []string{"email"}},
+		sleeping: {UserID: sleeping, Locale: "en", Channels: []string{"in_app"}, QuietHours: &notification.QuietHours{Start: "22:00", End: "07:00"}},
+	}
+	inbox := &recorder{}
+	inAppFilter := notification.NewPreferenceFilter(inbox, notification.ChannelInApp, inAppPrefs, notification.DefaultTemplates(), tp)
+	for _, userID := range []uuid.UUID{digested, muted, noEmail, sleeping} {
+		if !assert.NoError(t, inAppFilter.Notify(added(userID))) {
+			return
+		}
+	}
+	if !assert.Len(t, inbox.notifications, 2) {
+		return
+	}
+	assert.Equal(t, digested, inbox.notifications[0].UserID)
+	assert.Equal(t, sleeping, inbox.notifications[1].UserID)
+
+	// rendered notifications, like replayed dead letters, are not rendered again
+	next.notifications = nil
+	if !assert.NoError(t, filter.Notify(notification.Notification{UserID: unknown, Event: notification.EventFavoriteAdded, Subject: "as sent", Message: "before"})) {
+		return
+	}
+	assert.Equal(t, "as sent", next.notifications[0].Subject)
+}
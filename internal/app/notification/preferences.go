@@ -0,0 +1,159 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	gotime "time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrPreferencesNotFound is returned when a user never saved preferences, the defaults apply
+	ErrPreferencesNotFound = errors.New("notification preferences not found")
+	// ErrInvalidPreferences is returned for preferences naming unknown channels, events or times
+	ErrInvalidPreferences = errors.New("invalid notification preferences")
+)
+
+// Channels of delivery. The console or email channel of the deployment is chosen by
+// NOTIFICATION_CHANNEL, the in-app inbox is always there.
+const (
+	ChannelConsole = "console"
+	ChannelEmail   = "email"
+	ChannelInApp   = "in_app"
+)
+
+// Channels are the channels users can enable
+var Channels = []string{ChannelConsole, ChannelEmail, ChannelInApp}
+
+// MutableEvents are the events users may mute. Security notifications, like password resets,
+// always reach the user whatever their preferences.
+var MutableEvents = []string{EventFavoriteAdded}
+
+// Digest cadences. With a digest, the favourite events are summed up in one notification per period
+// instead of one notification each.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestCadences are the cadences users can choose
+var DigestCadences = []string{DigestOff, DigestDaily, DigestWeekly}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// QuietHours is a daily window, in the user's time zone, during which notifications are not sent.
+// Start and End are "HH:MM", a window ending before it starts spans midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"time_zone"`
+}
+
+// Contains reports whether t falls within the quiet hours
+func (q QuietHours) Contains(t gotime.Time) (bool, error) {
+	start, end, loc, err := q.parse()
+	if err != nil {
+		return false, err
+	}
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end, nil
+	}
+	return minute >= start || minute < end, nil
+}
+
+func (q QuietHours) parse() (int, int, *gotime.Location, error) {
+	start, err := gotime.Parse("15:04", q.Start)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("%w: quiet hours start must be HH:MM", ErrInvalidPreferences)
+	}
+	end, err := gotime.Parse("15:04", q.End)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("%w: quiet hours end must be HH:MM", ErrInvalidPreferences)
+	}
+	if start.Equal(end) {
+		return 0, 0, nil, fmt.Errorf("%w: quiet hours must not start and end at the same time", ErrInvalidPreferences)
+	}
+	tz := q.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := gotime.LoadLocation(tz)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidPreferences, q.TimeZone)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), loc, nil
+}
+
+// Preferences are the choices of a user about the notifications they receive
+type Preferences struct {
+	UserID uuid.UUID `json:"-"`
+	// Locale selects the language of the templates, like "en" or "el-GR"
+	Locale string `json:"locale"`
+	// Channels are the enabled channels, none to receive security notifications only
+	Channels []string `json:"channels"`
+	// MutedEvents are the events the user does not want to be notified about
+	MutedEvents []string `json:"muted_events"`
+	// Digest is the cadence of the favourite digests, DigestOff for a notification per favourite
+	Digest     string      `json:"digest"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	UpdatedAt  gotime.Time `json:"updated_at,omitzero"`
+}
+
+// DefaultPreferences are the preferences of a user who never saved any: every channel, nothing muted
+func DefaultPreferences(userID uuid.UUID) Preferences {
+	return Preferences{
+		UserID:      userID,
+		Locale:      DefaultLocale,
+		Channels:    append([]string{}, Channels...),
+		MutedEvents: []string{},
+		Digest:      DigestOff,
+	}
+}
+
+// Validate checks that the preferences only name known channels, mutable events and cadences
+func (p Preferences) Validate() error {
+	if !localePattern.MatchString(p.Locale) {
+		return fmt.Errorf("%w: locale must look like \"en\" or \"el-GR\"", ErrInvalidPreferences)
+	}
+	for _, c := range p.Channels {
+		if !contains(Channels, c) {
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidPreferences, c)
+		}
+	}
+	for _, e := range p.MutedEvents {
+		if !contains(MutableEvents, e) {
+			return fmt.Errorf("%w: event %q cannot be muted", ErrInvalidPreferences, e)
+		}
+	}
+	if !contains(DigestCadences, p.Digest) {
+		return fmt.Errorf("%w: unknown digest cadence %q", ErrInvalidPreferences, p.Digest)
+	}
+	if p.QuietHours != nil {
+		if _, _, _, err := p.QuietHours.parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PreferenceRepository keeps the notification preferences of the users
+type PreferenceRepository interface {
+	// Get returns the preferences of a user, ErrPreferencesNotFound when they never saved any
+	Get(userID uuid.UUID) (Preferences, error)
+	// Save replaces the preferences of a user
+	Save(p Preferences) error
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
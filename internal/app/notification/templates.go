@@ -0,0 +1,159 @@
+package notification
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+)
+
+// ErrTemplateNotFound is returned when no template renders an event, in any locale
+var ErrTemplateNotFound = errors.New("notification template not found")
+
+// DefaultLocale is the locale of the users who did not choose one, and the fallback of every template
+const DefaultLocale = "en"
+
+// Events of the notifications, they select the template and are what users mute
+const (
+	EventFavoriteAdded        = event.FavoriteAddedName
+	EventFavoriteDigest       = "favourite.digest"
+	EventPasswordResetRequest = "password.reset_requested"
+	EventAccountLocked        = "lockout.account_locked"
+	EventIPBlocked            = "lockout.ip_blocked"
+)
+
+// Template is the subject and message of a notification, as text/template sources over the notification data
+type Template struct {
+	Subject string
+	Message string
+}
+
+type parsedTemplate struct {
+	subject *template.Template
+	message *template.Template
+}
+
+// Templates renders the notifications of an event in a locale. A missing locale falls back to its
+// language ("el" for "el-GR"), then to DefaultLocale.
+type Templates struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]parsedTemplate // event, locale
+}
+
+// NewTemplates returns an empty registry
+func NewTemplates() *Templates {
+	return &Templates{templates: make(map[string]map[string]parsedTemplate)}
+}
+
+// DefaultTemplates returns a registry with the templates of every event of the application
+func DefaultTemplates() *Templates {
+	t := NewTemplates()
+	for eventName, locales := range defaultTemplates {
+		for locale, tmpl := range locales {
+			if err := t.Register(eventName, locale, tmpl); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return t
+}
+
+// Register adds or replaces the template of an event in a locale
+func (t *Templates) Register(eventName, locale string, tmpl Template) error {
+	subject, err := template.New(eventName + ".subject").Option("missingkey=error").Parse(tmpl.Subject)
+	if err != nil {
+		return fmt.Errorf("invalid subject template of %s (%s): %w", eventName, locale, err)
+	}
+	message, err := template.New(eventName + ".message").Option("missingkey=error").Parse(tmpl.Message)
+	if err != nil {
+		return fmt.Errorf("invalid message template of %s (%s): %w", eventName, locale, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.templates[eventName] == nil {
+		t.templates[eventName] = make(map[string]parsedTemplate)
+	}
+	t.templates[eventName][locale] = parsedTemplate{subject: subject, message: message}
+	return nil
+}
+
+// Render returns the subject and message of an event in the locale closest to the given one
+func (t *Templates) Render(eventName, locale string, data map[string]string) (string, string, error) {
+	tmpl, ok := t.lookup(eventName, locale)
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrTemplateNotFound, eventName)
+	}
+
+	var subject, message bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render the subject of %s: %w", eventName, err)
+	}
+	if err := tmpl.message.Execute(&message, data); err != nil {
+		return "", "", fmt.Errorf("failed to render the message of %s: %w", eventName, err)
+	}
+	return subject.String(), message.String(), nil
+}
+
+func (t *Templates) lookup(eventName, locale string) (parsedTemplate, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	locales := t.templates[eventName]
+	language, _, _ := strings.Cut(locale, "-")
+	for _, candidate := range []string{locale, language, DefaultLocale} {
+		if tmpl, ok := locales[candidate]; ok {
+			return tmpl, true
+		}
+	}
+	return parsedTemplate{}, false
+}
+
+// defaultTemplates are the built-in templates. The lockout notifications go to the operators, so only in English.
+var defaultTemplates = map[string]map[string]Template{
+	EventFavoriteAdded: {
+		"en": {
+			Subject: "New Favorite added",
+			Message: "A new favorite with description '{{.description}}' was added",
+		},
+		"el": {
+			Subject: "Νέο αγαπημένο",
+			Message: "Προστέθηκε νέο αγαπημένο με περιγραφή '{{.description}}'",
+		},
+	},
+	EventFavoriteDigest: {
+		"en": {
+			Subject: "Your {{.cadence}} favourites digest",
+			Message: "Your favourites from {{.from}} to {{.to}}\n\nAdded:\n{{or .added \"none\"}}\n\nRemoved:\n{{or .removed \"none\"}}",
+		},
+		"el": {
+			Subject: "Η σύνοψη των αγαπημένων σας",
+			Message: "Τα αγαπημένα σας από {{.from}} έως {{.to}}\n\nΠροστέθηκαν:\n{{or .added \"κανένα\"}}\n\nΑφαιρέθηκαν:\n{{or .removed \"κανένα\"}}",
+		},
+	},
+	EventPasswordResetRequest: {
+		"en": {
+			Subject: "Password reset requested",
+			Message: "A password reset was requested for user {{.username}}. Use the token '{{.token}}' within {{.ttl}} to choose a new password. If this wasn't you, ignore this message.",
+		},
+		"el": {
+			Subject: "Αίτημα επαναφοράς κωδικού",
+			Message: "Ζητήθηκε επαναφορά κωδικού για τον χρήστη {{.username}}. Χρησιμοποιήστε το κλειδί '{{.token}}' μέσα σε {{.ttl}} για να ορίσετε νέο κωδικό. Αν δεν ήσασταν εσείς, αγνοήστε αυτό το μήνυμα.",
+		},
+	},
+	EventAccountLocked: {
+		"en": {
+			Subject: "Account locked",
+			Message: "The account {{.username}} was locked after repeated failed login attempts until {{.until}}",
+		},
+	},
+	EventIPBlocked: {
+		"en": {
+			Subject: "Client IP blocked",
+			Message: "Logins from {{.ip}} were blocked after repeated failed login attempts until {{.until}}",
+		},
+	},
+}
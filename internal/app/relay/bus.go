@@ -0,0 +1,38 @@
+package relay
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+)
+
+// busSubscriber hands the outbox events to one subscription of the bus, so each
+// subscription is retried on its own and never sees an event again once it handled it
+type busSubscriber struct {
+	bus          *eventbus.Bus
+	subscription string
+}
+
+// BusSubscribers returns a Subscriber per subscription of the bus. Subscriptions must be
+// made before, and never renamed since their names record who handled an event.
+func BusSubscribers(bus *eventbus.Bus) []Subscriber {
+	var subscribers []Subscriber
+	for _, name := range bus.Subscriptions() {
+		subscribers = append(subscribers, busSubscriber{bus: bus, subscription: name})
+	}
+	return subscribers
+}
+
+// Name of the subscriber
+func (s busSubscriber) Name() string {
+	return s.subscription
+}
+
+// Handle decodes the typed event and delivers it, the outbox event ID becoming the envelope ID
+func (s busSubscriber) Handle(e outbox.Event) error {
+	decoded, err := event.Decode(e.Type, e.Payload)
+	if err != nil {
+		return err
+	}
+	return s.bus.DeliverTo(s.subscription, eventbus.Envelope{ID: e.ID, OccurredAt: e.OccurredAt, Event: decoded})
+}
@@ -0,0 +1,126 @@
+// Package relay publishes the events of the outbox to their subscribers.
+package relay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// Subscriber handles the published events. Events may be handled more than once, after a crash
+// or a failing subscriber, so subscribers drop duplicates by the event ID.
+type Subscriber interface {
+	// Name identifies the subscriber in the delivery records of the events, it must not change
+	Name() string
+	Handle(event outbox.Event) error
+}
+
+// Config paces the relay and its retries
+type Config struct {
+	// Interval is how often the outbox is polled for due events
+	Interval gotime.Duration
+	// BatchSize is how many events are published per poll
+	BatchSize int
+	// Retry is the delay between the attempts of an event
+	Retry backoff.Exponential
+	// Retention is how long published events are kept
+	Retention gotime.Duration
+}
+
+// Relay publishes outbox events to every subscriber, at least once. An event is retried with
+// exponential backoff until every subscriber handled it, subscribers that already did are skipped.
+type Relay struct {
+	repo         outbox.Repository
+	subscribers  []Subscriber
+	cfg          Config
+	timeProvider time.Provider
+}
+
+// NewRelay constructor
+func NewRelay(repo outbox.Repository, cfg Config, tp time.Provider, subscribers ...Subscriber) *Relay {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+	return &Relay{repo: repo, subscribers: subscribers, cfg: cfg, timeProvider: tp}
+}
+
+// Run publishes the due events on every tick until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := gotime.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Publish(); err != nil {
+				log.Printf("outbox relay: %v", err)
+			}
+		}
+	}
+}
+
+// Publish publishes one batch of due events, returning how many were fully published.
+// Failing subscribers do not fail the batch, their events are scheduled for a retry.
+func (r *Relay) Publish() (int, error) {
+	events, err := r.repo.Due(r.timeProvider.Now().UTC(), r.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the outbox: %w", err)
+	}
+
+	published := 0
+	for _, event := range events {
+		ok, err := r.publish(event)
+		if err != nil {
+			return published, err
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// PurgePublished removes the events published longer than the retention ago
+func (r *Relay) PurgePublished() (int, error) {
+	return r.repo.DeletePublished(r.timeProvider.Now().UTC().Add(-r.cfg.Retention))
+}
+
+// publish hands the event to the subscribers that did not handle it yet
+func (r *Relay) publish(event outbox.Event) (bool, error) {
+	var failures []string
+	for _, s := range r.subscribers {
+		if event.Delivered(s.Name()) {
+			continue
+		}
+		if err := s.Handle(event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.Name(), err))
+			continue
+		}
+		if err := r.repo.MarkDelivered(event.ID, s.Name()); err != nil {
+			return false, fmt.Errorf("failed to record delivery of event %s: %w", event.ID, err)
+		}
+	}
+
+	now := r.timeProvider.Now().UTC()
+	if len(failures) > 0 {
+		cause := strings.Join(failures, "; ")
+		log.Printf("outbox event %s (%s) failed attempt %d: %s", event.ID, event.Type, event.Attempts+1, cause)
+		if err := r.repo.MarkFailed(event.ID, cause, now.Add(r.cfg.Retry.Delay(event.Attempts+1))); err != nil {
+			return false, fmt.Errorf("failed to record failure of event %s: %w", event.ID, err)
+		}
+		return false, nil
+	}
+
+	if err := r.repo.MarkPublished(event.ID, now); err != nil {
+		return false, fmt.Errorf("failed to mark event %s published: %w", event.ID, err)
+	}
+	return true, nil
+}
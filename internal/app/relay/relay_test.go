@@ -0,0 +1,223 @@
+package relay_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/relay"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventStore is an outbox keeping its events in insertion order
+type eventStore struct {
+	events []outbox.Event
+}
+
+func (s *eventStore) Due(now time.Time, limit int) ([]outbox.Event, error) {
+	var due []outbox.Event
+	for _, e := range s.events {
+		if e.PublishedAt == nil && !e.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, e)
+		}
+	}
+	return due, nil
+}
+
+func (s *eventStore) MarkDelivered(id uuid.UUID, subscriber string) error {
+	return s.update(id, func(e *outbox.Event) { e.DeliveredTo = append(e.DeliveredTo, subscriber) })
+}
+
+func (s *eventStore) MarkPublished(id uuid.UUID, at time.Time) error {
+	return s.update(id, func(e *outbox.Event) { e.PublishedAt = &at })
+}
+
+func (s *eventStore) MarkFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	return s.update(id, func(e *outbox.Event) {
+		e.Attempts++
+		e.LastError = lastError
+		e.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (s *eventStore) DeletePublished(before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *eventStore) update(id uuid.UUID, apply func(e *outbox.Event)) error {
+	for i := range s.events {
+		if s.events[i].ID == id {
+			apply(&s.events[i])
+			return nil
+		}
+	}
+	return outbox.ErrEventNotFound
+}
+
+// subscriber records the handled events, failing while err is set
+type subscriber struct {
+	name    string
+	err     error
+	handled []uuid.UUID
+}
+
+func (s *subscriber) Name() string { return s.name }
+
+func (s *subscriber) Handle(e outbox.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.handled = append(s.handled, e.ID)
+	return nil
+}
+
+func newEvent(t *testing.T, eventType string, at time.Time) outbox.Event {
+	fav := favourite.Favorite{ID: uuid.New(), Description: "My chart"}
+	userID := uuid.New()
+	e, err := outbox.NewEvent(uuid.New(), eventType, fav.ID, userID, event.FavoriteAdded{UserID: userID, Favorite: fav}, at)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return e
+}
+
+func TestRelay_Publish(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+
+	e := newEvent(t, event.FavoriteAddedName, now)
+	store := &eventStore{events: []outbox.Event{e}}
+	ok := &subscriber{name: "ok"}
+	failing := &subscriber{name: "failing", err: errors.New("down")}
+	r := relay.NewRelay(store, relay.Config{BatchSize: 10, Retry: backoff.Exponential{Base: time.Second, Max: time.Minute}}, tp, ok, failing)
+
+	published, err := r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, published)
+	assert.Equal(t, []uuid.UUID{e.ID}, ok.handled)
+	assert.Equal(t, 1, store.events[0].Attempts)
+	assert.Equal(t, "failing: down", store.events[0].LastError)
+	assert.Equal(t, now.Add(time.Second), store.events[0].NextAttemptAt)
+
+	// not due before the backoff elapsed
+	published, err = r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, published)
+
+	// the retry only goes to the subscriber that failed
+	failing.err = nil
+	store.events[0].NextAttemptAt = now
+	published, err = r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []uuid.UUID{e.ID}, ok.handled)
+	assert.Equal(t, []uuid.UUID{e.ID}, failing.handled)
+	assert.NotNil(t, store.events[0].PublishedAt)
+
+	published, err = r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, published)
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+
+	e := newEvent(t, event.FavoriteAddedName, now)
+	e.Attempts = 10
+	store := &eventStore{events: []outbox.Event{e}}
+	r := relay.NewRelay(store, relay.Config{BatchSize: 10, Retry: backoff.Exponential{Base: time.Second, Max: time.Minute}}, tp,
+		&subscriber{name: "failing", err: errors.New("down")})
+
+	_, err := r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, now.Add(time.Minute), store.events[0].NextAttemptAt, "capped at MaxDelay")
+}
+
+func TestBusSubscribers(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+
+	bus := eventbus.NewBus(&uuidprovider.MockProvider{}, tp)
+	var notified []eventbus.Envelope
+	bus.Subscribe("notifications", eventbus.HandlerFunc(func(envelope eventbus.Envelope) error {
+		notified = append(notified, envelope)
+		return nil
+	}), event.FavoriteAddedName)
+	metricsErr := errors.New("down")
+	metrics := 0
+	bus.Subscribe("metrics", eventbus.HandlerFunc(func(envelope eventbus.Envelope) error {
+		if metricsErr != nil {
+			return metricsErr
+		}
+		metrics++
+		return nil
+	}))
+
+	added := newEvent(t, event.FavoriteAddedName, now)
+	deleted := newEvent(t, event.FavoriteDeletedName, now)
+	store := &eventStore{events: []outbox.Event{added, deleted}}
+	r := relay.NewRelay(store, relay.Config{BatchSize: 10, Retry: backoff.Exponential{Base: time.Second, Max: time.Minute}}, tp, relay.BusSubscribers(bus)...)
+
+	published, err := r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, published)
+	if !assert.Len(t, notified, 1, "only the subscribed events are delivered") {
+		return
+	}
+	assert.Equal(t, added.ID, notified[0].ID)
+	assert.Equal(t, added.OccurredAt, notified[0].OccurredAt)
+	assert.IsType(t, event.FavoriteAdded{}, notified[0].Event)
+	assert.Equal(t, "metrics: down", store.events[0].LastError)
+
+	// the retry only goes to the failed subscription
+	metricsErr = nil
+	for i := range store.events {
+		store.events[i].NextAttemptAt = now
+	}
+	published, err = r.Publish()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, published)
+	assert.Len(t, notified, 1)
+	assert.Equal(t, 2, metrics)
+}
+
+func TestBusSubscribers_UnknownEvent(t *testing.T) {
+	bus := eventbus.NewBus(&uuidprovider.MockProvider{}, &timeprovider.MockProvider{})
+	bus.Subscribe("all", eventbus.HandlerFunc(func(eventbus.Envelope) error { return nil }))
+
+	subscribers := relay.BusSubscribers(bus)
+	if !assert.Len(t, subscribers, 1) {
+		return
+	}
+	assert.Equal(t, "all", subscribers[0].Name())
+	err := subscribers[0].Handle(outbox.Event{ID: uuid.New(), Type: "favourite.archived", Payload: json.RawMessage(`{}`)})
+	assert.ErrorIs(t, err, event.ErrUnknownEvent)
+}
@@ -2,15 +2,39 @@
 package app
 
 import (
+	gotime "time"
+
+	admincommands "github.com/akazantzidis/gwi-ass/internal/app/admin/commands"
+	adminqueries "github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
 	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/policy"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/query"
+	"github.com/akazantzidis/gwi-ass/internal/app/digest"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/relay"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	commands2 "github.com/akazantzidis/gwi-ass/internal/app/user/commands"
 	queries2 "github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/akazantzidis/gwi-ass/internal/domain/stats"
 	"github.com/akazantzidis/gwi-ass/internal/domain/token"
 	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
 
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
 	"github.com/akazantzidis/gwi-ass/internal/app/notification"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
 )
@@ -19,8 +43,30 @@ import (
 type Queries struct {
 	GetAllFavoritesHandler queries.GetAllFavoritesRequestHandler
 	GetFavoriteHandler     queries.GetFavoriteRequestHandler
+	WatchFavoritesHandler  queries.WatchFavoritesHandler
+
+	GetUserHandler   queries2.GetUserHandler
+	ListUsersHandler queries2.ListUsersHandler
+
+	GetNotificationPreferencesHandler queries2.GetNotificationPreferencesHandler
+	ListNotificationsHandler          queries2.ListNotificationsHandler
+
+	ListSessionsHandler query.ListSessionsHandler
+	ListLockoutsHandler query.ListLockoutsHandler
+	ListAPIKeysHandler  query.ListAPIKeysHandler
+
+	ListClientsHandler query.ListClientsHandler
+	IntrospectHandler  query.IntrospectHandler
 
-	GetUserHandler queries2.GetUserHandler
+	GetStatsHandler    adminqueries.GetStatsHandler
+	ListAuditHandler   adminqueries.ListAuditHandler
+	ExportAuditHandler adminqueries.ExportAuditHandler
+
+	ListDeadLettersHandler adminqueries.ListDeadLettersHandler
+
+	ListWebhooksHandler          adminqueries.ListWebhooksHandler
+	GetWebhookHandler            adminqueries.GetWebhookHandler
+	ListWebhookDeliveriesHandler adminqueries.ListWebhookDeliveriesHandler
 }
 
 // Commands Contains all available command handlers of this app
@@ -29,17 +75,70 @@ type Commands struct {
 	UpdateFavoriteHandler        commands.UpdateFavoriteRequestHandler
 	UpdatePartialFavoriteHandler commands.UpdatePartialFavoriteRequestHandler
 
-	DeleteFavoriteHandler commands.DeleteFavoriteRequestHandler
+	DeleteFavoriteHandler   commands.DeleteFavoriteRequestHandler
+	ReorderFavoritesHandler commands.ReorderFavoritesRequestHandler
 
 	LoginUserHandler        command.LoginHandler
+	MFALoginUserHandler     command.MFALoginHandler
 	RefreshTokenUserHandler command.RefreshHandler
 	LogoutUserHandler       command.LogoutHandler
+	LogoutAllUserHandler    command.LogoutAllHandler
+	RevokeSessionHandler    command.RevokeSessionHandler
+
+	RevokeUserSessionsHandler command.RevokeUserSessionsHandler
+	ImpersonateHandler        command.ImpersonateHandler
+
+	PurgeExpiredSessionsHandler command.PurgeExpiredSessionsHandler
+
+	// StartOIDCLoginHandler and OIDCCallbackHandler are nil when no OpenID Connect provider is configured
+	StartOIDCLoginHandler         command.StartOIDCLoginHandler
+	OIDCCallbackHandler           command.OIDCCallbackHandler
+	PurgeExpiredOIDCStatesHandler command.PurgeExpiredOIDCStatesHandler
+
+	CreateAPIKeyHandler       command.CreateAPIKeyHandler
+	RevokeAPIKeyHandler       command.RevokeAPIKeyHandler
+	AuthenticateAPIKeyHandler command.AuthenticateAPIKeyHandler
+
+	ClientCredentialsHandler command.ClientCredentialsHandler
+	RegisterClientHandler    command.RegisterClientHandler
+	DeleteClientHandler      command.DeleteClientHandler
+
+	UnlockHandler                  command.UnlockHandler
+	PurgeStaleLoginAttemptsHandler command.PurgeStaleLoginAttemptsHandler
+
+	RegisterUserHandler   commands2.RegisterUserHandler
+	ChangePasswordHandler commands2.ChangePasswordHandler
+	UpdateEmailHandler    commands2.UpdateEmailHandler
+
+	UpdateNotificationPreferencesHandler commands2.UpdateNotificationPreferencesHandler
+	MarkNotificationReadHandler          commands2.MarkNotificationReadHandler
+	MarkAllNotificationsReadHandler      commands2.MarkAllNotificationsReadHandler
+
+	CreateUserHandler    commands2.CreateUserHandler
+	SetUserRolesHandler  commands2.SetUserRolesHandler
+	SetUserStatusHandler commands2.SetUserStatusHandler
+
+	EnrollTOTPHandler  commands2.EnrollTOTPHandler
+	ConfirmTOTPHandler commands2.ConfirmTOTPHandler
+	DisableTOTPHandler commands2.DisableTOTPHandler
+
+	ForgotPasswordHandler          commands2.ForgotPasswordHandler
+	ResetPasswordHandler           commands2.ResetPasswordHandler
+	PurgeExpiredResetTokensHandler commands2.PurgeExpiredResetTokensHandler
+
+	ReplayDeadLetterHandler admincommands.ReplayDeadLetterHandler
+
+	CreateWebhookHandler admincommands.CreateWebhookHandler
+	UpdateWebhookHandler admincommands.UpdateWebhookHandler
+	DeleteWebhookHandler admincommands.DeleteWebhookHandler
 }
 
 // FavoriteServices Contains the grouped queries and command of the app layer
 type FavoriteServices struct {
 	Queries  Queries
 	Commands Commands
+	// Stream pushes the favourite events of a user to their open streams
+	Stream *stream.Hub
 }
 
 type AuthServices struct {
@@ -52,42 +151,241 @@ type UserServices struct {
 	Commands Commands
 }
 
+// AdminServices contains the queries and commands only offered to admins
+type AdminServices struct {
+	Queries  Queries
+	Commands Commands
+}
+
 // Services contains all exposed services of the application layer
 type Services struct {
 	FavoriteServices FavoriteServices
 	AuthServices     AuthServices
 	UserServices     UserServices
+	AdminServices    AdminServices
+
+	// ActingPolicy decides whether the caller of a request may act on the data of a user
+	ActingPolicy policy.ActingPolicy
+
+	// OutboxRelay publishes the recorded domain events to their subscribers once it is run
+	OutboxRelay *relay.Relay
+	// WebhookDeliverer sends the webhook deliveries once it is run
+	WebhookDeliverer *webhooks.Deliverer
+	// DigestScheduler sends the favourite digests once it is run
+	DigestScheduler *digest.Scheduler
+}
+
+// Dependencies contains everything the application layer needs from the outside world
+type Dependencies struct {
+	FavoriteRepository     favourite.Repository
+	NotificationService    notification.Service
+	UserRepository         user.Repository
+	RefreshTokenRepository token.RefreshRepository
+	ResetTokenRepository   token.ResetRepository
+	LoginAttemptRepository lockout.Repository
+	APIKeyRepository       token.APIKeyRepository
+	ClientRepository       client.Repository
+	OIDCStateRepository    token.OIDCStateRepository
+	StatsRepository        stats.Repository
+	AuditRepository        audit.Repository
+	DeadLetterRepository   notification.DeadLetterRepository
+	PreferenceRepository   notification.PreferenceRepository
+	InboxRepository        notification.InboxRepository
+	OutboxRepository       outbox.Repository
+	WebhookRepository      webhook.Repository
+	DigestRepository       digest.Repository
+
+	// NotificationChannel names the channel NotificationService delivers through. The preferences
+	// of the recipients are applied before, with the templates in their locale. Notifications are
+	// also kept in the in-app inbox of InboxRepository.
+	NotificationChannel string
+
+	// OutboxRelay paces the publishing of the outbox events
+	OutboxRelay relay.Config
+
+	// WebhookPoster sends the webhook deliveries, paced by WebhookDelivery
+	WebhookPoster   webhooks.Poster
+	WebhookDelivery webhooks.Config
+
+	// Digests paces the sending of the favourite digests
+	Digests digest.Config
+
+	// FavoriteStream sizes and retains the resume buffers and paces the heartbeats of the favourite streams
+	FavoriteStream stream.Config
+
+	UUIDProvider uuid.Provider
+	TimeProvider time.Provider
+
+	PasswordPolicy   user.PasswordPolicy
+	PasswordHasher   user.PasswordHasher
+	PasswordResetTTL gotime.Duration
+
+	UsernameLockoutPolicy lockout.Policy
+	IPLockoutPolicy       lockout.Policy
+
+	// SecretCipher encrypts TOTP secrets, SecretHasher hashes recovery codes and client secrets
+	SecretCipher helper.SecretCipher
+	SecretHasher helper.TokenHasher
+	TOTPIssuer   string
+
+	// OIDCProvider enables federated login when set
+	OIDCProvider    oidc.Provider
+	OIDCRoleMapping oidc.RoleMapping
+	OIDCStateTTL    gotime.Duration
 }
 
 // NewServices Bootstraps Application Layer dependencies
-func NewServices(favoriteRepo favourite.Repository, ns notification.Service, userRepo user.Repository, refreshTokenRepo token.RefreshRepository, _ uuid.Provider, _ time.Provider) Services {
+func NewServices(deps Dependencies) Services {
+	favoriteRepo := deps.FavoriteRepository
+	userRepo := deps.UserRepository
+	refreshTokenRepo := deps.RefreshTokenRepository
+	resetTokenRepo := deps.ResetTokenRepository
+	loginAttemptRepo := deps.LoginAttemptRepository
+	apiKeyRepo := deps.APIKeyRepository
+	clientRepo := deps.ClientRepository
+	up, tp := deps.UUIDProvider, deps.TimeProvider
+	templates := notification.DefaultTemplates()
+	channel := notification.NewPreferenceFilter(deps.NotificationService, deps.NotificationChannel, deps.PreferenceRepository, templates, tp)
+	inbox := notification.NewPreferenceFilter(notification.NewInbox(deps.InboxRepository, up, tp), notification.ChannelInApp, deps.PreferenceRepository, templates, tp)
+	ns := notification.FanOut{channel, inbox}
+
+	recorder := auditlog.NewRecorder(deps.AuditRepository, up, tp)
+	stamper := auditlog.NewStamper(up, tp)
+
+	// side effects subscribe to the events instead of being called by the commands. The favourite
+	// events reach the bus through the outbox relay, which only knows the subscriptions made before it.
+	bus := eventbus.NewBus(up, tp)
+	bus.Subscribe("notifications", eventbus.NewNotificationHandler(ns), eventbus.NotificationEvents...)
+	bus.Subscribe("webhooks", webhooks.NewEventHandler(deps.WebhookRepository, up, tp), webhooks.Events...)
+	bus.Subscribe("digests", digest.NewEventHandler(deps.DigestRepository, deps.PreferenceRepository, tp), digest.Events...)
+	favoriteStream := stream.NewHub(deps.FavoriteStream, tp)
+	bus.Subscribe("stream", favoriteStream, stream.Events...)
+	outboxRelay := relay.NewRelay(deps.OutboxRepository, deps.OutboxRelay, tp, relay.BusSubscribers(bus)...)
+
+	loginGuard := bruteforce.NewGuard(loginAttemptRepo, deps.UsernameLockoutPolicy, deps.IPLockoutPolicy, ns, tp)
+	mfaVerifier := mfa.NewVerifier(userRepo, deps.SecretCipher, deps.SecretHasher, tp)
+	clientAuthenticator := oauth.NewClientAuthenticator(clientRepo, deps.SecretHasher)
+	loginAttemptRetention := deps.UsernameLockoutPolicy.ResetAfter
+	if deps.IPLockoutPolicy.ResetAfter > loginAttemptRetention {
+		loginAttemptRetention = deps.IPLockoutPolicy.ResetAfter
+	}
+
+	var startOIDCLogin command.StartOIDCLoginHandler
+	var oidcCallback command.OIDCCallbackHandler
+	if deps.OIDCProvider != nil {
+		startOIDCLogin = command.NewStartOIDCLoginHandler(deps.OIDCProvider, deps.OIDCStateRepository, deps.OIDCStateTTL, tp)
+		oidcCallback = command.NewOIDCCallbackHandler(deps.OIDCProvider, deps.OIDCStateRepository, userRepo, refreshTokenRepo,
+			deps.OIDCRoleMapping, commands2.DefaultRoles, recorder, bus, up, tp)
+	}
+
 	return Services{
 		FavoriteServices: FavoriteServices{
 			Queries: Queries{
-				GetAllFavoritesHandler: queries.NewGetAllFavoritesRequestHandler(favoriteRepo),
-				GetFavoriteHandler:     queries.NewGetFavoriteRequestHandler(favoriteRepo),
+				GetAllFavoritesHandler: queries.NewGetAllFavoritesRequestHandler(favoriteRepo, recorder),
+				GetFavoriteHandler:     queries.NewGetFavoriteRequestHandler(favoriteRepo, recorder),
+				WatchFavoritesHandler:  queries.NewWatchFavoritesHandler(favoriteStream, recorder),
 			},
 			Commands: Commands{
-				CreateFavoriteHandler:        commands.NewAddFavoriteRequestHandler(favoriteRepo, ns),
-				UpdateFavoriteHandler:        commands.NewUpdateFavoriteRequestHandler(favoriteRepo),
-				UpdatePartialFavoriteHandler: commands.NewUpdatePartialFavoriteRequestHandler(favoriteRepo),
+				CreateFavoriteHandler:        commands.NewAddFavoriteRequestHandler(favoriteRepo, stamper),
+				UpdateFavoriteHandler:        commands.NewUpdateFavoriteRequestHandler(favoriteRepo, stamper),
+				UpdatePartialFavoriteHandler: commands.NewUpdatePartialFavoriteRequestHandler(favoriteRepo, stamper),
 
-				DeleteFavoriteHandler: commands.NewDeleteFavoriteRequestHandler(favoriteRepo),
+				DeleteFavoriteHandler:   commands.NewDeleteFavoriteRequestHandler(favoriteRepo, stamper),
+				ReorderFavoritesHandler: commands.NewReorderFavoritesRequestHandler(favoriteRepo, stamper),
 			},
+			Stream: favoriteStream,
 		},
 		AuthServices: AuthServices{
-			Queries: Queries{},
+			Queries: Queries{
+				ListSessionsHandler: query.NewListSessionsHandler(refreshTokenRepo, tp),
+				ListLockoutsHandler: query.NewListLockoutsHandler(loginAttemptRepo, tp),
+				ListAPIKeysHandler:  query.NewListAPIKeysHandler(apiKeyRepo),
+
+				ListClientsHandler: query.NewListClientsHandler(clientRepo),
+				IntrospectHandler:  query.NewIntrospectHandler(clientAuthenticator, clientRepo, apiKeyRepo, userRepo, tp),
+			},
 			Commands: Commands{
-				LoginUserHandler:        command.NewLoginHandler(userRepo, refreshTokenRepo),
-				LogoutUserHandler:       command.NewLogoutHandler(refreshTokenRepo),
-				RefreshTokenUserHandler: command.NewRefreshHandler(refreshTokenRepo),
+				LoginUserHandler:        command.NewLoginHandler(userRepo, refreshTokenRepo, deps.PasswordHasher, loginGuard, recorder, bus, up, tp),
+				MFALoginUserHandler:     command.NewMFALoginHandler(userRepo, refreshTokenRepo, loginGuard, mfaVerifier, recorder, bus, up, tp),
+				LogoutUserHandler:       command.NewLogoutHandler(refreshTokenRepo, recorder, bus),
+				LogoutAllUserHandler:    command.NewLogoutAllHandler(refreshTokenRepo, recorder, bus),
+				RevokeSessionHandler:    command.NewRevokeSessionHandler(refreshTokenRepo, bus),
+				RefreshTokenUserHandler: command.NewRefreshHandler(refreshTokenRepo, userRepo, recorder, bus, tp),
+
+				RevokeUserSessionsHandler: command.NewRevokeUserSessionsHandler(userRepo, refreshTokenRepo, recorder, bus),
+				ImpersonateHandler:        command.NewImpersonateHandler(userRepo, recorder, bus),
+
+				PurgeExpiredSessionsHandler: command.NewPurgeExpiredSessionsHandler(refreshTokenRepo, tp),
+
+				StartOIDCLoginHandler:         startOIDCLogin,
+				OIDCCallbackHandler:           oidcCallback,
+				PurgeExpiredOIDCStatesHandler: command.NewPurgeExpiredOIDCStatesHandler(deps.OIDCStateRepository, tp),
+
+				CreateAPIKeyHandler:       command.NewCreateAPIKeyHandler(apiKeyRepo, bus, up, tp),
+				RevokeAPIKeyHandler:       command.NewRevokeAPIKeyHandler(apiKeyRepo, bus),
+				AuthenticateAPIKeyHandler: command.NewAuthenticateAPIKeyHandler(apiKeyRepo, userRepo, tp),
+
+				ClientCredentialsHandler: command.NewClientCredentialsHandler(clientAuthenticator, bus),
+				RegisterClientHandler:    command.NewRegisterClientHandler(clientRepo, deps.SecretHasher, recorder, bus, up, tp),
+				DeleteClientHandler:      command.NewDeleteClientHandler(clientRepo, recorder, bus),
+
+				UnlockHandler:                  command.NewUnlockHandler(loginAttemptRepo, recorder, bus),
+				PurgeStaleLoginAttemptsHandler: command.NewPurgeStaleLoginAttemptsHandler(loginAttemptRepo, loginAttemptRetention, tp),
 			},
 		},
 		UserServices: UserServices{
 			Queries: Queries{
-				GetUserHandler: queries2.NewGetUserHandler(userRepo),
+				GetUserHandler:   queries2.NewGetUserHandler(userRepo),
+				ListUsersHandler: queries2.NewListUsersHandler(userRepo),
+
+				GetNotificationPreferencesHandler: queries2.NewGetNotificationPreferencesHandler(deps.PreferenceRepository),
+				ListNotificationsHandler:          queries2.NewListNotificationsHandler(deps.InboxRepository),
+			},
+			Commands: Commands{
+				RegisterUserHandler:   commands2.NewRegisterUserHandler(userRepo, deps.PasswordPolicy),
+				ChangePasswordHandler: commands2.NewChangePasswordHandler(userRepo, deps.PasswordHasher, deps.PasswordPolicy),
+				UpdateEmailHandler:    commands2.NewUpdateEmailHandler(userRepo, deps.PasswordHasher),
+
+				UpdateNotificationPreferencesHandler: commands2.NewUpdateNotificationPreferencesHandler(deps.PreferenceRepository, tp),
+				MarkNotificationReadHandler:          commands2.NewMarkNotificationReadHandler(deps.InboxRepository, tp),
+				MarkAllNotificationsReadHandler:      commands2.NewMarkAllNotificationsReadHandler(deps.InboxRepository, tp),
+
+				CreateUserHandler:    commands2.NewCreateUserHandler(userRepo, deps.PasswordPolicy, recorder),
+				SetUserRolesHandler:  commands2.NewSetUserRolesHandler(userRepo, recorder),
+				SetUserStatusHandler: commands2.NewSetUserStatusHandler(userRepo, refreshTokenRepo, recorder),
+
+				EnrollTOTPHandler:  commands2.NewEnrollTOTPHandler(userRepo, deps.SecretCipher, deps.TOTPIssuer),
+				ConfirmTOTPHandler: commands2.NewConfirmTOTPHandler(userRepo, deps.SecretCipher, deps.SecretHasher, tp),
+				DisableTOTPHandler: commands2.NewDisableTOTPHandler(userRepo, deps.PasswordHasher, mfaVerifier),
+
+				ForgotPasswordHandler:          commands2.NewForgotPasswordHandler(userRepo, resetTokenRepo, ns, tp, deps.PasswordResetTTL),
+				ResetPasswordHandler:           commands2.NewResetPasswordHandler(userRepo, resetTokenRepo, refreshTokenRepo, apiKeyRepo, deps.PasswordPolicy, tp),
+				PurgeExpiredResetTokensHandler: commands2.NewPurgeExpiredResetTokensHandler(resetTokenRepo, tp),
+			},
+		},
+		AdminServices: AdminServices{
+			Queries: Queries{
+				GetStatsHandler:    adminqueries.NewGetStatsHandler(deps.StatsRepository, tp),
+				ListAuditHandler:   adminqueries.NewListAuditHandler(deps.AuditRepository),
+				ExportAuditHandler: adminqueries.NewExportAuditHandler(deps.AuditRepository),
+
+				ListDeadLettersHandler: adminqueries.NewListDeadLettersHandler(deps.DeadLetterRepository),
+
+				ListWebhooksHandler:          adminqueries.NewListWebhooksHandler(deps.WebhookRepository),
+				GetWebhookHandler:            adminqueries.NewGetWebhookHandler(deps.WebhookRepository),
+				ListWebhookDeliveriesHandler: adminqueries.NewListWebhookDeliveriesHandler(deps.WebhookRepository),
+			},
+			Commands: Commands{
+				ReplayDeadLetterHandler: admincommands.NewReplayDeadLetterHandler(deps.DeadLetterRepository, channel, recorder),
+
+				CreateWebhookHandler: admincommands.NewCreateWebhookHandler(deps.WebhookRepository, deps.SecretCipher, recorder, up, tp),
+				UpdateWebhookHandler: admincommands.NewUpdateWebhookHandler(deps.WebhookRepository, recorder, tp),
+				DeleteWebhookHandler: admincommands.NewDeleteWebhookHandler(deps.WebhookRepository, recorder),
 			},
-			Commands: Commands{},
 		},
+		ActingPolicy:     policy.NewActingPolicy(),
+		OutboxRelay:      outboxRelay,
+		WebhookDeliverer: webhooks.NewDeliverer(deps.WebhookRepository, deps.WebhookPoster, deps.SecretCipher, deps.WebhookDelivery, tp),
+		DigestScheduler:  digest.NewScheduler(deps.DigestRepository, deps.PreferenceRepository, ns, deps.Digests, tp),
 	}
 }
@@ -0,0 +1,196 @@
+// Package stream pushes the favourite events of a user to their open connections, keeping the
+// latest ones so a reconnecting client can resume where it stopped.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// Events are the events pushed to the streams
+var Events = []string{event.FavoriteAddedName, event.FavoriteUpdatedName, event.FavoriteDeletedName, event.FavoritesReorderedName}
+
+// subscriberQueue is how many events a subscriber may lag behind before it is dropped
+const subscriberQueue = 32
+
+// Config sizes the buffers and paces the streams
+type Config struct {
+	// BufferSize is how many of the latest events of each user are kept for resuming
+	BufferSize int
+	// Heartbeat is how often an idle stream is written to, so proxies keep it open
+	Heartbeat gotime.Duration
+	// Retention is how long the buffer of a user without streams is kept after their latest event
+	Retention gotime.Duration
+}
+
+// Event is a favourite event as pushed to the streams
+type Event struct {
+	// ID is the event ID, clients resume after it
+	ID         uuid.UUID
+	Type       string
+	OccurredAt gotime.Time
+	// Data is the event as JSON
+	Data []byte
+}
+
+// Subscription receives the events of a user as they happen. Its channel is closed when the
+// subscriber fell too far behind, it resumes by subscribing again after the last event it got.
+type Subscription struct {
+	C      <-chan Event
+	userID uuid.UUID
+	ch     chan Event
+}
+
+// Hub is an eventbus.Handler keeping the latest favourite events of each user and pushing them to
+// the subscriptions of that user
+type Hub struct {
+	cfg          Config
+	timeProvider time.Provider
+
+	mu          sync.Mutex
+	buffers     map[uuid.UUID][]Event
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// NewHub constructor
+func NewHub(cfg Config, tp time.Provider) *Hub {
+	if cfg.BufferSize < 1 {
+		cfg.BufferSize = 1
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 15 * gotime.Second
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 10 * gotime.Minute
+	}
+	return &Hub{
+		cfg:          cfg,
+		timeProvider: tp,
+		buffers:      make(map[uuid.UUID][]Event),
+		subscribers:  make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Heartbeat returns how often idle streams are written to
+func (h *Hub) Heartbeat() gotime.Duration {
+	return h.cfg.Heartbeat
+}
+
+// Handle keeps the favourite event in the buffer of its user and pushes it to their subscriptions.
+// A redelivered event is pushed once.
+func (h *Hub) Handle(envelope eventbus.Envelope) error {
+	var userID uuid.UUID
+	switch e := envelope.Event.(type) {
+	case event.FavoriteAdded:
+		userID = e.UserID
+	case event.FavoriteUpdated:
+		userID = e.UserID
+	case event.FavoriteDeleted:
+		userID = e.UserID
+	case event.FavoritesReordered:
+		userID = e.UserID
+	default:
+		return nil
+	}
+	data, err := json.Marshal(envelope.Event)
+	if err != nil {
+		return fmt.Errorf("failed to encode stream event: %w", err)
+	}
+	e := Event{ID: envelope.ID, Type: envelope.Event.EventName(), OccurredAt: envelope.OccurredAt, Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buffer := h.buffers[userID]
+	for _, buffered := range buffer {
+		if buffered.ID == e.ID {
+			return nil
+		}
+	}
+	buffer = append(buffer, e)
+	if len(buffer) > h.cfg.BufferSize {
+		buffer = append([]Event(nil), buffer[len(buffer)-h.cfg.BufferSize:]...)
+	}
+	h.buffers[userID] = buffer
+
+	for s := range h.subscribers[userID] {
+		select {
+		case s.ch <- e:
+		default:
+			h.drop(s)
+		}
+	}
+	return nil
+}
+
+// PurgeIdle removes the buffers of the users who have no stream open and no event newer than the
+// retention, returning how many were removed. Their clients reconnecting later reload the favourites.
+func (h *Hub) PurgeIdle() (int, error) {
+	before := h.timeProvider.Now().Add(-h.cfg.Retention)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	purged := 0
+	for userID, buffer := range h.buffers {
+		if len(h.subscribers[userID]) > 0 || buffer[len(buffer)-1].OccurredAt.After(before) {
+			continue
+		}
+		delete(h.buffers, userID)
+		purged++
+	}
+	return purged, nil
+}
+
+// Subscribe starts a subscription to the events of a user. With the ID of the last event the
+// client got, it also returns the buffered events that followed. The returned bool is false when
+// that event is no longer buffered, the client then missed events and must reload the favourites.
+func (h *Hub) Subscribe(userID uuid.UUID, lastEventID string) (*Subscription, []Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	missed, resumed := []Event{}, true
+	if lastEventID != "" {
+		resumed = false
+		for i, e := range h.buffers[userID] {
+			if e.ID.String() == lastEventID {
+				missed = append(missed, h.buffers[userID][i+1:]...)
+				resumed = true
+				break
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberQueue)
+	s := &Subscription{C: ch, userID: userID, ch: ch}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][s] = struct{}{}
+	return s, missed, resumed
+}
+
+// Unsubscribe ends a subscription
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(s)
+}
+
+// drop closes a subscription, under the lock
+func (h *Hub) drop(s *Subscription) {
+	subscribers := h.subscribers[s.userID]
+	if _, ok := subscribers[s]; !ok {
+		return
+	}
+	delete(subscribers, s)
+	if len(subscribers) == 0 {
+		delete(h.subscribers, s.userID)
+	}
+	close(s.ch)
+}
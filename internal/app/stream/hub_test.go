@@ -0,0 +1,145 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func added(userID uuid.UUID) eventbus.Envelope {
+	return eventbus.Envelope{
+		ID:         uuid.New(),
+		OccurredAt: time.Now(),
+		Event:      event.FavoriteAdded{UserID: userID, Favorite: favourite.Favorite{ID: uuid.New(), Type: favourite.AssetChart}},
+	}
+}
+
+func TestHub_Resume(t *testing.T) {
+	hub := stream.NewHub(stream.Config{BufferSize: 3}, timeprovider.NewTimeProvider())
+	userID := uuid.New()
+
+	var envelopes []eventbus.Envelope
+	for i := 0; i < 4; i++ {
+		envelope := added(userID)
+		if !assert.NoError(t, hub.Handle(envelope)) {
+			return
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	if !assert.NoError(t, hub.Handle(envelopes[3]), "a redelivered event") {
+		return
+	}
+	if !assert.NoError(t, hub.Handle(added(uuid.New())), "an event of another user") {
+		return
+	}
+
+	sub, missed, resumed := hub.Subscribe(userID, envelopes[1].ID.String())
+	hub.Unsubscribe(sub)
+	assert.True(t, resumed)
+	if !assert.Len(t, missed, 2) {
+		return
+	}
+	assert.Equal(t, envelopes[2].ID, missed[0].ID)
+	assert.Equal(t, envelopes[3].ID, missed[1].ID)
+	assert.Equal(t, event.FavoriteAddedName, missed[0].Type)
+	assert.Contains(t, string(missed[0].Data), userID.String())
+
+	sub, missed, resumed = hub.Subscribe(userID, envelopes[0].ID.String())
+	hub.Unsubscribe(sub)
+	assert.False(t, resumed, "the event is no longer buffered")
+	assert.Empty(t, missed)
+
+	sub, missed, resumed = hub.Subscribe(userID, "")
+	hub.Unsubscribe(sub)
+	assert.True(t, resumed, "a new stream")
+	assert.Empty(t, missed)
+}
+
+func TestHub_Push(t *testing.T) {
+	hub := stream.NewHub(stream.Config{BufferSize: 100}, timeprovider.NewTimeProvider())
+	userID := uuid.New()
+	first, _, _ := hub.Subscribe(userID, "")
+	second, _, _ := hub.Subscribe(userID, "")
+	other, _, _ := hub.Subscribe(uuid.New(), "")
+	defer hub.Unsubscribe(other)
+
+	envelope := added(userID)
+	if !assert.NoError(t, hub.Handle(envelope)) {
+		return
+	}
+	assert.Equal(t, envelope.ID, (<-first.C).ID)
+	assert.Equal(t, envelope.ID, (<-second.C).ID)
+	assert.Empty(t, other.C)
+
+	hub.Unsubscribe(second)
+	_, ok := <-second.C
+	assert.False(t, ok, "closed when unsubscribed")
+	hub.Unsubscribe(second)
+
+	// a subscriber that stops reading is dropped instead of holding the others back
+	var last eventbus.Envelope
+	for i := 0; i < 50; i++ {
+		last = added(userID)
+		if !assert.NoError(t, hub.Handle(last)) {
+			return
+		}
+	}
+	received := 0
+	for range first.C {
+		received++
+	}
+	assert.Less(t, received, 50)
+
+	resumed, missed, ok := hub.Subscribe(userID, envelope.ID.String())
+	defer hub.Unsubscribe(resumed)
+	assert.True(t, ok)
+	if !assert.Len(t, missed, 50) {
+		return
+	}
+	assert.Equal(t, last.ID, missed[49].ID)
+}
+
+func TestHub_PurgeIdle(t *testing.T) {
+	now := time.Now()
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now.Add(time.Hour))
+	hub := stream.NewHub(stream.Config{BufferSize: 10, Retention: 30 * time.Minute}, tp)
+
+	idle, watching, recent := uuid.New(), uuid.New(), uuid.New()
+	stale := added(idle)
+	stale.OccurredAt = now
+	watched := added(watching)
+	watched.OccurredAt = now
+	fresh := added(recent)
+	fresh.OccurredAt = now.Add(45 * time.Minute)
+	for _, envelope := range []eventbus.Envelope{stale, watched, fresh} {
+		if !assert.NoError(t, hub.Handle(envelope)) {
+			return
+		}
+	}
+	sub, _, _ := hub.Subscribe(watching, "")
+	defer hub.Unsubscribe(sub)
+
+	purged, err := hub.PurgeIdle()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, purged)
+
+	resume := func(userID uuid.UUID, lastEventID string) bool {
+		sub, _, resumed := hub.Subscribe(userID, lastEventID)
+		hub.Unsubscribe(sub)
+		return resumed
+	}
+	assert.False(t, resume(idle, stale.ID.String()), "no event of an idle user is kept")
+	assert.True(t, resume(watching, watched.ID.String()), "a user with a stream keeps the buffer")
+	assert.True(t, resume(recent, fresh.ID.String()), "a recent event is kept")
+}
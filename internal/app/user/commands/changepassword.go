@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCurrentPassword is returned when the current password does not match
+var ErrInvalidCurrentPassword = errors.New("invalid current password")
+
+// ChangePasswordRequest represents a password change of the authenticated user
+type ChangePasswordRequest struct {
+	UserID          uuid.UUID
+	CurrentPassword string
+	NewPassword     string
+}
+
+// ChangePasswordHandler interface
+type ChangePasswordHandler interface {
+	Handle(req ChangePasswordRequest) error
+}
+
+type changePasswordHandler struct {
+	repo   user.Repository
+	hasher user.PasswordHasher
+	policy user.PasswordPolicy
+}
+
+// NewChangePasswordHandler constructor
+func NewChangePasswordHandler(repo user.Repository, hasher user.PasswordHasher, policy user.PasswordPolicy) ChangePasswordHandler {
+	return &changePasswordHandler{repo: repo, hasher: hasher, policy: policy}
+}
+
+// Handle verifies the current password and replaces it with the new one
+func (h *changePasswordHandler) Handle(req ChangePasswordRequest) error {
+	u, err := h.repo.GetByID(req.UserID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := h.hasher.Verify(u.Password, req.CurrentPassword); err != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	if err := h.policy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	if err := h.repo.UpdatePassword(u.ID, req.NewPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChangePasswordHandler_Handle(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("current password"), bcrypt.MinCost)
+	existing := &user.User{ID: uuid.New(), Username: "alice", Password: string(hashed)}
+	hasher, _ := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+
+	tests := []struct {
+		name          string
+		req           commands.ChangePasswordRequest
+		setupMock     func(m *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			req:  commands.ChangePasswordRequest{UserID: existing.ID, CurrentPassword: "current password", NewPassword: "brand new password"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", existing.ID).Return(existing, nil)
+				m.On("UpdatePassword", existing.ID, "brand new password").Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "wrong current password",
+			req:  commands.ChangePasswordRequest{UserID: existing.ID, CurrentPassword: "wrong", NewPassword: "brand new password"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", existing.ID).Return(existing, nil)
+			},
+			expectedError: commands.ErrInvalidCurrentPassword,
+		},
+		{
+			name: "weak new password",
+			req:  commands.ChangePasswordRequest{UserID: existing.ID, CurrentPassword: "current password", NewPassword: "short"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", existing.ID).Return(existing, nil)
+			},
+			expectedError: user.ErrWeakPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+
+			handler := commands.NewChangePasswordHandler(mockRepo, hasher, testPolicy)
+
+			err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/totp"
+	"github.com/google/uuid"
+)
+
+// ErrNoPendingTOTP is returned when confirming without a started enrolment
+var ErrNoPendingTOTP = errors.New("no two-factor enrolment in progress")
+
+// ConfirmTOTPRequest confirms an enrolment with a code of the authenticator app
+type ConfirmTOTPRequest struct {
+	UserID uuid.UUID
+	Code   string
+}
+
+// ConfirmTOTPResult contains the recovery codes, shown only once
+type ConfirmTOTPResult struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ConfirmTOTPHandler completes a TOTP enrolment
+type ConfirmTOTPHandler interface {
+	Handle(req ConfirmTOTPRequest) (*ConfirmTOTPResult, error)
+}
+
+type confirmTOTPHandler struct {
+	repo         user.Repository
+	cipher       helper.SecretCipher
+	hasher       helper.TokenHasher
+	timeProvider time.Provider
+}
+
+// NewConfirmTOTPHandler constructor
+func NewConfirmTOTPHandler(repo user.Repository, cipher helper.SecretCipher, hasher helper.TokenHasher, tp time.Provider) ConfirmTOTPHandler {
+	return &confirmTOTPHandler{repo: repo, cipher: cipher, hasher: hasher, timeProvider: tp}
+}
+
+// Handle checks the code against the pending secret, enables two-factor and issues recovery codes
+func (h *confirmTOTPHandler) Handle(req ConfirmTOTPRequest) (*ConfirmTOTPResult, error) {
+	u, err := h.repo.GetByID(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if u.TOTP.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if u.TOTP.PendingSecret == "" {
+		return nil, ErrNoPendingTOTP
+	}
+
+	secret, err := h.cipher.Decrypt(u.TOTP.PendingSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.timeProvider.Now()
+	step, ok := totp.Validate(secret, req.Code, now, 1)
+	if !ok {
+		return nil, mfa.ErrInvalidCode
+	}
+
+	codes, hashes, err := mfa.GenerateRecoveryCodes(h.hasher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	if err := h.repo.UpdateTOTP(u.ID, user.TOTP{
+		Enabled:       true,
+		Secret:        u.TOTP.PendingSecret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+		EnabledAt:     now.UTC(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	return &ConfirmTOTPResult{RecoveryCodes: codes}, nil
+}
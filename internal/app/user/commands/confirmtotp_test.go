@@ -0,0 +1,98 @@
+package commands_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/totp"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConfirmTOTPHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cipher, _ := helper.NewSecretCipher([]byte(strings.Repeat("k", 32)))
+	hasher := helper.NewTokenHasher([]byte("hash key"))
+
+	secret, _ := totp.GenerateSecret()
+	pending, _ := cipher.Encrypt(secret)
+	code, _ := totp.Code(secret, totp.Step(now))
+
+	pendingUser := &user.User{ID: uuid.New(), Username: "alice", TOTP: user.TOTP{PendingSecret: pending}}
+	enabledUser := &user.User{ID: uuid.New(), Username: "bob", TOTP: user.TOTP{Enabled: true, Secret: pending}}
+	newUser := &user.User{ID: uuid.New(), Username: "carol"}
+
+	tests := []struct {
+		name          string
+		req           commands.ConfirmTOTPRequest
+		setupMock     func(m *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			req:  commands.ConfirmTOTPRequest{UserID: pendingUser.ID, Code: code},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", pendingUser.ID).Return(pendingUser, nil)
+				m.On("UpdateTOTP", pendingUser.ID, mock.MatchedBy(func(s user.TOTP) bool {
+					return s.Enabled && s.Secret == pending && s.PendingSecret == "" &&
+						len(s.RecoveryCodes) == 10 && s.LastUsedStep == totp.Step(now)
+				})).Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "wrong code",
+			req:  commands.ConfirmTOTPRequest{UserID: pendingUser.ID, Code: "123"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", pendingUser.ID).Return(pendingUser, nil)
+			},
+			expectedError: mfa.ErrInvalidCode,
+		},
+		{
+			name: "already enabled",
+			req:  commands.ConfirmTOTPRequest{UserID: enabledUser.ID, Code: code},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", enabledUser.ID).Return(enabledUser, nil)
+			},
+			expectedError: commands.ErrTOTPAlreadyEnabled,
+		},
+		{
+			name: "no enrolment started",
+			req:  commands.ConfirmTOTPRequest{UserID: newUser.ID, Code: code},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", newUser.ID).Return(newUser, nil)
+			},
+			expectedError: commands.ErrNoPendingTOTP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Return(now)
+
+			handler := commands.NewConfirmTOTPHandler(mockRepo, cipher, hasher, mockTime)
+
+			result, err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, result.RecoveryCodes, 10)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
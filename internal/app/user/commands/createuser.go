@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+)
+
+// CreateUserRequest represents an admin creating a user with the given roles
+type CreateUserRequest struct {
+	Username string
+	Password string
+	Roles    []string
+	Source   audit.Source
+}
+
+// CreateUserHandler interface
+type CreateUserHandler interface {
+	Handle(req CreateUserRequest) (*queries.UserSummary, error)
+}
+
+type createUserHandler struct {
+	repo   user.Repository
+	policy user.PasswordPolicy
+	audit  auditlog.Recorder
+}
+
+// NewCreateUserHandler constructor
+func NewCreateUserHandler(repo user.Repository, policy user.PasswordPolicy, recorder auditlog.Recorder) CreateUserHandler {
+	return &createUserHandler{repo: repo, policy: policy, audit: recorder}
+}
+
+// Handle validates the username, password and roles and stores the new user
+func (h *createUserHandler) Handle(req CreateUserRequest) (*queries.UserSummary, error) {
+	username := user.NormalizeUsername(req.Username)
+	if err := user.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := h.policy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+	roles := req.Roles
+	if len(roles) == 0 {
+		roles = DefaultRoles
+	}
+	roles, err := user.NormalizeRoles(roles)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := h.repo.Add(username, req.Password, roles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	summary := queries.NewUserSummary(u)
+	entry := audit.NewEntry(req.Source, audit.ActionUserCreate, audit.Target{Type: audit.TargetUser, ID: u.ID.String()})
+	entry.Changes = audit.Diff(nil, summary)
+	if err := h.audit.Record(entry); err != nil {
+		return nil, fmt.Errorf("user created but %w", err)
+	}
+	return &summary, nil
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// DisableTOTPRequest turns two-factor off, proving both factors
+type DisableTOTPRequest struct {
+	UserID   uuid.UUID
+	Password string
+	Code     string // TOTP code or recovery code
+}
+
+// DisableTOTPHandler interface
+type DisableTOTPHandler interface {
+	Handle(req DisableTOTPRequest) error
+}
+
+type disableTOTPHandler struct {
+	repo     user.Repository
+	hasher   user.PasswordHasher
+	verifier mfa.Verifier
+}
+
+// NewDisableTOTPHandler constructor
+func NewDisableTOTPHandler(repo user.Repository, hasher user.PasswordHasher, verifier mfa.Verifier) DisableTOTPHandler {
+	return &disableTOTPHandler{repo: repo, hasher: hasher, verifier: verifier}
+}
+
+// Handle verifies the password and the second factor, then removes the enrolment
+func (h *disableTOTPHandler) Handle(req DisableTOTPRequest) error {
+	u, err := h.repo.GetByID(req.UserID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := h.hasher.Verify(u.Password, req.Password); err != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	if err := h.verifier.Verify(*u, req.Code); err != nil {
+		return err
+	}
+
+	if err := h.repo.UpdateTOTP(u.ID, user.TOTP{}); err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+	return nil
+}
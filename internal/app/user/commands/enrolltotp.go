@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/totp"
+	"github.com/google/uuid"
+)
+
+// ErrTOTPAlreadyEnabled is returned when enrolling a user that already has two-factor enabled
+var ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// EnrollTOTPResult contains what the user needs to set up an authenticator app
+type EnrollTOTPResult struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// EnrollTOTPHandler starts a TOTP enrolment
+type EnrollTOTPHandler interface {
+	Handle(userID uuid.UUID) (*EnrollTOTPResult, error)
+}
+
+type enrollTOTPHandler struct {
+	repo   user.Repository
+	cipher helper.SecretCipher
+	issuer string
+}
+
+// NewEnrollTOTPHandler constructor, issuer is the name shown in authenticator apps
+func NewEnrollTOTPHandler(repo user.Repository, cipher helper.SecretCipher, issuer string) EnrollTOTPHandler {
+	return &enrollTOTPHandler{repo: repo, cipher: cipher, issuer: issuer}
+}
+
+// Handle generates a new secret and keeps it pending until confirmed with a valid code
+func (h *enrollTOTPHandler) Handle(userID uuid.UUID) (*EnrollTOTPResult, error) {
+	u, err := h.repo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if u.TOTP.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	encrypted, err := h.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	state := u.TOTP
+	state.PendingSecret = encrypted
+	if err := h.repo.UpdateTOTP(u.ID, state); err != nil {
+		return nil, fmt.Errorf("failed to store totp enrolment: %w", err)
+	}
+
+	return &EnrollTOTPResult{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(h.issuer, u.Username, secret),
+	}, nil
+}
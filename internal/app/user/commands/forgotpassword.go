@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// ForgotPasswordRequest represents a request for a password reset token
+type ForgotPasswordRequest struct {
+	Username string
+}
+
+// ForgotPasswordHandler interface
+type ForgotPasswordHandler interface {
+	Handle(req ForgotPasswordRequest) error
+	// Run issues the queued reset tokens until the context is done
+	Run(ctx context.Context)
+}
+
+// forgotPasswordQueue is how many reset requests may wait to be issued, more are dropped
+const forgotPasswordQueue = 100
+
+type forgotPasswordHandler struct {
+	queue               chan ForgotPasswordRequest
+	userRepo            user.Repository
+	resetRepo           token.ResetRepository
+	notificationService notification.Service
+	timeProvider        time.Provider
+	ttl                 gotime.Duration
+}
+
+// NewForgotPasswordHandler constructor
+func NewForgotPasswordHandler(
+	userRepo user.Repository,
+	resetRepo token.ResetRepository,
+	ns notification.Service,
+	tp time.Provider,
+	ttl gotime.Duration,
+) ForgotPasswordHandler {
+	return &forgotPasswordHandler{
+		queue:               make(chan ForgotPasswordRequest, forgotPasswordQueue),
+		userRepo:            userRepo,
+		resetRepo:           resetRepo,
+		notificationService: ns,
+		timeProvider:        tp,
+		ttl:                 ttl,
+	}
+}
+
+// Handle queues the request for Run. Known and unknown usernames are answered the same way and at
+// the same speed, since the lookup, the token and its delivery all happen off the request path.
+func (h *forgotPasswordHandler) Handle(req ForgotPasswordRequest) error {
+	select {
+	case h.queue <- req:
+	default:
+		log.Printf("password reset: queue full, request dropped")
+	}
+	return nil
+}
+
+func (h *forgotPasswordHandler) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-h.queue:
+			if err := h.issue(req); err != nil {
+				log.Printf("password reset: %v", err)
+			}
+		}
+	}
+}
+
+// issue generates a single-use reset token and delivers it to the user, an unknown or disabled user is ignored
+func (h *forgotPasswordHandler) issue(req ForgotPasswordRequest) error {
+	u, err := h.userRepo.GetByUsername(req.Username)
+	if err != nil || !u.Active() {
+		return nil
+	}
+
+	resetToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	now := h.timeProvider.Now().UTC()
+	h.resetRepo.Save(resetToken, token.ResetRecord{
+		UserID:    u.ID,
+		CreatedAt: now,
+		Expiry:    now.Add(h.ttl),
+	})
+
+	n := notification.Notification{
+		UserID: u.ID,
+		Kind:   notification.KindSecurity,
+		Event:  notification.EventPasswordResetRequest,
+		Data: map[string]string{
+			"username": u.Username,
+			"token":    resetToken,
+			"ttl":      h.ttl.String(),
+		},
+	}
+
+	if err := h.notificationService.Notify(n); err != nil {
+		h.resetRepo.Consume(resetToken)
+		return fmt.Errorf("failed to deliver reset token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for reset tokens
+type MockResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockResetRepository) Save(t string, rec token.ResetRecord) {
+	m.Called(t, rec)
+}
+
+func (m *MockResetRepository) Consume(t string) (token.ResetRecord, bool) {
+	args := m.Called(t)
+	return args.Get(0).(token.ResetRecord), args.Bool(1)
+}
+
+func (m *MockResetRepository) DeleteByUser(userID uuid.UUID) int {
+	args := m.Called(userID)
+	return args.Int(0)
+}
+
+func (m *MockResetRepository) DeleteExpired(now time.Time) int {
+	args := m.Called(now)
+	return args.Int(0)
+}
+
+func TestForgotPasswordHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	existing := &user.User{ID: uuid.New(), Username: "alice"}
+
+	tests := []struct {
+		name       string
+		username   string
+		setupMocks func(u *MockUserRepository, r *MockResetRepository, n *notification.MockNotificationService, done func(mock.Arguments))
+	}{
+		{
+			name:     "happy path - token stored and delivered",
+			username: "alice",
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, n *notification.MockNotificationService, done func(mock.Arguments)) {
+				u.On("GetByUsername", "alice").Return(existing, nil)
+				r.On("Save", mock.Anything, token.ResetRecord{UserID: existing.ID, CreatedAt: now, Expiry: now.Add(15 * time.Minute)}).Return()
+				n.On("Notify", mock.MatchedBy(func(n notification.Notification) bool {
+					return n.Kind == notification.KindSecurity && n.UserID == existing.ID &&
+						n.Event == notification.EventPasswordResetRequest && n.Data["token"] != "" && n.Data["ttl"] == "15m0s"
+				})).Return(nil).Run(done)
+			},
+		},
+		{
+			name:     "unknown user is silently ignored",
+			username: "nobody",
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, n *notification.MockNotificationService, done func(mock.Arguments)) {
+				u.On("GetByUsername", "nobody").Return(nil, errors.New("user not found")).Run(done)
+			},
+		},
+		{
+			name:     "disabled user is silently ignored",
+			username: "mallory",
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, n *notification.MockNotificationService, done func(mock.Arguments)) {
+				u.On("GetByUsername", "mallory").Return(&user.User{ID: uuid.New(), Username: "mallory", Status: user.StatusDisabled}, nil).Run(done)
+			},
+		},
+		{
+			name:     "delivery failure discards the token",
+			username: "alice",
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, n *notification.MockNotificationService, done func(mock.Arguments)) {
+				u.On("GetByUsername", "alice").Return(existing, nil)
+				r.On("Save", mock.Anything, mock.Anything).Return()
+				n.On("Notify", mock.Anything).Return(errors.New("notify failed"))
+				r.On("Consume", mock.Anything).Return(token.ResetRecord{}, true).Run(done)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockResets := &MockResetRepository{}
+			mockNotification := &notification.MockNotificationService{}
+			issued := make(chan struct{})
+			tt.setupMocks(mockUsers, mockResets, mockNotification, func(mock.Arguments) { close(issued) })
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Maybe().Return(now)
+
+			handler := commands.NewForgotPasswordHandler(mockUsers, mockResets, mockNotification, mockTime, 15*time.Minute)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go handler.Run(ctx)
+
+			// the answer is the same whatever happens to the request
+			assert.NoError(t, handler.Handle(commands.ForgotPasswordRequest{Username: tt.username}))
+			select {
+			case <-issued:
+			case <-time.After(time.Second):
+				assert.Fail(t, "the request was not issued")
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockResets.AssertExpectations(t)
+			mockNotification.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// MarkNotificationReadRequest represents the authenticated user reading a notification of their inbox
+type MarkNotificationReadRequest struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+// MarkNotificationReadHandler interface
+type MarkNotificationReadHandler interface {
+	Handle(req MarkNotificationReadRequest) error
+}
+
+type markNotificationReadHandler struct {
+	repo         notification.InboxRepository
+	timeProvider time.Provider
+}
+
+// NewMarkNotificationReadHandler constructor
+func NewMarkNotificationReadHandler(repo notification.InboxRepository, tp time.Provider) MarkNotificationReadHandler {
+	return &markNotificationReadHandler{repo: repo, timeProvider: tp}
+}
+
+// Handle marks the notification read, a notification already read keeps its read time
+func (h *markNotificationReadHandler) Handle(req MarkNotificationReadRequest) error {
+	return h.repo.MarkRead(req.UserID, req.ID, h.timeProvider.Now().UTC())
+}
+
+// MarkAllNotificationsReadHandler interface
+type MarkAllNotificationsReadHandler interface {
+	Handle(userID uuid.UUID) (int, error)
+}
+
+type markAllNotificationsReadHandler struct {
+	repo         notification.InboxRepository
+	timeProvider time.Provider
+}
+
+// NewMarkAllNotificationsReadHandler constructor
+func NewMarkAllNotificationsReadHandler(repo notification.InboxRepository, tp time.Provider) MarkAllNotificationsReadHandler {
+	return &markAllNotificationsReadHandler{repo: repo, timeProvider: tp}
+}
+
+// Handle marks every unread notification of the user read, returning how many there were
+func (h *markAllNotificationsReadHandler) Handle(userID uuid.UUID) (int, error) {
+	marked, err := h.repo.MarkAllRead(userID, h.timeProvider.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return marked, nil
+}
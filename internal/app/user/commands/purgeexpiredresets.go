@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// PurgeExpiredResetTokensHandler removes reset tokens that are past their expiry
+type PurgeExpiredResetTokensHandler interface {
+	Handle() (int, error)
+}
+
+type purgeExpiredResetTokensHandler struct {
+	resetRepo    token.ResetRepository
+	timeProvider time.Provider
+}
+
+// NewPurgeExpiredResetTokensHandler constructor
+func NewPurgeExpiredResetTokensHandler(rr token.ResetRepository, tp time.Provider) PurgeExpiredResetTokensHandler {
+	return &purgeExpiredResetTokensHandler{resetRepo: rr, timeProvider: tp}
+}
+
+// Handle purges the expired reset tokens and returns how many were removed
+func (h *purgeExpiredResetTokensHandler) Handle() (int, error) {
+	return h.resetRepo.DeleteExpired(h.timeProvider.Now().UTC()), nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// DefaultRoles are the roles of a self-registered user
+var DefaultRoles = []string{"user"}
+
+// RegisterUserRequest represents a self-service registration
+type RegisterUserRequest struct {
+	Username string
+	Password string
+}
+
+// RegisterUserResult represents the registered user
+type RegisterUserResult struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+// RegisterUserHandler interface
+type RegisterUserHandler interface {
+	Handle(req RegisterUserRequest) (*RegisterUserResult, error)
+}
+
+type registerUserHandler struct {
+	repo   user.Repository
+	policy user.PasswordPolicy
+}
+
+// NewRegisterUserHandler constructor
+func NewRegisterUserHandler(repo user.Repository, policy user.PasswordPolicy) RegisterUserHandler {
+	return &registerUserHandler{repo: repo, policy: policy}
+}
+
+// Handle validates the username and password and stores the new user
+func (h *registerUserHandler) Handle(req RegisterUserRequest) (*RegisterUserResult, error) {
+	username := user.NormalizeUsername(req.Username)
+	if err := user.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := h.policy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
+	u, err := h.repo.Add(username, req.Password, DefaultRoles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register user: %w", err)
+	}
+
+	return &RegisterUserResult{ID: u.ID, Username: u.Username}, nil
+}
@@ -0,0 +1,160 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for users
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetByID(id uuid.UUID) (*user.User, error) {
+	args := m.Called(id)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(username string) (*user.User, error) {
+	args := m.Called(username)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Add(username, plainPassword string, roles []string) (*user.User, error) {
+	args := m.Called(username, plainPassword, roles)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uuid.UUID, plainPassword string) error {
+	args := m.Called(id, plainPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(query user.ListQuery) ([]*user.User, int, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*user.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) SetStatus(id uuid.UUID, status user.Status) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ReplacePasswordHash(id uuid.UUID, oldHash, newHash string) error {
+	args := m.Called(id, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateTOTP(id uuid.UUID, totp user.TOTP) error {
+	args := m.Called(id, totp)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByIdentity(identity user.Identity) (*user.User, error) {
+	args := m.Called(identity)
+	u := args.Get(0)
+	if u == nil {
+		return nil, args.Error(1)
+	}
+	return u.(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) LinkIdentity(id uuid.UUID, identity user.Identity) error {
+	args := m.Called(id, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateRoles(id uuid.UUID, roles []string) error {
+	args := m.Called(id, roles)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateEmail(id uuid.UUID, email string) error {
+	args := m.Called(id, email)
+	return args.Error(0)
+}
+
+var testPolicy = user.PasswordPolicy{MinLength: 8, MaxLength: 72}
+
+func TestRegisterUserHandler_Handle(t *testing.T) {
+	newUser := &user.User{ID: uuid.New(), Username: "carol"}
+
+	tests := []struct {
+		name          string
+		req           commands.RegisterUserRequest
+		setupMock     func(m *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "happy path - username is normalized",
+			req:  commands.RegisterUserRequest{Username: " Carol ", Password: "long enough password"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("Add", "carol", "long enough password", commands.DefaultRoles).Return(newUser, nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "invalid username",
+			req:           commands.RegisterUserRequest{Username: "c", Password: "long enough password"},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: user.ErrInvalidUsername,
+		},
+		{
+			name:          "weak password",
+			req:           commands.RegisterUserRequest{Username: "carol", Password: "short"},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: user.ErrWeakPassword,
+		},
+		{
+			name: "username taken",
+			req:  commands.RegisterUserRequest{Username: "CAROL", Password: "long enough password"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("Add", "carol", "long enough password", commands.DefaultRoles).Return(nil, user.ErrUsernameTaken)
+			},
+			expectedError: user.ErrUsernameTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+
+			handler := commands.NewRegisterUserHandler(mockRepo, testPolicy)
+
+			result, err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, newUser.ID, result.ID)
+				assert.Equal(t, "carol", result.Username)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
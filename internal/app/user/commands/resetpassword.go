@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+)
+
+// ErrInvalidResetToken is returned when a reset token is unknown, used or expired
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// ResetPasswordRequest represents the completion of a password reset
+type ResetPasswordRequest struct {
+	Token       string
+	NewPassword string
+}
+
+// ResetPasswordHandler interface
+type ResetPasswordHandler interface {
+	Handle(req ResetPasswordRequest) error
+}
+
+type resetPasswordHandler struct {
+	userRepo     user.Repository
+	resetRepo    token.ResetRepository
+	refreshRepo  token.RefreshRepository
+	apiKeyRepo   token.APIKeyRepository
+	policy       user.PasswordPolicy
+	timeProvider time.Provider
+}
+
+// NewResetPasswordHandler constructor
+func NewResetPasswordHandler(
+	userRepo user.Repository,
+	resetRepo token.ResetRepository,
+	refreshRepo token.RefreshRepository,
+	apiKeyRepo token.APIKeyRepository,
+	policy user.PasswordPolicy,
+	tp time.Provider,
+) ResetPasswordHandler {
+	return &resetPasswordHandler{
+		userRepo:     userRepo,
+		resetRepo:    resetRepo,
+		refreshRepo:  refreshRepo,
+		apiKeyRepo:   apiKeyRepo,
+		policy:       policy,
+		timeProvider: tp,
+	}
+}
+
+// Handle consumes the reset token, sets the new password and signs the user out everywhere, revoking their
+// API keys too since they may have been created with the stolen credentials
+func (h *resetPasswordHandler) Handle(req ResetPasswordRequest) error {
+	// validate first, so a rejected password does not burn the token
+	if err := h.policy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	rec, ok := h.resetRepo.Consume(req.Token)
+	if !ok || h.timeProvider.Now().After(rec.Expiry) {
+		return ErrInvalidResetToken
+	}
+	u, err := h.userRepo.GetByID(rec.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	// a disabled account stays locked out, whoever holds the token
+	if !u.Active() {
+		return ErrInvalidResetToken
+	}
+
+	if err := h.userRepo.UpdatePassword(rec.UserID, req.NewPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	h.resetRepo.DeleteByUser(rec.UserID)
+	h.refreshRepo.DeleteByUser(rec.UserID)
+	h.apiKeyRepo.DeleteByUser(rec.UserID)
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository for refresh tokens
+type MockRefreshRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshRepository) Save(t string, rec token.RefreshRecord) {
+	m.Called(t, rec)
+}
+
+func (m *MockRefreshRepository) Get(t string) (token.RefreshRecord, bool) {
+	args := m.Called(t)
+	return args.Get(0).(token.RefreshRecord), args.Bool(1)
+}
+
+func (m *MockRefreshRepository) Delete(t string) {
+	m.Called(t)
+}
+
+func (m *MockRefreshRepository) ListByUser(userID uuid.UUID) []token.RefreshRecord {
+	args := m.Called(userID)
+	return args.Get(0).([]token.RefreshRecord)
+}
+
+func (m *MockRefreshRepository) DeleteByID(userID uuid.UUID, sessionID uuid.UUID) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshRepository) DeleteByUser(userID uuid.UUID) int {
+	args := m.Called(userID)
+	return args.Int(0)
+}
+
+func (m *MockRefreshRepository) DeleteExpired(now time.Time) int {
+	args := m.Called(now)
+	return args.Int(0)
+}
+
+// Mock repository for API keys
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Save(t string, key token.APIKey) {
+	m.Called(t, key)
+}
+
+func (m *MockAPIKeyRepository) Get(t string) (token.APIKey, bool) {
+	args := m.Called(t)
+	return args.Get(0).(token.APIKey), args.Bool(1)
+}
+
+func (m *MockAPIKeyRepository) ListByUser(userID uuid.UUID) []token.APIKey {
+	args := m.Called(userID)
+	return args.Get(0).([]token.APIKey)
+}
+
+func (m *MockAPIKeyRepository) DeleteByID(userID uuid.UUID, keyID uuid.UUID) error {
+	args := m.Called(userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) DeleteByUser(userID uuid.UUID) int {
+	args := m.Called(userID)
+	return args.Int(0)
+}
+
+func (m *MockAPIKeyRepository) Touch(keyID uuid.UUID, at time.Time) {
+	m.Called(keyID, at)
+}
+
+func TestResetPasswordHandler_Handle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	valid := token.ResetRecord{UserID: userID, CreatedAt: now.Add(-time.Minute), Expiry: now.Add(time.Minute)}
+	active := &user.User{ID: userID, Username: "alice", Status: user.StatusActive}
+
+	tests := []struct {
+		name          string
+		req           commands.ResetPasswordRequest
+		setupMocks    func(u *MockUserRepository, r *MockResetRepository, rr *MockRefreshRepository, k *MockAPIKeyRepository)
+		expectedError error
+	}{
+		{
+			name: "happy path - all sessions and api keys revoked",
+			req:  commands.ResetPasswordRequest{Token: "tok", NewPassword: "brand new password"},
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, rr *MockRefreshRepository, k *MockAPIKeyRepository) {
+				r.On("Consume", "tok").Return(valid, true)
+				u.On("GetByID", userID).Return(active, nil)
+				u.On("UpdatePassword", userID, "brand new password").Return(nil)
+				r.On("DeleteByUser", userID).Return(0)
+				rr.On("DeleteByUser", userID).Return(2)
+				k.On("DeleteByUser", userID).Return(1)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "disabled user cannot reset",
+			req:  commands.ResetPasswordRequest{Token: "tok", NewPassword: "brand new password"},
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, rr *MockRefreshRepository, k *MockAPIKeyRepository) {
+				r.On("Consume", "tok").Return(valid, true)
+				u.On("GetByID", userID).Return(&user.User{ID: userID, Username: "alice", Status: user.StatusDisabled}, nil)
+			},
+			expectedError: commands.ErrInvalidResetToken,
+		},
+		{
+			name: "unknown or used token",
+			req:  commands.ResetPasswordRequest{Token: "tok", NewPassword: "brand new password"},
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, rr *MockRefreshRepository, k *MockAPIKeyRepository) {
+				r.On("Consume", "tok").Return(token.ResetRecord{}, false)
+			},
+			expectedError: commands.ErrInvalidResetToken,
+		},
+		{
+			name: "expired token",
+			req:  commands.ResetPasswordRequest{Token: "tok", NewPassword: "brand new password"},
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, rr *MockRefreshRepository, k *MockAPIKeyRepository) {
+				expired := valid
+				expired.Expiry = now.Add(-time.Second)
+				r.On("Consume", "tok").Return(expired, true)
+			},
+			expectedError: commands.ErrInvalidResetToken,
+		},
+		{
+			name: "weak password keeps the token",
+			req:  commands.ResetPasswordRequest{Token: "tok", NewPassword: "short"},
+			setupMocks: func(u *MockUserRepository, r *MockResetRepository, rr *MockRefreshRepository, k *MockAPIKeyRepository) {
+			},
+			expectedError: user.ErrWeakPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockResets := &MockResetRepository{}
+			mockRefresh := &MockRefreshRepository{}
+			mockKeys := &MockAPIKeyRepository{}
+			tt.setupMocks(mockUsers, mockResets, mockRefresh, mockKeys)
+			mockTime := &timeprovider.MockProvider{}
+			mockTime.On("Now").Maybe().Return(now)
+
+			handler := commands.NewResetPasswordHandler(mockUsers, mockResets, mockRefresh, mockKeys, testPolicy, mockTime)
+
+			err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockResets.AssertExpectations(t)
+			mockRefresh.AssertExpectations(t)
+			mockKeys.AssertExpectations(t)
+		})
+	}
+}
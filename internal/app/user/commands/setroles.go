@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// ErrSelfLockout is returned when an admin would disable themselves or drop their own admin role
+var ErrSelfLockout = errors.New("admins cannot disable themselves or remove their own admin role")
+
+// SetUserRolesRequest represents an admin replacing the roles of a user
+type SetUserRolesRequest struct {
+	ActorID uuid.UUID
+	UserID  uuid.UUID
+	Roles   []string
+	Source  audit.Source
+}
+
+// SetUserRolesHandler interface
+type SetUserRolesHandler interface {
+	Handle(req SetUserRolesRequest) (*queries.UserSummary, error)
+}
+
+type setUserRolesHandler struct {
+	repo  user.Repository
+	audit auditlog.Recorder
+}
+
+// NewSetUserRolesHandler constructor
+func NewSetUserRolesHandler(repo user.Repository, recorder auditlog.Recorder) SetUserRolesHandler {
+	return &setUserRolesHandler{repo: repo, audit: recorder}
+}
+
+// Handle replaces the roles. They apply to new access tokens, at the latest on the next refresh.
+func (h *setUserRolesHandler) Handle(req SetUserRolesRequest) (*queries.UserSummary, error) {
+	roles, err := user.NormalizeRoles(req.Roles)
+	if err != nil {
+		return nil, err
+	}
+	if req.ActorID == req.UserID && !containsRole(roles, user.RoleAdmin) {
+		return nil, ErrSelfLockout
+	}
+
+	u, err := h.repo.GetByID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.repo.UpdateRoles(u.ID, roles); err != nil {
+		return nil, fmt.Errorf("failed to update roles: %w", err)
+	}
+
+	before := queries.NewUserSummary(u)
+	u.Roles = roles
+	summary := queries.NewUserSummary(u)
+
+	entry := audit.NewEntry(req.Source, audit.ActionUserSetRoles, audit.Target{Type: audit.TargetUser, ID: u.ID.String()})
+	entry.Changes = audit.Diff(before, summary)
+	if err := h.audit.Record(entry); err != nil {
+		return nil, fmt.Errorf("roles updated but %w", err)
+	}
+	return &summary, nil
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
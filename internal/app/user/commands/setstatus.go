@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// SetUserStatusRequest represents an admin disabling or re-enabling a user
+type SetUserStatusRequest struct {
+	ActorID uuid.UUID
+	UserID  uuid.UUID
+	Status  user.Status
+	Source  audit.Source
+}
+
+// SetUserStatusHandler interface
+type SetUserStatusHandler interface {
+	Handle(req SetUserStatusRequest) (*queries.UserSummary, error)
+}
+
+type setUserStatusHandler struct {
+	repo        user.Repository
+	refreshRepo token.RefreshRepository
+	audit       auditlog.Recorder
+}
+
+// NewSetUserStatusHandler constructor
+func NewSetUserStatusHandler(repo user.Repository, refreshRepo token.RefreshRepository, recorder auditlog.Recorder) SetUserStatusHandler {
+	return &setUserStatusHandler{repo: repo, refreshRepo: refreshRepo, audit: recorder}
+}
+
+// Handle sets the status. Disabling also revokes every session, so only access tokens
+// already issued keep working until they expire.
+func (h *setUserStatusHandler) Handle(req SetUserStatusRequest) (*queries.UserSummary, error) {
+	if !req.Status.Valid() {
+		return nil, user.ErrInvalidStatus
+	}
+	if req.ActorID == req.UserID && req.Status == user.StatusDisabled {
+		return nil, ErrSelfLockout
+	}
+
+	u, err := h.repo.GetByID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.repo.SetStatus(u.ID, req.Status); err != nil {
+		return nil, fmt.Errorf("failed to set status: %w", err)
+	}
+	if req.Status == user.StatusDisabled {
+		h.refreshRepo.DeleteByUser(u.ID)
+	}
+
+	before := queries.NewUserSummary(u)
+	u.Status = req.Status
+	summary := queries.NewUserSummary(u)
+
+	action := audit.ActionUserEnable
+	if req.Status == user.StatusDisabled {
+		action = audit.ActionUserDisable
+	}
+	entry := audit.NewEntry(req.Source, action, audit.Target{Type: audit.TargetUser, ID: u.ID.String()})
+	entry.Changes = audit.Diff(before, summary)
+	if err := h.audit.Record(entry); err != nil {
+		return nil, fmt.Errorf("status updated but %w", err)
+	}
+	return &summary, nil
+}
@@ -0,0 +1,163 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetUserStatusHandler_Handle(t *testing.T) {
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		req           commands.SetUserStatusRequest
+		setupMocks    func(users *MockUserRepository, refresh *MockRefreshRepository)
+		expectedError error
+	}{
+		{
+			name: "disable revokes sessions",
+			req:  commands.SetUserStatusRequest{ActorID: adminID, UserID: userID, Status: user.StatusDisabled},
+			setupMocks: func(users *MockUserRepository, refresh *MockRefreshRepository) {
+				users.On("GetByID", userID).Return(&user.User{ID: userID, Username: "carol", Status: user.StatusActive}, nil)
+				users.On("SetStatus", userID, user.StatusDisabled).Return(nil)
+				refresh.On("DeleteByUser", userID).Return(2)
+			},
+		},
+		{
+			name: "enable keeps sessions",
+			req:  commands.SetUserStatusRequest{ActorID: adminID, UserID: userID, Status: user.StatusActive},
+			setupMocks: func(users *MockUserRepository, refresh *MockRefreshRepository) {
+				users.On("GetByID", userID).Return(&user.User{ID: userID, Username: "carol", Status: user.StatusDisabled}, nil)
+				users.On("SetStatus", userID, user.StatusActive).Return(nil)
+			},
+		},
+		{
+			name:          "admin cannot disable themselves",
+			req:           commands.SetUserStatusRequest{ActorID: adminID, UserID: adminID, Status: user.StatusDisabled},
+			setupMocks:    func(users *MockUserRepository, refresh *MockRefreshRepository) {},
+			expectedError: commands.ErrSelfLockout,
+		},
+		{
+			name:          "invalid status",
+			req:           commands.SetUserStatusRequest{ActorID: adminID, UserID: userID, Status: "banned"},
+			setupMocks:    func(users *MockUserRepository, refresh *MockRefreshRepository) {},
+			expectedError: user.ErrInvalidStatus,
+		},
+		{
+			name: "unknown user",
+			req:  commands.SetUserStatusRequest{ActorID: adminID, UserID: userID, Status: user.StatusDisabled},
+			setupMocks: func(users *MockUserRepository, refresh *MockRefreshRepository) {
+				users.On("GetByID", userID).Return(nil, user.ErrUserNotFound)
+			},
+			expectedError: user.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &MockUserRepository{}
+			refresh := &MockRefreshRepository{}
+			tt.setupMocks(users, refresh)
+
+			trail := &auditlogtest.Trail{}
+
+			handler := commands.NewSetUserStatusHandler(users, refresh, trail)
+
+			result, err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Nil(t, result)
+				assert.Empty(t, trail.Entries)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, string(tt.req.Status), result.Status)
+				if assert.Len(t, trail.Entries, 1) {
+					assert.Equal(t, userID.String(), trail.Entries[0].Target.ID)
+					assert.Contains(t, trail.Entries[0].Changes, "status")
+				}
+			}
+
+			users.AssertExpectations(t)
+			refresh.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetUserRolesHandler_Handle(t *testing.T) {
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		req           commands.SetUserRolesRequest
+		setupMock     func(m *MockUserRepository)
+		expectedRoles []string
+		expectedError error
+	}{
+		{
+			name: "roles are normalized",
+			req:  commands.SetUserRolesRequest{ActorID: adminID, UserID: userID, Roles: []string{"user", "admin", "user"}},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", userID).Return(&user.User{ID: userID, Username: "carol", Roles: []string{"user"}}, nil)
+				m.On("UpdateRoles", userID, []string{"admin", "user"}).Return(nil)
+			},
+			expectedRoles: []string{"admin", "user"},
+		},
+		{
+			name: "admin keeping their admin role",
+			req:  commands.SetUserRolesRequest{ActorID: adminID, UserID: adminID, Roles: []string{"admin"}},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", adminID).Return(&user.User{ID: adminID, Username: "root", Roles: []string{"admin", "user"}}, nil)
+				m.On("UpdateRoles", adminID, []string{"admin"}).Return(nil)
+			},
+			expectedRoles: []string{"admin"},
+		},
+		{
+			name:          "admin cannot drop their own admin role",
+			req:           commands.SetUserRolesRequest{ActorID: adminID, UserID: adminID, Roles: []string{"user"}},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: commands.ErrSelfLockout,
+		},
+		{
+			name:          "invalid roles",
+			req:           commands.SetUserRolesRequest{ActorID: adminID, UserID: userID, Roles: []string{"Super User"}},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: user.ErrInvalidRoles,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+
+			trail := &auditlogtest.Trail{}
+
+			handler := commands.NewSetUserRolesHandler(mockRepo, trail)
+
+			result, err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Nil(t, result)
+				assert.Empty(t, trail.Entries)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRoles, result.Roles)
+				if assert.Len(t, trail.Entries, 1) {
+					assert.Equal(t, audit.ActionUserSetRoles, trail.Entries[0].Action)
+				}
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// UpdateEmailRequest represents the authenticated user changing their email address
+type UpdateEmailRequest struct {
+	UserID          uuid.UUID
+	Email           string
+	CurrentPassword string
+}
+
+// UpdateEmailHandler interface
+type UpdateEmailHandler interface {
+	Handle(req UpdateEmailRequest) error
+}
+
+type updateEmailHandler struct {
+	repo   user.Repository
+	hasher user.PasswordHasher
+}
+
+// NewUpdateEmailHandler constructor
+func NewUpdateEmailHandler(repo user.Repository, hasher user.PasswordHasher) UpdateEmailHandler {
+	return &updateEmailHandler{repo: repo, hasher: hasher}
+}
+
+// Handle verifies the current password and replaces the email address. Password reset tokens
+// are sent to this address, so a stolen session alone must not be enough to change it.
+func (h *updateEmailHandler) Handle(req UpdateEmailRequest) error {
+	email := user.NormalizeEmail(req.Email)
+	if err := user.ValidateEmail(email); err != nil {
+		return err
+	}
+
+	u, err := h.repo.GetByID(req.UserID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := h.hasher.Verify(u.Password, req.CurrentPassword); err != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	if err := h.repo.UpdateEmail(u.ID, email); err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUpdateEmailHandler_Handle(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("current password"), bcrypt.MinCost)
+	existing := &user.User{ID: uuid.New(), Username: "alice", Password: string(hashed)}
+	hasher, _ := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+
+	tests := []struct {
+		name          string
+		req           commands.UpdateEmailRequest
+		setupMock     func(m *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			req:  commands.UpdateEmailRequest{UserID: existing.ID, Email: " alice@example.com ", CurrentPassword: "current password"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", existing.ID).Return(existing, nil)
+				m.On("UpdateEmail", existing.ID, "alice@example.com").Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "removing the address",
+			req:  commands.UpdateEmailRequest{UserID: existing.ID, Email: "", CurrentPassword: "current password"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", existing.ID).Return(existing, nil)
+				m.On("UpdateEmail", existing.ID, "").Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "wrong current password",
+			req:  commands.UpdateEmailRequest{UserID: existing.ID, Email: "alice@example.com", CurrentPassword: "wrong"},
+			setupMock: func(m *MockUserRepository) {
+				m.On("GetByID", existing.ID).Return(existing, nil)
+			},
+			expectedError: commands.ErrInvalidCurrentPassword,
+		},
+		{
+			name:          "invalid address",
+			req:           commands.UpdateEmailRequest{UserID: existing.ID, Email: "Alice <alice@example.com>", CurrentPassword: "current password"},
+			setupMock:     func(m *MockUserRepository) {},
+			expectedError: user.ErrInvalidEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+
+			handler := commands.NewUpdateEmailHandler(mockRepo, hasher)
+
+			err := handler.Handle(tt.req)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
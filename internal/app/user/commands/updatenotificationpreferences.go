@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// UpdateNotificationPreferencesRequest represents the authenticated user replacing their notification preferences
+type UpdateNotificationPreferencesRequest struct {
+	UserID      uuid.UUID
+	Preferences notification.Preferences
+}
+
+// UpdateNotificationPreferencesHandler interface
+type UpdateNotificationPreferencesHandler interface {
+	Handle(req UpdateNotificationPreferencesRequest) (*notification.Preferences, error)
+}
+
+type updateNotificationPreferencesHandler struct {
+	repo         notification.PreferenceRepository
+	timeProvider time.Provider
+}
+
+// NewUpdateNotificationPreferencesHandler constructor
+func NewUpdateNotificationPreferencesHandler(repo notification.PreferenceRepository, tp time.Provider) UpdateNotificationPreferencesHandler {
+	return &updateNotificationPreferencesHandler{repo: repo, timeProvider: tp}
+}
+
+// Handle validates and stores the preferences, which apply to the next notification
+func (h *updateNotificationPreferencesHandler) Handle(req UpdateNotificationPreferencesRequest) (*notification.Preferences, error) {
+	p := req.Preferences
+	p.UserID = req.UserID
+	if p.Locale == "" {
+		p.Locale = notification.DefaultLocale
+	}
+	if p.Digest == "" {
+		p.Digest = notification.DigestOff
+	}
+	if p.Channels == nil {
+		p.Channels = []string{}
+	}
+	if p.MutedEvents == nil {
+		p.MutedEvents = []string{}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	p.UpdatedAt = h.timeProvider.Now().UTC()
+	if err := h.repo.Save(p); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,70 @@
+package commands_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// preferenceStore keeps the saved preferences by user
+type preferenceStore map[uuid.UUID]notification.Preferences
+
+func (s preferenceStore) Get(userID uuid.UUID) (notification.Preferences, error) {
+	p, ok := s[userID]
+	if !ok {
+		return notification.Preferences{}, notification.ErrPreferencesNotFound
+	}
+	return p, nil
+}
+
+func (s preferenceStore) Save(p notification.Preferences) error {
+	s[p.UserID] = p
+	return nil
+}
+
+func TestUpdateNotificationPreferencesHandler_Handle(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(now)
+	repo := preferenceStore{}
+	handler := commands.NewUpdateNotificationPreferencesHandler(repo, tp)
+	userID := uuid.New()
+
+	saved, err := handler.Handle(commands.UpdateNotificationPreferencesRequest{
+		UserID: userID,
+		Preferences: notification.Preferences{
+			Channels:    []string{notification.ChannelEmail},
+			MutedEvents: []string{notification.EventFavoriteAdded},
+			QuietHours:  &notification.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Athens"},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, userID, saved.UserID)
+	assert.Equal(t, notification.DefaultLocale, saved.Locale, "the default locale")
+	assert.Equal(t, notification.DigestOff, saved.Digest, "a notification per favourite")
+	assert.Equal(t, now, saved.UpdatedAt)
+	assert.Equal(t, *saved, repo[userID])
+
+	saved, err = handler.Handle(commands.UpdateNotificationPreferencesRequest{UserID: userID, Preferences: notification.Preferences{Locale: "el-GR"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{}, saved.Channels, "security notifications only")
+	assert.Equal(t, []string{}, saved.MutedEvents)
+	assert.Nil(t, repo[userID].QuietHours, "the preferences are replaced")
+
+	_, err = handler.Handle(commands.UpdateNotificationPreferencesRequest{
+		UserID:      userID,
+		Preferences: notification.Preferences{MutedEvents: []string{notification.EventPasswordResetRequest}},
+	})
+	assert.ErrorIs(t, err, notification.ErrInvalidPreferences)
+	assert.Equal(t, "el-GR", repo[userID].Locale, "invalid preferences are not saved")
+}
@@ -0,0 +1,34 @@
+package queries
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+)
+
+// GetNotificationPreferencesHandler interface
+type GetNotificationPreferencesHandler interface {
+	Handle(userID uuid.UUID) (*notification.Preferences, error)
+}
+
+type getNotificationPreferencesHandler struct {
+	repo notification.PreferenceRepository
+}
+
+// NewGetNotificationPreferencesHandler constructor
+func NewGetNotificationPreferencesHandler(repo notification.PreferenceRepository) GetNotificationPreferencesHandler {
+	return &getNotificationPreferencesHandler{repo: repo}
+}
+
+// Handle returns the preferences of the user, the defaults when they never saved any
+func (h *getNotificationPreferencesHandler) Handle(userID uuid.UUID) (*notification.Preferences, error) {
+	p, err := h.repo.Get(userID)
+	if errors.Is(err, notification.ErrPreferencesNotFound) {
+		p = notification.DefaultPreferences(userID)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read notification preferences: %w", err)
+	}
+	return &p, nil
+}
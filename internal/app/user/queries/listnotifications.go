@@ -0,0 +1,67 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+)
+
+// ListNotificationsRequest represents the authenticated user reading a page of their inbox
+type ListNotificationsRequest struct {
+	UserID     uuid.UUID
+	UnreadOnly bool
+	Offset     int
+	Limit      int
+}
+
+// ListNotificationsResult represents one page of an inbox, with the unread count of the whole inbox
+type ListNotificationsResult struct {
+	Notifications []notification.InboxItem `json:"notifications"`
+	Total         int                      `json:"total"`
+	Unread        int                      `json:"unread"`
+	Offset        int                      `json:"offset"`
+	Limit         int                      `json:"limit"`
+}
+
+// ListNotificationsHandler interface
+type ListNotificationsHandler interface {
+	Handle(req ListNotificationsRequest) (*ListNotificationsResult, error)
+}
+
+type listNotificationsHandler struct {
+	repo notification.InboxRepository
+}
+
+// NewListNotificationsHandler constructor
+func NewListNotificationsHandler(repo notification.InboxRepository) ListNotificationsHandler {
+	return &listNotificationsHandler{repo: repo}
+}
+
+// Handle returns the requested page of the inbox, newest notifications first
+func (h *listNotificationsHandler) Handle(req ListNotificationsRequest) (*ListNotificationsResult, error) {
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+	if req.Limit <= 0 {
+		req.Limit = DefaultPageLimit
+	}
+	if req.Limit > MaxPageLimit {
+		req.Limit = MaxPageLimit
+	}
+
+	items, total, err := h.repo.List(notification.InboxQuery{
+		UserID:     req.UserID,
+		UnreadOnly: req.UnreadOnly,
+		Offset:     req.Offset,
+		Limit:      req.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	unread, err := h.repo.Unread(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return &ListNotificationsResult{Notifications: items, Total: total, Unread: unread, Offset: req.Offset, Limit: req.Limit}, nil
+}
@@ -0,0 +1,111 @@
+package queries
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPageLimit is the page size when none is requested
+	DefaultPageLimit = 20
+	// MaxPageLimit caps the page size
+	MaxPageLimit = 100
+)
+
+// ListUsersRequest represents a search of the users by an admin
+type ListUsersRequest struct {
+	Search string
+	Role   string
+	Status string
+	Offset int
+	Limit  int
+}
+
+// UserSummary represents a user as seen by an admin
+type UserSummary struct {
+	ID         uuid.UUID `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email,omitempty"`
+	Roles      []string  `json:"roles"`
+	Status     string    `json:"status"`
+	MFAEnabled bool      `json:"mfa_enabled"`
+	Federated  bool      `json:"federated"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListUsersResult represents one page of users
+type ListUsersResult struct {
+	Users  []UserSummary `json:"users"`
+	Total  int           `json:"total"`
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+}
+
+// ListUsersHandler interface
+type ListUsersHandler interface {
+	Handle(req ListUsersRequest) (*ListUsersResult, error)
+}
+
+type listUsersHandler struct {
+	repo user.Repository
+}
+
+// NewListUsersHandler constructor
+func NewListUsersHandler(repo user.Repository) ListUsersHandler {
+	return &listUsersHandler{repo: repo}
+}
+
+// Handle returns the requested page of the users matching the filters, ordered by username
+func (h *listUsersHandler) Handle(req ListUsersRequest) (*ListUsersResult, error) {
+	status := user.Status(req.Status)
+	if status != "" && !status.Valid() {
+		return nil, user.ErrInvalidStatus
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+	if req.Limit <= 0 {
+		req.Limit = DefaultPageLimit
+	}
+	if req.Limit > MaxPageLimit {
+		req.Limit = MaxPageLimit
+	}
+
+	users, total, err := h.repo.List(user.ListQuery{
+		Search: req.Search,
+		Role:   req.Role,
+		Status: status,
+		Offset: req.Offset,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	result := &ListUsersResult{Users: make([]UserSummary, 0, len(users)), Total: total, Offset: req.Offset, Limit: req.Limit}
+	for _, u := range users {
+		result.Users = append(result.Users, NewUserSummary(u))
+	}
+	return result, nil
+}
+
+// NewUserSummary maps a user to its admin view
+func NewUserSummary(u *user.User) UserSummary {
+	status := u.Status
+	if status == "" {
+		status = user.StatusActive
+	}
+	return UserSummary{
+		ID:         u.ID,
+		Username:   u.Username,
+		Email:      u.Email,
+		Roles:      u.Roles,
+		Status:     string(status),
+		MFAEnabled: u.TOTP.Enabled,
+		Federated:  len(u.Identities) > 0,
+		CreatedAt:  u.CreatedAt,
+	}
+}
@@ -0,0 +1,184 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// Poster POSTs a body to a webhook endpoint and returns the status code of the response.
+// Errors are for requests that got no response at all.
+type Poster interface {
+	Post(url string, headers map[string]string, body []byte) (int, error)
+}
+
+// Config paces the deliveries and their retries
+type Config struct {
+	// Interval is how often the due deliveries are sent
+	Interval gotime.Duration
+	// BatchSize is how many deliveries are sent per interval
+	BatchSize int
+	// MaxAttempts is how many times a delivery is attempted before it fails
+	MaxAttempts int
+	// Retry is the delay between the attempts of a delivery
+	Retry backoff.Exponential
+	// DisableAfter is how many consecutive failed attempts disable a subscription, never when 0
+	DisableAfter int
+	// Retention is how long completed deliveries are kept in the delivery log
+	Retention gotime.Duration
+}
+
+// Deliverer sends the pending deliveries, retrying failed attempts with exponential backoff
+// and disabling the subscriptions that keep failing
+type Deliverer struct {
+	repo         webhook.Repository
+	poster       Poster
+	cipher       helper.SecretCipher
+	cfg          Config
+	timeProvider time.Provider
+}
+
+// NewDeliverer constructor
+func NewDeliverer(repo webhook.Repository, poster Poster, cipher helper.SecretCipher, cfg Config, tp time.Provider) *Deliverer {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &Deliverer{repo: repo, poster: poster, cipher: cipher, cfg: cfg, timeProvider: tp}
+}
+
+// Run sends the due deliveries on every tick until the context is cancelled
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := gotime.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Deliver(); err != nil {
+				log.Printf("webhook deliverer: %v", err)
+			}
+		}
+	}
+}
+
+// Deliver sends one batch of due deliveries, returning how many succeeded.
+// Failing endpoints do not fail the batch, their deliveries are scheduled for a retry.
+func (d *Deliverer) Deliver() (int, error) {
+	deliveries, err := d.repo.DueDeliveries(d.timeProvider.Now().UTC(), d.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the due webhook deliveries: %w", err)
+	}
+
+	succeeded := 0
+	for _, delivery := range deliveries {
+		ok, err := d.attempt(delivery)
+		if err != nil {
+			return succeeded, err
+		}
+		if ok {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// PurgeDeliveries removes the deliveries completed longer than the retention ago
+func (d *Deliverer) PurgeDeliveries() (int, error) {
+	return d.repo.DeleteCompletedDeliveries(d.timeProvider.Now().UTC().Add(-d.cfg.Retention))
+}
+
+// attempt sends the delivery once and records the outcome on the delivery and its subscription
+func (d *Deliverer) attempt(delivery webhook.Delivery) (bool, error) {
+	s, err := d.repo.Get(delivery.SubscriptionID)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		return false, nil // deleted with its deliveries since they were read
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read webhook subscription %s: %w", delivery.SubscriptionID, err)
+	}
+
+	now := d.timeProvider.Now().UTC()
+	if s.Status != webhook.StatusActive {
+		delivery.Status, delivery.CompletedAt = webhook.DeliveryFailed, &now
+		delivery.LastError = "subscription disabled"
+		return false, d.updateDelivery(delivery)
+	}
+
+	secret, err := d.cipher.Decrypt(s.Secret)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt the secret of webhook subscription %s: %w", s.ID, err)
+	}
+	headers := map[string]string{
+		"Content-Type":          "application/json",
+		webhook.SignatureHeader: webhook.Sign(secret, now, delivery.Payload),
+		webhook.EventHeader:     delivery.EventType,
+		webhook.IDHeader:        delivery.EventID.String(),
+	}
+	status, postErr := d.poster.Post(s.URL, headers, delivery.Payload)
+
+	delivery.Attempts++
+	delivery.LastStatusCode = status
+	if postErr == nil && status >= 200 && status < 300 {
+		delivery.Status, delivery.CompletedAt, delivery.LastError = webhook.DeliverySucceeded, &now, ""
+		if err := d.updateDelivery(delivery); err != nil {
+			return false, err
+		}
+		if s.ConsecutiveFailures > 0 {
+			return true, d.recordAttempt(s.ID, true, "", now)
+		}
+		return true, nil
+	}
+
+	if postErr != nil {
+		delivery.LastError = postErr.Error()
+	} else {
+		delivery.LastError = fmt.Sprintf("unexpected status %d", status)
+	}
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status, delivery.CompletedAt = webhook.DeliveryFailed, &now
+	} else {
+		delivery.NextAttemptAt = now.Add(d.cfg.Retry.Delay(delivery.Attempts))
+	}
+	log.Printf("webhook delivery %s of event %s to %s failed attempt %d: %s", delivery.ID, delivery.EventID, s.ID, delivery.Attempts, delivery.LastError)
+	if err := d.updateDelivery(delivery); err != nil {
+		return false, err
+	}
+
+	return false, d.recordAttempt(s.ID, false, delivery.LastError, now)
+}
+
+func (d *Deliverer) updateDelivery(delivery webhook.Delivery) error {
+	if err := d.repo.UpdateDelivery(delivery); err != nil {
+		return fmt.Errorf("failed to record webhook delivery %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// recordAttempt counts the attempt on the subscription as stored now, not as read before the
+// attempt, so an admin editing the subscription meanwhile does not lose the edit
+func (d *Deliverer) recordAttempt(id uuid.UUID, ok bool, lastError string, now gotime.Time) error {
+	disabled, err := d.repo.RecordAttempt(id, ok, d.cfg.DisableAfter, lastError, now)
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record webhook subscription %s: %w", id, err)
+	}
+	if disabled {
+		log.Printf("webhook subscription %s disabled after %d consecutive failed deliveries", id, d.cfg.DisableAfter)
+	}
+	return nil
+}
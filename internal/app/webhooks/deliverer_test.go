@@ -0,0 +1,390 @@
+package webhooks_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// store keeps subscriptions and deliveries in insertion order
+type store struct {
+	subscriptions []webhook.Subscription
+	deliveries    []webhook.Delivery
+}
+
+func (s *store) Add(sub webhook.Subscription) error {
+	s.subscriptions = append(s.subscriptions, sub)
+	return nil
+}
+
+func (s *store) Get(id uuid.UUID) (webhook.Subscription, error) {
+	for _, sub := range s.subscriptions {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return webhook.Subscription{}, webhook.ErrSubscriptionNotFound
+}
+
+func (s *store) List() ([]webhook.Subscription, error) {
+	return append([]webhook.Subscription(nil), s.subscriptions...), nil
+}
+
+func (s *store) Update(sub webhook.Subscription) error {
+	for i := range s.subscriptions {
+		if s.subscriptions[i].ID == sub.ID {
+			s.subscriptions[i] = sub
+			return nil
+		}
+	}
+	return webhook.ErrSubscriptionNotFound
+}
+
+func (s *store) RecordAttempt(id uuid.UUID, ok bool, disableAfter int, lastError string, at time.Time) (bool, error) {
+	for i := range s.subscriptions {
+		if s.subscriptions[i].ID == id {
+			return s.subscriptions[i].RecordAttempt(ok, disableAfter, lastError, at), nil
+		}
+	}
+	return false, webhook.ErrSubscriptionNotFound
+}
+
+func (s *store) Delete(id uuid.UUID) error {
+	return errors.New("not implemented")
+}
+
+func (s *store) Enqueue(d webhook.Delivery) (bool, error) {
+	for _, existing := range s.deliveries {
+		if existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID {
+			return false, nil
+		}
+	}
+	s.deliveries = append(s.deliveries, d)
+	return true, nil
+}
+
+func (s *store) DueDeliveries(now time.Time, limit int) ([]webhook.Delivery, error) {
+	var due []webhook.Delivery
+	for _, d := range s.deliveries {
+		if d.Status == webhook.DeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (s *store) UpdateDelivery(d webhook.Delivery) error {
+	for i := range s.deliveries {
+		if s.deliveries[i].ID == d.ID {
+			s.deliveries[i] = d
+			return nil
+		}
+	}
+	return webhook.ErrDeliveryNotFound
+}
+
+func (s *store) Deliveries(subscriptionID uuid.UUID, limit int) ([]webhook.Delivery, error) {
+	return nil, nil
+}
+
+func (s *store) DeleteCompletedDeliveries(before time.Time) (int, error) {
+	return 0, nil
+}
+
+// poster answers with the queued statuses, then with 200, and keeps the requests
+type poster struct {
+	statuses []int
+	requests []request
+	// inFlight runs while a request is being sent
+	inFlight func()
+}
+
+type request struct {
+	url     string
+	headers map[string]string
+	body    []byte
+}
+
+func (p *poster) Post(url string, headers map[string]string, body []byte) (int, error) {
+	p.requests = append(p.requests, request{url: url, headers: headers, body: body})
+	if p.inFlight != nil {
+		p.inFlight()
+	}
+	if len(p.statuses) == 0 {
+		return 200, nil
+	}
+	status := p.statuses[0]
+	p.statuses = p.statuses[1:]
+	if status == 0 {
+		return 0, errors.New("connection refused")
+	}
+	return status, nil
+}
+
+// clock is a time provider the tests move forward
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+type fixture struct {
+	repo   *store
+	poster *poster
+	clock  *clock
+	cipher helper.SecretCipher
+	events eventbus.Handler
+}
+
+func newFixture(t *testing.T) *fixture {
+	cipher, err := helper.NewSecretCipher([]byte(strings.Repeat("k", 32)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	f := &fixture{repo: &store{}, poster: &poster{}, clock: &clock{now: time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)}, cipher: cipher}
+	f.events = webhooks.NewEventHandler(f.repo, uuidprovider.NewUUIDProvider(), f.clock)
+	return f
+}
+
+func (f *fixture) subscribe(t *testing.T, url string, events ...string) webhook.Subscription {
+	secret, err := f.cipher.Encrypt("0123456789abcdef")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	s := webhook.Subscription{ID: uuid.New(), URL: url, Events: events, Secret: secret, Status: webhook.StatusActive}
+	if !assert.NoError(t, f.repo.Add(s)) {
+		t.FailNow()
+	}
+	return s
+}
+
+func (f *fixture) deliverer(cfg webhooks.Config) *webhooks.Deliverer {
+	return webhooks.NewDeliverer(f.repo, f.poster, f.cipher, cfg, f.clock)
+}
+
+func (f *fixture) publish(t *testing.T, e event.Event) eventbus.Envelope {
+	envelope := eventbus.Envelope{ID: uuid.New(), OccurredAt: f.clock.now, Event: e}
+	if !assert.NoError(t, f.events.Handle(envelope)) {
+		t.FailNow()
+	}
+	return envelope
+}
+
+func TestEventHandler_EnqueuesPerSubscription(t *testing.T) {
+	f := newFixture(t)
+	all := f.subscribe(t, "https://all.example.com")
+	deletes := f.subscribe(t, "https://deletes.example.com", event.FavoriteDeletedName)
+	disabled := f.subscribe(t, "https://disabled.example.com")
+	disabled.Status = webhook.StatusDisabled
+	if !assert.NoError(t, f.repo.Update(disabled)) {
+		return
+	}
+
+	userID := uuid.New()
+	envelope := f.publish(t, event.FavoriteAdded{UserID: userID, Favorite: favourite.Favorite{Description: "chart"}})
+	if !assert.NoError(t, f.events.Handle(envelope), "a redelivered event") {
+		return
+	}
+
+	if !assert.Len(t, f.repo.deliveries, 1) {
+		return
+	}
+	d := f.repo.deliveries[0]
+	assert.Equal(t, all.ID, d.SubscriptionID)
+	assert.Equal(t, envelope.ID, d.EventID)
+	assert.Equal(t, webhook.DeliveryPending, d.Status)
+
+	var payload map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(d.Payload, &payload)) {
+		return
+	}
+	assert.Equal(t, envelope.ID.String(), payload["id"])
+	assert.Equal(t, event.FavoriteAddedName, payload["type"])
+	assert.Equal(t, userID.String(), payload["data"].(map[string]interface{})["user_id"])
+
+	f.publish(t, event.FavoriteDeleted{UserID: userID})
+	if !assert.Len(t, f.repo.deliveries, 3) {
+		return
+	}
+	assert.ElementsMatch(t, []uuid.UUID{all.ID, deletes.ID}, []uuid.UUID{f.repo.deliveries[1].SubscriptionID, f.repo.deliveries[2].SubscriptionID})
+}
+
+func TestDeliverer_SignsAndSends(t *testing.T) {
+	f := newFixture(t)
+	s := f.subscribe(t, "https://partner.example.com/hooks")
+	envelope := f.publish(t, event.FavoriteAdded{UserID: uuid.New()})
+
+	sent, err := f.deliverer(webhooks.Config{BatchSize: 10, MaxAttempts: 3}).Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, sent)
+
+	if !assert.Len(t, f.poster.requests, 1) {
+		return
+	}
+	req := f.poster.requests[0]
+	assert.Equal(t, s.URL, req.url)
+	assert.Equal(t, event.FavoriteAddedName, req.headers[webhook.EventHeader])
+	assert.Equal(t, envelope.ID.String(), req.headers[webhook.IDHeader])
+	assert.NoError(t, webhook.Verify("0123456789abcdef", req.headers[webhook.SignatureHeader], req.body, f.clock.now, time.Minute))
+
+	d := f.repo.deliveries[0]
+	assert.Equal(t, webhook.DeliverySucceeded, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, 200, d.LastStatusCode)
+	assert.Equal(t, f.clock.now, *d.CompletedAt)
+}
+
+func TestDeliverer_RetriesWithBackoff(t *testing.T) {
+	f := newFixture(t)
+	f.subscribe(t, "https://partner.example.com/hooks")
+	f.publish(t, event.FavoriteAdded{UserID: uuid.New()})
+	f.poster.statuses = []int{500, 0, 503}
+	d := f.deliverer(webhooks.Config{BatchSize: 10, MaxAttempts: 3, Retry: backoff.Exponential{Base: time.Minute, Max: time.Hour}})
+
+	start := f.clock.now
+	_, err := d.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	delivery := f.repo.deliveries[0]
+	assert.Equal(t, webhook.DeliveryPending, delivery.Status)
+	assert.Equal(t, "unexpected status 500", delivery.LastError)
+	assert.Equal(t, start.Add(time.Minute), delivery.NextAttemptAt)
+
+	_, err = d.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, f.poster.requests, 1, "not due yet")
+
+	f.clock.now = start.Add(time.Minute)
+	_, err = d.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	delivery = f.repo.deliveries[0]
+	assert.Equal(t, "connection refused", delivery.LastError)
+	assert.Equal(t, 0, delivery.LastStatusCode)
+	assert.Equal(t, f.clock.now.Add(2*time.Minute), delivery.NextAttemptAt, "doubled")
+
+	f.clock.now = f.clock.now.Add(2 * time.Minute)
+	_, err = d.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	delivery = f.repo.deliveries[0]
+	assert.Equal(t, webhook.DeliveryFailed, delivery.Status, "out of attempts")
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, 503, delivery.LastStatusCode)
+	assert.NotNil(t, delivery.CompletedAt)
+	assert.Equal(t, 3, f.repo.subscriptions[0].ConsecutiveFailures)
+	assert.Equal(t, webhook.StatusActive, f.repo.subscriptions[0].Status)
+}
+
+func TestDeliverer_DisablesFailingSubscriptions(t *testing.T) {
+	f := newFixture(t)
+	s := f.subscribe(t, "https://down.example.com")
+	healthy := f.subscribe(t, "https://up.example.com")
+	f.publish(t, event.FavoriteAdded{UserID: uuid.New()})
+	f.publish(t, event.FavoriteDeleted{UserID: uuid.New()})
+	f.poster.statuses = []int{500, 200, 500, 200}
+
+	sent, err := f.deliverer(webhooks.Config{BatchSize: 10, MaxAttempts: 5, DisableAfter: 2}).Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, sent)
+
+	disabled, err := f.repo.Get(s.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, webhook.StatusDisabled, disabled.Status)
+	assert.Equal(t, "2 consecutive failed deliveries, last: unexpected status 500", disabled.DisabledReason)
+	stillActive, err := f.repo.Get(healthy.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, webhook.StatusActive, stillActive.Status)
+
+	// the pending deliveries of a disabled subscription fail without being sent
+	_, err = f.deliverer(webhooks.Config{BatchSize: 10, MaxAttempts: 5, DisableAfter: 2}).Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, f.poster.requests, 4)
+	for _, d := range f.repo.deliveries {
+		if d.SubscriptionID == s.ID {
+			assert.Equal(t, webhook.DeliveryFailed, d.Status)
+			assert.Equal(t, "subscription disabled", d.LastError)
+		}
+	}
+}
+
+func TestDeliverer_SuccessResetsFailures(t *testing.T) {
+	f := newFixture(t)
+	s := f.subscribe(t, "https://flaky.example.com")
+	f.publish(t, event.FavoriteAdded{UserID: uuid.New()})
+	f.poster.statuses = []int{502}
+	d := f.deliverer(webhooks.Config{BatchSize: 10, MaxAttempts: 5, DisableAfter: 2})
+
+	_, err := d.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, _ := f.repo.Get(s.ID)
+	assert.Equal(t, 1, got.ConsecutiveFailures)
+
+	_, err = d.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, _ = f.repo.Get(s.ID)
+	assert.Equal(t, 0, got.ConsecutiveFailures)
+	assert.Equal(t, webhook.StatusActive, got.Status)
+}
+
+func TestDeliverer_KeepsEditsMadeDuringAnAttempt(t *testing.T) {
+	f := newFixture(t)
+	s := f.subscribe(t, "https://old.example.com")
+	f.publish(t, event.FavoriteAdded{UserID: uuid.New()})
+	f.poster.statuses = []int{500}
+
+	// an admin moves the subscription to another URL while the attempt is in flight
+	f.poster.inFlight = func() {
+		edited, err := f.repo.Get(s.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		edited.URL, edited.Events = "https://new.example.com", []string{event.FavoriteAddedName}
+		assert.NoError(t, f.repo.Update(edited))
+	}
+
+	_, err := f.deliverer(webhooks.Config{BatchSize: 10, MaxAttempts: 5, DisableAfter: 2}).Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, err := f.repo.Get(s.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "https://new.example.com", got.URL)
+	assert.Equal(t, []string{event.FavoriteAddedName}, got.Events)
+	assert.Equal(t, 1, got.ConsecutiveFailures)
+}
@@ -0,0 +1,112 @@
+// Package webhooks delivers the favourite events to the webhook subscriptions as signed JSON POSTs.
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+	googleuuid "github.com/google/uuid"
+)
+
+// Events are the events delivered to webhooks, subscriptions filter on their names
+var Events = []string{event.FavoriteAddedName, event.FavoriteUpdatedName, event.FavoriteDeletedName}
+
+// ValidateEvents checks that every name of a subscription filter is a webhook event
+func ValidateEvents(names []string) error {
+	for _, name := range names {
+		known := false
+		for _, e := range Events {
+			known = known || e == name
+		}
+		if !known {
+			return fmt.Errorf("%w %q", webhook.ErrInvalidEvent, name)
+		}
+	}
+	return nil
+}
+
+// Payload is the JSON body POSTed to the subscriptions
+type Payload struct {
+	// ID is the event ID, the same for every subscription and attempt
+	ID         googleuuid.UUID `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt gotime.Time     `json:"occurred_at"`
+	Data       event.Event     `json:"data"`
+}
+
+// NewEventHandler enqueues a delivery of the events to every active subscription that wants them.
+// The envelope ID deduplicates the deliveries, so a redelivered event is sent once per subscription.
+func NewEventHandler(repo webhook.Repository, up uuid.Provider, tp time.Provider) eventbus.Handler {
+	return eventbus.HandlerFunc(func(envelope eventbus.Envelope) error {
+		name := envelope.Event.EventName()
+		subscriptions, err := repo.List()
+		if err != nil {
+			return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+		}
+
+		var payload []byte
+		for _, s := range subscriptions {
+			if !s.Wants(name) {
+				continue
+			}
+			if payload == nil {
+				payload, err = json.Marshal(Payload{ID: envelope.ID, Type: name, OccurredAt: envelope.OccurredAt, Data: envelope.Event})
+				if err != nil {
+					return fmt.Errorf("failed to encode webhook payload: %w", err)
+				}
+			}
+
+			now := tp.Now().UTC()
+			_, err := repo.Enqueue(webhook.Delivery{
+				ID:             up.NewUUID(),
+				SubscriptionID: s.ID,
+				EventID:        envelope.ID,
+				EventType:      name,
+				Payload:        payload,
+				Status:         webhook.DeliveryPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Summary is what admins see of a subscription, never its secret
+type Summary struct {
+	ID                  googleuuid.UUID `json:"id"`
+	URL                 string          `json:"url"`
+	Events              []string        `json:"events"`
+	Status              webhook.Status  `json:"status"`
+	ConsecutiveFailures int             `json:"consecutive_failures"`
+	DisabledReason      string          `json:"disabled_reason,omitempty"`
+	CreatedAt           gotime.Time     `json:"created_at"`
+	UpdatedAt           gotime.Time     `json:"updated_at"`
+}
+
+// Summarize returns the summary of a subscription
+func Summarize(s webhook.Subscription) Summary {
+	events := s.Events
+	if events == nil {
+		events = []string{}
+	}
+	return Summary{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		Events:              events,
+		Status:              s.Status,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledReason:      s.DisabledReason,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
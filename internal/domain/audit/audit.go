@@ -0,0 +1,169 @@
+// Package audit contains the append-only record of who changed what and when.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the audit log. Actions are dotted, so filters can select a whole group.
+const (
+	ActionFavouriteCreate = "favourite.create"
+	ActionFavouriteUpdate = "favourite.update"
+	ActionFavouritePatch  = "favourite.patch"
+	ActionFavouriteDelete = "favourite.delete"
+	// ActionFavouriteReorder targets the user whose favourites were reordered
+	ActionFavouriteReorder = "favourite.reorder"
+	// ActionFavouriteRead and ActionFavouriteWatch are only recorded for impersonated requests
+	ActionFavouriteRead  = "favourite.read"
+	ActionFavouriteWatch = "favourite.watch"
+
+	ActionLogin     = "auth.login"
+	ActionLoginMFA  = "auth.login.mfa"
+	ActionLoginOIDC = "auth.login.oidc"
+	ActionRefresh   = "auth.refresh"
+	ActionLogout    = "auth.logout"
+	ActionLogoutAll = "auth.logout_all"
+
+	ActionUserCreate         = "admin.user.create"
+	ActionUserSetRoles       = "admin.user.roles"
+	ActionUserDisable        = "admin.user.disable"
+	ActionUserEnable         = "admin.user.enable"
+	ActionUserRevokeSessions = "admin.user.revoke_sessions"
+	ActionUserImpersonate    = "admin.user.impersonate"
+	ActionClientRegister     = "admin.client.register"
+	ActionClientDelete       = "admin.client.delete"
+	ActionLockoutUnlock      = "admin.lockout.unlock"
+	ActionNotificationReplay = "admin.notification.replay"
+	ActionWebhookCreate      = "admin.webhook.create"
+	ActionWebhookUpdate      = "admin.webhook.update"
+	ActionWebhookDelete      = "admin.webhook.delete"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Kinds of actor, matching the principals of the authentication chain
+const (
+	ActorAnonymous = "anonymous"
+	ActorUser      = "user"
+	ActorAPIKey    = "api_key"
+	ActorClient    = "client"
+	// ActorImpersonation is an admin acting as another user, OnBehalfOf is that user
+	ActorImpersonation = "impersonation"
+)
+
+// Types of target
+const (
+	TargetFavourite = "favourite"
+	TargetUser      = "user"
+	TargetSession   = "session"
+	TargetClient    = "client"
+	TargetLockout   = "lockout"
+	// TargetDeadLetter is a notification that could not be delivered
+	TargetDeadLetter = "dead_letter"
+	// TargetWebhook is a webhook subscription
+	TargetWebhook = "webhook"
+)
+
+// Actor is who performed an action. ID is the user id, or the client id of clients. An admin
+// impersonating a user is the actor, with the user in OnBehalfOf.
+type Actor struct {
+	Kind       string `json:"kind"`
+	ID         string `json:"id,omitempty"`
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
+}
+
+// Target is what an action was performed on
+type Target struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Change is the value of a field before and after an action, absent when the field did not exist
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Source describes the request an action was performed by
+type Source struct {
+	Actor     Actor
+	RequestID string
+	IP        string
+}
+
+// Entry is a single record of the audit log. Entries are never changed once appended.
+type Entry struct {
+	ID        uuid.UUID         `json:"id"`
+	Time      time.Time         `json:"time"`
+	Actor     Actor             `json:"actor"`
+	Action    string            `json:"action"`
+	Outcome   string            `json:"outcome"`
+	Target    Target            `json:"target"`
+	Changes   map[string]Change `json:"changes,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+}
+
+// NewEntry starts the entry of a successful action of source. The recorder sets its id and time.
+func NewEntry(source Source, action string, target Target) Entry {
+	return Entry{
+		Actor:     source.Actor,
+		Action:    action,
+		Outcome:   OutcomeSuccess,
+		Target:    target,
+		RequestID: source.RequestID,
+		IP:        source.IP,
+	}
+}
+
+// Diff compares the top level JSON fields of before and after, either of which may be nil,
+// and returns the fields that differ
+func Diff(before, after interface{}) map[string]Change {
+	b, a := fields(before), fields(after)
+
+	changes := map[string]Change{}
+	for name, value := range b {
+		if other, ok := a[name]; !ok || !bytes.Equal(value, other) {
+			changes[name] = Change{Before: value, After: a[name]}
+		}
+	}
+	for name, value := range a {
+		if _, ok := b[name]; !ok {
+			changes[name] = Change{After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// fields returns the top level JSON fields of v, none when v is nil or not a JSON object
+func fields(v interface{}) map[string]json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var f map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil
+	}
+	for name, value := range f {
+		var compact bytes.Buffer
+		if json.Compact(&compact, value) == nil {
+			f[name] = compact.Bytes()
+		}
+	}
+	return f
+}
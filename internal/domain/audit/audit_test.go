@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type record struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+	Note  string   `json:"note,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   map[string]Change
+	}{
+		{
+			name:   "created",
+			before: nil,
+			after:  record{Name: "a", Roles: []string{"user"}},
+			want: map[string]Change{
+				"name":  {After: []byte(`"a"`)},
+				"roles": {After: []byte(`["user"]`)},
+			},
+		},
+		{
+			name:   "deleted",
+			before: record{Name: "a", Roles: []string{"user"}},
+			after:  nil,
+			want: map[string]Change{
+				"name":  {Before: []byte(`"a"`)},
+				"roles": {Before: []byte(`["user"]`)},
+			},
+		},
+		{
+			name:   "changed field only",
+			before: record{Name: "a", Roles: []string{"user"}},
+			after:  record{Name: "a", Roles: []string{"admin", "user"}},
+			want:   map[string]Change{"roles": {Before: []byte(`["user"]`), After: []byte(`["admin","user"]`)}},
+		},
+		{
+			name:   "omitted field",
+			before: record{Name: "a", Note: "x"},
+			after:  record{Name: "a"},
+			want: map[string]Change{
+				"note": {Before: []byte(`"x"`)},
+			},
+		},
+		{
+			name:   "unchanged",
+			before: record{Name: "a"},
+			after:  record{Name: "a"},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.before, tt.after)
+			assert.Equal(t, len(tt.want), len(got))
+			for name, change := range tt.want {
+				if assert.Contains(t, got, name) {
+					assert.Equal(t, string(change.Before), string(got[name].Before))
+					assert.Equal(t, string(change.After), string(got[name].After))
+				}
+			}
+		})
+	}
+}
+
+func TestQuery_Matches(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := Entry{
+		Time:      now,
+		Actor:     Actor{Kind: ActorUser, ID: "u1"},
+		Action:    ActionUserCreate,
+		Target:    Target{Type: TargetUser, ID: "u2"},
+		RequestID: "req-1",
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  bool
+	}{
+		{name: "empty query", query: Query{}, want: true},
+		{name: "actor", query: Query{ActorID: "u1"}, want: true},
+		{name: "other actor", query: Query{ActorID: "u2"}, want: false},
+		{name: "exact action", query: Query{Action: ActionUserCreate}, want: true},
+		{name: "action group", query: Query{Action: "admin.user"}, want: true},
+		{name: "partial segment is no group", query: Query{Action: "admin.us"}, want: false},
+		{name: "target", query: Query{TargetType: TargetUser, TargetID: "u2"}, want: true},
+		{name: "other target type", query: Query{TargetType: TargetFavourite}, want: false},
+		{name: "request id", query: Query{RequestID: "req-2"}, want: false},
+		{name: "since is inclusive", query: Query{Since: now}, want: true},
+		{name: "until is exclusive", query: Query{Until: now}, want: false},
+		{name: "within range", query: Query{Since: now.Add(-time.Hour), Until: now.Add(time.Hour)}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.Matches(e))
+		})
+	}
+}
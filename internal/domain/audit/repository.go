@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// Query filters the audit log. Zero fields match everything.
+type Query struct {
+	ActorID string
+	// Action matches the action itself and, as a dotted prefix, its group: "admin.user" matches "admin.user.create"
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	// Since and Until bound the time of the entries, Since inclusive and Until exclusive
+	Since time.Time
+	Until time.Time
+	// OldestFirst orders the entries chronologically instead of newest first
+	OldestFirst bool
+	Offset      int
+	Limit       int
+}
+
+// Matches reports whether an entry satisfies the filters of the query
+func (q Query) Matches(e Entry) bool {
+	switch {
+	case q.ActorID != "" && e.Actor.ID != q.ActorID:
+		return false
+	case q.Action != "" && e.Action != q.Action && !strings.HasPrefix(e.Action, q.Action+"."):
+		return false
+	case q.TargetType != "" && e.Target.Type != q.TargetType:
+		return false
+	case q.TargetID != "" && e.Target.ID != q.TargetID:
+		return false
+	case q.RequestID != "" && e.RequestID != q.RequestID:
+		return false
+	case !q.Since.IsZero() && e.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !e.Time.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// Repository is the append-only store of the audit log
+type Repository interface {
+	// Append stores an entry. There is deliberately no way to change or remove one.
+	Append(entry Entry) error
+	// List returns a page of the entries matching the query and how many match in total
+	List(query Query) ([]Entry, int, error)
+}
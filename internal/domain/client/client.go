@@ -0,0 +1,30 @@
+// Package client contains the registered OAuth2 clients, the machine identities of internal services.
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrClientNotFound is returned when a client is not registered
+var ErrClientNotFound = errors.New("client not found")
+
+// Client is a service principal authenticating with the client_credentials grant
+type Client struct {
+	ID         uuid.UUID
+	Name       string
+	SecretHash string // keyed hash of the client secret
+	Scopes     []string
+	CreatedAt  time.Time
+}
+
+// Repository stores the registered clients
+type Repository interface {
+	Save(c Client)
+	GetByID(id uuid.UUID) (Client, error)
+	List() []Client
+	// Delete removes a client, returning ErrClientNotFound if it does not exist
+	Delete(id uuid.UUID) error
+}
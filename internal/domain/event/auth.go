@@ -0,0 +1,162 @@
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Names of the authentication events
+const (
+	UserLoggedInName        = "user.logged_in"
+	UserLoginFailedName     = "user.login_failed"
+	UserLoggedOutName       = "user.logged_out"
+	SessionRefreshedName    = "session.refreshed"
+	SessionRevokedName      = "session.revoked"
+	UserSessionsRevokedName = "user.sessions_revoked"
+	UserImpersonatedName    = "user.impersonated"
+	APIKeyCreatedName       = "api_key.created"
+	APIKeyRevokedName       = "api_key.revoked"
+	ClientRegisteredName    = "client.registered"
+	ClientDeletedName       = "client.deleted"
+	ClientTokenIssuedName   = "client.token_issued"
+	LockoutClearedName      = "lockout.cleared"
+)
+
+// Login methods of UserLoggedIn and UserLoginFailed
+const (
+	LoginPassword = "password"
+	LoginMFA      = "mfa"
+	LoginOIDC     = "oidc"
+)
+
+// UserLoggedIn is emitted when a user starts a session
+type UserLoggedIn struct {
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Method    string    `json:"method"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// EventName of the event
+func (UserLoggedIn) EventName() string { return UserLoggedInName }
+
+// UserLoginFailed is emitted when a login is refused. UserID is nil when no user has the username.
+type UserLoginFailed struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Method   string    `json:"method"`
+	IP       string    `json:"ip,omitempty"`
+	Reason   string    `json:"reason"`
+}
+
+// EventName of the event
+func (UserLoginFailed) EventName() string { return UserLoginFailedName }
+
+// UserLoggedOut is emitted when a user ends a session
+type UserLoggedOut struct {
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// EventName of the event
+func (UserLoggedOut) EventName() string { return UserLoggedOutName }
+
+// SessionRefreshed is emitted when the refresh token of a session is rotated
+type SessionRefreshed struct {
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// EventName of the event
+func (SessionRefreshed) EventName() string { return SessionRefreshedName }
+
+// SessionRevoked is emitted when a user revokes one of their sessions
+type SessionRevoked struct {
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// EventName of the event
+func (SessionRevoked) EventName() string { return SessionRevokedName }
+
+// UserSessionsRevoked is emitted when every session of a user is revoked, by the user or an admin
+type UserSessionsRevoked struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Revoked int       `json:"revoked"`
+	// ByAdmin is set when an admin forced the logout
+	ByAdmin bool `json:"by_admin"`
+}
+
+// EventName of the event
+func (UserSessionsRevoked) EventName() string { return UserSessionsRevokedName }
+
+// UserImpersonated is emitted when an admin is issued an impersonation token
+type UserImpersonated struct {
+	ActorID   uuid.UUID `json:"actor_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Mode      string    `json:"mode"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EventName of the event
+func (UserImpersonated) EventName() string { return UserImpersonatedName }
+
+// APIKeyCreated is emitted when a user creates an API key
+type APIKeyCreated struct {
+	UserID uuid.UUID `json:"user_id"`
+	KeyID  uuid.UUID `json:"key_id"`
+	Name   string    `json:"name"`
+	Scopes []string  `json:"scopes"`
+}
+
+// EventName of the event
+func (APIKeyCreated) EventName() string { return APIKeyCreatedName }
+
+// APIKeyRevoked is emitted when a user revokes an API key
+type APIKeyRevoked struct {
+	UserID uuid.UUID `json:"user_id"`
+	KeyID  uuid.UUID `json:"key_id"`
+}
+
+// EventName of the event
+func (APIKeyRevoked) EventName() string { return APIKeyRevokedName }
+
+// ClientRegistered is emitted when an admin registers an OAuth2 client
+type ClientRegistered struct {
+	ClientID uuid.UUID `json:"client_id"`
+	Name     string    `json:"name"`
+	Scopes   []string  `json:"scopes"`
+}
+
+// EventName of the event
+func (ClientRegistered) EventName() string { return ClientRegisteredName }
+
+// ClientDeleted is emitted when an admin deletes an OAuth2 client
+type ClientDeleted struct {
+	ClientID uuid.UUID `json:"client_id"`
+	Name     string    `json:"name"`
+}
+
+// EventName of the event
+func (ClientDeleted) EventName() string { return ClientDeletedName }
+
+// ClientTokenIssued is emitted when a client obtains an access token with its credentials
+type ClientTokenIssued struct {
+	ClientID  uuid.UUID `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EventName of the event
+func (ClientTokenIssued) EventName() string { return ClientTokenIssuedName }
+
+// LockoutCleared is emitted when an admin clears the failed logins of a username or IP
+type LockoutCleared struct {
+	Username string `json:"username,omitempty"`
+	IP       string `json:"ip,omitempty"`
+}
+
+// EventName of the event
+func (LockoutCleared) EventName() string { return LockoutClearedName }
@@ -0,0 +1,44 @@
+// Package event contains the typed domain events emitted by the commands of the application.
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownEvent is returned when decoding an event of a name nobody registered
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Event is something that happened in the domain. EventName identifies its type,
+// subscribers select the events they are interested in by it.
+type Event interface {
+	EventName() string
+}
+
+// decoders unmarshal the events of every name that travels through the outbox
+var decoders = map[string]func(payload []byte) (Event, error){
+	FavoriteAddedName:      decodeAs[FavoriteAdded],
+	FavoriteUpdatedName:    decodeAs[FavoriteUpdated],
+	FavoriteDeletedName:    decodeAs[FavoriteDeleted],
+	FavoritesReorderedName: decodeAs[FavoritesReordered],
+}
+
+func decodeAs[T Event](payload []byte) (Event, error) {
+	var e T
+	err := json.Unmarshal(payload, &e)
+	return e, err
+}
+
+// Decode unmarshals the JSON payload of a stored event of the given name
+func Decode(name string, payload []byte) (Event, error) {
+	decode, ok := decoders[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEvent, name)
+	}
+	e, err := decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", name, err)
+	}
+	return e, nil
+}
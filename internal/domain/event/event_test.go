@@ -0,0 +1,34 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	updated := FavoriteUpdated{
+		UserID:   uuid.New(),
+		Favorite: favourite.Favorite{ID: uuid.New(), Description: "chart", Data: json.RawMessage(`{"x":1}`)},
+		Changed:  []string{"description"},
+	}
+	payload, err := json.Marshal(updated)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	decoded, err := Decode(updated.EventName(), payload)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, updated, decoded)
+
+	_, err = Decode(UserLoggedInName, payload)
+	assert.ErrorIs(t, err, ErrUnknownEvent, "auth events do not travel through the outbox")
+
+	_, err = Decode(FavoriteAddedName, []byte(`{"user_id":1}`))
+	assert.ErrorContains(t, err, "invalid favourite.created payload")
+}
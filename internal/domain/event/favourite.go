@@ -0,0 +1,53 @@
+package event
+
+import (
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/google/uuid"
+)
+
+// Names of the favourite events, they are also the types of their outbox events
+const (
+	FavoriteAddedName   = "favourite.created"
+	FavoriteUpdatedName = "favourite.updated"
+	FavoriteDeletedName = "favourite.deleted"
+	// FavoritesReorderedName is not a change of one favourite, its aggregate is the user
+	FavoritesReorderedName = "favourite.reordered"
+)
+
+// FavoriteAdded is emitted when a user adds a favourite
+type FavoriteAdded struct {
+	UserID   uuid.UUID          `json:"user_id"`
+	Favorite favourite.Favorite `json:"favorite"`
+}
+
+// EventName of the event
+func (FavoriteAdded) EventName() string { return FavoriteAddedName }
+
+// FavoriteUpdated is emitted when a favourite is replaced or patched
+type FavoriteUpdated struct {
+	UserID   uuid.UUID          `json:"user_id"`
+	Favorite favourite.Favorite `json:"favorite"`
+	// Changed lists the JSON names of the fields that changed, sorted
+	Changed []string `json:"changed"`
+}
+
+// EventName of the event
+func (FavoriteUpdated) EventName() string { return FavoriteUpdatedName }
+
+// FavoriteDeleted is emitted when a favourite is deleted, it carries the favourite as it was
+type FavoriteDeleted struct {
+	UserID   uuid.UUID          `json:"user_id"`
+	Favorite favourite.Favorite `json:"favorite"`
+}
+
+// EventName of the event
+func (FavoriteDeleted) EventName() string { return FavoriteDeletedName }
+
+// FavoritesReordered is emitted when a user reorders their favourites, Order lists them all, first to last
+type FavoritesReordered struct {
+	UserID uuid.UUID   `json:"user_id"`
+	Order  []uuid.UUID `json:"order"`
+}
+
+// EventName of the event
+func (FavoritesReordered) EventName() string { return FavoritesReorderedName }
@@ -3,11 +3,15 @@ package favourite
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrInvalidOrder is returned when an order does not list every favourite of the user exactly once
+var ErrInvalidOrder = errors.New("the order must list every favourite of the user once")
+
 type AssetType string
 
 const (
@@ -30,6 +34,8 @@ type Favorite struct {
 	Type        AssetType       `json:"type"`
 	Description string          `json:"description"`
 	Data        json.RawMessage `json:"data"`
-	CreatedAt   time.Time       `json:"createdAt,omitempty"`
-	UpdatedAt   time.Time       `json:"updatedAt,omitempty"`
+	// Position orders the favourites of a user, the lowest first
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
 }
@@ -1,14 +1,21 @@
 package favourite
 
 import (
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
 	"github.com/google/uuid"
 )
 
-// Repository Interface for favorites
+// Repository Interface for favorites. GetAll returns the favourites ordered by position.
+// Add, Update, Delete and Reorder append the given audit entry and outbox events atomically with
+// the change: the change is never stored without its audit entry and events, nor them without it.
 type Repository interface {
 	GetByID(userID uuid.UUID, favoriteID uuid.UUID) (*Favorite, error)
 	GetAll(userID uuid.UUID) ([]Favorite, error)
-	Add(userID uuid.UUID, favorite Favorite) error
-	Update(userID uuid.UUID, favorite Favorite) error
-	Delete(userID uuid.UUID, favoriteID uuid.UUID) error
+	Add(userID uuid.UUID, favorite Favorite, entry audit.Entry, events ...outbox.Event) error
+	Update(userID uuid.UUID, favorite Favorite, entry audit.Entry, events ...outbox.Event) error
+	Delete(userID uuid.UUID, favoriteID uuid.UUID, entry audit.Entry, events ...outbox.Event) error
+	// Reorder gives the favourites the positions of their ids in order, which must list every favourite
+	// of the user once, or fails with ErrInvalidOrder
+	Reorder(userID uuid.UUID, order []uuid.UUID, entry audit.Entry, events ...outbox.Event) error
 }
@@ -0,0 +1,50 @@
+// Package lockout contains the failed login attempt model and the backoff/lockout policy.
+package lockout
+
+import (
+	"strings"
+	"time"
+)
+
+// Scope is the dimension failed attempts are tracked on
+type Scope string
+
+const (
+	// ScopeUsername tracks failed attempts against a username
+	ScopeUsername Scope = "username"
+	// ScopeIP tracks failed attempts coming from a client IP
+	ScopeIP Scope = "ip"
+)
+
+// Key identifies a tracked subject, e.g. username "alice" or ip "10.0.0.1"
+type Key struct {
+	Scope Scope  `json:"scope"`
+	Value string `json:"value"`
+}
+
+// String returns the storage form of the key
+func (k Key) String() string {
+	return string(k.Scope) + ":" + strings.ToLower(k.Value)
+}
+
+// Attempts is the failed login attempt state of a Key
+type Attempts struct {
+	Key          Key       `json:"key"`
+	Failures     int       `json:"failures"`
+	LastFailure  time.Time `json:"last_failure"`
+	BlockedUntil time.Time `json:"blocked_until"`
+	Locked       bool      `json:"locked"`
+}
+
+// Repository stores failed login attempts
+type Repository interface {
+	Get(key Key) (Attempts, bool)
+	Save(attempts Attempts)
+	// Delete clears the state of a key and reports whether it existed
+	Delete(key Key) bool
+	// List returns the state of every tracked key
+	List() []Attempts
+	// DeleteStale removes all records whose last failure is before the given time and
+	// that are no longer blocked, returning how many were removed
+	DeleteStale(before time.Time, now time.Time) int
+}
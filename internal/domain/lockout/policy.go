@@ -0,0 +1,65 @@
+package lockout
+
+import "time"
+
+// Policy decides how failed login attempts are throttled.
+//
+// The first FreeAttempts failures are not throttled. Every further failure blocks the key
+// for an exponentially growing delay (BaseDelay, 2*BaseDelay, ...) capped at MaxDelay.
+// Once LockoutThreshold failures are reached the key is locked for LockoutDuration.
+// Failures are forgotten after ResetAfter without a new failure, or once a lock has expired.
+type Policy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	ResetAfter       time.Duration
+}
+
+// RetryAfter returns how long the key must wait before its next attempt, zero if it may try now
+func (p Policy) RetryAfter(a Attempts, now time.Time) time.Duration {
+	if now.Before(a.BlockedUntil) {
+		return a.BlockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// RecordFailure returns the state after one more failed attempt at the given time
+// and reports whether this failure locked the key.
+func (p Policy) RecordFailure(a Attempts, now time.Time) (Attempts, bool) {
+	expired := p.ResetAfter > 0 && !a.LastFailure.IsZero() && now.Sub(a.LastFailure) > p.ResetAfter
+	if (expired || a.Locked) && !now.Before(a.BlockedUntil) {
+		a = Attempts{Key: a.Key}
+	}
+
+	a.Failures++
+	a.LastFailure = now
+
+	if p.LockoutThreshold > 0 && a.Failures >= p.LockoutThreshold {
+		lockedNow := !a.Locked
+		a.Locked = true
+		a.BlockedUntil = now.Add(p.LockoutDuration)
+		return a, lockedNow
+	}
+
+	if a.Failures > p.FreeAttempts {
+		a.BlockedUntil = now.Add(p.delay(a.Failures - p.FreeAttempts))
+	}
+	return a, false
+}
+
+// delay returns the backoff of the n-th throttled failure
+func (p Policy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
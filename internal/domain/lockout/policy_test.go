@@ -0,0 +1,80 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_RecordFailure(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := Policy{
+		FreeAttempts:     2,
+		BaseDelay:        time.Second,
+		MaxDelay:         4 * time.Second,
+		LockoutThreshold: 6,
+		LockoutDuration:  time.Hour,
+		ResetAfter:       24 * time.Hour,
+	}
+
+	a := Attempts{Key: Key{Scope: ScopeUsername, Value: "alice"}}
+	expectedDelays := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, expected := range expectedDelays {
+		var locked bool
+		a, locked = policy.RecordFailure(a, now)
+		assert.False(t, locked)
+		assert.Equal(t, expected, policy.RetryAfter(a, now), "failure %d", i+1)
+	}
+
+	a, locked := policy.RecordFailure(a, now)
+	assert.True(t, locked)
+	assert.True(t, a.Locked)
+	assert.Equal(t, time.Hour, policy.RetryAfter(a, now))
+
+	// further failures while locked do not report a new lockout
+	_, locked = policy.RecordFailure(a, now.Add(time.Minute))
+	assert.False(t, locked)
+
+	// the lock expires
+	assert.Equal(t, time.Duration(0), policy.RetryAfter(a, now.Add(2*time.Hour)))
+
+	// failures are forgotten after ResetAfter
+	a, _ = policy.RecordFailure(a, now.Add(48*time.Hour))
+	assert.Equal(t, 1, a.Failures)
+	assert.False(t, a.Locked)
+}
+
+func TestPolicy_RecordFailure_AfterTheLockExpired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := Policy{
+		FreeAttempts:     2,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 3,
+		LockoutDuration:  15 * time.Minute,
+		ResetAfter:       time.Hour,
+	}
+
+	a := Attempts{Key: Key{Scope: ScopeUsername, Value: "alice"}}
+	var locked bool
+	for i := 0; i < 3; i++ {
+		a, locked = policy.RecordFailure(a, now)
+	}
+	assert.True(t, locked)
+
+	// one wrong password once the lock expired, well within ResetAfter, does not lock again
+	later := now.Add(16 * time.Minute)
+	a, locked = policy.RecordFailure(a, later)
+	assert.False(t, locked)
+	assert.False(t, a.Locked)
+	assert.Equal(t, 1, a.Failures)
+	assert.Equal(t, time.Duration(0), policy.RetryAfter(a, later))
+
+	// reaching the threshold again is a new lock, and reported as one
+	for i := 0; i < 2; i++ {
+		a, locked = policy.RecordFailure(a, later)
+	}
+	assert.True(t, locked)
+	assert.Equal(t, 15*time.Minute, policy.RetryAfter(a, later))
+}
@@ -0,0 +1,75 @@
+// Package outbox contains the domain events recorded together with the changes they describe.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrEventNotFound is returned when an outbox event does not exist
+var ErrEventNotFound = errors.New("outbox event not found")
+
+// Event is a domain event waiting in the outbox to be published.
+// ID is stable across redeliveries, so subscribers use it to drop duplicates.
+type Event struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+
+	// Attempts, NextAttemptAt and LastError track the failed publishing attempts
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	// DeliveredTo lists the subscribers that already handled the event
+	DeliveredTo []string `json:"delivered_to,omitempty"`
+	// PublishedAt is set once every subscriber handled the event
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// NewEvent builds an event of the given type, marshalling payload as its body
+func NewEvent(id uuid.UUID, eventType string, aggregateID, userID uuid.UUID, payload interface{}, occurredAt time.Time) (Event, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:            id,
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		UserID:        userID,
+		Payload:       body,
+		OccurredAt:    occurredAt,
+		NextAttemptAt: occurredAt,
+	}, nil
+}
+
+// Delivered reports whether subscriber already handled the event
+func (e Event) Delivered(subscriber string) bool {
+	for _, s := range e.DeliveredTo {
+		if s == subscriber {
+			return true
+		}
+	}
+	return false
+}
+
+// Repository is the outbox. Events are appended by the repositories of the aggregates,
+// in the same transaction as the change they describe, and read back by the relay.
+type Repository interface {
+	// Due returns up to limit unpublished events whose next attempt is due at now, oldest first
+	Due(now time.Time, limit int) ([]Event, error)
+	// MarkDelivered records that subscriber handled the event
+	MarkDelivered(id uuid.UUID, subscriber string) error
+	// MarkPublished records that every subscriber handled the event
+	MarkPublished(id uuid.UUID, at time.Time) error
+	// MarkFailed records a failed attempt and when to try again
+	MarkFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time) error
+	// DeletePublished removes the events published before the given time, returning how many were removed
+	DeletePublished(before time.Time) (int, error)
+}
@@ -0,0 +1,64 @@
+// Package stats contains the operational statistics of the service.
+package stats
+
+import (
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/google/uuid"
+)
+
+// DayLayout formats the days favourites are counted per, in UTC
+const DayLayout = "2006-01-02"
+
+// Query selects the aggregates to compute
+type Query struct {
+	// Since starts the window of favourites counted per day
+	Since time.Time
+	// Now decides which sessions are still active
+	Now time.Time
+	// TopUsers is how many of the users with the most favourites are returned
+	TopUsers int
+}
+
+// Days returns the days of the window, from the day of Since to the day of Now in UTC
+func (q Query) Days() []string {
+	since, now := q.Since.UTC(), q.Now.UTC()
+	day := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.UTC)
+	var days []string
+	for !day.After(now) {
+		days = append(days, day.Format(DayLayout))
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
+
+// UserFavourites is the number of favourites of a user
+type UserFavourites struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Username   string    `json:"username"`
+	Favourites int       `json:"favourites"`
+}
+
+// DayFavourites is the number of favourites created on a day
+type DayFavourites struct {
+	Day        string `json:"day"`
+	Favourites int    `json:"favourites"`
+}
+
+// Stats are the aggregates of a Query
+type Stats struct {
+	Users            int                         `json:"users"`
+	Favourites       int                         `json:"favourites"`
+	FavouritesByType map[favourite.AssetType]int `json:"favourites_by_type"`
+	// TopUsers is ordered by favourites, most first
+	TopUsers []UserFavourites `json:"top_users"`
+	// FavouritesPerDay has one entry for each of the Query.Days, in order, zero for the days without favourites
+	FavouritesPerDay []DayFavourites `json:"favourites_per_day"`
+	ActiveSessions   int             `json:"active_sessions"`
+}
+
+// Repository computes the aggregates where the data is stored, without loading the data itself
+type Repository interface {
+	Get(query Query) (*Stats, error)
+}
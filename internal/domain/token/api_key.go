@@ -0,0 +1,44 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrAPIKeyNotFound is returned when an API key does not exist for a user
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is a long-lived personal access token acting on behalf of a user, limited to its scopes
+type APIKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string // first characters of the token, to tell keys apart
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // zero when the key never expires
+	LastUsedAt time.Time // zero when the key was never used
+}
+
+// Expired reports whether the key is expired at the given time
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
+
+// APIKeyRepository stores API keys.
+// Implementations must only persist a keyed hash of the token, never the token itself.
+type APIKeyRepository interface {
+	Save(token string, key APIKey)
+	Get(token string) (APIKey, bool)
+
+	// ListByUser returns all keys of a user
+	ListByUser(userID uuid.UUID) []APIKey
+	// DeleteByID removes a single key of a user, returning ErrAPIKeyNotFound if it does not exist
+	DeleteByID(userID uuid.UUID, keyID uuid.UUID) error
+	// DeleteByUser removes all keys of a user and returns how many were removed
+	DeleteByUser(userID uuid.UUID) int
+	// Touch records that a key was used at the given time
+	Touch(keyID uuid.UUID, at time.Time)
+}
@@ -0,0 +1,29 @@
+package token
+
+import (
+	"errors"
+)
+
+// ImpersonationMode is the access an admin is granted to the data of an impersonated user
+type ImpersonationMode string
+
+const (
+	// ImpersonationReadOnly only allows reading the favourites of the user
+	ImpersonationReadOnly ImpersonationMode = "read_only"
+	// ImpersonationReadWrite also allows changing the favourites of the user
+	ImpersonationReadWrite ImpersonationMode = "read_write"
+)
+
+// ErrInvalidImpersonationMode is returned for an unknown impersonation mode
+var ErrInvalidImpersonationMode = errors.New("invalid impersonation mode, expected read_only or read_write")
+
+// Scopes returns the scopes of an impersonation token of the mode
+func (m ImpersonationMode) Scopes() ([]string, error) {
+	switch m {
+	case ImpersonationReadOnly:
+		return []string{ScopeFavoritesRead}, nil
+	case ImpersonationReadWrite:
+		return []string{ScopeFavoritesRead, ScopeFavoritesWrite}, nil
+	}
+	return nil, ErrInvalidImpersonationMode
+}
@@ -0,0 +1,21 @@
+package token
+
+import "time"
+
+// OIDCStateRecord is the server side state of an OpenID Connect login waiting for the provider callback
+type OIDCStateRecord struct {
+	Nonce        string
+	CodeVerifier string
+	CreatedAt    time.Time
+	Expiry       time.Time
+}
+
+// OIDCStateRepository stores pending OpenID Connect logins keyed by their state parameter.
+// Implementations must only persist a keyed hash of the state, never the state itself.
+type OIDCStateRepository interface {
+	Save(state string, record OIDCStateRecord)
+	// Consume atomically looks up and removes a state, so a callback can only be completed once
+	Consume(state string) (OIDCStateRecord, bool)
+	// DeleteExpired removes all states expired at the given time and returns how many were removed
+	DeleteExpired(now time.Time) int
+}
@@ -1,7 +1,19 @@
 package token
 
-type RefreshRepository interface {
-	Save(token string, record RefreshRecord)
-	Get(token string) (RefreshRecord, bool)
-	Delete(token string)
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RefreshRecord is the server side state of an issued refresh token.
+// Each record represents one login session of a user.
+type RefreshRecord struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Expiry          time.Time
+	Roles           []string
+	CreatedAt       time.Time
+	LastRefreshedAt time.Time
+	UserAgent       string
+	IP              string
 }
@@ -1,12 +1,28 @@
 package token
 
 import (
-	"github.com/google/uuid"
+	"errors"
 	"time"
+
+	"github.com/google/uuid"
 )
 
-type RefreshRecord struct {
-	UserID uuid.UUID
-	Expiry time.Time
-	Roles  []string
+// ErrSessionNotFound is returned when a session does not exist for a user
+var ErrSessionNotFound = errors.New("session not found")
+
+// RefreshRepository stores refresh token sessions.
+// Implementations must only persist a keyed hash of the token, never the token itself.
+type RefreshRepository interface {
+	Save(token string, record RefreshRecord)
+	Get(token string) (RefreshRecord, bool)
+	Delete(token string)
+
+	// ListByUser returns all sessions of a user
+	ListByUser(userID uuid.UUID) []RefreshRecord
+	// DeleteByID removes a single session of a user, returning ErrSessionNotFound if it does not exist
+	DeleteByID(userID uuid.UUID, sessionID uuid.UUID) error
+	// DeleteByUser removes all sessions of a user and returns how many were removed
+	DeleteByUser(userID uuid.UUID) int
+	// DeleteExpired removes all sessions expired at the given time and returns how many were removed
+	DeleteExpired(now time.Time) int
 }
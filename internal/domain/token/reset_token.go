@@ -0,0 +1,26 @@
+package token
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResetRecord is the server side state of a single-use password reset token
+type ResetRecord struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	Expiry    time.Time
+}
+
+// ResetRepository stores password reset tokens.
+// Implementations must only persist a keyed hash of the token, never the token itself.
+type ResetRepository interface {
+	Save(token string, record ResetRecord)
+	// Consume atomically looks up and removes a token, so it can only be used once
+	Consume(token string) (ResetRecord, bool)
+	// DeleteByUser removes all outstanding reset tokens of a user and returns how many were removed
+	DeleteByUser(userID uuid.UUID) int
+	// DeleteExpired removes all tokens expired at the given time and returns how many were removed
+	DeleteExpired(now time.Time) int
+}
@@ -0,0 +1,50 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// ScopeFavoritesRead allows reading favourites
+	ScopeFavoritesRead = "favorites:read"
+	// ScopeFavoritesWrite allows creating, updating and deleting favourites
+	ScopeFavoritesWrite = "favorites:write"
+	// ScopeUsersOnBehalf allows a service client to act on the data of any user
+	ScopeUsersOnBehalf = "users:on_behalf"
+	// ScopeTokensIntrospect allows a service client to introspect tokens
+	ScopeTokensIntrospect = "tokens:introspect"
+)
+
+var (
+	// APIKeyScopes lists every scope an API key can be granted
+	APIKeyScopes = []string{ScopeFavoritesRead, ScopeFavoritesWrite}
+	// ClientScopes lists every scope an OAuth2 client can be granted
+	ClientScopes = []string{ScopeFavoritesRead, ScopeFavoritesWrite, ScopeUsersOnBehalf, ScopeTokensIntrospect}
+)
+
+// ErrInvalidScope is returned when an unknown or not allowed scope is requested
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ValidateScopes checks that at least one scope is requested and that all of them are allowed
+func ValidateScopes(scopes []string, allowed []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, s := range scopes {
+		if !ContainsScope(allowed, s) {
+			return fmt.Errorf("%w: %q", ErrInvalidScope, s)
+		}
+	}
+	return nil
+}
+
+// ContainsScope reports whether scope is one of scopes
+func ContainsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
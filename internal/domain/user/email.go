@@ -0,0 +1,33 @@
+package user
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// ErrInvalidEmail is returned when an email address is not a plain address
+var ErrInvalidEmail = errors.New("invalid email address")
+
+const emailMaxLength = 254
+
+// NormalizeEmail returns the stored form of an email address
+func NormalizeEmail(email string) string {
+	return strings.TrimSpace(email)
+}
+
+// ValidateEmail checks a normalized email address: a bare address like alice@example.com, without a display name.
+// The empty address is valid, it removes the address of a user.
+func ValidateEmail(email string) error {
+	if email == "" {
+		return nil
+	}
+	if len(email) > emailMaxLength {
+		return ErrInvalidEmail
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email, ".") {
+		return ErrInvalidEmail
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEmail(t *testing.T) {
+	for _, email := range []string{"", "alice@example.com", "a.b+tag@mail.example.org"} {
+		assert.NoError(t, ValidateEmail(email), email)
+	}
+	for _, email := range []string{"alice", "alice@", "Alice <alice@example.com>", "alice@localhost", "a@b.c\r\nBcc: x@y.z"} {
+		assert.ErrorIs(t, ValidateEmail(email), ErrInvalidEmail, email)
+	}
+	assert.Equal(t, "alice@example.com", NormalizeEmail("  alice@example.com "))
+}
@@ -0,0 +1,23 @@
+package user
+
+import "errors"
+
+var (
+	// ErrPasswordMismatch is returned when a password does not match its hash
+	ErrPasswordMismatch = errors.New("password does not match")
+	// ErrPasswordHashChanged is returned when a password hash was replaced concurrently
+	ErrPasswordHashChanged = errors.New("password hash has changed")
+)
+
+// PasswordHasher hashes passwords into self-describing PHC strings, so hashes made with
+// older algorithms or parameters keep verifying and can be upgraded on the next login
+type PasswordHasher interface {
+	Hash(plainPassword string) (string, error)
+	// Verify returns ErrPasswordMismatch when the password does not match the hash
+	Verify(hash, plainPassword string) error
+	// VerifyUniform verifies like Verify but costs the same whatever the algorithm of hash, or when
+	// there is no hash at all (an empty hash never matches), so its timing does not tell them apart
+	VerifyUniform(hash, plainPassword string) error
+	// NeedsRehash reports whether hash was made with another algorithm or parameters than Hash uses
+	NeedsRehash(hash string) bool
+}
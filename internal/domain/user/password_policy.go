@@ -0,0 +1,38 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword is returned when a password does not satisfy the PasswordPolicy
+var ErrWeakPassword = errors.New("password does not satisfy the password policy")
+
+// BreachedPasswords is a list of known compromised passwords
+type BreachedPasswords interface {
+	Contains(password string) bool
+}
+
+// PasswordPolicy describes the requirements a new password must satisfy
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	Breached  BreachedPasswords // optional
+}
+
+// Validate checks the password against the policy
+func (p PasswordPolicy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	// bcrypt silently truncates after 72 bytes, so the maximum is enforced in bytes
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return fmt.Errorf("%w: must be at most %d bytes", ErrWeakPassword, p.MaxLength)
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return fmt.Errorf("%w: password appears in a list of breached passwords", ErrWeakPassword)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type breachedList map[string]struct{}
+
+func (b breachedList) Contains(password string) bool {
+	_, ok := b[password]
+	return ok
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 72,
+		Breached:  breachedList{"password123": {}},
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "valid password", password: "correct horse battery", wantErr: false},
+		{name: "too short", password: "short", wantErr: true},
+		{name: "too long", password: strings.Repeat("a", 73), wantErr: true},
+		{name: "breached", password: "password123", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrWeakPassword))
+			}
+		})
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		wantErr  bool
+	}{
+		{name: "valid", username: NormalizeUsername("  Alice.Smith "), wantErr: false},
+		{name: "too short", username: "al", wantErr: true},
+		{name: "invalid characters", username: "alice smith", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUsername(tt.username)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -1,10 +1,53 @@
 package user
 
-import "github.com/google/uuid"
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrUserNotFound is returned when no user matches a lookup
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrIdentityLinked is returned when an external identity is already linked to another user
+var ErrIdentityLinked = errors.New("identity is linked to another user")
+
+// ListQuery filters and pages a listing of users
+type ListQuery struct {
+	// Search matches users whose username contains it, case-insensitively
+	Search string
+	Role   string
+	Status Status
+	Offset int
+	Limit  int
+}
 
 type Repository interface {
 	GetByID(id uuid.UUID) (*User, error)
+	// GetByUsername looks up a user by username, case-insensitively
 	GetByUsername(username string) (*User, error)
 
+	// Add stores a new user under its normalized username, returning ErrUsernameTaken on conflict
 	Add(username, plainPassword string, roles []string) (*User, error)
+	// UpdatePassword replaces the password of a user
+	UpdatePassword(id uuid.UUID, plainPassword string) error
+	// ReplacePasswordHash swaps the stored hash for an upgraded hash of the same password. It returns
+	// ErrPasswordHashChanged when the stored hash is no longer oldHash, so a concurrent password change wins.
+	ReplacePasswordHash(id uuid.UUID, oldHash, newHash string) error
+	// UpdateTOTP replaces the two-factor enrolment state of a user
+	UpdateTOTP(id uuid.UUID, totp TOTP) error
+
+	// GetByIdentity looks up the user linked to an external identity
+	GetByIdentity(identity Identity) (*User, error)
+	// LinkIdentity links an external identity to a user, returning ErrIdentityLinked if it is linked to another user
+	LinkIdentity(id uuid.UUID, identity Identity) error
+	// UpdateRoles replaces the roles of a user
+	UpdateRoles(id uuid.UUID, roles []string) error
+	// UpdateEmail replaces the email address of a user
+	UpdateEmail(id uuid.UUID, email string) error
+
+	// List returns the page of users matching the query ordered by username, and how many match in total
+	List(query ListQuery) ([]*User, int, error)
+	// SetStatus enables or disables a user
+	SetStatus(id uuid.UUID, status Status) error
 }
@@ -0,0 +1,45 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidRoles is returned when a set of roles is empty or contains a malformed role
+var ErrInvalidRoles = errors.New("invalid roles")
+
+// RoleAdmin grants access to the admin endpoints
+const RoleAdmin = "admin"
+
+const roleMaxLength = 32
+
+// NormalizeRoles validates roles and returns them sorted and without duplicates. A user needs
+// at least one role, each of 1-32 characters of a-z, 0-9, '_', '-' or ':'.
+func NormalizeRoles(roles []string) ([]string, error) {
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("%w: at least one role is required", ErrInvalidRoles)
+	}
+
+	set := make(map[string]struct{}, len(roles))
+	for _, r := range roles {
+		if len(r) == 0 || len(r) > roleMaxLength {
+			return nil, fmt.Errorf("%w: %q must be between 1 and %d characters", ErrInvalidRoles, r, roleMaxLength)
+		}
+		for _, c := range r {
+			switch {
+			case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_', c == '-', c == ':':
+			default:
+				return nil, fmt.Errorf("%w: %q may only contain a-z, 0-9, '_', '-' and ':'", ErrInvalidRoles, r)
+			}
+		}
+		set[r] = struct{}{}
+	}
+
+	normalized := make([]string, 0, len(set))
+	for r := range set {
+		normalized = append(normalized, r)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
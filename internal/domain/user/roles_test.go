@@ -0,0 +1,38 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "sorted and deduplicated", roles: []string{"user", "admin", "user"}, want: []string{"admin", "user"}},
+		{name: "namespaced role", roles: []string{"reports:read"}, want: []string{"reports:read"}},
+		{name: "no roles", roles: nil, wantErr: true},
+		{name: "empty role", roles: []string{""}, wantErr: true},
+		{name: "upper case", roles: []string{"Admin"}, wantErr: true},
+		{name: "whitespace", roles: []string{"super user"}, wantErr: true},
+		{name: "too long", roles: []string{strings.Repeat("a", 33)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeRoles(tt.roles)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidRoles))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,10 +1,70 @@
 package user
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
 	ID       uuid.UUID
 	Username string
+	// Email is where the user receives email notifications, empty when not known
+	Email    string
 	Password string
 	Roles    []string
+	Status   Status
+	TOTP     TOTP
+	// Identities are the accounts at external identity providers linked to the user
+	Identities []Identity
+	CreatedAt  time.Time
+}
+
+// Status tells whether a user may authenticate
+type Status string
+
+const (
+	// StatusActive users may log in and use their sessions and API keys
+	StatusActive Status = "active"
+	// StatusDisabled users are rejected at login, on refresh and when using their API keys
+	StatusDisabled Status = "disabled"
+)
+
+// ErrUserDisabled is returned when a disabled user tries to authenticate
+var ErrUserDisabled = errors.New("user is disabled")
+
+// ErrInvalidStatus is returned for an unknown user status
+var ErrInvalidStatus = errors.New("invalid user status")
+
+// Valid reports whether s is a known status
+func (s Status) Valid() bool {
+	return s == StatusActive || s == StatusDisabled
+}
+
+// Active reports whether the user may authenticate. Users stored before statuses existed have none and are active.
+func (u User) Active() bool {
+	return u.Status != StatusDisabled
+}
+
+// Identity is an account at an external OpenID Connect provider, unique per issuer and subject
+type Identity struct {
+	Issuer  string
+	Subject string
+}
+
+// TOTP is the two-factor enrolment state of a user. Secrets are stored encrypted
+// and recovery codes are stored hashed.
+type TOTP struct {
+	// Enabled is true once enrolment has been confirmed with a valid code
+	Enabled bool
+	// Secret is the encrypted secret of the confirmed enrolment
+	Secret string
+	// PendingSecret is the encrypted secret of an enrolment waiting for confirmation
+	PendingSecret string
+	// RecoveryCodes are the hashes of the unused recovery codes
+	RecoveryCodes []string
+	// LastUsedStep is the time step of the last accepted code, to reject replays
+	LastUsedStep int64
+	EnabledAt    time.Time
 }
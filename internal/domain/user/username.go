@@ -0,0 +1,39 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUsernameTaken is returned when a username is already registered, regardless of case
+	ErrUsernameTaken = errors.New("username already exists")
+	// ErrInvalidUsername is returned when a username does not satisfy the allowed format
+	ErrInvalidUsername = errors.New("invalid username")
+)
+
+const (
+	usernameMinLength = 3
+	usernameMaxLength = 32
+)
+
+// NormalizeUsername returns the canonical form of a username used for storage and uniqueness
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// ValidateUsername checks a normalized username: 3-32 characters of a-z, 0-9, '.', '_' or '-'
+func ValidateUsername(username string) error {
+	if len(username) < usernameMinLength || len(username) > usernameMaxLength {
+		return fmt.Errorf("%w: must be between %d and %d characters", ErrInvalidUsername, usernameMinLength, usernameMaxLength)
+	}
+	for _, c := range username {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return fmt.Errorf("%w: only letters, digits, '.', '_' and '-' are allowed", ErrInvalidUsername)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a delivery
+const (
+	// SignatureHeader carries the timestamp and the signature, "t=<unix seconds>,v1=<hex HMAC-SHA256>"
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader carries the name of the event
+	EventHeader = "X-Webhook-Event"
+	// IDHeader carries the event ID, the same for every attempt so receivers can drop duplicates
+	IDHeader = "X-Webhook-ID"
+)
+
+// ErrInvalidSignature is returned when a signature header does not match the body
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header of body sent at timestamp. The HMAC-SHA256 covers
+// "<timestamp>.<body>", so a captured request cannot be replayed with a fresh timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac(secret, t, body))
+}
+
+// Verify checks a signature header against the body, rejecting timestamps further than tolerance from now.
+// It is what receivers are expected to do.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing timestamp", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+	signature, err := hex.DecodeString(v1)
+	if err != nil || !hmac.Equal(signature, mac(secret, t, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"type":"favourite.created"}`)
+
+	header := Sign("0123456789abcdef", now, body)
+	assert.Equal(t, "t=1767225600,v1=", header[:16])
+	assert.NoError(t, Verify("0123456789abcdef", header, body, now.Add(time.Minute), 5*time.Minute))
+
+	assert.ErrorIs(t, Verify("another secret!!", header, body, now, 5*time.Minute), ErrInvalidSignature, "wrong secret")
+	assert.ErrorIs(t, Verify("0123456789abcdef", header, []byte(`{}`), now, 5*time.Minute), ErrInvalidSignature, "tampered body")
+	assert.ErrorIs(t, Verify("0123456789abcdef", header, body, now.Add(time.Hour), 5*time.Minute), ErrInvalidSignature, "replayed")
+	assert.ErrorIs(t, Verify("0123456789abcdef", "v1=abc", body, now, 5*time.Minute), ErrInvalidSignature, "no timestamp")
+}
+
+func TestSubscription_Wants(t *testing.T) {
+	all := Subscription{Status: StatusActive}
+	assert.True(t, all.Wants("favourite.deleted"))
+
+	filtered := Subscription{Status: StatusActive, Events: []string{"favourite.created"}}
+	assert.True(t, filtered.Wants("favourite.created"))
+	assert.False(t, filtered.Wants("favourite.deleted"))
+
+	disabled := Subscription{Status: StatusDisabled}
+	assert.False(t, disabled.Wants("favourite.created"))
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://partner.example.com/hooks?team=1"))
+	assert.NoError(t, ValidateURL("http://127.0.0.1:8081/"))
+	for _, raw := range []string{"", "partner.example.com/hooks", "ftp://example.com", "https://user:pw@example.com", "https:///path"} {
+		assert.ErrorIs(t, ValidateURL(raw), ErrInvalidURL, raw)
+	}
+}
@@ -0,0 +1,156 @@
+// Package webhook contains the outgoing webhook subscriptions and their deliveries.
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSubscriptionNotFound is returned when a webhook subscription does not exist
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	// ErrDeliveryNotFound is returned when a webhook delivery does not exist
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrInvalidURL is returned for an endpoint that is not an absolute http or https URL
+	ErrInvalidURL = errors.New("webhook URL must be an absolute http or https URL")
+	// ErrInvalidEvent is returned when a subscription filters on an event that is not delivered by webhooks
+	ErrInvalidEvent = errors.New("unknown webhook event")
+	// ErrInvalidSecret is returned for a secret too short to sign with
+	ErrInvalidSecret = errors.New("webhook secret must be at least 16 characters")
+)
+
+// SecretMinLength is the minimum length of a secret chosen by an admin
+const SecretMinLength = 16
+
+// Status tells whether a subscription receives deliveries
+type Status string
+
+const (
+	// StatusActive subscriptions receive the events they subscribed to
+	StatusActive Status = "active"
+	// StatusDisabled subscriptions receive nothing until an admin enables them again
+	StatusDisabled Status = "disabled"
+)
+
+// Subscription is an endpoint receiving the events it subscribed to as signed JSON POSTs
+type Subscription struct {
+	ID  uuid.UUID
+	URL string
+	// Events are the names of the events delivered, every webhook event when empty
+	Events []string
+	// Secret is the encrypted signing secret
+	Secret string
+	Status Status
+	// ConsecutiveFailures counts the failed attempts since the last successful delivery
+	ConsecutiveFailures int
+	// DisabledReason tells why the subscription was disabled, for instance repeated failures
+	DisabledReason string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Wants reports whether the subscription receives the events of the given name
+func (s Subscription) Wants(eventName string) bool {
+	if s.Status != StatusActive {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordAttempt applies the outcome of a delivery attempt: a success resets ConsecutiveFailures, a failure
+// increments it and disables an active subscription once it reaches disableAfter, never when 0.
+// It reports whether the attempt disabled the subscription.
+func (s *Subscription) RecordAttempt(ok bool, disableAfter int, lastError string, at time.Time) bool {
+	if ok {
+		s.ConsecutiveFailures = 0
+		return false
+	}
+	s.ConsecutiveFailures++
+	if disableAfter <= 0 || s.ConsecutiveFailures < disableAfter || s.Status != StatusActive {
+		return false
+	}
+	s.Status = StatusDisabled
+	s.DisabledReason = fmt.Sprintf("%d consecutive failed deliveries, last: %s", s.ConsecutiveFailures, lastError)
+	s.UpdatedAt = at
+	return true
+}
+
+// ValidateURL checks that a webhook endpoint is an absolute http or https URL without credentials
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// DeliveryStatus is the state of a delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded deliveries were answered with a 2xx status
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed deliveries ran out of attempts, or their subscription was disabled
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one subscription, with the outcome of its attempts.
+// There is at most one delivery per subscription and event.
+type Delivery struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	// LastStatusCode is the HTTP status of the last attempt, 0 when no response was received
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// Repository keeps the subscriptions and their deliveries
+type Repository interface {
+	Add(s Subscription) error
+	Get(id uuid.UUID) (Subscription, error)
+	// List returns the subscriptions, oldest first
+	List() ([]Subscription, error)
+	// Update replaces a subscription, returning ErrSubscriptionNotFound when it does not exist
+	Update(s Subscription) error
+	// RecordAttempt applies Subscription.RecordAttempt to the stored subscription in one step, so that
+	// an edit made while the attempt was in flight is kept. It reports whether the attempt disabled
+	// the subscription, returning ErrSubscriptionNotFound when it does not exist.
+	RecordAttempt(id uuid.UUID, ok bool, disableAfter int, lastError string, at time.Time) (bool, error)
+	// Delete removes a subscription and its deliveries
+	Delete(id uuid.UUID) error
+
+	// Enqueue stores a new delivery, unless one exists for the same subscription and event.
+	// It reports whether the delivery was stored.
+	Enqueue(d Delivery) (bool, error)
+	// DueDeliveries returns up to limit pending deliveries whose next attempt is due at now, oldest first
+	DueDeliveries(now time.Time, limit int) ([]Delivery, error)
+	// UpdateDelivery replaces a delivery
+	UpdateDelivery(d Delivery) error
+	// Deliveries returns up to limit deliveries of a subscription, newest first
+	Deliveries(subscriptionID uuid.UUID, limit int) ([]Delivery, error)
+	// DeleteCompletedDeliveries removes the deliveries completed before the given time, returning how many were removed
+	DeleteCompletedDeliveries(before time.Time) (int, error)
+}
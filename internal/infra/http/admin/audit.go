@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+)
+
+// ListAudit returns a page of the audit log, newest entries first, filtered by ?actor=, ?action=,
+// ?target_type=, ?target_id=, ?request_id= and the RFC 3339 ?since= and ?until=
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := auditFilter(q)
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+	offset, err := intParam(q.Get("offset"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid offset"), nil)
+		return
+	}
+	limit, err := intParam(q.Get("limit"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid limit"), nil)
+		return
+	}
+
+	result, err := h.adminServices.Queries.ListAuditHandler.Handle(queries.ListAuditRequest{
+		Filter: filter,
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ExportAudit streams the matching audit log as NDJSON, oldest entries first. It takes the filters of ListAudit.
+func (h *Handler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r.URL.Query())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	written := 0
+	err = h.adminServices.Queries.ExportAuditHandler.Handle(filter, func(e audit.Entry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		written++
+		if flusher != nil && written%100 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && written == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Del("Content-Disposition")
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	// a failure after the first line cannot change the status anymore, the export just ends early
+}
+
+// auditFilter reads the audit log filters of the query string
+func auditFilter(q url.Values) (queries.AuditFilter, error) {
+	filter := queries.AuditFilter{
+		ActorID:    q.Get("actor"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+		RequestID:  q.Get("request_id"),
+	}
+	var err error
+	if filter.Since, err = timeParam(q.Get("since")); err != nil {
+		return filter, fmt.Errorf("invalid since, expected RFC 3339")
+	}
+	if filter.Until, err = timeParam(q.Get("until")); err != nil {
+		return filter, fmt.Errorf("invalid until, expected RFC 3339")
+	}
+	return filter, nil
+}
+
+// timeParam parses an optional RFC 3339 timestamp, the zero time when empty
+func timeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
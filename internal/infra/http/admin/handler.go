@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ClientIDURLParam is the URL param of a client id
+const ClientIDURLParam = "id"
+
+// Handler Admin http request Handler
+type Handler struct {
+	authServices  app.AuthServices
+	userServices  app.UserServices
+	adminServices app.AdminServices
+}
+
+// NewHandler constructor
+func NewHandler(authApp app.AuthServices, userApp app.UserServices, adminApp app.AdminServices) *Handler {
+	return &Handler{authServices: authApp, userServices: userApp, adminServices: adminApp}
+}
+
+// UnlockRequestModel represents the request model of Unlock
+type UnlockRequestModel struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+// RegisterClientRequestModel represents the request model of RegisterClient
+type RegisterClientRequestModel struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// ListLockouts returns the usernames and IPs currently throttled or locked
+func (h *Handler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.authServices.Queries.ListLockoutsHandler.Handle()
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lockouts)
+}
+
+// Unlock clears the failed login attempts of a username and/or IP
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	var req UnlockRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+	if req.Username == "" && req.IP == "" {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("username or ip is required"), nil)
+		return
+	}
+
+	err := h.authServices.Commands.UnlockHandler.Handle(command.UnlockRequest{
+		Username: req.Username,
+		IP:       req.IP,
+		Source:   middleware.AuditSource(r),
+	})
+	if errors.Is(err, command.ErrLockoutNotFound) {
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListClients returns the registered OAuth2 clients
+func (h *Handler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.authServices.Queries.ListClientsHandler.Handle()
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clients)
+}
+
+// RegisterClient registers an OAuth2 client. The secret is only returned in this response.
+func (h *Handler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	var req RegisterClientRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.authServices.Commands.RegisterClientHandler.Handle(command.RegisterClientRequest{
+		Name:   req.Name,
+		Scopes: req.Scopes,
+		Source: middleware.AuditSource(r),
+	})
+	switch {
+	case errors.Is(err, token.ErrInvalidScope):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, map[string][]string{"allowed_scopes": token.ClientScopes})
+		return
+	case errors.Is(err, command.ErrInvalidClientName):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	case err != nil:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// DeleteClient removes a registered OAuth2 client
+func (h *Handler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	clientID, err := uuid.Parse(mux.Vars(r)[ClientIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid client ID"), nil)
+		return
+	}
+
+	err = h.authServices.Commands.DeleteClientHandler.Handle(command.DeleteClientRequest{
+		ClientID: clientID,
+		Source:   middleware.AuditSource(r),
+	})
+	if errors.Is(err, client.ErrClientNotFound) {
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+)
+
+// ImpersonateRequestModel represents the request model of Impersonate, an empty body is read-only
+type ImpersonateRequestModel struct {
+	Mode token.ImpersonationMode `json:"mode"`
+}
+
+// ImpersonateResponseModel is the token of an admin acting as a user
+type ImpersonateResponseModel struct {
+	AccessToken string                  `json:"access_token"`
+	TokenType   string                  `json:"token_type"`
+	ExpiresAt   time.Time               `json:"expires_at"`
+	UserID      string                  `json:"user_id"`
+	Mode        token.ImpersonationMode `json:"mode"`
+	Scopes      []string                `json:"scopes"`
+}
+
+// Impersonate issues a short-lived token acting as the user of the URL, limited to their favourites
+func (h *Handler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, ok := actorAndUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req ImpersonateRequestModel
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+			return
+		}
+	}
+
+	result, err := h.authServices.Commands.ImpersonateHandler.Handle(command.ImpersonateRequest{
+		ActorID: actorID,
+		UserID:  userID,
+		Mode:    req.Mode,
+		Source:  middleware.AuditSource(r),
+	})
+	switch {
+	case errors.Is(err, token.ErrInvalidImpersonationMode):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	case errors.Is(err, command.ErrSelfImpersonation), errors.Is(err, user.ErrUserDisabled):
+		helper.WriteJSONError(w, http.StatusConflict, err, nil)
+		return
+	case err != nil:
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ImpersonateResponseModel{
+		AccessToken: result.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresAt:   result.ExpiresAt,
+		UserID:      userID.String(),
+		Mode:        result.Mode,
+		Scopes:      result.Scopes,
+	})
+}
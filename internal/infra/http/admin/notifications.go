@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	admincommands "github.com/akazantzidis/gwi-ass/internal/app/admin/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// DeadLetterIDURLParam is the URL param of a dead letter id
+const DeadLetterIDURLParam = "id"
+
+// ListDeadLetters returns the notifications that could not be delivered, oldest first
+func (h *Handler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	result, err := h.adminServices.Queries.ListDeadLettersHandler.Handle()
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ReplayDeadLetter queues a dead letter for delivery again. It answers 200 with the reason when the
+// recipient's preferences held the notification back, the dead letter is kept then.
+func (h *Handler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)[DeadLetterIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid dead letter ID"), nil)
+		return
+	}
+
+	result, err := h.adminServices.Commands.ReplayDeadLetterHandler.Handle(admincommands.ReplayDeadLetterRequest{
+		ID:     id,
+		Source: middleware.AuditSource(r),
+	})
+	if errors.Is(err, notification.ErrDeadLetterNotFound) {
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if errors.Is(err, notification.ErrDeadLetterRedacted) {
+		helper.WriteJSONError(w, http.StatusConflict, err, nil)
+		return
+	}
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Replayed {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+)
+
+// Stats returns the operational stats, favourites per day over ?days= and the ?top= users
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	days, err := intParam(q.Get("days"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid days"), nil)
+		return
+	}
+	top, err := intParam(q.Get("top"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid top"), nil)
+		return
+	}
+
+	result, err := h.adminServices.Queries.GetStatsHandler.Handle(queries.GetStatsRequest{Days: days, Top: top})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// UserIDURLParam is the URL param of a user id
+const UserIDURLParam = "id"
+
+// CreateUserRequestModel represents the request model of CreateUser
+type CreateUserRequestModel struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+// SetRolesRequestModel represents the request model of SetUserRoles
+type SetRolesRequestModel struct {
+	Roles []string `json:"roles"`
+}
+
+// ListUsers returns a page of users, filtered by ?search=, ?role= and ?status=
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, err := intParam(q.Get("offset"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid offset"), nil)
+		return
+	}
+	limit, err := intParam(q.Get("limit"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid limit"), nil)
+		return
+	}
+
+	result, err := h.userServices.Queries.ListUsersHandler.Handle(queries.ListUsersRequest{
+		Search: q.Get("search"),
+		Role:   q.Get("role"),
+		Status: q.Get("status"),
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CreateUser creates a user with the given roles, "user" when none are given
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.userServices.Commands.CreateUserHandler.Handle(commands.CreateUserRequest{
+		Username: req.Username,
+		Password: req.Password,
+		Roles:    req.Roles,
+		Source:   middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// SetUserRoles replaces the roles of a user
+func (h *Handler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, ok := actorAndUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetRolesRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.userServices.Commands.SetUserRolesHandler.Handle(commands.SetUserRolesRequest{
+		ActorID: actorID,
+		UserID:  userID,
+		Roles:   req.Roles,
+		Source:  middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DisableUser disables a user and revokes their sessions
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserStatus(w, r, user.StatusDisabled)
+}
+
+// EnableUser re-enables a disabled user
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserStatus(w, r, user.StatusActive)
+}
+
+func (h *Handler) setUserStatus(w http.ResponseWriter, r *http.Request, status user.Status) {
+	actorID, userID, ok := actorAndUser(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.userServices.Commands.SetUserStatusHandler.Handle(commands.SetUserStatusRequest{
+		ActorID: actorID,
+		UserID:  userID,
+		Status:  status,
+		Source:  middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// RevokeUserSessions force-revokes every session of a user
+func (h *Handler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)[UserIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"), nil)
+		return
+	}
+
+	revoked, err := h.authServices.Commands.RevokeUserSessionsHandler.Handle(command.RevokeUserSessionsRequest{
+		UserID: userID,
+		Source: middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"revoked": revoked})
+}
+
+// actorAndUser returns the authenticated admin and the user of the URL
+func actorAndUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	actorID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(mux.Vars(r)[UserIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"), nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return actorID, userID, true
+}
+
+func intParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid number %q", v)
+	}
+	return i, nil
+}
+
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+	case errors.Is(err, user.ErrUsernameTaken):
+		helper.WriteJSONError(w, http.StatusConflict, err, nil)
+	case errors.Is(err, user.ErrInvalidUsername), errors.Is(err, user.ErrWeakPassword),
+		errors.Is(err, user.ErrInvalidRoles), errors.Is(err, user.ErrInvalidStatus):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+	case errors.Is(err, commands.ErrSelfLockout):
+		helper.WriteJSONError(w, http.StatusConflict, err, nil)
+	default:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+	}
+}
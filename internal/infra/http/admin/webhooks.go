@@ -0,0 +1,181 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	admincommands "github.com/akazantzidis/gwi-ass/internal/app/admin/commands"
+	adminqueries "github.com/akazantzidis/gwi-ass/internal/app/admin/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// WebhookIDURLParam is the URL param of a webhook subscription id
+const WebhookIDURLParam = "id"
+
+// CreateWebhookRequestModel represents the request model of CreateWebhook
+type CreateWebhookRequestModel struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// UpdateWebhookRequestModel represents the request model of UpdateWebhook, absent fields are left unchanged
+type UpdateWebhookRequestModel struct {
+	URL     *string   `json:"url"`
+	Events  *[]string `json:"events"`
+	Enabled *bool     `json:"enabled"`
+}
+
+// ListWebhooks returns the webhook subscriptions
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	result, err := h.adminServices.Queries.ListWebhooksHandler.Handle()
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CreateWebhook subscribes an endpoint to the webhook events. The secret is only returned in this response.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.adminServices.Commands.CreateWebhookHandler.Handle(admincommands.CreateWebhookRequest{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+		Source: middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetWebhook returns a webhook subscription
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminServices.Queries.GetWebhookHandler.Handle(id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// UpdateWebhook changes the URL or events of a subscription, or disables and enables it
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	var req UpdateWebhookRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.adminServices.Commands.UpdateWebhookHandler.Handle(admincommands.UpdateWebhookRequest{
+		ID:      id,
+		URL:     req.URL,
+		Events:  req.Events,
+		Enabled: req.Enabled,
+		Source:  middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DeleteWebhook removes a subscription with its deliveries
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.adminServices.Commands.DeleteWebhookHandler.Handle(admincommands.DeleteWebhookRequest{
+		ID:     id,
+		Source: middleware.AuditSource(r),
+	})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries returns the delivery log of a subscription, newest first
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	limit, err := intParam(r.URL.Query().Get("limit"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid limit"), nil)
+		return
+	}
+
+	result, err := h.adminServices.Queries.ListWebhookDeliveriesHandler.Handle(adminqueries.ListWebhookDeliveriesRequest{
+		SubscriptionID: id,
+		Limit:          limit,
+	})
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)[WebhookIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid webhook ID"), nil)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrSubscriptionNotFound):
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+	case errors.Is(err, webhook.ErrInvalidEvent):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, map[string][]string{"allowed_events": webhooks.Events})
+	case errors.Is(err, webhook.ErrInvalidURL), errors.Is(err, webhook.ErrInvalidSecret):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+	default:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+	}
+}
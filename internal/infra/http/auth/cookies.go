@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+)
+
+// SessionModeCookie is the login mode of browsers: the tokens are set as HttpOnly cookies
+// instead of being returned in the body
+const SessionModeCookie = "cookie"
+
+const (
+	// oidcStateCookie binds a started OpenID Connect login to the browser that started it
+	oidcStateCookie = "gwi_oidc_state"
+	// oidcModeCookie carries the session mode of the login through the provider redirect
+	oidcModeCookie = "gwi_oidc_mode"
+	oidcCookiePath = "/oidc/callback"
+)
+
+// CookieConfig controls the cookies of browser sessions
+type CookieConfig struct {
+	// Secure restricts the cookies to HTTPS, only disable it for local development over plain HTTP
+	Secure bool
+}
+
+// CookieSessionResponse is returned instead of TokenResponse in cookie mode. The csrf_token
+// is also readable from the gwi_csrf cookie and must be sent in X-CSRF-Token on state-changing requests.
+type CookieSessionResponse struct {
+	CSRFToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// writeTokens answers a successful login or refresh, in the body or as cookies
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, cookieMode bool, accessToken, refreshToken string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	expiresAt := time.Now().Add(helper.AccessTokenTTL)
+
+	if !cookieMode {
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			ExpiresAt:    expiresAt,
+		})
+		return
+	}
+
+	csrfToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	// the CSRF cookie lives as long as the refresh cookie, so a refresh can still be proven
+	h.setCookie(w, middleware.AccessCookie, accessToken, helper.AccessTokenTTL, true)
+	h.setCookie(w, middleware.RefreshCookie, refreshToken, helper.RefreshTokenTTL, true)
+	h.setCookie(w, middleware.CSRFCookie, csrfToken, helper.RefreshTokenTTL, false)
+	json.NewEncoder(w).Encode(CookieSessionResponse{CSRFToken: csrfToken, ExpiresAt: expiresAt})
+}
+
+// clearSessionCookies expires the cookies of a browser session
+func (h *AuthHandler) clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{middleware.AccessCookie, middleware.RefreshCookie, middleware.CSRFCookie} {
+		h.setCookie(w, name, "", -1, name != middleware.CSRFCookie)
+	}
+}
+
+func (h *AuthHandler) setCookie(w http.ResponseWriter, name, value string, ttl time.Duration, httpOnly bool) {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   h.cookies.Secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// setOIDCCookies remembers the state and session mode of a login started at the provider. They are
+// SameSite=Lax, as the provider redirects back with a top-level cross-site navigation.
+func (h *AuthHandler) setOIDCCookies(w http.ResponseWriter, state, mode string, expiry time.Time) {
+	maxAge := int(time.Until(expiry).Seconds())
+	h.setOIDCCookie(w, oidcStateCookie, state, maxAge)
+	h.setOIDCCookie(w, oidcModeCookie, mode, maxAge)
+}
+
+// clearOIDCCookies expires the cookies of a started login, they are single-use like the state
+func (h *AuthHandler) clearOIDCCookies(w http.ResponseWriter) {
+	h.setOIDCCookie(w, oidcStateCookie, "", -1)
+	h.setOIDCCookie(w, oidcModeCookie, "", -1)
+}
+
+func (h *AuthHandler) setOIDCCookie(w http.ResponseWriter, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.cookies.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// oidcStateMatches reports whether the state of the callback is the one this browser started
+func oidcStateMatches(r *http.Request, state string) bool {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) == 1
+}
+
+// oidcCookieMode reports whether the login was started for a browser session in cookie mode
+func oidcCookieMode(r *http.Request) bool {
+	cookie, err := r.Cookie(oidcModeCookie)
+	return err == nil && cookie.Value == SessionModeCookie
+}
+
+// refreshCookie returns the refresh token of a browser session, if any
+func refreshCookie(r *http.Request) string {
+	cookie, err := r.Cookie(middleware.RefreshCookie)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
@@ -2,17 +2,39 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/bruteforce"
 	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/query"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+const (
+	// SessionIDURLParam is the URL param of a session id
+	SessionIDURLParam = "id"
+	// APIKeyIDURLParam is the URL param of an api key id
+	APIKeyIDURLParam = "id"
+)
+
 type Credential struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Mode is "cookie" for browser sessions, the tokens are returned in the body otherwise
+	Mode string `json:"mode,omitempty"`
 }
 
 type TokenResponse struct {
@@ -21,8 +43,30 @@ type TokenResponse struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 }
 
+// MFAChallengeResponse is returned by Login when the user has two-factor authentication enabled
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// MFALoginRequestModel represents the request model of LoginMFA
+type MFALoginRequestModel struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+	Mode     string `json:"mode,omitempty"`
+}
+
+// CreateAPIKeyRequestModel represents the request model of CreateAPIKey
+type CreateAPIKeyRequestModel struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
 type AuthHandler struct {
 	authServices app.AuthServices
+	cookies      CookieConfig
 }
 
 type RefreshRecord struct {
@@ -31,8 +75,8 @@ type RefreshRecord struct {
 	Roles  []string
 }
 
-func NewAuthHandler(app app.AuthServices) *AuthHandler {
-	return &AuthHandler{authServices: app}
+func NewAuthHandler(app app.AuthServices, cookies CookieConfig) *AuthHandler {
+	return &AuthHandler{authServices: app, cookies: cookies}
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -42,70 +86,381 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	access, refresh, err := h.authServices.Commands.LoginUserHandler.Handle(command.LoginRequest{
-		Username: cred.Username,
-		Password: cred.Password,
+	result, err := h.authServices.Commands.LoginUserHandler.Handle(command.LoginRequest{
+		Username:  cred.Username,
+		Password:  cred.Password,
+		UserAgent: r.UserAgent(),
+		IP:        helper.ClientIP(r),
+		RequestID: middleware.RequestIDFromContext(r.Context()),
 	})
+	if writeThrottled(w, err) {
+		return
+	}
+	if errors.Is(err, user.ErrUserDisabled) {
+		helper.WriteJSONError(w, http.StatusForbidden, err, nil)
+		return
+	}
 	if err != nil {
 		helper.WriteJSONError(w, http.StatusUnauthorized, err, "invalid username or password")
 		return
 	}
 
-	resp := TokenResponse{
-		AccessToken:  access,
-		RefreshToken: refresh,
-		ExpiresAt:    time.Now().Add(helper.AccessTokenTTL),
+	if result.MFARequired {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			ExpiresAt:   result.MFAExpiresAt,
+		})
+		return
 	}
+	h.writeTokens(w, cred.Mode == SessionModeCookie, result.AccessToken, result.RefreshToken)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+// LoginMFA completes a two-factor login with a TOTP or recovery code
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req MFALoginRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, err, "invalid payload")
+		return
+	}
+
+	result, err := h.authServices.Commands.MFALoginUserHandler.Handle(command.MFALoginRequest{
+		MFAToken:  req.MFAToken,
+		Code:      req.Code,
+		UserAgent: r.UserAgent(),
+		IP:        helper.ClientIP(r),
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	})
+	if writeThrottled(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, command.ErrInvalidMFAToken), errors.Is(err, mfa.ErrInvalidCode), errors.Is(err, mfa.ErrNotEnrolled):
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	case errors.Is(err, user.ErrUserDisabled):
+		helper.WriteJSONError(w, http.StatusForbidden, err, nil)
+		return
+	case err != nil:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	h.writeTokens(w, req.Mode == SessionModeCookie, result.AccessToken, result.RefreshToken)
+}
+
+// OIDCLogin redirects the user agent to the OpenID Connect provider. With ?mode=cookie the
+// callback starts a browser session instead of returning the tokens in the body.
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	result, err := h.authServices.Commands.StartOIDCLoginHandler.Handle()
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadGateway, err, nil)
+		return
+	}
+
+	h.setOIDCCookies(w, result.State, r.URL.Query().Get("mode"), result.Expiry)
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, result.AuthURL, http.StatusFound)
+}
+
+// OIDCCallback completes an OpenID Connect login started by this browser and returns the token pair
+// of the linked user, or the MFA challenge when the user is enrolled in two-factor authentication
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cookieMode := oidcCookieMode(r)
+	stateMatches := oidcStateMatches(r, q.Get("state"))
+	h.clearOIDCCookies(w)
+
+	if providerErr := q.Get("error"); providerErr != "" {
+		helper.WriteJSONError(w, http.StatusUnauthorized, fmt.Errorf("%w: %s", command.ErrOIDCLoginFailed, providerErr), q.Get("error_description"))
+		return
+	}
+	if q.Get("state") == "" || q.Get("code") == "" {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("state and code are required"), nil)
+		return
+	}
+	// a callback this browser did not start is a login CSRF attempt, the state is left for its owner
+	if !stateMatches {
+		helper.WriteJSONError(w, http.StatusBadRequest, command.ErrInvalidOIDCState, nil)
+		return
+	}
+
+	result, err := h.authServices.Commands.OIDCCallbackHandler.Handle(command.OIDCCallbackRequest{
+		State:     q.Get("state"),
+		Code:      q.Get("code"),
+		UserAgent: r.UserAgent(),
+		IP:        helper.ClientIP(r),
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	})
+	switch {
+	case errors.Is(err, command.ErrInvalidOIDCState):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	case errors.Is(err, command.ErrOIDCLoginFailed):
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	case errors.Is(err, user.ErrUserDisabled):
+		helper.WriteJSONError(w, http.StatusForbidden, err, nil)
+		return
+	case err != nil:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	if result.MFARequired {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			ExpiresAt:   result.MFAExpiresAt,
+		})
+		return
+	}
+	h.writeTokens(w, cookieMode, result.AccessToken, result.RefreshToken)
+}
+
+// writeThrottled answers 429 with a Retry-After header when the login was throttled
+func writeThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *bruteforce.ThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	helper.WriteJSONError(w, http.StatusTooManyRequests, err, nil)
+	return true
 }
 
 // Refresh - exchanges valid refresh token for new access + refresh (rotate refresh token)
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	refreshToken, cookieMode, ok := h.refreshTokenFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	access, newRefresh, err := h.authServices.Commands.RefreshTokenUserHandler.Handle(command.RefreshRequest{
+		RefreshToken: refreshToken,
+		UserAgent:    r.UserAgent(),
+		IP:           helper.ClientIP(r),
+		RequestID:    middleware.RequestIDFromContext(r.Context()),
+	})
+	if err != nil {
+		if cookieMode {
+			h.clearSessionCookies(w)
+		}
+		if errors.Is(err, user.ErrUserDisabled) {
+			helper.WriteJSONError(w, http.StatusForbidden, err, nil)
+			return
+		}
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	h.writeTokens(w, cookieMode, access, newRefresh)
+}
+
+// refreshTokenFromRequest reads the refresh token of the body or, when the body has none, of the
+// refresh cookie. A cookie is only accepted with the CSRF token. It writes the error response when !ok.
+func (h *AuthHandler) refreshTokenFromRequest(w http.ResponseWriter, r *http.Request) (refreshToken string, cookieMode bool, ok bool) {
 	var p struct {
 		RefreshToken string `json:"refresh_token"`
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil && !errors.Is(err, io.EOF) {
 		helper.WriteJSONError(w, http.StatusBadRequest, err, "invalid payload")
+		return "", false, false
+	}
+	if p.RefreshToken != "" {
+		return p.RefreshToken, false, true
+	}
+
+	cookie := refreshCookie(r)
+	if cookie == "" {
+		return "", false, true
+	}
+	if err := middleware.CheckCSRF(r); err != nil {
+		helper.WriteJSONError(w, http.StatusForbidden, err, nil)
+		return "", true, false
+	}
+	return cookie, true, true
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	refreshToken, cookieMode, ok := h.refreshTokenFromRequest(w, r)
+	if !ok {
 		return
 	}
 
-	access, newRefresh, err := h.authServices.Commands.RefreshTokenUserHandler.Handle(command.RefreshRequest{
-		RefreshToken: p.RefreshToken,
+	if refreshToken == "" {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("missing refresh_token"), nil)
+		return
+	}
+
+	err := h.authServices.Commands.LogoutUserHandler.Handle(command.LogoutRequest{
+		RefreshToken: refreshToken,
+		IP:           helper.ClientIP(r),
+		RequestID:    middleware.RequestIDFromContext(r.Context()),
+	})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	if cookieMode {
+		h.clearSessionCookies(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll revokes every session of the authenticated user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	revoked, err := h.authServices.Commands.LogoutAllUserHandler.Handle(command.LogoutAllRequest{
+		UserID: userID,
+		Source: middleware.AuditSource(r),
 	})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	if _, err := r.Cookie(middleware.AccessCookie); err == nil {
+		h.clearSessionCookies(w)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"revoked": revoked})
+}
+
+// Sessions lists the active sessions of the authenticated user
+func (h *AuthHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
 	if err != nil {
 		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
 		return
 	}
 
-	resp := TokenResponse{
-		AccessToken:  access,
-		RefreshToken: newRefresh,
-		ExpiresAt:    time.Now().Add(helper.AccessTokenTTL),
+	sessions, err := h.authServices.Queries.ListSessionsHandler.Handle(query.ListSessionsRequest{UserID: userID})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(sessions)
 }
 
-func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	var p struct {
-		RefreshToken string `json:"refresh_token"`
+// RevokeSession revokes one session of the authenticated user
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	sessionID, err := uuid.Parse(mux.Vars(r)[SessionIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid session ID"), nil)
+		return
+	}
+
+	err = h.authServices.Commands.RevokeSessionHandler.Handle(command.RevokeSessionRequest{
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if errors.Is(err, token.ErrSessionNotFound) {
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIKeys lists the API keys of the authenticated user
+func (h *AuthHandler) APIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	keys, err := h.authServices.Queries.ListAPIKeysHandler.Handle(query.ListAPIKeysRequest{UserID: userID})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// CreateAPIKey creates an API key for the authenticated user. The token is only returned in this response.
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	var req CreateAPIKeyRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		helper.WriteJSONError(w, http.StatusBadRequest, err, "invalid payload")
 		return
 	}
 
-	if p.RefreshToken == "" {
-		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("missing refresh_token"), nil)
+	cmd := command.CreateAPIKeyRequest{UserID: userID, Name: req.Name, Scopes: req.Scopes}
+	if req.ExpiresAt != nil {
+		cmd.ExpiresAt = *req.ExpiresAt
+	}
+	result, err := h.authServices.Commands.CreateAPIKeyHandler.Handle(cmd)
+	switch {
+	case errors.Is(err, token.ErrInvalidScope):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, map[string][]string{"allowed_scopes": token.APIKeyScopes})
+		return
+	case errors.Is(err, command.ErrInvalidAPIKeyName), errors.Is(err, command.ErrInvalidAPIKeyExpiry):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	case err != nil:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// RevokeAPIKey revokes one API key of the authenticated user
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
 		return
 	}
 
-	err := h.authServices.Commands.LogoutUserHandler.Handle(p.RefreshToken)
+	keyID, err := uuid.Parse(mux.Vars(r)[APIKeyIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid api key ID"), nil)
+		return
+	}
+
+	err = h.authServices.Commands.RevokeAPIKeyHandler.Handle(command.RevokeAPIKeyRequest{
+		UserID: userID,
+		KeyID:  keyID,
+	})
+	if errors.Is(err, token.ErrAPIKeyNotFound) {
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+		return
+	}
 	if err != nil {
 		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
 		return
@@ -113,3 +468,21 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ResolveAPIKey turns an API key into the claims of the user it acts for, for middleware.APIKeyAuthenticator
+func (h *AuthHandler) ResolveAPIKey(key string) (*helper.CustomClaims, error) {
+	principal, err := h.authServices.Commands.AuthenticateAPIKeyHandler.Handle(key)
+	if err != nil {
+		return nil, err
+	}
+	return &helper.CustomClaims{
+		UserID:   principal.UserID.String(),
+		Roles:    principal.Roles,
+		TokenUse: helper.TokenUseAPIKey,
+		Scopes:   principal.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: principal.UserID.String(),
+			ID:      principal.KeyID.String(),
+		},
+	}, nil
+}
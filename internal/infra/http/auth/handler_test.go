@@ -0,0 +1,141 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/infra/http/auth"
+	"github.com/akazantzidis/gwi-ass/internal/infra/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/infra/oidc/fakeidp"
+	"github.com/akazantzidis/gwi-ass/internal/infra/storage/memory"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const redirectURL = "http://localhost:8080/oidc/callback"
+
+func newOIDCHandler(t *testing.T) *auth.AuthHandler {
+	idp, err := fakeidp.New("gwi", "s3cret", redirectURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(idp.Close)
+	idp.SignIn(fakeidp.User{Subject: "sub-1", PreferredUsername: "carol"})
+
+	provider := oidc.NewProvider(oidc.Config{IssuerURL: idp.URL, ClientID: "gwi", ClientSecret: "s3cret", RedirectURL: redirectURL})
+	hasher := helper.NewTokenHasher([]byte("test key"))
+	states := memory.NewOIDCStateRepo(hasher)
+	passwords, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	tp, up := time.NewTimeProvider(), uuid.NewUUIDProvider()
+
+	return auth.NewAuthHandler(app.AuthServices{Commands: app.Commands{
+		StartOIDCLoginHandler: command.NewStartOIDCLoginHandler(provider, states, gotime.Minute, tp),
+		OIDCCallbackHandler: command.NewOIDCCallbackHandler(provider, states, memory.NewUserRepo(passwords), memory.NewRefreshRepo(hasher), nil, []string{"user"},
+			auditlog.NewRecorder(memory.NewAuditRepo(), up, tp), eventbus.NewBus(up, tp), up, tp),
+	}}, auth.CookieConfig{})
+}
+
+// startLogin starts a login and follows it to the provider, returning the cookies set for
+// the browser and the callback the provider redirects it to
+func startLogin(t *testing.T, h *auth.AuthHandler, query string) ([]*http.Cookie, string) {
+	rec := httptest.NewRecorder()
+	h.OIDCLogin(rec, httptest.NewRequest(http.MethodGet, "/oidc/login"+query, nil))
+	if !assert.Equal(t, http.StatusFound, rec.Code) {
+		t.FailNow()
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(rec.Header().Get("Location"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return rec.Result().Cookies(), callback.RequestURI()
+}
+
+func callback(h *auth.AuthHandler, uri string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	h.OIDCCallback(rec, req)
+	return rec
+}
+
+func cookieNamed(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestOIDCCallback_BindsStateToBrowser(t *testing.T) {
+	h := newOIDCHandler(t)
+	cookies, uri := startLogin(t, h, "")
+
+	state := cookieNamed(cookies, "gwi_oidc_state")
+	if !assert.NotNil(t, state) {
+		return
+	}
+	assert.True(t, state.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, state.SameSite)
+
+	// a victim's browser sent to the attacker's callback has no or another state cookie
+	rec := callback(h, uri, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	_, other := startLogin(t, h, "")
+	rec = callback(h, other, cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// the refused callbacks do not use up the state of the browser that started the login
+	rec = callback(h, uri, cookies)
+	if !assert.Equal(t, http.StatusOK, rec.Code) {
+		return
+	}
+	assert.Contains(t, rec.Body.String(), "access_token")
+	cleared := cookieNamed(rec.Result().Cookies(), "gwi_oidc_state")
+	if !assert.NotNil(t, cleared) {
+		return
+	}
+	assert.Negative(t, cleared.MaxAge)
+}
+
+func TestOIDCCallback_CookieMode(t *testing.T) {
+	h := newOIDCHandler(t)
+	cookies, uri := startLogin(t, h, "?mode=cookie")
+
+	rec := callback(h, uri, cookies)
+	if !assert.Equal(t, http.StatusOK, rec.Code) {
+		return
+	}
+	assert.NotContains(t, rec.Body.String(), "access_token")
+	assert.Contains(t, rec.Body.String(), "csrf_token")
+	access := cookieNamed(rec.Result().Cookies(), middleware.AccessCookie)
+	if !assert.NotNil(t, access) {
+		return
+	}
+	assert.True(t, access.HttpOnly)
+}
@@ -2,6 +2,7 @@ package favourite
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	queries2 "github.com/akazantzidis/gwi-ass/internal/app/user/queries"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 
 	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/policy"
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
 	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
@@ -20,10 +22,12 @@ import (
 type Handler struct {
 	favoriteServices app.FavoriteServices
 	userServices     app.UserServices
+	actingPolicy     policy.ActingPolicy
+	socket           SocketConfig
 }
 
-func NewHandler(app app.FavoriteServices, userApp app.UserServices) *Handler {
-	return &Handler{favoriteServices: app, userServices: userApp}
+func NewHandler(app app.FavoriteServices, userApp app.UserServices, actingPolicy policy.ActingPolicy, socket SocketConfig) *Handler {
+	return &Handler{favoriteServices: app, userServices: userApp, actingPolicy: actingPolicy, socket: socket.withDefaults()}
 }
 
 // URL param constants
@@ -36,13 +40,13 @@ const (
 
 // GetAll returns all favorites for a given user
 func (c Handler) GetAll(w http.ResponseWriter, r *http.Request) {
-	userID, ok := extractUserID(w, r)
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
 		return
 	}
 
 	favorites, err := c.favoriteServices.Queries.GetAllFavoritesHandler.Handle(
-		queries.GetAllFavoritesRequest{UserID: userID},
+		queries.GetAllFavoritesRequest{UserID: userID, Source: middleware.AuditSource(r)},
 	)
 	if err != nil {
 		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
@@ -58,7 +62,7 @@ func (c Handler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 // GetByID returns a favorite for a given user
 func (c Handler) GetByID(w http.ResponseWriter, r *http.Request) {
-	userID, ok := extractUserID(w, r)
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
 		return
 	}
@@ -74,6 +78,7 @@ func (c Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 		queries.GetFavoriteRequest{
 			UserID:     userID,
 			FavoriteID: favoriteID,
+			Source:     middleware.AuditSource(r),
 		},
 	)
 
@@ -98,7 +103,7 @@ type CreateFavoriteRequestModel struct {
 
 // Create adds a new favorite for a user
 func (c Handler) Create(w http.ResponseWriter, r *http.Request) {
-	userID, ok := extractUserID(w, r)
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
 		return
 	}
@@ -131,6 +136,7 @@ func (c Handler) Create(w http.ResponseWriter, r *http.Request) {
 			Type:        req.Type,
 			Description: req.Description,
 			Data:        req.Data,
+			Source:      middleware.AuditSource(r),
 		},
 	)
 	if err != nil {
@@ -160,7 +166,7 @@ type PatchFavoriteRequestModel struct {
 
 // Patch handles partial updates for favorites
 func (c Handler) Patch(w http.ResponseWriter, r *http.Request) {
-	userID, ok := extractUserID(w, r)
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
 		return
 	}
@@ -190,6 +196,7 @@ func (c Handler) Patch(w http.ResponseWriter, r *http.Request) {
 			Type:        req.Type,
 			Description: req.Description,
 			Data:        req.Data,
+			Source:      middleware.AuditSource(r),
 		},
 	)
 	if err != nil {
@@ -202,7 +209,7 @@ func (c Handler) Patch(w http.ResponseWriter, r *http.Request) {
 
 // Update handles full updates
 func (c Handler) Update(w http.ResponseWriter, r *http.Request) {
-	userID, ok := extractUserID(w, r)
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
 		return
 	}
@@ -233,6 +240,7 @@ func (c Handler) Update(w http.ResponseWriter, r *http.Request) {
 			Type:        req.Type,
 			Description: req.Description,
 			Data:        req.Data,
+			Source:      middleware.AuditSource(r),
 		},
 	)
 	if err != nil {
@@ -245,7 +253,7 @@ func (c Handler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete deletes a favorite
 func (c Handler) Delete(w http.ResponseWriter, r *http.Request) {
-	userID, ok := extractUserID(w, r)
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
 		return
 	}
@@ -261,6 +269,7 @@ func (c Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		commands.DeleteFavoriteRequest{
 			UserID:     userID,
 			FavoriteID: favoriteID,
+			Source:     middleware.AuditSource(r),
 		},
 	)
 	if err != nil {
@@ -271,24 +280,49 @@ func (c Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// extractUserID checks both context and URL param, ensuring they match.
-func extractUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
-	// 1. Read from JWT context
-	ctxVal := r.Context().Value(middleware.ContextUserKey)
-	if ctxVal == nil {
-		helper.WriteJSONError(w, http.StatusUnauthorized, fmt.Errorf("missing user in token"), nil)
-		return uuid.Nil, false
-	}
+// ReorderFavoritesRequestModel lists the ids of every favourite of the user, first to last
+type ReorderFavoritesRequestModel struct {
+	Order []uuid.UUID `json:"order"`
+}
 
-	userIDStr, ok := ctxVal.(string) // JWT middleware should store as string
+// Reorder orders the favourites of a user
+func (c Handler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := c.extractUserID(w, r)
 	if !ok {
-		helper.WriteJSONError(w, http.StatusUnauthorized, fmt.Errorf("invalid user ID in token"), nil)
-		return uuid.Nil, false
+		return
+	}
+
+	var req ReorderFavoritesRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
 	}
 
-	ctxUserID, err := uuid.Parse(userIDStr)
+	err := c.favoriteServices.Commands.ReorderFavoritesHandler.Handle(
+		commands.ReorderFavoritesRequest{
+			UserID: userID,
+			Order:  req.Order,
+			Source: middleware.AuditSource(r),
+		},
+	)
+	if errors.Is(err, favourite.ErrInvalidOrder) {
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+		return
+	}
 	if err != nil {
-		helper.WriteJSONError(w, http.StatusUnauthorized, fmt.Errorf("invalid user ID format in token"), nil)
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// extractUserID reads the user of the URL param, ensuring the authenticated principal may act for it.
+func (c Handler) extractUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	// 1. Read the principal from the authenticated claims
+	principal, err := principalFromClaims(middleware.ClaimsFromContext(r.Context()))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
 		return uuid.Nil, false
 	}
 
@@ -300,11 +334,38 @@ func extractUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
 		return uuid.Nil, false
 	}
 
-	// 3. Compare — forbid accessing other users
-	if ctxUserID != paramID {
-		helper.WriteJSONError(w, http.StatusForbidden, fmt.Errorf("forbidden: cannot access another user's data"), nil)
+	// 3. Ask the policy — users only access their own data, clients need an explicit scope
+	if err := c.actingPolicy.CanActFor(principal, paramID); err != nil {
+		helper.WriteJSONError(w, http.StatusForbidden, err, nil)
 		return uuid.Nil, false
 	}
 
-	return ctxUserID, true
+	return paramID, true
+}
+
+// principalFromClaims maps the claims stored by the authentication middleware to a policy principal
+func principalFromClaims(claims *helper.CustomClaims) (policy.Principal, error) {
+	if claims == nil {
+		return policy.Principal{}, fmt.Errorf("missing user in token")
+	}
+	if claims.TokenUse == helper.TokenUseClient {
+		return policy.Principal{Kind: policy.KindClient, ClientID: claims.ClientID, Scopes: claims.Scopes}, nil
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return policy.Principal{}, fmt.Errorf("invalid user ID format in token")
+	}
+	if claims.TokenUse == helper.TokenUseImpersonation {
+		actorID, err := uuid.Parse(claims.Act.Subject)
+		if err != nil {
+			return policy.Principal{}, fmt.Errorf("invalid actor ID format in token")
+		}
+		return policy.Principal{Kind: policy.KindImpersonation, UserID: userID, ActorID: actorID, Scopes: claims.Scopes}, nil
+	}
+	kind := policy.KindUser
+	if claims.TokenUse == helper.TokenUseAPIKey {
+		kind = policy.KindAPIKey
+	}
+	return policy.Principal{Kind: kind, UserID: userID, Scopes: claims.Scopes}, nil
 }
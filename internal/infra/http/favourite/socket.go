@@ -0,0 +1,636 @@
+package favourite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/policy"
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	queries2 "github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/websocket"
+	"github.com/google/uuid"
+)
+
+// SocketConfig limits and paces the WebSocket connections of the dashboards
+type SocketConfig struct {
+	// AuthTimeout is how long a connection may take to send its auth message
+	AuthTimeout time.Duration
+	// ReauthWarning is how long before its token expires a connection is asked for a new one
+	ReauthWarning time.Duration
+	// PingInterval is how often connections are pinged, a connection silent for two intervals is closed
+	PingInterval time.Duration
+	// WriteTimeout bounds every write to a client, a client not reading is disconnected
+	WriteTimeout time.Duration
+	// SendQueue is how many messages may wait for a client before the connection stops reading its commands
+	SendQueue int
+	// MaxMessageSize is the largest message accepted from a client, in bytes
+	MaxMessageSize int
+}
+
+// withDefaults fills the unset settings
+func (c SocketConfig) withDefaults() SocketConfig {
+	if c.AuthTimeout <= 0 {
+		c.AuthTimeout = 10 * time.Second
+	}
+	if c.ReauthWarning <= 0 {
+		c.ReauthWarning = time.Minute
+	}
+	if c.PingInterval <= 0 {
+		c.PingInterval = 30 * time.Second
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = 10 * time.Second
+	}
+	if c.SendQueue < 1 {
+		c.SendQueue = 64
+	}
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = 64 << 10
+	}
+	return c
+}
+
+// Types of the socket messages
+const (
+	// sent by the clients
+	SocketAuth        = "auth"
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketList        = "list"
+	SocketGet         = "get"
+	SocketStar        = "star"
+	SocketUpdate      = "update"
+	SocketUnstar      = "unstar"
+	SocketReorder     = "reorder"
+
+	// sent by the server
+	SocketResult       = "result"
+	SocketError        = "error"
+	SocketEvent        = "event"
+	SocketUnsubscribed = "unsubscribed"
+	SocketReauth       = "reauth"
+)
+
+// Codes of the socket errors
+const (
+	SocketInvalidRequest = "invalid_request"
+	SocketUnauthorized   = "unauthorized"
+	SocketForbidden      = "forbidden"
+	SocketUnknownType    = "unknown_type"
+	SocketFailed         = "failed"
+	SocketTooSlow        = "too_slow"
+	SocketTokenExpired   = "token_expired"
+)
+
+// CloseUnauthorized closes connections that did not authenticate in time, sent an invalid token or let it expire
+const CloseUnauthorized = 4001
+
+// SocketRequest is a message of a client. ID is echoed in the answer to correlate it, UserID defaults to the
+// authenticated user.
+type SocketRequest struct {
+	ID          string          `json:"id,omitempty"`
+	Type        string          `json:"type"`
+	Token       string          `json:"token,omitempty"`
+	UserID      uuid.UUID       `json:"user_id,omitempty"`
+	FavoriteID  uuid.UUID       `json:"favorite_id,omitempty"`
+	LastEventID string          `json:"last_event_id,omitempty"`
+	Favorite    json.RawMessage `json:"favorite,omitempty"`
+	// Order lists the ids of every favourite of the user, first to last
+	Order []uuid.UUID `json:"order,omitempty"`
+}
+
+// SocketMessage is a message of the server: the result or error of a request, or a pushed event
+type SocketMessage struct {
+	ID        string             `json:"id,omitempty"`
+	Type      string             `json:"type"`
+	UserID    *uuid.UUID         `json:"user_id,omitempty"`
+	Result    any                `json:"result,omitempty"`
+	Error     *SocketErrorDetail `json:"error,omitempty"`
+	Event     *SocketEventDetail `json:"event,omitempty"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty"`
+}
+
+// SocketErrorDetail explains why a request failed or a subscription ended
+type SocketErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SocketEventDetail is a favourite event, as in the favourite stream
+type SocketEventDetail struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Socket serves the WebSocket API of the dashboards: favourite subscriptions and commands over one
+// connection. A connection authenticates with an access token in the Authorization header of the
+// handshake, or in an auth message first, and sends a new one before it expires.
+func (c Handler) Socket(w http.ResponseWriter, r *http.Request) {
+	claims, err := middleware.JWTAuthenticator().Authenticate(r)
+	if err != nil && !errors.Is(err, middleware.ErrNoCredentials) {
+		helper.WriteJSONError(w, http.StatusUnauthorized, fmt.Errorf("invalid token: %w", err), nil)
+		return
+	}
+	conn, err := websocket.Upgrade(w, r, int64(c.socket.MaxMessageSize))
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetWriteTimeout(c.socket.WriteTimeout)
+
+	s := &socketSession{
+		handler:       c,
+		request:       r,
+		conn:          conn,
+		send:          make(chan []byte, c.socket.SendQueue),
+		done:          make(chan struct{}),
+		renewed:       make(chan struct{}, 1),
+		subscriptions: make(map[uuid.UUID]*stream.Subscription),
+	}
+	if claims != nil {
+		if err := s.authenticate(claims); err != nil {
+			s.closeUnauthorized(SocketForbidden, err.Error())
+			return
+		}
+	}
+	s.serve()
+}
+
+// socketSession is a connection of the WebSocket API. Its reader handles the requests in order, its writer
+// sends the queued messages, pings the client and watches the expiry of the token.
+type socketSession struct {
+	handler Handler
+	request *http.Request
+	conn    *websocket.Conn
+	send    chan []byte
+	// done is closed by stop when the connection ends
+	done     chan struct{}
+	stopOnce sync.Once
+	// renewed tells the writer the token was replaced
+	renewed chan struct{}
+
+	mu            sync.Mutex
+	claims        *helper.CustomClaims
+	principal     policy.Principal
+	subscriptions map[uuid.UUID]*stream.Subscription
+}
+
+// serve reads the requests until the connection ends
+func (s *socketSession) serve() {
+	cfg := s.handler.socket
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer s.stop()
+		s.write()
+	}()
+	defer func() {
+		s.stop()
+		s.mu.Lock()
+		for userID, sub := range s.subscriptions {
+			delete(s.subscriptions, userID)
+			s.handler.favoriteServices.Stream.Unsubscribe(sub)
+		}
+		s.mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		authenticated := s.currentClaims() != nil
+		if authenticated {
+			s.conn.SetReadTimeout(2 * cfg.PingInterval)
+		} else {
+			s.conn.SetReadTimeout(cfg.AuthTimeout)
+		}
+
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if !authenticated && errors.Is(err, os.ErrDeadlineExceeded) {
+				s.closeUnauthorized(SocketUnauthorized, "authentication timeout")
+			}
+			return
+		}
+
+		var req SocketRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			if !authenticated {
+				s.closeUnauthorized(SocketInvalidRequest, "the first message must be an auth message")
+				return
+			}
+			// answer with the id when only the other fields are invalid
+			var correlation struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(data, &correlation)
+			if !s.reply(correlation.ID, nil, &SocketErrorDetail{Code: SocketInvalidRequest, Message: "invalid message"}) {
+				return
+			}
+			continue
+		}
+		if !authenticated && req.Type != SocketAuth {
+			s.closeUnauthorized(SocketUnauthorized, "the first message must be an auth message")
+			return
+		}
+		if !s.handle(req) {
+			return
+		}
+	}
+}
+
+// handle answers a request, returning false when the connection must end
+func (s *socketSession) handle(req SocketRequest) bool {
+	if req.Type == SocketAuth {
+		result, errDetail := s.reauthenticate(req.Token)
+		if errDetail != nil && s.currentClaims() == nil {
+			s.closeUnauthorized(errDetail.Code, errDetail.Message)
+			return false
+		}
+		return s.reply(req.ID, result, errDetail)
+	}
+
+	claims, principal := s.identity()
+	userID := req.UserID
+	if userID == uuid.Nil {
+		userID = principal.UserID
+	}
+	if userID == uuid.Nil {
+		return s.reply(req.ID, nil, &SocketErrorDetail{Code: SocketInvalidRequest, Message: "missing user_id"})
+	}
+
+	scope := token.ScopeFavoritesRead
+	switch req.Type {
+	case SocketStar, SocketUpdate, SocketUnstar, SocketReorder:
+		scope = token.ScopeFavoritesWrite
+	}
+	if !middleware.HasScope(claims, scope) {
+		return s.reply(req.ID, nil, &SocketErrorDetail{Code: SocketForbidden, Message: "insufficient scope"})
+	}
+	if err := s.handler.actingPolicy.CanActFor(principal, userID); err != nil {
+		return s.reply(req.ID, nil, &SocketErrorDetail{Code: SocketForbidden, Message: err.Error()})
+	}
+
+	var result any
+	var err error
+	switch req.Type {
+	case SocketSubscribe:
+		return s.subscribe(req.ID, userID, req.LastEventID, claims)
+	case SocketUnsubscribe:
+		s.unsubscribe(userID)
+	case SocketList:
+		result, err = s.list(userID, claims)
+	case SocketGet:
+		result, err = s.handler.favoriteServices.Queries.GetFavoriteHandler.Handle(
+			queries.GetFavoriteRequest{UserID: userID, FavoriteID: req.FavoriteID, Source: s.auditSource(claims)},
+		)
+	case SocketStar:
+		result, err = s.star(userID, req.Favorite, claims)
+	case SocketUpdate:
+		result, err = s.update(userID, req.FavoriteID, req.Favorite, claims)
+	case SocketUnstar:
+		err = s.handler.favoriteServices.Commands.DeleteFavoriteHandler.Handle(
+			commands.DeleteFavoriteRequest{UserID: userID, FavoriteID: req.FavoriteID, Source: s.auditSource(claims)},
+		)
+	case SocketReorder:
+		err = s.handler.favoriteServices.Commands.ReorderFavoritesHandler.Handle(
+			commands.ReorderFavoritesRequest{UserID: userID, Order: req.Order, Source: s.auditSource(claims)},
+		)
+	default:
+		return s.reply(req.ID, nil, &SocketErrorDetail{Code: SocketUnknownType, Message: fmt.Sprintf("unknown message type %q", req.Type)})
+	}
+
+	var invalid invalidRequestError
+	switch {
+	case errors.As(err, &invalid), errors.Is(err, favourite.ErrInvalidOrder):
+		return s.reply(req.ID, nil, &SocketErrorDetail{Code: SocketInvalidRequest, Message: err.Error()})
+	case err != nil:
+		return s.reply(req.ID, nil, &SocketErrorDetail{Code: SocketFailed, Message: err.Error()})
+	}
+	return s.reply(req.ID, result, nil)
+}
+
+// invalidRequestError is a request the handlers were not asked to run
+type invalidRequestError string
+
+func (e invalidRequestError) Error() string { return string(e) }
+
+func (s *socketSession) list(userID uuid.UUID, claims *helper.CustomClaims) ([]queries.GetAllFavoritesResult, error) {
+	favorites, err := s.handler.favoriteServices.Queries.GetAllFavoritesHandler.Handle(
+		queries.GetAllFavoritesRequest{UserID: userID, Source: s.auditSource(claims)},
+	)
+	if favorites == nil && err == nil {
+		favorites = []queries.GetAllFavoritesResult{}
+	}
+	return favorites, err
+}
+
+func (s *socketSession) star(userID uuid.UUID, body json.RawMessage, claims *helper.CustomClaims) (map[string]string, error) {
+	var req CreateFavoriteRequestModel
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, invalidRequestError("invalid favorite")
+	}
+	if !req.Type.IsValid() {
+		return nil, invalidRequestError("invalid asset type")
+	}
+	if _, err := s.handler.userServices.Queries.GetUserHandler.Handle(queries2.GetUserRequest{ID: userID}); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	err := s.handler.favoriteServices.Commands.CreateFavoriteHandler.Handle(
+		commands.AddFavoriteRequest{
+			ID:          id,
+			UserID:      userID,
+			Type:        req.Type,
+			Description: req.Description,
+			Data:        req.Data,
+			Source:      s.auditSource(claims),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": id.String()}, nil
+}
+
+func (s *socketSession) update(userID, favoriteID uuid.UUID, body json.RawMessage, claims *helper.CustomClaims) (any, error) {
+	var req PatchFavoriteRequestModel
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, invalidRequestError("invalid favorite")
+	}
+	if req.Type != nil && !req.Type.IsValid() {
+		return nil, invalidRequestError("invalid asset type")
+	}
+	return s.handler.favoriteServices.Commands.UpdatePartialFavoriteHandler.HandlePartial(
+		userID, favoriteID,
+		commands.PatchFavoriteRequest{
+			Type:        req.Type,
+			Description: req.Description,
+			Data:        req.Data,
+			Source:      s.auditSource(claims),
+		},
+	)
+}
+
+// subscribe starts pushing the events of a user, after the buffered ones following lastEventID. The result
+// tells whether the client resumed, when it did not it reloads the favourites.
+func (s *socketSession) subscribe(requestID string, userID uuid.UUID, lastEventID string, claims *helper.CustomClaims) bool {
+	s.unsubscribe(userID)
+	watch, err := s.handler.favoriteServices.Queries.WatchFavoritesHandler.Handle(
+		queries.WatchFavoritesRequest{UserID: userID, LastEventID: lastEventID, Source: s.auditSource(claims)},
+	)
+	if err != nil {
+		return s.reply(requestID, nil, &SocketErrorDetail{Code: SocketFailed, Message: err.Error()})
+	}
+	sub := watch.Subscription
+	s.mu.Lock()
+	s.subscriptions[userID] = sub
+	s.mu.Unlock()
+
+	if !s.reply(requestID, map[string]bool{"resumed": watch.Resumed}, nil) {
+		return false
+	}
+	for _, e := range watch.Missed {
+		if !s.enqueue(eventMessage(userID, e)) {
+			return false
+		}
+	}
+	go s.forward(userID, sub)
+	return true
+}
+
+// forward queues the events of a subscription. When the client fell behind the hub ends the
+// subscription, and the client is told to subscribe again from its last event.
+func (s *socketSession) forward(userID uuid.UUID, sub *stream.Subscription) {
+	for e := range sub.C {
+		if !s.enqueue(eventMessage(userID, e)) {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	dropped := s.subscriptions[userID] == sub
+	if dropped {
+		delete(s.subscriptions, userID)
+	}
+	s.mu.Unlock()
+	if dropped {
+		s.enqueue(SocketMessage{
+			Type:   SocketUnsubscribed,
+			UserID: &userID,
+			Error:  &SocketErrorDetail{Code: SocketTooSlow, Message: "the events were not read in time, subscribe again with the last event id"},
+		})
+	}
+}
+
+func (s *socketSession) unsubscribe(userID uuid.UUID) {
+	s.mu.Lock()
+	sub, ok := s.subscriptions[userID]
+	delete(s.subscriptions, userID)
+	s.mu.Unlock()
+	if ok {
+		s.handler.favoriteServices.Stream.Unsubscribe(sub)
+	}
+}
+
+func eventMessage(userID uuid.UUID, e stream.Event) SocketMessage {
+	return SocketMessage{
+		Type:   SocketEvent,
+		UserID: &userID,
+		Event:  &SocketEventDetail{ID: e.ID, Type: e.Type, OccurredAt: e.OccurredAt, Data: e.Data},
+	}
+}
+
+// reauthenticate validates the token of an auth message. A connection keeps its principal, a new
+// token of someone else is refused.
+func (s *socketSession) reauthenticate(tokenStr string) (any, *SocketErrorDetail) {
+	if tokenStr == "" {
+		return nil, &SocketErrorDetail{Code: SocketUnauthorized, Message: "missing token"}
+	}
+	claims, err := helper.ParseAndValidateToken(tokenStr)
+	if err != nil {
+		return nil, &SocketErrorDetail{Code: SocketUnauthorized, Message: "invalid token: " + err.Error()}
+	}
+	if err := s.authenticate(claims); err != nil {
+		return nil, &SocketErrorDetail{Code: SocketForbidden, Message: err.Error()}
+	}
+
+	result := map[string]any{}
+	if claims.UserID != "" {
+		result["user_id"] = claims.UserID
+	}
+	if claims.ExpiresAt != nil {
+		result["expires_at"] = claims.ExpiresAt.Time
+	}
+	return result, nil
+}
+
+// authenticate sets the claims of the connection
+func (s *socketSession) authenticate(claims *helper.CustomClaims) error {
+	principal, err := principalFromClaims(claims)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claims != nil && !samePrincipal(s.principal, principal) {
+		return errors.New("the token is for another principal")
+	}
+	s.claims, s.principal = claims, principal
+
+	select {
+	case s.renewed <- struct{}{}:
+	default:
+	}
+	if claims.Act != nil {
+		log.Printf("impersonation: admin %s acting as user %s: %s %s request_id=%s",
+			claims.Act.Subject, claims.UserID, s.request.Method, s.request.URL.Path, middleware.RequestIDFromContext(s.request.Context()))
+	}
+	return nil
+}
+
+func samePrincipal(a, b policy.Principal) bool {
+	return a.Kind == b.Kind && a.UserID == b.UserID && a.ClientID == b.ClientID && a.ActorID == b.ActorID
+}
+
+func (s *socketSession) currentClaims() *helper.CustomClaims {
+	claims, _ := s.identity()
+	return claims
+}
+
+// identity returns the claims and principal of the connection, nil claims until it authenticated
+func (s *socketSession) identity() (*helper.CustomClaims, policy.Principal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.claims, s.principal
+}
+
+// auditSource attributes a command to the principal of the connection
+func (s *socketSession) auditSource(claims *helper.CustomClaims) audit.Source {
+	ctx := context.WithValue(s.request.Context(), middleware.ContextClaimsKey, claims)
+	return middleware.AuditSource(s.request.WithContext(ctx))
+}
+
+// reply queues the answer of a request
+func (s *socketSession) reply(id string, result any, errDetail *SocketErrorDetail) bool {
+	msg := SocketMessage{ID: id, Type: SocketResult, Result: result}
+	if errDetail != nil {
+		msg = SocketMessage{ID: id, Type: SocketError, Error: errDetail}
+	}
+	return s.enqueue(msg)
+}
+
+// enqueue waits for room in the send queue, returning false when the connection ended
+func (s *socketSession) enqueue(msg SocketMessage) bool {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("socket: failed to encode a %s message: %v", msg.Type, err)
+		return true
+	}
+	select {
+	case s.send <- data:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// write sends the queued messages and pings until the connection ends. It asks for a new token ahead
+// of the expiry of the current one, and closes the connection when it expires.
+func (s *socketSession) write() {
+	cfg := s.handler.socket
+	ping := time.NewTicker(cfg.PingInterval)
+	defer ping.Stop()
+	var warn, expire <-chan time.Time
+	var warnTimer, expireTimer *time.Timer
+	stopTimers := func() {
+		if warnTimer != nil {
+			warnTimer.Stop()
+			expireTimer.Stop()
+		}
+	}
+	defer stopTimers()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.renewed:
+			stopTimers()
+			warn, expire = nil, nil
+			if claims := s.currentClaims(); claims != nil && claims.ExpiresAt != nil {
+				expiresAt := claims.ExpiresAt.Time
+				warnTimer = time.NewTimer(time.Until(expiresAt.Add(-cfg.ReauthWarning)))
+				expireTimer = time.NewTimer(time.Until(expiresAt))
+				warn, expire = warnTimer.C, expireTimer.C
+			}
+		case <-warn:
+			claims := s.currentClaims()
+			if claims == nil || claims.ExpiresAt == nil {
+				continue
+			}
+			expiresAt := claims.ExpiresAt.Time
+			if !s.writeMessage(SocketMessage{Type: SocketReauth, ExpiresAt: &expiresAt}) {
+				return
+			}
+		case <-expire:
+			s.closeUnauthorized(SocketTokenExpired, "the token expired")
+			return
+		case data := <-s.send:
+			if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.Ping(); err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// stop ends the connection, releasing whoever waits for room in the send queue
+func (s *socketSession) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// writeMessage sends a message right away, closing the connection when the client does not read it
+func (s *socketSession) writeMessage(msg SocketMessage) bool {
+	data, err := json.Marshal(msg)
+	if err == nil {
+		err = s.conn.WriteMessage(websocket.TextMessage, data)
+	}
+	if err != nil {
+		s.conn.Close()
+		return false
+	}
+	return true
+}
+
+// closeUnauthorized sends the error and closes the connection with CloseUnauthorized
+func (s *socketSession) closeUnauthorized(code, message string) {
+	s.writeMessage(SocketMessage{Type: SocketError, Error: &SocketErrorDetail{Code: code, Message: message}})
+	s.conn.WriteClose(CloseUnauthorized, message)
+	s.conn.Close()
+}
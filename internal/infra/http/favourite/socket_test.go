@@ -0,0 +1,330 @@
+package favourite_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/policy"
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	favouritehttp "github.com/akazantzidis/gwi-ass/internal/infra/http/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/infra/storage/memory"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// socketServer serves the WebSocket API of a hub
+func socketServer(t *testing.T, hub *stream.Hub, cfg favouritehttp.SocketConfig) *httptest.Server {
+	return favouriteSocketServer(t, app.FavoriteServices{
+		Queries: app.Queries{WatchFavoritesHandler: queries.NewWatchFavoritesHandler(hub, &auditlogtest.Trail{})},
+		Stream:  hub,
+	}, cfg)
+}
+
+// favouriteSocketServer serves the WebSocket API of the favourite services
+func favouriteSocketServer(t *testing.T, services app.FavoriteServices, cfg favouritehttp.SocketConfig) *httptest.Server {
+	h := favouritehttp.NewHandler(services, app.UserServices{}, policy.NewActingPolicy(), cfg)
+	server := httptest.NewServer(http.HandlerFunc(h.Socket))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// socketClient speaks the client side of the WebSocket API over a raw connection
+type socketClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialSocket(t *testing.T, server *httptest.Server) *socketClient {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(gotime.Now().Add(10 * gotime.Second))
+
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if !assert.NoError(t, err) || !assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode) {
+		t.FailNow()
+	}
+	return &socketClient{t: t, conn: conn, br: br}
+}
+
+// send writes the request as a masked text frame
+func (c *socketClient) send(req any) {
+	payload, err := json.Marshal(req)
+	if !assert.NoError(c.t, err) {
+		c.t.FailNow()
+	}
+	frame := []byte{0x81}
+	if len(payload) <= 125 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err = c.conn.Write(frame)
+	assert.NoError(c.t, err)
+}
+
+// frame reads the next frame, pings aside
+func (c *socketClient) frame() (byte, []byte) {
+	for {
+		var header [2]byte
+		_, err := io.ReadFull(c.br, header[:])
+		if !assert.NoError(c.t, err) {
+			c.t.FailNow()
+		}
+		length := uint64(header[1] & 0x7f)
+		switch length {
+		case 126:
+			var ext [2]byte
+			_, err = io.ReadFull(c.br, ext[:])
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			_, err = io.ReadFull(c.br, ext[:])
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		payload := make([]byte, length)
+		if err == nil {
+			_, err = io.ReadFull(c.br, payload)
+		}
+		if !assert.NoError(c.t, err) {
+			c.t.FailNow()
+		}
+		if op := header[0] & 0x0f; op != 9 {
+			return op, payload
+		}
+	}
+}
+
+func (c *socketClient) message() favouritehttp.SocketMessage {
+	op, payload := c.frame()
+	if !assert.Equal(c.t, byte(1), op, "a text message") {
+		c.t.FailNow()
+	}
+	var msg favouritehttp.SocketMessage
+	if !assert.NoError(c.t, json.Unmarshal(payload, &msg)) {
+		c.t.FailNow()
+	}
+	return msg
+}
+
+// errorCode reads the next message and returns its error code
+func (c *socketClient) errorCode() string {
+	msg := c.message()
+	if !assert.Equal(c.t, favouritehttp.SocketError, msg.Type) || !assert.NotNil(c.t, msg.Error) {
+		c.t.FailNow()
+	}
+	return msg.Error.Code
+}
+
+func (c *socketClient) closeCode() int {
+	op, payload := c.frame()
+	if !assert.Equal(c.t, byte(8), op, "a close frame") || !assert.GreaterOrEqual(c.t, len(payload), 2) {
+		c.t.FailNow()
+	}
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+func (c *socketClient) authenticate(tokenStr string) {
+	c.send(favouritehttp.SocketRequest{ID: "auth", Type: favouritehttp.SocketAuth, Token: tokenStr})
+	msg := c.message()
+	if !assert.Equal(c.t, favouritehttp.SocketResult, msg.Type, "authenticated") {
+		c.t.FailNow()
+	}
+}
+
+func accessToken(t *testing.T, userID uuid.UUID) string {
+	tokenStr, err := helper.GenerateAccessToken(userID.String(), []string{"user"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return tokenStr
+}
+
+func newSocketHub() *stream.Hub {
+	return stream.NewHub(stream.Config{BufferSize: 10, Heartbeat: gotime.Hour}, time.NewTimeProvider())
+}
+
+func TestSocket_Authentication(t *testing.T) {
+	server := socketServer(t, newSocketHub(), favouritehttp.SocketConfig{AuthTimeout: 200 * gotime.Millisecond})
+
+	tests := []struct {
+		name  string
+		first *favouritehttp.SocketRequest
+		code  string
+	}{
+		{name: "no auth message in time", code: favouritehttp.SocketUnauthorized},
+		{name: "another message first", first: &favouritehttp.SocketRequest{Type: favouritehttp.SocketList}, code: favouritehttp.SocketUnauthorized},
+		{name: "an invalid token", first: &favouritehttp.SocketRequest{Type: favouritehttp.SocketAuth, Token: "nope"}, code: favouritehttp.SocketUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dialSocket(t, server)
+			if tt.first != nil {
+				client.send(tt.first)
+			}
+			assert.Equal(t, tt.code, client.errorCode())
+			assert.Equal(t, favouritehttp.CloseUnauthorized, client.closeCode())
+		})
+	}
+}
+
+func TestSocket_ReauthKeepsThePrincipal(t *testing.T) {
+	server := socketServer(t, newSocketHub(), favouritehttp.SocketConfig{})
+	userID, otherID := uuid.New(), uuid.New()
+
+	client := dialSocket(t, server)
+	client.authenticate(accessToken(t, userID))
+
+	client.send(favouritehttp.SocketRequest{ID: "1", Type: favouritehttp.SocketAuth, Token: accessToken(t, otherID)})
+	assert.Equal(t, favouritehttp.SocketForbidden, client.errorCode(), "the token of another user is refused")
+	client.send(favouritehttp.SocketRequest{ID: "2", Type: favouritehttp.SocketSubscribe, UserID: otherID})
+	assert.Equal(t, favouritehttp.SocketForbidden, client.errorCode(), "the connection is still the first user's")
+
+	client.authenticate(accessToken(t, userID))
+	client.send(favouritehttp.SocketRequest{ID: "3", Type: favouritehttp.SocketSubscribe})
+	assert.Equal(t, favouritehttp.SocketResult, client.message().Type, "a new token of the same user is accepted")
+}
+
+func TestSocket_Expiry(t *testing.T) {
+	ttl := helper.AccessTokenTTL
+	helper.AccessTokenTTL = 2 * gotime.Second
+	t.Cleanup(func() { helper.AccessTokenTTL = ttl })
+	server := socketServer(t, newSocketHub(), favouritehttp.SocketConfig{ReauthWarning: gotime.Second})
+
+	client := dialSocket(t, server)
+	client.authenticate(accessToken(t, uuid.New()))
+
+	msg := client.message()
+	assert.Equal(t, favouritehttp.SocketReauth, msg.Type, "asked for a new token before the expiry")
+	assert.NotNil(t, msg.ExpiresAt)
+	assert.Equal(t, favouritehttp.SocketTokenExpired, client.errorCode())
+	assert.Equal(t, favouritehttp.CloseUnauthorized, client.closeCode())
+}
+
+func TestSocket_ReadOnlyImpersonation(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	server := socketServer(t, newSocketHub(), favouritehttp.SocketConfig{})
+	userID, adminID := uuid.New(), uuid.New()
+	tokenStr, _, err := helper.GenerateImpersonationToken(userID.String(), adminID.String(), []string{token.ScopeFavoritesRead})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	client := dialSocket(t, server)
+	client.authenticate(tokenStr)
+	client.send(favouritehttp.SocketRequest{ID: "1", Type: favouritehttp.SocketStar, Favorite: json.RawMessage(`{"type":"chart"}`)})
+	assert.Equal(t, favouritehttp.SocketForbidden, client.errorCode())
+	client.send(favouritehttp.SocketRequest{ID: "2", Type: favouritehttp.SocketSubscribe})
+	assert.Equal(t, favouritehttp.SocketResult, client.message().Type, "reading is allowed")
+
+	assert.Contains(t, logs.String(), fmt.Sprintf("impersonation: admin %s acting as user %s: GET /ws", adminID, userID))
+}
+
+func TestSocket_TooSlow(t *testing.T) {
+	hub := newSocketHub()
+	server := socketServer(t, hub, favouritehttp.SocketConfig{SendQueue: 1})
+	userID := uuid.New()
+
+	client := dialSocket(t, server)
+	client.authenticate(accessToken(t, userID))
+	client.send(favouritehttp.SocketRequest{ID: "1", Type: favouritehttp.SocketSubscribe})
+	if !assert.Equal(t, favouritehttp.SocketResult, client.message().Type) {
+		return
+	}
+
+	// the client does not read while the events pile up
+	for i := 0; i < 5000; i++ {
+		publish(t, hub, userID)
+	}
+	for {
+		msg := client.message()
+		if msg.Type == favouritehttp.SocketEvent {
+			continue
+		}
+		assert.Equal(t, favouritehttp.SocketUnsubscribed, msg.Type)
+		if assert.NotNil(t, msg.Error) {
+			assert.Equal(t, favouritehttp.SocketTooSlow, msg.Error.Code)
+		}
+		return
+	}
+}
+
+func TestSocket_Reorder(t *testing.T) {
+	repo := memory.NewRepo(memory.NewOutboxRepo(), memory.NewAuditRepo())
+	stamper := auditlog.NewStamper(uuidprovider.NewUUIDProvider(), time.NewTimeProvider())
+	trail := &auditlogtest.Trail{}
+	server := favouriteSocketServer(t, app.FavoriteServices{
+		Queries: app.Queries{GetAllFavoritesHandler: queries.NewGetAllFavoritesRequestHandler(repo, trail)},
+		Commands: app.Commands{
+			CreateFavoriteHandler:   commands.NewAddFavoriteRequestHandler(repo, stamper),
+			ReorderFavoritesHandler: commands.NewReorderFavoritesRequestHandler(repo, stamper),
+		},
+	}, favouritehttp.SocketConfig{})
+	userID := uuid.New()
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		id := uuid.New()
+		if !assert.NoError(t, commands.NewAddFavoriteRequestHandler(repo, stamper).Handle(commands.AddFavoriteRequest{ID: id, UserID: userID, Type: favourite.AssetChart})) {
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	client := dialSocket(t, server)
+	client.authenticate(accessToken(t, userID))
+	client.send(favouritehttp.SocketRequest{ID: "1", Type: favouritehttp.SocketReorder, Order: []uuid.UUID{ids[2], ids[0]}})
+	assert.Equal(t, favouritehttp.SocketInvalidRequest, client.errorCode(), "an order missing a favourite")
+
+	client.send(favouritehttp.SocketRequest{ID: "2", Type: favouritehttp.SocketReorder, Order: []uuid.UUID{ids[2], ids[0], ids[1]}})
+	assert.Equal(t, favouritehttp.SocketResult, client.message().Type)
+
+	client.send(favouritehttp.SocketRequest{ID: "3", Type: favouritehttp.SocketList})
+	var listed []struct {
+		ID       uuid.UUID `json:"id"`
+		Position int       `json:"position"`
+	}
+	raw, _ := json.Marshal(client.message().Result)
+	if !assert.NoError(t, json.Unmarshal(raw, &listed)) || !assert.Len(t, listed, 3) {
+		return
+	}
+	for i, fav := range listed {
+		assert.Equal(t, []uuid.UUID{ids[2], ids[0], ids[1]}[i], fav.ID)
+		assert.Equal(t, i, fav.Position)
+	}
+}
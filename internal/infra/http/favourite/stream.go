@@ -0,0 +1,92 @@
+package favourite
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+)
+
+// Stream pushes the favourite events of a user as Server-Sent Events. A client reconnecting with
+// Last-Event-ID gets the buffered events it missed first, or a "reset" event when they are no longer
+// buffered. The stream ends when the credentials of the request expire, with an "expired" event.
+func (c Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := c.extractUserID(w, r)
+	if !ok {
+		return
+	}
+	rc := http.NewResponseController(w)
+	hub := c.favoriteServices.Stream
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	watch, err := c.favoriteServices.Queries.WatchFavoritesHandler.Handle(
+		queries.WatchFavoritesRequest{UserID: userID, LastEventID: lastEventID, Source: middleware.AuditSource(r)},
+	)
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	defer hub.Unsubscribe(watch.Subscription)
+
+	// the stream outlives the write deadline of the server
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !watch.Resumed {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range watch.Missed {
+		writeEvent(w, e)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(hub.Heartbeat())
+	defer heartbeat.Stop()
+	var expired <-chan time.Time
+	if claims := middleware.ClaimsFromContext(r.Context()); claims != nil && claims.ExpiresAt != nil {
+		timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired:
+			fmt.Fprint(w, "event: expired\ndata: {}\n\n")
+			rc.Flush()
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case e, ok := <-watch.Subscription.C:
+			if !ok {
+				return // fell behind, the client resumes from its last event
+			}
+			writeEvent(w, e)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e stream.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
@@ -0,0 +1,162 @@
+package favourite_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog/auditlogtest"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/policy"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/favourite/queries"
+	"github.com/akazantzidis/gwi-ass/internal/app/stream"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	favouritehttp "github.com/akazantzidis/gwi-ass/internal/infra/http/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamServer serves the favourite stream of a hub, as the claims returned by claims
+func streamServer(t *testing.T, hub *stream.Hub, claims func() *helper.CustomClaims) *httptest.Server {
+	services := app.FavoriteServices{
+		Queries: app.Queries{WatchFavoritesHandler: queries.NewWatchFavoritesHandler(hub, &auditlogtest.Trail{})},
+		Stream:  hub,
+	}
+	h := favouritehttp.NewHandler(services, app.UserServices{}, policy.NewActingPolicy(), favouritehttp.SocketConfig{})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{userID}/favourites/stream", func(w http.ResponseWriter, r *http.Request) {
+		h.Stream(w, r.WithContext(context.WithValue(r.Context(), middleware.ContextClaimsKey, claims())))
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func userClaims(userID uuid.UUID, expiresIn gotime.Duration) func() *helper.CustomClaims {
+	return func() *helper.CustomClaims {
+		return &helper.CustomClaims{
+			UserID:           userID.String(),
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(gotime.Now().Add(expiresIn))},
+		}
+	}
+}
+
+// sseEvent is an event read from a stream, heartbeats aside
+type sseEvent struct {
+	id, name string
+}
+
+// openStream opens the stream of the user, resuming after lastEventID when set, and returns its events
+func openStream(t *testing.T, server *httptest.Server, userID uuid.UUID, lastEventID string) <-chan sseEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/users/"+userID.String()+"/favourites/stream", nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := server.Client().Do(req)
+	if !assert.NoError(t, err) || !assert.Equal(t, http.StatusOK, resp.StatusCode) {
+		t.FailNow()
+	}
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		var e sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				e.name = strings.TrimPrefix(line, "event: ")
+			case line == "" && e.name != "":
+				events <- e
+				e = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func next(t *testing.T, events <-chan sseEvent) sseEvent {
+	select {
+	case e, ok := <-events:
+		if !ok {
+			assert.Fail(t, "the stream ended")
+			t.FailNow()
+		}
+		return e
+	case <-gotime.After(2 * gotime.Second):
+		assert.Fail(t, "no event received")
+		t.FailNow()
+	}
+	return sseEvent{}
+}
+
+func publish(t *testing.T, hub *stream.Hub, userID uuid.UUID) string {
+	envelope := eventbus.Envelope{
+		ID:         uuid.New(),
+		OccurredAt: gotime.Now(),
+		Event:      event.FavoriteAdded{UserID: userID, Favorite: favourite.Favorite{ID: uuid.New(), Type: favourite.AssetChart}},
+	}
+	if !assert.NoError(t, hub.Handle(envelope)) {
+		t.FailNow()
+	}
+	return envelope.ID.String()
+}
+
+func TestStream_Resume(t *testing.T) {
+	hub := stream.NewHub(stream.Config{BufferSize: 2, Heartbeat: gotime.Hour}, time.NewTimeProvider())
+	userID := uuid.New()
+	server := streamServer(t, hub, userClaims(userID, gotime.Hour))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, publish(t, hub, userID))
+	}
+
+	events := openStream(t, server, userID, ids[1])
+	assert.Equal(t, sseEvent{id: ids[2], name: event.FavoriteAddedName}, next(t, events), "the missed event first")
+	live := publish(t, hub, userID)
+	assert.Equal(t, sseEvent{id: live, name: event.FavoriteAddedName}, next(t, events), "then the live ones")
+
+	events = openStream(t, server, userID, ids[0])
+	assert.Equal(t, "reset", next(t, events).name, "the event is no longer buffered")
+	live = publish(t, hub, userID)
+	assert.Equal(t, live, next(t, events).id)
+
+	events = openStream(t, server, userID, uuid.NewString())
+	assert.Equal(t, "reset", next(t, events).name, "an unknown event")
+
+	events = openStream(t, server, userID, "")
+	live = publish(t, hub, userID)
+	assert.Equal(t, sseEvent{id: live, name: event.FavoriteAddedName}, next(t, events), "a new stream only gets the live events")
+}
+
+func TestStream_ExpiresWithTheCredentials(t *testing.T) {
+	hub := stream.NewHub(stream.Config{BufferSize: 10, Heartbeat: gotime.Hour}, time.NewTimeProvider())
+	userID := uuid.New()
+	server := streamServer(t, hub, userClaims(userID, 300*gotime.Millisecond))
+
+	events := openStream(t, server, userID, "")
+	assert.Equal(t, "expired", next(t, events).name)
+	_, open := <-events
+	assert.False(t, open, "the stream ends")
+}
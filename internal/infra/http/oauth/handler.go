@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/query"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+)
+
+// GrantTypeClientCredentials is the only grant supported by the token endpoint
+const GrantTypeClientCredentials = "client_credentials"
+
+// TokenResponse is the RFC 6749 access token response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// ErrorResponse is the RFC 6749 error response
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Handler OAuth2 http request Handler
+type Handler struct {
+	authServices app.AuthServices
+}
+
+// NewHandler constructor
+func NewHandler(authApp app.AuthServices) *Handler {
+	return &Handler{authServices: authApp}
+}
+
+// Token implements the client_credentials grant. Clients authenticate with HTTP Basic
+// or with client_id and client_secret form parameters.
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+	if grant := r.PostForm.Get("grant_type"); grant != GrantTypeClientCredentials {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	clientID, secret := clientCredentials(r)
+	result, err := h.authServices.Commands.ClientCredentialsHandler.Handle(command.ClientCredentialsRequest{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Scopes:       strings.Fields(r.PostForm.Get("scope")),
+	})
+	switch {
+	case errors.Is(err, oauth.ErrInvalidClient):
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	case errors.Is(err, token.ErrInvalidScope):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+		return
+	case err != nil:
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(TokenResponse{
+		AccessToken: result.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(result.ExpiresAt).Seconds()),
+		Scope:       strings.Join(result.Scopes, " "),
+	})
+}
+
+// Introspect implements RFC 7662 token introspection for clients granted tokens:introspect
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+	tok := r.PostForm.Get("token")
+	if tok == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "missing token")
+		return
+	}
+
+	clientID, secret := clientCredentials(r)
+	result, err := h.authServices.Queries.IntrospectHandler.Handle(query.IntrospectRequest{
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Token:        tok,
+	})
+	switch {
+	case errors.Is(err, oauth.ErrInvalidClient):
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	case errors.Is(err, query.ErrIntrospectionNotAllowed):
+		writeOAuthError(w, http.StatusForbidden, "insufficient_scope", err.Error())
+		return
+	case err != nil:
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(result)
+}
+
+// clientCredentials reads the client credentials from HTTP Basic, falling back to the form
+func clientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: code, ErrorDescription: description})
+}
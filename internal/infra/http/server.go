@@ -3,8 +3,12 @@ package http
 import (
 	"fmt"
 	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/infra/http/admin"
 	"github.com/akazantzidis/gwi-ass/internal/infra/http/auth"
 	"github.com/akazantzidis/gwi-ass/internal/infra/http/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/infra/http/oauth"
+	"github.com/akazantzidis/gwi-ass/internal/infra/http/user"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
 	"github.com/gorilla/mux"
 	"log"
@@ -18,43 +22,110 @@ type Server struct {
 }
 
 // NewServer HTTP Server constructor
-func NewServer(appServicesF app.Services) *Server {
+func NewServer(appServicesF app.Services, cookies auth.CookieConfig, socket favourite.SocketConfig) *Server {
 	httpServer := &Server{appServicesF: appServicesF}
 	httpServer.router = mux.NewRouter()
+	httpServer.router.Use(middleware.RequestID)
 
 	// use services to initialize handlers
-	authHandler := auth.NewAuthHandler(appServicesF.AuthServices)
+	authHandler := auth.NewAuthHandler(appServicesF.AuthServices, cookies)
+	userHandler := user.NewHandler(appServicesF.UserServices)
+	adminHandler := admin.NewHandler(appServicesF.AuthServices, appServicesF.UserServices, appServicesF.AdminServices)
+	oauthHandler := oauth.NewHandler(appServicesF.AuthServices)
 
 	// Public routes
 	public := httpServer.router.PathPrefix("/").Subrouter()
 	public.HandleFunc("/login", authHandler.Login).Methods("POST")
+	public.HandleFunc("/login/mfa", authHandler.LoginMFA).Methods("POST")
 	public.HandleFunc("/refresh", authHandler.Refresh).Methods("POST")
 	public.HandleFunc("/logout", authHandler.Logout).Methods("POST")
+	public.HandleFunc("/oauth/token", oauthHandler.Token).Methods("POST")
+	public.HandleFunc("/oauth/introspect", oauthHandler.Introspect).Methods("POST")
+	if appServicesF.AuthServices.Commands.StartOIDCLoginHandler != nil {
+		public.HandleFunc("/oidc/login", authHandler.OIDCLogin).Methods("GET")
+		public.HandleFunc("/oidc/callback", authHandler.OIDCCallback).Methods("GET")
+	}
+	public.HandleFunc("/register", userHandler.Register).Methods("POST")
+	public.HandleFunc("/password/forgot", userHandler.ForgotPassword).Methods("POST")
+	public.HandleFunc("/password/reset", userHandler.ResetPassword).Methods("POST")
 	public.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	}).Methods("GET")
 
-	// Private routes - apply JWT middleware
+	// Private routes - accept access tokens of users and clients, API keys and browser session cookies
 	private := httpServer.router.PathPrefix("/").Subrouter()
-	private.Use(middleware.JWTMiddleware)
+	private.Use(middleware.Authenticate(
+		middleware.JWTAuthenticator(),
+		middleware.APIKeyAuthenticator(authHandler.ResolveAPIKey),
+		middleware.CookieAuthenticator(),
+	))
 
-	h := favourite.NewHandler(httpServer.appServicesF.FavoriteServices, httpServer.appServicesF.UserServices)
+	h := favourite.NewHandler(httpServer.appServicesF.FavoriteServices, httpServer.appServicesF.UserServices, httpServer.appServicesF.ActingPolicy, socket)
 	base := "/users/{userID}/favorites"
+	read := middleware.RequireScope(token.ScopeFavoritesRead)
+	write := middleware.RequireScope(token.ScopeFavoritesWrite)
 
-	private.HandleFunc(base, h.GetAll).Methods("GET")
-	private.HandleFunc(base+"/{favoriteId}", h.GetByID).Methods("GET")
-	private.HandleFunc(base, h.Create).Methods("POST")
-	private.HandleFunc(base+"/{favoriteId}", h.Patch).Methods("PATCH")
-	private.HandleFunc(base+"/{favoriteId}", h.Update).Methods("PUT")
-	private.HandleFunc(base+"/{favoriteId}", h.Delete).Methods("DELETE")
+	private.Handle(base, read(http.HandlerFunc(h.GetAll))).Methods("GET")
+	private.Handle(base+"/stream", read(http.HandlerFunc(h.Stream))).Methods("GET")
+	private.Handle(base+"/{favoriteId}", read(http.HandlerFunc(h.GetByID))).Methods("GET")
+	private.Handle(base, write(http.HandlerFunc(h.Create))).Methods("POST")
+	private.Handle(base+"/order", write(http.HandlerFunc(h.Reorder))).Methods("PUT")
+	private.Handle(base+"/{favoriteId}", write(http.HandlerFunc(h.Patch))).Methods("PATCH")
+	private.Handle(base+"/{favoriteId}", write(http.HandlerFunc(h.Update))).Methods("PUT")
+	private.Handle(base+"/{favoriteId}", write(http.HandlerFunc(h.Delete))).Methods("DELETE")
+
+	// the socket authenticates its connections itself, browsers cannot send an Authorization header with the handshake
+	public.HandleFunc("/ws", h.Socket).Methods("GET")
+
+	// account routes are only available to user sessions
+	account := private.PathPrefix("/").Subrouter()
+	account.Use(middleware.RequireSession)
+
+	// session management of the authenticated user
+	account.HandleFunc("/logout-all", authHandler.LogoutAll).Methods("POST")
+	account.HandleFunc("/me/sessions", authHandler.Sessions).Methods("GET")
+	account.HandleFunc("/me/sessions/{id}", authHandler.RevokeSession).Methods("DELETE")
+	account.HandleFunc("/me/api-keys", authHandler.APIKeys).Methods("GET")
+	account.HandleFunc("/me/api-keys", authHandler.CreateAPIKey).Methods("POST")
+	account.HandleFunc("/me/api-keys/{id}", authHandler.RevokeAPIKey).Methods("DELETE")
+	account.HandleFunc("/me/password", userHandler.ChangePassword).Methods("POST")
+	account.HandleFunc("/me/email", userHandler.UpdateEmail).Methods("PUT")
+	account.HandleFunc("/me/notification-preferences", userHandler.NotificationPreferences).Methods("GET")
+	account.HandleFunc("/me/notification-preferences", userHandler.UpdateNotificationPreferences).Methods("PUT")
+	account.HandleFunc("/me/notifications", userHandler.Notifications).Methods("GET")
+	account.HandleFunc("/me/notifications/read-all", userHandler.MarkAllNotificationsRead).Methods("POST")
+	account.HandleFunc("/me/notifications/{id}/read", userHandler.MarkNotificationRead).Methods("POST")
+	account.HandleFunc("/me/mfa/totp/enroll", userHandler.EnrollTOTP).Methods("POST")
+	account.HandleFunc("/me/mfa/totp/confirm", userHandler.ConfirmTOTP).Methods("POST")
+	account.HandleFunc("/me/mfa/totp/disable", userHandler.DisableTOTP).Methods("POST")
 
 	// admin-only route
-	adminOnly := private.PathPrefix("/admin").Subrouter()
+	adminOnly := account.PathPrefix("/admin").Subrouter()
 	adminOnly.Use(middleware.RequireRole("admin"))
-	adminOnly.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("secret admin stats"))
-	}).Methods("GET")
+	adminOnly.HandleFunc("/stats", adminHandler.Stats).Methods("GET")
+	adminOnly.HandleFunc("/audit", adminHandler.ListAudit).Methods("GET")
+	adminOnly.HandleFunc("/audit/export", adminHandler.ExportAudit).Methods("GET")
+	adminOnly.HandleFunc("/notifications/dead-letters", adminHandler.ListDeadLetters).Methods("GET")
+	adminOnly.HandleFunc("/notifications/dead-letters/{id}/replay", adminHandler.ReplayDeadLetter).Methods("POST")
+	adminOnly.HandleFunc("/webhooks", adminHandler.ListWebhooks).Methods("GET")
+	adminOnly.HandleFunc("/webhooks", adminHandler.CreateWebhook).Methods("POST")
+	adminOnly.HandleFunc("/webhooks/{id}", adminHandler.GetWebhook).Methods("GET")
+	adminOnly.HandleFunc("/webhooks/{id}", adminHandler.UpdateWebhook).Methods("PATCH")
+	adminOnly.HandleFunc("/webhooks/{id}", adminHandler.DeleteWebhook).Methods("DELETE")
+	adminOnly.HandleFunc("/webhooks/{id}/deliveries", adminHandler.ListWebhookDeliveries).Methods("GET")
+	adminOnly.HandleFunc("/lockouts", adminHandler.ListLockouts).Methods("GET")
+	adminOnly.HandleFunc("/lockouts/unlock", adminHandler.Unlock).Methods("POST")
+	adminOnly.HandleFunc("/clients", adminHandler.ListClients).Methods("GET")
+	adminOnly.HandleFunc("/clients", adminHandler.RegisterClient).Methods("POST")
+	adminOnly.HandleFunc("/clients/{id}", adminHandler.DeleteClient).Methods("DELETE")
+	adminOnly.HandleFunc("/users", adminHandler.ListUsers).Methods("GET")
+	adminOnly.HandleFunc("/users", adminHandler.CreateUser).Methods("POST")
+	adminOnly.HandleFunc("/users/{id}/roles", adminHandler.SetUserRoles).Methods("PUT")
+	adminOnly.HandleFunc("/users/{id}/disable", adminHandler.DisableUser).Methods("POST")
+	adminOnly.HandleFunc("/users/{id}/enable", adminHandler.EnableUser).Methods("POST")
+	adminOnly.HandleFunc("/users/{id}/sessions", adminHandler.RevokeUserSessions).Methods("DELETE")
+	adminOnly.HandleFunc("/users/{id}/impersonate", adminHandler.Impersonate).Methods("POST")
 
 	http.Handle("/", httpServer.router)
 	return httpServer
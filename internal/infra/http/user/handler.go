@@ -0,0 +1,272 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/akazantzidis/gwi-ass/internal/app"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/mfa"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+)
+
+// Handler User http request Handler
+type Handler struct {
+	userServices app.UserServices
+}
+
+// NewHandler constructor
+func NewHandler(userApp app.UserServices) *Handler {
+	return &Handler{userServices: userApp}
+}
+
+// RegisterRequestModel represents the request model of Register
+type RegisterRequestModel struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ChangePasswordRequestModel represents the request model of ChangePassword
+type ChangePasswordRequestModel struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// UpdateEmailRequestModel represents the request model of UpdateEmail
+type UpdateEmailRequestModel struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+// ForgotPasswordRequestModel represents the request model of ForgotPassword
+type ForgotPasswordRequestModel struct {
+	Username string `json:"username"`
+}
+
+// ResetPasswordRequestModel represents the request model of ResetPassword
+type ResetPasswordRequestModel struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ConfirmTOTPRequestModel represents the request model of ConfirmTOTP
+type ConfirmTOTPRequestModel struct {
+	Code string `json:"code"`
+}
+
+// DisableTOTPRequestModel represents the request model of DisableTOTP
+type DisableTOTPRequestModel struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// Register creates a new user account
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.userServices.Commands.RegisterUserHandler.Handle(commands.RegisterUserRequest{
+		Username: req.Username,
+		Password: req.Password,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ChangePassword replaces the password of the authenticated user
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	var req ChangePasswordRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	err = h.userServices.Commands.ChangePasswordHandler.Handle(commands.ChangePasswordRequest{
+		UserID:          userID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateEmail replaces the email address of the authenticated user, an empty one removes it
+func (h *Handler) UpdateEmail(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	var req UpdateEmailRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	err = h.userServices.Commands.UpdateEmailHandler.Handle(commands.UpdateEmailRequest{
+		UserID:          userID,
+		Email:           req.Email,
+		CurrentPassword: req.CurrentPassword,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword sends a reset token to the user. The response is the same whether the user exists or not.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	if err := h.userServices.Commands.ForgotPasswordHandler.Handle(commands.ForgotPasswordRequest{
+		Username: req.Username,
+	}); err != nil {
+		// never surface delivery failures, they would reveal that the user exists
+		log.Printf("password reset: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "if the account exists, a password reset token has been sent",
+	})
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	err := h.userServices.Commands.ResetPasswordHandler.Handle(commands.ResetPasswordRequest{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EnrollTOTP starts a TOTP enrolment and returns the secret to add to an authenticator app
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	result, err := h.userServices.Commands.EnrollTOTPHandler.Handle(userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ConfirmTOTP enables two-factor authentication and returns the recovery codes, shown only once
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	var req ConfirmTOTPRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	result, err := h.userServices.Commands.ConfirmTOTPHandler.Handle(commands.ConfirmTOTPRequest{
+		UserID: userID,
+		Code:   req.Code,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DisableTOTP turns two-factor authentication off
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	var req DisableTOTPRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+
+	err = h.userServices.Commands.DisableTOTPHandler.Handle(commands.DisableTOTPRequest{
+		UserID:   userID,
+		Password: req.Password,
+		Code:     req.Code,
+	})
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUserError maps the user domain errors to their http status
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, user.ErrUsernameTaken):
+		helper.WriteJSONError(w, http.StatusConflict, err, nil)
+	case errors.Is(err, user.ErrInvalidUsername), errors.Is(err, user.ErrWeakPassword), errors.Is(err, user.ErrInvalidEmail), errors.Is(err, commands.ErrInvalidResetToken):
+		helper.WriteJSONError(w, http.StatusBadRequest, err, nil)
+	case errors.Is(err, commands.ErrInvalidCurrentPassword), errors.Is(err, mfa.ErrInvalidCode):
+		helper.WriteJSONError(w, http.StatusForbidden, err, nil)
+	case errors.Is(err, commands.ErrTOTPAlreadyEnabled), errors.Is(err, commands.ErrNoPendingTOTP), errors.Is(err, mfa.ErrNotEnrolled):
+		helper.WriteJSONError(w, http.StatusConflict, err, nil)
+	default:
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+	}
+}
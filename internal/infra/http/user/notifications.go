@@ -0,0 +1,192 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/commands"
+	"github.com/akazantzidis/gwi-ass/internal/app/user/queries"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// NotificationIDURLParam is the URL param of an inbox notification id
+const NotificationIDURLParam = "id"
+
+// NotificationPreferencesRequestModel represents the request model of UpdateNotificationPreferences.
+// It replaces the preferences, so an absent quiet_hours removes them.
+type NotificationPreferencesRequestModel struct {
+	Locale      string                   `json:"locale"`
+	Channels    []string                 `json:"channels"`
+	MutedEvents []string                 `json:"muted_events"`
+	Digest      string                   `json:"digest"`
+	QuietHours  *notification.QuietHours `json:"quiet_hours"`
+}
+
+// NotificationPreferences returns the notification preferences of the authenticated user
+func (h *Handler) NotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	prefs, err := h.userServices.Queries.GetNotificationPreferencesHandler.Handle(userID)
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdateNotificationPreferences replaces the notification preferences of the authenticated user
+func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	var req NotificationPreferencesRequestModel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body"), nil)
+		return
+	}
+	if req.Channels == nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("channels is required, [] for security notifications only"), nil)
+		return
+	}
+
+	prefs, err := h.userServices.Commands.UpdateNotificationPreferencesHandler.Handle(commands.UpdateNotificationPreferencesRequest{
+		UserID: userID,
+		Preferences: notification.Preferences{
+			Locale:      req.Locale,
+			Channels:    req.Channels,
+			MutedEvents: req.MutedEvents,
+			Digest:      req.Digest,
+			QuietHours:  req.QuietHours,
+		},
+	})
+	if errors.Is(err, notification.ErrInvalidPreferences) {
+		helper.WriteJSONError(w, http.StatusBadRequest, err, map[string][]string{
+			"channels":       notification.Channels,
+			"mutable_events": notification.MutableEvents,
+			"digest":         notification.DigestCadences,
+		})
+		return
+	}
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// Notifications returns a page of the inbox of the authenticated user, newest first, with the
+// unread count. ?unread=true lists the unread notifications only.
+func (h *Handler) Notifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	q := r.URL.Query()
+	offset, err := intParam(q.Get("offset"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid offset"), nil)
+		return
+	}
+	limit, err := intParam(q.Get("limit"))
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid limit"), nil)
+		return
+	}
+	unreadOnly := false
+	if v := q.Get("unread"); v != "" {
+		if unreadOnly, err = strconv.ParseBool(v); err != nil {
+			helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid unread"), nil)
+			return
+		}
+	}
+
+	result, err := h.userServices.Queries.ListNotificationsHandler.Handle(queries.ListNotificationsRequest{
+		UserID:     userID,
+		UnreadOnly: unreadOnly,
+		Offset:     offset,
+		Limit:      limit,
+	})
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// MarkNotificationRead marks one notification of the inbox of the authenticated user read
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)[NotificationIDURLParam])
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid notification ID"), nil)
+		return
+	}
+
+	err = h.userServices.Commands.MarkNotificationReadHandler.Handle(commands.MarkNotificationReadRequest{UserID: userID, ID: id})
+	if errors.Is(err, notification.ErrInboxItemNotFound) {
+		helper.WriteJSONError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllNotificationsRead marks every notification of the inbox of the authenticated user read
+func (h *Handler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+
+	marked, err := h.userServices.Commands.MarkAllNotificationsReadHandler.Handle(userID)
+	if err != nil {
+		helper.WriteJSONError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"marked": marked})
+}
+
+func intParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid number %q", v)
+	}
+	return i, nil
+}
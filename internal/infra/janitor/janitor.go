@@ -0,0 +1,51 @@
+// Package janitor runs periodic background cleanup tasks.
+package janitor
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Task removes stale records and reports how many were removed
+type Task func() (int, error)
+
+// Janitor runs a Task on a fixed interval
+type Janitor struct {
+	name     string
+	interval time.Duration
+	task     Task
+}
+
+// New Janitor constructor
+func New(name string, interval time.Duration, task Task) *Janitor {
+	return &Janitor{name: name, interval: interval, task: task}
+}
+
+// Run executes the task on every tick until the context is cancelled
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.RunOnce()
+		}
+	}
+}
+
+// RunOnce executes the task a single time, logging the outcome
+func (j *Janitor) RunOnce() (int, error) {
+	removed, err := j.task()
+	if err != nil {
+		log.Printf("janitor %s: purge failed: %v", j.name, err)
+		return removed, err
+	}
+	if removed > 0 {
+		log.Printf("janitor %s: purged %d expired records", j.name, removed)
+	}
+	return removed, nil
+}
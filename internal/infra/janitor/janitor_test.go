@@ -0,0 +1,60 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJanitor_RunOnce(t *testing.T) {
+	tests := []struct {
+		name            string
+		task            Task
+		expectedRemoved int
+		wantErr         bool
+	}{
+		{
+			name:            "reports removed records",
+			task:            func() (int, error) { return 4, nil },
+			expectedRemoved: 4,
+			wantErr:         false,
+		},
+		{
+			name:            "propagates task error",
+			task:            func() (int, error) { return 0, errors.New("boom") },
+			expectedRemoved: 0,
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := New("test", time.Minute, tt.task)
+			removed, err := j.RunOnce()
+			assert.Equal(t, tt.expectedRemoved, removed)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestJanitor_Run(t *testing.T) {
+	var calls int32
+	j := New("test", time.Millisecond, func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 1, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		j.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) >= 2 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
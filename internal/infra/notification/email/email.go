@@ -0,0 +1,181 @@
+// Package email contains the SMTP implementation of the notification service
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/google/uuid"
+)
+
+// TLSMode is how the connection to the SMTP server is secured
+type TLSMode string
+
+const (
+	// TLSNone sends in clear text, for local relays only
+	TLSNone TLSMode = "none"
+	// TLSStartTLS upgrades the connection with STARTTLS, failing when the server does not offer it
+	TLSStartTLS TLSMode = "starttls"
+	// TLSImplicit connects with TLS from the first byte, usually on port 465
+	TLSImplicit TLSMode = "tls"
+)
+
+// ErrInvalidConfig is returned for an unusable SMTP configuration
+var ErrInvalidConfig = errors.New("invalid SMTP configuration")
+
+// Config is the SMTP server to send through
+type Config struct {
+	Host string
+	Port int
+	// Username and Password authenticate with AUTH PLAIN when Username is set
+	Username string
+	Password string
+	// From is the sender address, with an optional display name
+	From string
+	TLS  TLSMode
+	// TLSConfig overrides the TLS client configuration, for instance to trust a private CA
+	TLSConfig *tls.Config
+	// Timeout bounds the connection and the whole SMTP conversation
+	Timeout gotime.Duration
+}
+
+// NotificationService sends notifications by email to the address of their recipient
+type NotificationService struct {
+	cfg          Config
+	from         *mail.Address
+	users        user.Repository
+	timeProvider time.Provider
+}
+
+// NewNotificationService constructor for NotificationService
+func NewNotificationService(cfg Config, users user.Repository, tp time.Provider) (*NotificationService, error) {
+	if cfg.Host == "" || cfg.Port <= 0 {
+		return nil, fmt.Errorf("%w: host and port are required", ErrInvalidConfig)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid sender %q", ErrInvalidConfig, cfg.From)
+	}
+	switch cfg.TLS {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("%w: unknown TLS mode %q", ErrInvalidConfig, cfg.TLS)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * gotime.Second
+	}
+	return &NotificationService{cfg: cfg, from: from, users: users, timeProvider: tp}, nil
+}
+
+// Notify emails the notification to its recipient. Notifications without a recipient, or whose
+// recipient has no address, cannot be emailed and are only logged; retrying would not help them.
+func (s *NotificationService) Notify(n notification.Notification) error {
+	if n.UserID == uuid.Nil {
+		log.Printf("notification %q has no recipient, not emailed", n.Subject)
+		return nil
+	}
+	u, err := s.users.GetByID(n.UserID)
+	if errors.Is(err, user.ErrUserNotFound) {
+		log.Printf("notification %q: recipient %s not found, not emailed", n.Subject, n.UserID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve recipient: %w", err)
+	}
+	if u.Email == "" {
+		return nil
+	}
+
+	to := &mail.Address{Address: u.Email}
+	msg, err := s.compose(n, u, to)
+	if err != nil {
+		return err
+	}
+	return s.send(to.Address, msg)
+}
+
+// send delivers one message in its own SMTP session
+func (s *NotificationService) send(to string, msg []byte) error {
+	c, err := s.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer c.Close()
+
+	if s.cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := c.StartTLS(s.tlsConfig()); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth refuses to send the credentials over a connection without TLS, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("SMTP server refused the sender: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("SMTP server refused the recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server refused the message: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server refused the message: %w", err)
+	}
+	return c.Quit()
+}
+
+func (s *NotificationService) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout}
+
+	var conn net.Conn
+	var err error
+	if s.cfg.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, s.tlsConfig())
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(gotime.Now().Add(s.cfg.Timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *NotificationService) tlsConfig() *tls.Config {
+	if s.cfg.TLSConfig != nil {
+		return s.cfg.TLSConfig.Clone()
+	}
+	return &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+}
@@ -0,0 +1,267 @@
+package email_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/infra/notification/email"
+	"github.com/akazantzidis/gwi-ass/internal/infra/notification/email/smtptest"
+	"github.com/akazantzidis/gwi-ass/internal/infra/storage/memory"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixture struct {
+	server *smtptest.Server
+	users  *memory.UserRepo
+	alice  uuid.UUID
+	bob    uuid.UUID
+}
+
+// newFixture starts a capture server and stores alice, who has an address, and bob, who has none
+func newFixture(t *testing.T, cfg smtptest.Config) *fixture {
+	server, err := smtptest.New(cfg)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { server.Close() })
+
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: 4})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	users := memory.NewUserRepo(hasher)
+	alice, err := users.Add("alice", "password1", []string{"user"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, users.UpdateEmail(alice.ID, "alice@example.com")) {
+		t.FailNow()
+	}
+	bob, err := users.Add("bob", "password1", []string{"user"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return &fixture{server: server, users: users, alice: alice.ID, bob: bob.ID}
+}
+
+func (f *fixture) service(t *testing.T, cfg email.Config) *email.NotificationService {
+	cfg.Host, cfg.Port = f.server.Host, f.server.Port
+	if cfg.From == "" {
+		cfg.From = "GWI Favorites <no-reply@gwi.test>"
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = email.TLSNone
+	}
+	tp := &timeprovider.MockProvider{}
+	tp.On("Now").Return(time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC))
+
+	s, err := email.NewNotificationService(cfg, f.users, tp)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return s
+}
+
+// parts reads the text and HTML parts of a captured message, keyed by content type
+func parts(t *testing.T, m smtptest.Message) map[string]string {
+	msg, err := m.Parse()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Equal(t, "multipart/alternative", mediaType) {
+		t.FailNow()
+	}
+
+	found := make(map[string]string)
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return found
+		}
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		body, err := io.ReadAll(p) // quoted-printable is decoded by the reader
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		found[contentType] = string(body)
+	}
+}
+
+func TestNotificationService_Notify(t *testing.T) {
+	f := newFixture(t, smtptest.Config{})
+	s := f.service(t, email.Config{})
+
+	id := uuid.New().String()
+	err := s.Notify(notification.Notification{
+		ID:      id,
+		UserID:  f.alice,
+		Kind:    notification.KindFavourite,
+		Subject: "New Favorite added – <chart>",
+		Message: "A new favorite with description '<b>Q1 sales</b>' was added",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	messages := f.server.Messages()
+	if !assert.Len(t, messages, 1) {
+		return
+	}
+	assert.Equal(t, "no-reply@gwi.test", messages[0].From)
+	assert.Equal(t, []string{"alice@example.com"}, messages[0].To)
+
+	msg, err := messages[0].Parse()
+	if !assert.NoError(t, err) {
+		return
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "New Favorite added – <chart>", subject)
+	assert.Equal(t, "<alice@example.com>", msg.Header.Get("To"))
+	assert.Equal(t, `"GWI Favorites" <no-reply@gwi.test>`, msg.Header.Get("From"))
+	assert.Equal(t, "<"+id+"@gwi.test>", msg.Header.Get("Message-Id"))
+	assert.Equal(t, "Fri, 02 Jan 2026 15:04:05 +0000", msg.Header.Get("Date"))
+
+	body := parts(t, messages[0])
+	assert.Contains(t, body["text/plain"], "Hello alice,")
+	assert.Contains(t, body["text/plain"], "A new favorite with description '<b>Q1 sales</b>' was added")
+	assert.Contains(t, body["text/html"], "A new favorite with description &#39;&lt;b&gt;Q1 sales&lt;/b&gt;&#39; was added")
+	assert.NotContains(t, body["text/html"], "<b>Q1 sales</b>", "the message is escaped in HTML")
+}
+
+func TestNotificationService_NotifyWithoutAddress(t *testing.T) {
+	f := newFixture(t, smtptest.Config{})
+	s := f.service(t, email.Config{})
+
+	assert.NoError(t, s.Notify(notification.Notification{Subject: "Account locked"}), "no recipient")
+	assert.NoError(t, s.Notify(notification.Notification{UserID: f.bob, Subject: "hi"}), "recipient without address")
+	assert.NoError(t, s.Notify(notification.Notification{UserID: uuid.New(), Subject: "hi"}), "unknown recipient")
+	assert.Empty(t, f.server.Messages())
+}
+
+func TestNotificationService_TLSAndAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		server smtptest.Config
+		client email.Config
+	}{
+		{
+			name:   "STARTTLS",
+			server: smtptest.Config{StartTLS: true, Username: "mailer", Password: "secret"},
+			client: email.Config{TLS: email.TLSStartTLS, Username: "mailer", Password: "secret"},
+		},
+		{
+			name:   "implicit TLS",
+			server: smtptest.Config{TLS: true, Username: "mailer", Password: "secret"},
+			client: email.Config{TLS: email.TLSImplicit, Username: "mailer", Password: "secret"},
+		},
+		{
+			name:   "local relay without TLS",
+			server: smtptest.Config{Username: "mailer", Password: "secret"},
+			client: email.Config{TLS: email.TLSNone, Username: "mailer", Password: "secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, tt.server)
+			tt.client.TLSConfig = f.server.ClientTLSConfig()
+			s := f.service(t, tt.client)
+
+			if !assert.NoError(t, s.Notify(notification.Notification{UserID: f.alice, Subject: "hi", Message: "there"})) {
+				return
+			}
+			assert.Len(t, f.server.Messages(), 1)
+
+			tt.client.Password = "wrong"
+			err := f.service(t, tt.client).Notify(notification.Notification{UserID: f.alice, Subject: "hi"})
+			assert.ErrorContains(t, err, "SMTP authentication failed")
+			assert.Len(t, f.server.Messages(), 1)
+		})
+	}
+}
+
+func TestNotificationService_Failures(t *testing.T) {
+	f := newFixture(t, smtptest.Config{})
+	n := notification.Notification{UserID: f.alice, Subject: "hi"}
+
+	err := f.service(t, email.Config{TLS: email.TLSStartTLS}).Notify(n)
+	assert.ErrorContains(t, err, "does not support STARTTLS", "never falls back to clear text")
+
+	err = f.service(t, email.Config{TLS: email.TLSImplicit, Timeout: time.Second}).Notify(n)
+	assert.Error(t, err, "TLS handshake with a clear text server")
+
+	f.server.Fail(451)
+	err = f.service(t, email.Config{}).Notify(n)
+	assert.ErrorContains(t, err, "451")
+	assert.Empty(t, f.server.Messages())
+}
+
+func TestNewNotificationService_ValidatesConfig(t *testing.T) {
+	valid := email.Config{Host: "smtp.example.com", Port: 587, From: "no-reply@example.com", TLS: email.TLSStartTLS}
+	_, err := email.NewNotificationService(valid, nil, nil)
+	assert.NoError(t, err)
+
+	for name, mutate := range map[string]func(c *email.Config){
+		"no host":        func(c *email.Config) { c.Host = "" },
+		"no port":        func(c *email.Config) { c.Port = 0 },
+		"invalid sender": func(c *email.Config) { c.From = "nobody" },
+		"unknown TLS":    func(c *email.Config) { c.TLS = "ssl" },
+	} {
+		cfg := valid
+		mutate(&cfg)
+		_, err := email.NewNotificationService(cfg, nil, nil)
+		assert.ErrorIs(t, err, email.ErrInvalidConfig, name)
+	}
+}
+
+// TestDispatcher_DeliversByEmail runs the background delivery of the service against the capture server
+func TestDispatcher_DeliversByEmail(t *testing.T) {
+	f := newFixture(t, smtptest.Config{})
+	f.server.Fail(421)
+
+	d := notification.NewDispatcher(f.service(t, email.Config{}), memory.NewDeadLetterRepo(), notification.DispatcherConfig{
+		QueueSize: 10, Workers: 1, MaxAttempts: 5, Retry: backoff.Exponential{Base: 10 * time.Millisecond},
+	}, uuidprovider.NewUUIDProvider(), timeprovider.NewTimeProvider())
+	if !assert.NoError(t, d.Notify(notification.Notification{UserID: f.alice, Subject: "Password reset requested"})) {
+		return
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	f.server.Fail(0)
+	if !assert.Eventually(t, func() bool { return len(f.server.Messages()) == 1 }, time.Second, 5*time.Millisecond, "retried until accepted") {
+		return
+	}
+	if !assert.NoError(t, d.Close(context.Background())) {
+		return
+	}
+
+	msg, err := f.server.Messages()[0].Parse()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(msg.Header.Get("Subject"), "Password reset requested"))
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/notification.txt.tmpl"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/notification.html.tmpl"))
+)
+
+// templateData is what the templates render
+type templateData struct {
+	Username string
+	Subject  string
+	Message  string
+	Kind     notification.Kind
+}
+
+// compose renders the notification as a multipart/alternative message with a text and an HTML part
+func (s *NotificationService) compose(n notification.Notification, u *user.User, to *mail.Address) ([]byte, error) {
+	data := templateData{Username: u.Username, Subject: n.Subject, Message: n.Message, Kind: n.Kind}
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render text email: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML email: %w", err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	if err := writePart(parts, "text/plain; charset=utf-8", text.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writePart(parts, "text/html; charset=utf-8", html.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+	header("From", s.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", n.Subject))
+	header("Date", s.timeProvider.Now().Format(gotime.RFC1123Z))
+	if n.ID != "" {
+		// the notification id survives retries, so receivers can drop a message delivered twice
+		header("Message-ID", fmt.Sprintf("<%s@%s>", n.ID, domain(s.from.Address)))
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, contentType string, content []byte) error {
+	w, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write(content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func domain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,342 @@
+// Package smtptest is an in-process SMTP server for tests. It accepts every message, optionally
+// offering STARTTLS or serving implicit TLS and requiring AUTH PLAIN credentials, and keeps the
+// delivered messages so tests can assert on them without an external mail service.
+package smtptest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+const hostname = "smtptest"
+
+// Config selects the features of the server
+type Config struct {
+	// Username and Password, when set, must be given with AUTH PLAIN before sending
+	Username string
+	Password string
+	// StartTLS offers the STARTTLS extension
+	StartTLS bool
+	// TLS serves TLS from the first byte, like SMTPS on port 465
+	TLS bool
+}
+
+// Message is a delivered message
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Parse parses the message headers, leaving the body to read
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(bytes.NewReader(m.Data))
+}
+
+// Server is a running capture server
+type Server struct {
+	// Host and Port are where the server listens
+	Host string
+	Port int
+
+	cfg       Config
+	tlsConfig *tls.Config
+	rootCAs   *x509.CertPool
+	ln        net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	failCode int
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// New starts a server on a random local port
+func New(cfg Config) (*Server, error) {
+	cert, roots, err := selfSignedCertificate()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		cfg:       cfg,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		rootCAs:   roots,
+		conns:     make(map[net.Conn]struct{}),
+	}
+
+	if cfg.TLS {
+		s.ln, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		return nil, err
+	}
+	addr := s.ln.Addr().(*net.TCPAddr)
+	s.Host, s.Port = addr.IP.String(), addr.Port
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// ClientTLSConfig trusts the certificate of the server
+func (s *Server) ClientTLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: s.rootCAs, ServerName: s.Host}
+}
+
+// Messages returns the delivered messages, oldest first
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Fail makes the server reject the following messages with the given reply code, 0 accepts them again
+func (s *Server) Fail(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failCode = code
+}
+
+// Close stops the server and waits for its connections to end
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// session is the state of one connection
+type session struct {
+	conn          net.Conn
+	text          *textproto.Conn
+	tls           bool
+	authenticated bool
+	from          string
+	to            []string
+	hasFrom       bool
+}
+
+func (s *Server) handle(conn net.Conn) {
+	ss := &session{conn: conn, text: textproto.NewConn(conn), tls: s.cfg.TLS}
+	defer func() {
+		ss.conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	ss.reply(220, hostname+" ESMTP ready")
+	for {
+		line, err := ss.text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			ss.reset()
+			ss.reply(250, hostname)
+		case "EHLO":
+			ss.reset()
+			s.hello(ss)
+		case "STARTTLS":
+			if !s.cfg.StartTLS || ss.tls {
+				ss.reply(502, "STARTTLS not available")
+				continue
+			}
+			ss.reply(220, "ready to start TLS")
+			tlsConn := tls.Server(ss.conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			ss.conn, ss.text, ss.tls = tlsConn, textproto.NewConn(tlsConn), true
+			ss.reset()
+		case "AUTH":
+			s.auth(ss, arg)
+		case "MAIL":
+			s.mail(ss, arg)
+		case "RCPT":
+			if !ss.hasFrom {
+				ss.reply(503, "need MAIL first")
+				continue
+			}
+			ss.to = append(ss.to, address(arg, "TO:"))
+			ss.reply(250, "OK")
+		case "DATA":
+			s.data(ss)
+		case "RSET":
+			ss.reset()
+			ss.reply(250, "OK")
+		case "NOOP":
+			ss.reply(250, "OK")
+		case "QUIT":
+			ss.reply(221, "bye")
+			return
+		default:
+			ss.reply(502, "command not implemented")
+		}
+	}
+}
+
+// hello lists the extensions. Credentials are only accepted over TLS when STARTTLS is offered.
+func (s *Server) hello(ss *session) {
+	lines := []string{hostname, "8BITMIME"}
+	if s.cfg.StartTLS && !ss.tls {
+		lines = append(lines, "STARTTLS")
+	}
+	if s.cfg.Username != "" && (ss.tls || !s.cfg.StartTLS) {
+		lines = append(lines, "AUTH PLAIN")
+	}
+	for i, l := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		ss.text.PrintfLine("250%s%s", sep, l)
+	}
+}
+
+func (s *Server) auth(ss *session, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	if s.cfg.Username == "" || !strings.EqualFold(mechanism, "PLAIN") {
+		ss.reply(504, "unrecognized authentication type")
+		return
+	}
+	if initial == "" {
+		ss.reply(334, "")
+		line, err := ss.text.ReadLine()
+		if err != nil {
+			return
+		}
+		initial = line
+	}
+	decoded, err := base64.StdEncoding.DecodeString(initial)
+	parts := strings.Split(string(decoded), "\x00")
+	if err != nil || len(parts) != 3 || parts[1] != s.cfg.Username || parts[2] != s.cfg.Password {
+		ss.reply(535, "authentication failed")
+		return
+	}
+	ss.authenticated = true
+	ss.reply(235, "authenticated")
+}
+
+func (s *Server) mail(ss *session, arg string) {
+	if s.cfg.Username != "" && !ss.authenticated {
+		ss.reply(530, "authentication required")
+		return
+	}
+	s.mu.Lock()
+	failCode := s.failCode
+	s.mu.Unlock()
+	if failCode != 0 {
+		ss.reply(failCode, "rejected")
+		return
+	}
+	ss.from, ss.to, ss.hasFrom = address(arg, "FROM:"), nil, true
+	ss.reply(250, "OK")
+}
+
+func (s *Server) data(ss *session) {
+	if len(ss.to) == 0 {
+		ss.reply(503, "need RCPT first")
+		return
+	}
+	ss.reply(354, "end data with <CR><LF>.<CR><LF>")
+	data, err := io.ReadAll(ss.text.DotReader())
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, Message{From: ss.from, To: ss.to, Data: data})
+	s.mu.Unlock()
+	ss.reset()
+	ss.reply(250, "OK queued")
+}
+
+func (ss *session) reply(code int, text string) {
+	ss.text.PrintfLine("%d %s", code, text)
+}
+
+func (ss *session) reset() {
+	ss.from, ss.to, ss.hasFrom = "", nil, false
+}
+
+// address extracts the address of a MAIL FROM:<a> or RCPT TO:<a> argument, ignoring its parameters
+func address(arg, prefix string) string {
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	arg, _, _ = strings.Cut(strings.TrimSpace(arg), " ")
+	return strings.TrimSuffix(strings.TrimPrefix(arg, "<"), ">")
+}
+
+// selfSignedCertificate creates the certificate of 127.0.0.1 and a pool trusting it
+func selfSignedCertificate() (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: hostname},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, roots, nil
+}
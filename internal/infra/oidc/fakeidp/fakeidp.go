@@ -0,0 +1,233 @@
+// Package fakeidp is an in-process OpenID Connect provider for tests. It serves discovery,
+// the key set, an authorization endpoint that signs in a configured user without prompting,
+// and a token endpoint enforcing the client secret, the redirect URI and PKCE S256.
+package fakeidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "fakeidp-1"
+
+// User is the identity the provider signs in
+type User struct {
+	Subject           string
+	PreferredUsername string
+	Email             string
+	Groups            []string
+}
+
+// authorization is an issued, not yet redeemed authorization code
+type authorization struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	user          User
+}
+
+// Server is a running fake provider
+type Server struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	user  *User
+	codes map[string]authorization
+}
+
+// New starts a provider with a single client registered for redirectURL
+func New(clientID, clientSecret, redirectURL string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		key:          key,
+		codes:        make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s, nil
+}
+
+// SignIn sets the user signed in by the next authorization requests
+func (s *Server) SignIn(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = &u
+}
+
+// Close shuts the provider down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize signs in the configured user and redirects back with a code, or with
+// login_required when no user is signed in
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.ClientID || q.Get("redirect_uri") != s.RedirectURL {
+		http.Error(w, "unknown client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	back, _ := url.Parse(s.RedirectURL)
+	params := back.Query()
+	params.Set("state", q.Get("state"))
+
+	s.mu.Lock()
+	switch {
+	case q.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+	case s.user == nil:
+		params.Set("error", "login_required")
+	default:
+		code, err := helper.GenerateOpaqueToken()
+		if err != nil {
+			s.mu.Unlock()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.codes[code] = authorization{
+			redirectURI:   q.Get("redirect_uri"),
+			codeChallenge: q.Get("code_challenge"),
+			nonce:         q.Get("nonce"),
+			user:          *s.user,
+		}
+		params.Set("code", code)
+	}
+	s.mu.Unlock()
+
+	back.RawQuery = params.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+// token redeems a code once and returns a signed ID token
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostForm.Get("code")
+	auth, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	if !ok || auth.redirectURI != r.PostForm.Get("redirect_uri") ||
+		helper.PKCEChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.URL,
+		"sub":                auth.user.Subject,
+		"aud":                s.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              auth.nonce,
+		"preferred_username": auth.user.PreferredUsername,
+		"email":              auth.user.Email,
+		"groups":             auth.user.Groups,
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = keyID
+	idToken, err := t.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	accessToken, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
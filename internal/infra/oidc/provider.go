@@ -0,0 +1,296 @@
+// Package oidc implements the OpenID Connect relying party of the service: discovery,
+// the authorization code exchange and the verification of RS256 signed ID tokens.
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidIDToken is returned when the ID token fails verification
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// Config contains the relying party registration at the provider
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// GroupsClaim is the ID token claim listing the groups of the user, "groups" when empty
+	GroupsClaim string
+	// HTTPClient is used to call the provider, a client with a 10s timeout when nil
+	HTTPClient *http.Client
+}
+
+// metadata is the subset of the provider discovery document the service uses
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *metadata
+	keys map[string]*rsa.PublicKey
+}
+
+// NewProvider constructor. The provider is discovered on first use, so the service
+// starts even while the provider is unreachable.
+func NewProvider(cfg Config) oidc.Provider {
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	return &provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL returns the authorization endpoint URL requesting a code bound to the PKCE challenge
+func (p *provider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {"openid profile email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems the code at the token endpoint and verifies the returned ID token
+func (p *provider) Exchange(code, codeVerifier, nonce string) (oidc.Identity, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return oidc.Identity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oidc.Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return oidc.Identity{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return oidc.Identity{}, fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return oidc.Identity{}, fmt.Errorf("token request rejected: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return oidc.Identity{}, fmt.Errorf("%w: missing from token response", ErrInvalidIDToken)
+	}
+
+	return p.verify(meta, body.IDToken, nonce)
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *provider) verify(meta *metadata, rawIDToken, nonce string) (oidc.Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return oidc.Identity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if n, _ := claims["nonce"].(string); n == "" || n != nonce {
+		return oidc.Identity{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	// with several audiences the token must have been issued to us
+	if azp, ok := claims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return oidc.Identity{}, fmt.Errorf("%w: issued to another party", ErrInvalidIDToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return oidc.Identity{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	identity := oidc.Identity{Issuer: meta.Issuer, Subject: subject}
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Groups = stringsClaim(claims[p.cfg.GroupsClaim])
+	return identity, nil
+}
+
+// keyFunc returns the provider key matching the token kid, refreshing the key set once on a miss
+// so key rotation at the provider is picked up
+func (p *provider) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := p.refreshKeys(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// discover fetches the discovery document once and caches it
+func (p *provider) discover() (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta metadata
+	if err := p.getJSON(p.cfg.IssuerURL+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	if meta.Issuer != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery failed: issuer %q does not match %q", meta.Issuer, p.cfg.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery failed: incomplete provider metadata")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// refreshKeys replaces the cached key set with the RSA signing keys currently published by the provider
+func (p *provider) refreshKeys() error {
+	meta, err := p.discover()
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(meta.JWKSURI, &set); err != nil {
+		return fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := rsaPublicKey(k.N, k.E)
+		if err != nil {
+			return fmt.Errorf("invalid signing key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *provider) getJSON(u string, v interface{}) error {
+	resp, err := p.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// rsaPublicKey builds a key from the base64url encoded modulus and exponent of a JWK
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	exp := new(big.Int).SetBytes(eb)
+	if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, errors.New("unsupported exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exp.Int64())}, nil
+}
+
+// stringsClaim reads a claim holding either a list of strings or a single string
+func stringsClaim(v interface{}) []string {
+	switch c := v.(type) {
+	case string:
+		return []string{c}
+	case []interface{}:
+		out := make([]string, 0, len(c))
+		for _, s := range c {
+			if s, ok := s.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package oidc_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	gotime "time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/auditlog"
+	"github.com/akazantzidis/gwi-ass/internal/app/auth/command"
+	appoidc "github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/infra/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/infra/oidc/fakeidp"
+	"github.com/akazantzidis/gwi-ass/internal/infra/storage/memory"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	googleuuid "github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const redirectURL = "http://localhost:8080/oidc/callback"
+
+type flow struct {
+	idp      *fakeidp.Server
+	users    *memory.UserRepo
+	start    command.StartOIDCLoginHandler
+	callback command.OIDCCallbackHandler
+}
+
+func newFlow(t *testing.T) *flow {
+	idp, err := fakeidp.New("gwi", "s3cret", redirectURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(idp.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    idp.URL,
+		ClientID:     "gwi",
+		ClientSecret: "s3cret",
+		RedirectURL:  redirectURL,
+	})
+	hasher := helper.NewTokenHasher([]byte("test key"))
+	states := memory.NewOIDCStateRepo(hasher)
+	passwords, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	users := memory.NewUserRepo(passwords)
+	tp, up := time.NewTimeProvider(), uuid.NewUUIDProvider()
+	mapping := appoidc.RoleMapping{"platform-admins": {"admin"}}
+
+	return &flow{
+		idp:      idp,
+		users:    users,
+		start:    command.NewStartOIDCLoginHandler(provider, states, gotime.Minute, tp),
+		callback: command.NewOIDCCallbackHandler(provider, states, users, memory.NewRefreshRepo(hasher), mapping, []string{"user"}, auditlog.NewRecorder(memory.NewAuditRepo(), up, tp), eventbus.NewBus(up, tp), up, tp),
+	}
+}
+
+// authorize follows the login to the provider and returns the query of its redirect back to the service
+func (f *flow) authorize(t *testing.T) url.Values {
+	started, err := f.start.Handle()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(started.AuthURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	if !assert.Equal(t, http.StatusFound, resp.StatusCode) {
+		t.FailNow()
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Equal(t, redirectURL, location.Scheme+"://"+location.Host+location.Path) {
+		t.FailNow()
+	}
+	return location.Query()
+}
+
+func (f *flow) login(t *testing.T) (command.LoginResult, *helper.CustomClaims) {
+	q := f.authorize(t)
+	if !assert.Empty(t, q.Get("error")) {
+		t.FailNow()
+	}
+
+	result, err := f.callback.Handle(command.OIDCCallbackRequest{State: q.Get("state"), Code: q.Get("code")})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NotEmpty(t, result.RefreshToken) {
+		t.FailNow()
+	}
+
+	claims, err := helper.ParseAndValidateToken(result.AccessToken)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return result, claims
+}
+
+func TestOIDCLogin_ProvisionsAndReusesUser(t *testing.T) {
+	f := newFlow(t)
+	f.idp.SignIn(fakeidp.User{Subject: "sub-1", PreferredUsername: "Carol", Email: "carol@example.com", Groups: []string{"platform-admins"}})
+
+	_, claims := f.login(t)
+	assert.ElementsMatch(t, []string{"admin", "user"}, claims.Roles)
+
+	u, err := f.users.GetByUsername("carol")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, u.ID.String(), claims.UserID)
+	assert.Equal(t, []user.Identity{{Issuer: f.idp.URL, Subject: "sub-1"}}, u.Identities)
+	assert.Equal(t, "carol@example.com", u.Email)
+
+	// the second login reuses the linked user and follows the group change at the provider
+	f.idp.SignIn(fakeidp.User{Subject: "sub-1", PreferredUsername: "carol-renamed"})
+	_, claims = f.login(t)
+	assert.Equal(t, u.ID.String(), claims.UserID)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+
+	u, err = f.users.GetByID(u.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"user"}, u.Roles)
+	assert.Equal(t, "carol@example.com", u.Email, "kept when the provider shares none")
+}
+
+func TestOIDCLogin_DoesNotLinkExistingLocalUser(t *testing.T) {
+	f := newFlow(t)
+	local, err := f.users.Add("alice", "password1", []string{"user"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f.idp.SignIn(fakeidp.User{Subject: "sub-2", PreferredUsername: "alice"})
+	_, claims := f.login(t)
+
+	assert.NotEqual(t, local.ID.String(), claims.UserID)
+	local, err = f.users.GetByID(local.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, local.Identities)
+}
+
+func TestOIDCLogin_RequiresSecondFactor(t *testing.T) {
+	f := newFlow(t)
+	f.idp.SignIn(fakeidp.User{Subject: "sub-6", PreferredUsername: "frank"})
+	_, claims := f.login(t)
+
+	if !assert.NoError(t, f.users.UpdateTOTP(googleuuid.MustParse(claims.UserID), user.TOTP{Enabled: true})) {
+		return
+	}
+
+	q := f.authorize(t)
+	result, err := f.callback.Handle(command.OIDCCallbackRequest{State: q.Get("state"), Code: q.Get("code")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, result.MFARequired)
+	assert.NotEmpty(t, result.MFAToken)
+	assert.Empty(t, result.AccessToken)
+	assert.Empty(t, result.RefreshToken)
+}
+
+func TestOIDCLogin_RejectsReplayedState(t *testing.T) {
+	f := newFlow(t)
+	f.idp.SignIn(fakeidp.User{Subject: "sub-3", PreferredUsername: "dave"})
+
+	q := f.authorize(t)
+	req := command.OIDCCallbackRequest{State: q.Get("state"), Code: q.Get("code")}
+	_, err := f.callback.Handle(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = f.callback.Handle(req)
+	assert.ErrorIs(t, err, command.ErrInvalidOIDCState)
+}
+
+func TestOIDCLogin_ProviderErrors(t *testing.T) {
+	f := newFlow(t)
+
+	// nobody is signed in at the provider
+	q := f.authorize(t)
+	assert.Equal(t, "login_required", q.Get("error"))
+
+	// a code is bound to the state it was issued for, so it fails under another login's verifier and nonce
+	f.idp.SignIn(fakeidp.User{Subject: "sub-4", PreferredUsername: "erin"})
+	first := f.authorize(t)
+	second := f.authorize(t)
+	_, err := f.callback.Handle(command.OIDCCallbackRequest{State: second.Get("state"), Code: first.Get("code")})
+	assert.ErrorIs(t, err, command.ErrOIDCLoginFailed)
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	idp, err := fakeidp.New("gwi", "s3cret", redirectURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer idp.Close()
+	idp.SignIn(fakeidp.User{Subject: "sub-5", Groups: []string{"a", "b"}})
+
+	tests := []struct {
+		name    string
+		secret  string
+		nonce   string
+		wantErr bool
+	}{
+		{name: "valid exchange", secret: "s3cret", nonce: "n-1"},
+		{name: "wrong client secret", secret: "nope", nonce: "n-1", wantErr: true},
+		{name: "wrong nonce", secret: "s3cret", nonce: "other", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := oidc.NewProvider(oidc.Config{IssuerURL: idp.URL, ClientID: "gwi", ClientSecret: tt.secret, RedirectURL: redirectURL})
+			verifier, challenge, err := helper.GeneratePKCE()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			authURL, err := p.AuthCodeURL("state", "n-1", challenge)
+			if !assert.NoError(t, err) {
+				return
+			}
+			client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+			resp, err := client.Get(authURL)
+			if !assert.NoError(t, err) {
+				return
+			}
+			resp.Body.Close()
+			location, err := url.Parse(resp.Header.Get("Location"))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			identity, err := p.Exchange(location.Query().Get("code"), verifier, tt.nonce)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, appoidc.Identity{Issuer: idp.URL, Subject: "sub-5", Groups: []string{"a", "b"}}, identity)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Package passwordlist contains file backed lists of breached passwords.
+package passwordlist
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// List is an in-memory set of breached passwords loaded from a local file
+type List struct {
+	passwords map[string]struct{}
+}
+
+// LoadFile reads a breached password list with one password per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadFile(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	l := &List{passwords: make(map[string]struct{})}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l.passwords[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return l, nil
+}
+
+// Contains reports whether the password is in the list, case-insensitively
+func (l *List) Contains(password string) bool {
+	_, ok := l.passwords[strings.ToLower(password)]
+	return ok
+}
+
+// Len returns the number of passwords in the list
+func (l *List) Len() int {
+	return len(l.passwords)
+}
@@ -0,0 +1,25 @@
+package passwordlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte("# common passwords\npassword1\n\nQwerty123\n"), 0o600)
+	assert.NoError(t, err)
+
+	l, err := LoadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, l.Len())
+	assert.True(t, l.Contains("password1"))
+	assert.True(t, l.Contains("qwerty123"))
+	assert.False(t, l.Contains("correct horse battery"))
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -1,14 +1,37 @@
 package infra
 
 import (
+	"log"
+
 	"github.com/akazantzidis/gwi-ass/internal/app"
+	appoidc "github.com/akazantzidis/gwi-ass/internal/app/auth/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/app/digest"
 	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/akazantzidis/gwi-ass/internal/domain/stats"
 	"github.com/akazantzidis/gwi-ass/internal/domain/token"
 	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
 	"github.com/akazantzidis/gwi-ass/internal/infra/http"
+	"github.com/akazantzidis/gwi-ass/internal/infra/http/auth"
+	httpfavourite "github.com/akazantzidis/gwi-ass/internal/infra/http/favourite"
 	"github.com/akazantzidis/gwi-ass/internal/infra/notification/console"
+	"github.com/akazantzidis/gwi-ass/internal/infra/notification/email"
+	"github.com/akazantzidis/gwi-ass/internal/infra/oidc"
+	"github.com/akazantzidis/gwi-ass/internal/infra/passwordlist"
 	"github.com/akazantzidis/gwi-ass/internal/infra/storage/memory"
+	infrawebhook "github.com/akazantzidis/gwi-ass/internal/infra/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/config"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
 )
 
 // Services contains the exposed services of interface adapters
@@ -17,20 +40,187 @@ type Services struct {
 	FavoriteRepository     favourite.Repository
 	UserRepository         user.Repository
 	RefreshTokenRepository token.RefreshRepository
+	ResetTokenRepository   token.ResetRepository
+	LoginAttemptRepository lockout.Repository
+	APIKeyRepository       token.APIKeyRepository
+	ClientRepository       client.Repository
+	OIDCStateRepository    token.OIDCStateRepository
+	StatsRepository        stats.Repository
+	AuditRepository        audit.Repository
+	DeadLetterRepository   notification.DeadLetterRepository
+	PreferenceRepository   notification.PreferenceRepository
+	InboxRepository        notification.InboxRepository
+	OutboxRepository       outbox.Repository
+	WebhookRepository      webhook.Repository
+	WebhookPoster          webhooks.Poster
+	DigestRepository       digest.Repository
+	PasswordPolicy         user.PasswordPolicy
+	PasswordHasher         user.PasswordHasher
+	UsernameLockoutPolicy  lockout.Policy
+	IPLockoutPolicy        lockout.Policy
+	SecretCipher           helper.SecretCipher
+	SecretHasher           helper.TokenHasher
+	OIDCProvider           appoidc.Provider
 	Server                 *http.Server
 }
 
 // NewInfraProviders Instantiates the infra services
-func NewInfraProviders() Services {
+func NewInfraProviders(cfg config.Config) Services {
+	hasher := newPasswordHasher(cfg)
+	events := memory.NewOutboxRepo()
+	audits := memory.NewAuditRepo()
+	favourites := memory.NewRepo(events, audits)
+	users := memory.NewUserRepo(hasher)
+	sessions := memory.NewRefreshRepo(helper.NewTokenHasher(cfg.TokenHashKey))
+	deadLetters := memory.NewDeadLetterRepo()
 	return Services{
-		NotificationService:    console.NewNotificationService(),
-		FavoriteRepository:     memory.NewRepo(),
-		UserRepository:         memory.NewUserRepo(),
-		RefreshTokenRepository: memory.NewRefreshRepo(),
+		NotificationService:    newNotificationDispatcher(cfg, deadLetters, users),
+		FavoriteRepository:     favourites,
+		UserRepository:         users,
+		RefreshTokenRepository: sessions,
+		ResetTokenRepository:   memory.NewResetRepo(helper.NewTokenHasher(cfg.TokenHashKey)),
+		LoginAttemptRepository: memory.NewLoginAttemptRepo(),
+		APIKeyRepository:       memory.NewAPIKeyRepo(helper.NewTokenHasher(cfg.TokenHashKey)),
+		ClientRepository:       memory.NewClientRepo(),
+		OIDCStateRepository:    memory.NewOIDCStateRepo(helper.NewTokenHasher(cfg.TokenHashKey)),
+		StatsRepository:        memory.NewStatsRepo(users, favourites, sessions),
+		AuditRepository:        audits,
+		DeadLetterRepository:   deadLetters,
+		PreferenceRepository:   memory.NewNotificationPreferenceRepo(),
+		InboxRepository:        memory.NewInboxRepo(),
+		OutboxRepository:       events,
+		WebhookRepository:      memory.NewWebhookRepo(),
+		WebhookPoster:          infrawebhook.NewPoster(cfg.WebhookTimeout),
+		DigestRepository:       memory.NewDigestRepo(),
+		PasswordPolicy:         newPasswordPolicy(cfg),
+		PasswordHasher:         hasher,
+		UsernameLockoutPolicy:  newLockoutPolicy(cfg, cfg.LoginFreeAttempts, cfg.LoginLockoutThreshold),
+		IPLockoutPolicy:        newLockoutPolicy(cfg, cfg.LoginIPFreeAttempts, cfg.LoginIPLockoutThreshold),
+		SecretCipher:           newSecretCipher(cfg),
+		SecretHasher:           helper.NewTokenHasher(cfg.TokenHashKey),
+		OIDCProvider:           newOIDCProvider(cfg),
+	}
+}
+
+// newNotificationDispatcher delivers notifications through the configured channel in the background
+func newNotificationDispatcher(cfg config.Config, deadLetters notification.DeadLetterRepository, users user.Repository) *notification.Dispatcher {
+	return notification.NewDispatcher(newNotificationChannel(cfg, users), deadLetters, notification.DispatcherConfig{
+		QueueSize:   cfg.NotificationQueueSize,
+		Workers:     cfg.NotificationWorkers,
+		MaxAttempts: cfg.NotificationMaxAttempts,
+		Retry:       backoff.Exponential{Base: cfg.NotificationRetryBase, Max: cfg.NotificationRetryMax},
+	}, uuid.NewUUIDProvider(), time.NewTimeProvider())
+}
+
+// newNotificationChannel builds the service delivering the notifications
+func newNotificationChannel(cfg config.Config, users user.Repository) notification.Service {
+	switch cfg.NotificationChannel {
+	case "console":
+		return console.NewNotificationService()
+	case "email":
+		service, err := email.NewNotificationService(email.Config{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			TLS:      email.TLSMode(cfg.SMTPTLS),
+			Timeout:  cfg.SMTPTimeout,
+		}, users, time.NewTimeProvider())
+		if err != nil {
+			log.Fatalf("invalid email notification channel: %v", err)
+		}
+		return service
+	default:
+		log.Fatalf("unknown NOTIFICATION_CHANNEL %q, expected console or email", cfg.NotificationChannel)
+		return nil
+	}
+}
+
+// newOIDCProvider builds the OpenID Connect relying party, nil when federated login is not configured
+func newOIDCProvider(cfg config.Config) appoidc.Provider {
+	if cfg.OIDCIssuerURL == "" {
+		return nil
+	}
+	if cfg.OIDCClientID == "" {
+		log.Fatalf("OIDC_CLIENT_ID is required when OIDC_ISSUER_URL is set")
+	}
+	return oidc.NewProvider(oidc.Config{
+		IssuerURL:    cfg.OIDCIssuerURL,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		GroupsClaim:  cfg.OIDCGroupsClaim,
+	})
+}
+
+// newPasswordHasher builds the hasher of new passwords, hashes of the other algorithm keep verifying
+func newPasswordHasher(cfg config.Config) user.PasswordHasher {
+	if cfg.Argon2Memory < 0 || cfg.Argon2Iterations < 0 || cfg.Argon2Parallelism < 0 || cfg.Argon2Parallelism > 255 {
+		log.Fatalf("invalid argon2 parameters m=%d,t=%d,p=%d", cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+	}
+	params := passwordhash.DefaultArgon2idParams
+	params.Memory = uint32(cfg.Argon2Memory)
+	params.Iterations = uint32(cfg.Argon2Iterations)
+	params.Parallelism = uint8(cfg.Argon2Parallelism)
+
+	hasher, err := passwordhash.New(passwordhash.Config{
+		Algorithm:  cfg.PasswordHashAlgorithm,
+		Argon2id:   params,
+		BcryptCost: cfg.BcryptCost,
+	})
+	if err != nil {
+		log.Fatalf("invalid password hashing configuration: %v", err)
+	}
+	return hasher
+}
+
+// newSecretCipher builds the cipher protecting TOTP secrets at rest
+func newSecretCipher(cfg config.Config) helper.SecretCipher {
+	cipher, err := helper.NewSecretCipher(cfg.TOTPEncryptionKey)
+	if err != nil {
+		log.Fatalf("invalid TOTP_ENCRYPTION_KEY: %v", err)
+	}
+	return cipher
+}
+
+// newLockoutPolicy builds a failed login policy sharing the configured delays
+func newLockoutPolicy(cfg config.Config, freeAttempts, lockoutThreshold int) lockout.Policy {
+	return lockout.Policy{
+		FreeAttempts:     freeAttempts,
+		BaseDelay:        cfg.LoginBackoffBase,
+		MaxDelay:         cfg.LoginBackoffMax,
+		LockoutThreshold: lockoutThreshold,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		ResetAfter:       cfg.LoginAttemptsResetAfter,
+	}
+}
+
+// newPasswordPolicy builds the password policy, loading the breached password list when configured
+func newPasswordPolicy(cfg config.Config) user.PasswordPolicy {
+	policy := user.PasswordPolicy{
+		MinLength: cfg.PasswordMinLength,
+		MaxLength: cfg.PasswordMaxLength,
+	}
+	if cfg.BreachedPasswordsFile != "" {
+		list, err := passwordlist.LoadFile(cfg.BreachedPasswordsFile)
+		if err != nil {
+			log.Fatalf("failed to load password policy: %v", err)
+		}
+		log.Printf("loaded %d breached passwords", list.Len())
+		policy.Breached = list
 	}
+	return policy
 }
 
 // NewHTTPServer creates a new server
-func NewHTTPServer(services app.Services) *http.Server {
-	return http.NewServer(services)
+func NewHTTPServer(services app.Services, cfg config.Config) *http.Server {
+	return http.NewServer(services, auth.CookieConfig{Secure: cfg.CookieSecure}, httpfavourite.SocketConfig{
+		AuthTimeout:    cfg.SocketAuthTimeout,
+		ReauthWarning:  cfg.SocketReauthWarning,
+		PingInterval:   cfg.SocketPingInterval,
+		WriteTimeout:   cfg.SocketWriteTimeout,
+		SendQueue:      cfg.SocketSendQueue,
+		MaxMessageSize: cfg.SocketMaxMessageSize,
+	})
 }
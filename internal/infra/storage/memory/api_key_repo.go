@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepo keeps API keys keyed by the keyed hash of the token, like RefreshRepo
+type APIKeyRepo struct {
+	mu     sync.RWMutex
+	hasher helper.TokenHasher
+	keys   map[string]apiKeyEntry // token hash -> entry
+	byID   map[uuid.UUID]string   // key id -> token hash
+}
+
+type apiKeyEntry struct {
+	key token.APIKey
+}
+
+func NewAPIKeyRepo(hasher helper.TokenHasher) *APIKeyRepo {
+	return &APIKeyRepo{
+		hasher: hasher,
+		keys:   make(map[string]apiKeyEntry),
+		byID:   make(map[uuid.UUID]string),
+	}
+}
+
+func (r *APIKeyRepo) Save(t string, key token.APIKey) {
+	hash := r.hasher.Hash(t)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[hash] = apiKeyEntry{key: key}
+	r.byID[key.ID] = hash
+}
+
+func (r *APIKeyRepo) Get(t string) (token.APIKey, bool) {
+	hash := r.hasher.Hash(t)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.keys[hash]
+	if !ok {
+		return token.APIKey{}, false
+	}
+	return entry.key, true
+}
+
+func (r *APIKeyRepo) ListByUser(userID uuid.UUID) []token.APIKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []token.APIKey
+	for _, entry := range r.keys {
+		if entry.key.UserID == userID {
+			keys = append(keys, entry.key)
+		}
+	}
+	return keys
+}
+
+func (r *APIKeyRepo) DeleteByID(userID uuid.UUID, keyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash, ok := r.byID[keyID]
+	if !ok || r.keys[hash].key.UserID != userID {
+		return token.ErrAPIKeyNotFound
+	}
+	delete(r.keys, hash)
+	delete(r.byID, keyID)
+	return nil
+}
+
+func (r *APIKeyRepo) DeleteByUser(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for hash, entry := range r.keys {
+		if entry.key.UserID == userID {
+			delete(r.keys, hash)
+			delete(r.byID, entry.key.ID)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (r *APIKeyRepo) Touch(keyID uuid.UUID, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash, ok := r.byID[keyID]
+	if !ok {
+		return
+	}
+	entry := r.keys[hash]
+	entry.key.LastUsedAt = at
+	r.keys[hash] = entry
+}
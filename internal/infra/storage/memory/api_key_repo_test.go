@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRepo_OwnerScopedDeleteAndTouch(t *testing.T) {
+	repo := NewAPIKeyRepo(helper.NewTokenHasher([]byte("key")))
+	owner := uuid.New()
+	key := token.APIKey{ID: uuid.New(), UserID: owner, Name: "etl"}
+	repo.Save("gwi_raw", key)
+
+	_, stored := repo.keys["gwi_raw"]
+	assert.False(t, stored, "raw key must not be a storage key")
+
+	used := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.Touch(key.ID, used)
+	got, ok := repo.Get("gwi_raw")
+	assert.True(t, ok)
+	assert.Equal(t, used, got.LastUsedAt)
+
+	assert.ErrorIs(t, repo.DeleteByID(uuid.New(), key.ID), token.ErrAPIKeyNotFound)
+	assert.Len(t, repo.ListByUser(owner), 1)
+
+	assert.NoError(t, repo.DeleteByID(owner, key.ID))
+	_, ok = repo.Get("gwi_raw")
+	assert.False(t, ok)
+	assert.Empty(t, repo.ListByUser(owner))
+}
+
+func TestAPIKeyRepo_DeleteByUser(t *testing.T) {
+	repo := NewAPIKeyRepo(helper.NewTokenHasher([]byte("key")))
+	owner, other := uuid.New(), uuid.New()
+	repo.Save("gwi_one", token.APIKey{ID: uuid.New(), UserID: owner})
+	repo.Save("gwi_two", token.APIKey{ID: uuid.New(), UserID: owner})
+	repo.Save("gwi_other", token.APIKey{ID: uuid.New(), UserID: other})
+
+	assert.Equal(t, 2, repo.DeleteByUser(owner))
+	assert.Empty(t, repo.ListByUser(owner))
+	_, ok := repo.Get("gwi_one")
+	assert.False(t, ok)
+	assert.Len(t, repo.ListByUser(other), 1, "the keys of other users are kept")
+	assert.Len(t, repo.byID, 1)
+}
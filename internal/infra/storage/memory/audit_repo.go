@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+)
+
+// AuditRepo keeps the audit log in memory, in the order the entries were appended
+type AuditRepo struct {
+	mu      sync.RWMutex
+	entries []audit.Entry
+}
+
+func NewAuditRepo() *AuditRepo {
+	return &AuditRepo{}
+}
+
+func (r *AuditRepo) Append(entry audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *AuditRepo) List(query audit.Query) ([]audit.Entry, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := []audit.Entry{}
+	total := 0
+	for i := range r.entries {
+		e := r.entries[len(r.entries)-1-i]
+		if query.OldestFirst {
+			e = r.entries[i]
+		}
+		if !query.Matches(e) {
+			continue
+		}
+		if total >= query.Offset && (query.Limit <= 0 || len(page) < query.Limit) {
+			page = append(page, e)
+		}
+		total++
+	}
+	return page, total, nil
+}
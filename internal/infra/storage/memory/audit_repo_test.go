@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepo_List(t *testing.T) {
+	repo := NewAuditRepo()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	actions := []string{audit.ActionLogin, audit.ActionFavouriteCreate, audit.ActionFavouriteDelete, audit.ActionLogout}
+	for i, action := range actions {
+		if !assert.NoError(t, repo.Append(audit.Entry{Time: start.Add(time.Duration(i) * time.Minute), Action: action})) {
+			return
+		}
+	}
+
+	entries, total, err := repo.List(audit.Query{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, total)
+	assert.Equal(t, audit.ActionLogout, entries[0].Action, "newest first by default")
+
+	entries, total, err = repo.List(audit.Query{Action: "favourite", OldestFirst: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []string{audit.ActionFavouriteCreate, audit.ActionFavouriteDelete}, []string{entries[0].Action, entries[1].Action})
+
+	entries, total, err = repo.List(audit.Query{Offset: 1, Limit: 2})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, total, "total ignores paging")
+	if !assert.Len(t, entries, 2) {
+		return
+	}
+	assert.Equal(t, audit.ActionFavouriteDelete, entries[0].Action)
+	assert.Equal(t, audit.ActionFavouriteCreate, entries[1].Action)
+
+	entries, total, err = repo.List(audit.Query{Offset: 10})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, total)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/client"
+	"github.com/google/uuid"
+)
+
+// ClientRepo keeps the registered OAuth2 clients
+type ClientRepo struct {
+	mu      sync.RWMutex
+	clients map[uuid.UUID]client.Client
+}
+
+func NewClientRepo() *ClientRepo {
+	return &ClientRepo{
+		clients: make(map[uuid.UUID]client.Client),
+	}
+}
+
+func (r *ClientRepo) Save(c client.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[c.ID] = c
+}
+
+func (r *ClientRepo) GetByID(id uuid.UUID) (client.Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.clients[id]
+	if !ok {
+		return client.Client{}, client.ErrClientNotFound
+	}
+	return c, nil
+}
+
+func (r *ClientRepo) List() []client.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := make([]client.Client, 0, len(r.clients))
+	for _, c := range r.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+func (r *ClientRepo) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clients[id]; !ok {
+		return client.ErrClientNotFound
+	}
+	delete(r.clients, id)
+	return nil
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+)
+
+// DeadLetterRepo keeps undelivered notifications in memory, in the order they failed
+type DeadLetterRepo struct {
+	mu          sync.RWMutex
+	deadLetters []notification.DeadLetter
+}
+
+func NewDeadLetterRepo() *DeadLetterRepo {
+	return &DeadLetterRepo{}
+}
+
+func (r *DeadLetterRepo) Add(deadLetter notification.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deadLetters = append(r.deadLetters, deadLetter)
+	return nil
+}
+
+func (r *DeadLetterRepo) List() ([]notification.DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]notification.DeadLetter{}, r.deadLetters...), nil
+}
+
+func (r *DeadLetterRepo) Get(id uuid.UUID) (notification.DeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, dl := range r.deadLetters {
+		if dl.ID == id {
+			return dl, nil
+		}
+	}
+	return notification.DeadLetter{}, notification.ErrDeadLetterNotFound
+}
+
+func (r *DeadLetterRepo) Take(id uuid.UUID) (notification.DeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, dl := range r.deadLetters {
+		if dl.ID == id {
+			r.deadLetters = append(r.deadLetters[:i:i], r.deadLetters[i+1:]...)
+			return dl, nil
+		}
+	}
+	return notification.DeadLetter{}, notification.ErrDeadLetterNotFound
+}
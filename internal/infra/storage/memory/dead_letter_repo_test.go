@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterRepo(t *testing.T) {
+	repo := NewDeadLetterRepo()
+	first := notification.DeadLetter{ID: uuid.New(), Notification: notification.Notification{Subject: "first"}}
+	second := notification.DeadLetter{ID: uuid.New(), Notification: notification.Notification{Subject: "second"}}
+	if !assert.NoError(t, repo.Add(first)) {
+		return
+	}
+	if !assert.NoError(t, repo.Add(second)) {
+		return
+	}
+
+	got, err := repo.Get(second.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, second, got)
+
+	taken, err := repo.Take(first.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, first, taken)
+	_, err = repo.Take(first.ID)
+	assert.ErrorIs(t, err, notification.ErrDeadLetterNotFound)
+	_, err = repo.Get(first.ID)
+	assert.ErrorIs(t, err, notification.ErrDeadLetterNotFound)
+
+	list, err := repo.List()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []notification.DeadLetter{second}, list)
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/digest"
+	"github.com/google/uuid"
+)
+
+// DigestRepo keeps the pending digest entries and the sent digests in memory
+type DigestRepo struct {
+	mu      sync.RWMutex
+	entries []digest.Entry
+	sent    map[uuid.UUID]time.Time
+}
+
+func NewDigestRepo() *DigestRepo {
+	return &DigestRepo{sent: make(map[uuid.UUID]time.Time)}
+}
+
+func (r *DigestRepo) Add(e digest.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sent[e.DigestID]; ok {
+		return digest.ErrDigestSent
+	}
+	for _, existing := range r.entries {
+		if existing.DigestID == e.DigestID && existing.EventID == e.EventID {
+			return nil
+		}
+	}
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func (r *DigestRepo) Due(until time.Time) ([]digest.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	due := []digest.Entry{}
+	for _, e := range r.entries {
+		if !e.PeriodEnd.After(until) {
+			due = append(due, e)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].OccurredAt.Before(due[j].OccurredAt) })
+	return due, nil
+}
+
+func (r *DigestRepo) Complete(digestID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.entries[:0]
+	for _, e := range r.entries {
+		if e.DigestID != digestID {
+			kept = append(kept, e)
+		}
+	}
+	r.entries = kept
+	r.sent[digestID] = at
+	return nil
+}
+
+func (r *DigestRepo) DeleteSent(before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := 0
+	for id, at := range r.sent {
+		if at.Before(before) {
+			delete(r.sent, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/digest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestRepo(t *testing.T) {
+	repo := NewDigestRepo()
+	monday := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	daily := digest.Entry{EventID: uuid.New(), DigestID: uuid.New(), PeriodEnd: monday.AddDate(0, 0, 1), OccurredAt: monday.Add(time.Hour * 2)}
+	earlier := digest.Entry{EventID: uuid.New(), DigestID: daily.DigestID, PeriodEnd: daily.PeriodEnd, OccurredAt: monday.Add(time.Hour)}
+	weekly := digest.Entry{EventID: uuid.New(), DigestID: uuid.New(), PeriodEnd: monday.AddDate(0, 0, 7), OccurredAt: monday}
+	if !assert.NoError(t, repo.Add(daily)) {
+		return
+	}
+	if !assert.NoError(t, repo.Add(earlier)) {
+		return
+	}
+	if !assert.NoError(t, repo.Add(weekly)) {
+		return
+	}
+	if !assert.NoError(t, repo.Add(daily), "a redelivered event") {
+		return
+	}
+
+	due, err := repo.Due(monday.AddDate(0, 0, 1))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []digest.Entry{earlier, daily}, due)
+
+	if !assert.NoError(t, repo.Complete(daily.DigestID, monday.AddDate(0, 0, 1))) {
+		return
+	}
+	due, err = repo.Due(monday.AddDate(0, 0, 7))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []digest.Entry{weekly}, due)
+	assert.ErrorIs(t, repo.Add(digest.Entry{EventID: uuid.New(), DigestID: daily.DigestID}), digest.ErrDigestSent)
+
+	removed, err := repo.DeleteSent(monday.AddDate(0, 0, 2))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, removed)
+}
@@ -2,20 +2,29 @@ package memory
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
 	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
 	"github.com/google/uuid"
 )
 
+// Repo keeps the favourites in memory, appending their audit entries and outbox events while holding
+// its lock. The audit entry is appended first, a change whose entry cannot be stored is not made.
 type Repo struct {
 	mu         sync.RWMutex
 	favourites map[string]map[string]favourite.Favorite
+	outbox     *OutboxRepo
+	audit      audit.Repository
 }
 
-func NewRepo() *Repo {
+func NewRepo(outbox *OutboxRepo, audit audit.Repository) *Repo {
 	return &Repo{
 		favourites: make(map[string]map[string]favourite.Favorite),
+		outbox:     outbox,
+		audit:      audit,
 	}
 }
 
@@ -55,28 +64,46 @@ func (r *Repo) GetAll(userID uuid.UUID) ([]favourite.Favorite, error) {
 	for _, fav := range userMap {
 		values = append(values, fav)
 	}
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i], values[j]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
 	return values, nil
 }
 
-func (r *Repo) Add(userID uuid.UUID, favorite favourite.Favorite) error {
+func (r *Repo) Add(userID uuid.UUID, favorite favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.audit.Append(entry); err != nil {
+		return err
+	}
 	r.ensureUser(userID.String())
 	r.favourites[userID.String()][favorite.ID.String()] = favorite
+	r.outbox.append(events)
 	return nil
 }
 
-func (r *Repo) Update(userID uuid.UUID, favorite favourite.Favorite) error {
+func (r *Repo) Update(userID uuid.UUID, favorite favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.audit.Append(entry); err != nil {
+		return err
+	}
 	r.ensureUser(userID.String())
 	r.favourites[userID.String()][favorite.ID.String()] = favorite
+	r.outbox.append(events)
 	return nil
 }
 
-func (r *Repo) Delete(userID uuid.UUID, favoriteID uuid.UUID) error {
+func (r *Repo) Delete(userID uuid.UUID, favoriteID uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -89,6 +116,38 @@ func (r *Repo) Delete(userID uuid.UUID, favoriteID uuid.UUID) error {
 		return fmt.Errorf("favorite %v not found", favoriteID.String())
 	}
 
+	if err := r.audit.Append(entry); err != nil {
+		return err
+	}
 	delete(userMap, favoriteID.String())
+	r.outbox.append(events)
+	return nil
+}
+
+func (r *Repo) Reorder(userID uuid.UUID, order []uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userMap := r.favourites[userID.String()]
+	if len(order) != len(userMap) {
+		return favourite.ErrInvalidOrder
+	}
+	seen := make(map[uuid.UUID]bool, len(order))
+	for _, id := range order {
+		if _, exists := userMap[id.String()]; !exists || seen[id] {
+			return favourite.ErrInvalidOrder
+		}
+		seen[id] = true
+	}
+
+	if err := r.audit.Append(entry); err != nil {
+		return err
+	}
+	for position, id := range order {
+		fav := userMap[id.String()]
+		fav.Position = position
+		userMap[id.String()] = fav
+	}
+	r.outbox.append(events)
 	return nil
 }
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepo_Reorder(t *testing.T) {
+	events := NewOutboxRepo()
+	audits := NewAuditRepo()
+	repo := NewRepo(events, audits)
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		fav := favourite.Favorite{ID: uuid.New(), Position: i, CreatedAt: now}
+		if !assert.NoError(t, repo.Add(userID, fav, audit.Entry{})) {
+			return
+		}
+		ids = append(ids, fav.ID)
+	}
+	other := uuid.New()
+
+	for name, order := range map[string][]uuid.UUID{
+		"a missing favourite":  {ids[2], ids[0]},
+		"a repeated favourite": {ids[2], ids[0], ids[0]},
+		"another favourite":    {ids[2], ids[0], other},
+	} {
+		assert.ErrorIs(t, repo.Reorder(userID, order, audit.Entry{Action: audit.ActionFavouriteReorder}), favourite.ErrInvalidOrder, name)
+	}
+
+	reordered := outbox.Event{ID: uuid.New(), Type: event.FavoritesReorderedName, OccurredAt: now, NextAttemptAt: now}
+	order := []uuid.UUID{ids[2], ids[0], ids[1]}
+	if !assert.NoError(t, repo.Reorder(userID, order, audit.Entry{Action: audit.ActionFavouriteReorder}, reordered)) {
+		return
+	}
+
+	favs, err := repo.GetAll(userID)
+	if !assert.NoError(t, err) || !assert.Len(t, favs, 3) {
+		return
+	}
+	for i, fav := range favs {
+		assert.Equal(t, order[i], fav.ID)
+		assert.Equal(t, i, fav.Position)
+	}
+
+	// only the stored order left an entry and an event
+	_, total, err := audits.List(audit.Query{Action: audit.ActionFavouriteReorder})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, total)
+	due, err := events.Due(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []outbox.Event{reordered}, due)
+}
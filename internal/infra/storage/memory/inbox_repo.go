@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+)
+
+// InboxRepo keeps the inboxes in memory, by user
+type InboxRepo struct {
+	mu    sync.RWMutex
+	items map[uuid.UUID][]notification.InboxItem
+}
+
+func NewInboxRepo() *InboxRepo {
+	return &InboxRepo{items: make(map[uuid.UUID][]notification.InboxItem)}
+}
+
+func (r *InboxRepo) Add(item notification.InboxItem) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if item.NotificationID != "" {
+		for _, existing := range r.items[item.UserID] {
+			if existing.NotificationID == item.NotificationID {
+				return false, nil
+			}
+		}
+	}
+	r.items[item.UserID] = append(r.items[item.UserID], copyInboxItem(item))
+	return true, nil
+}
+
+func (r *InboxRepo) List(q notification.InboxQuery) ([]notification.InboxItem, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matching := []notification.InboxItem{}
+	for _, item := range r.items[q.UserID] {
+		if q.UnreadOnly && item.ReadAt != nil {
+			continue
+		}
+		matching = append(matching, copyInboxItem(item))
+	}
+	// items are added in order, the newest last
+	for i, j := 0, len(matching)-1; i < j; i, j = i+1, j-1 {
+		matching[i], matching[j] = matching[j], matching[i]
+	}
+
+	total := len(matching)
+	if q.Offset >= total {
+		return []notification.InboxItem{}, total, nil
+	}
+	matching = matching[q.Offset:]
+	if q.Limit > 0 && len(matching) > q.Limit {
+		matching = matching[:q.Limit]
+	}
+	return matching, total, nil
+}
+
+func (r *InboxRepo) Unread(userID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	unread := 0
+	for _, item := range r.items[userID] {
+		if item.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (r *InboxRepo) MarkRead(userID, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := r.items[userID]
+	for i := range items {
+		if items[i].ID == id {
+			if items[i].ReadAt == nil {
+				items[i].ReadAt = &at
+			}
+			return nil
+		}
+	}
+	return notification.ErrInboxItemNotFound
+}
+
+func (r *InboxRepo) MarkAllRead(userID uuid.UUID, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	marked := 0
+	items := r.items[userID]
+	for i := range items {
+		if items[i].ReadAt == nil {
+			items[i].ReadAt = &at
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// copyInboxItem keeps callers from sharing the read time with the stored item
+func copyInboxItem(item notification.InboxItem) notification.InboxItem {
+	if item.ReadAt != nil {
+		at := *item.ReadAt
+		item.ReadAt = &at
+	}
+	return item
+}
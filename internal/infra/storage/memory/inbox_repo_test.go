@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInboxRepo(t *testing.T) {
+	repo := NewInboxRepo()
+	alice, bob := uuid.New(), uuid.New()
+	var items []notification.InboxItem
+	for i, subject := range []string{"first", "second", "third"} {
+		item := notification.InboxItem{ID: uuid.New(), UserID: alice, NotificationID: subject, Subject: subject, CreatedAt: time.Unix(int64(i), 0)}
+		added, err := repo.Add(item)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.True(t, added) {
+			return
+		}
+		items = append(items, item)
+	}
+	added, err := repo.Add(notification.InboxItem{ID: uuid.New(), UserID: alice, NotificationID: "first"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, added, "a notification is kept once")
+	_, err = repo.Add(notification.InboxItem{ID: uuid.New(), UserID: bob, NotificationID: "first"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	page, total, err := repo.List(notification.InboxQuery{UserID: alice, Offset: 1, Limit: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []notification.InboxItem{items[1]}, page, "newest first")
+
+	readAt := time.Unix(100, 0)
+	if !assert.NoError(t, repo.MarkRead(alice, items[2].ID, readAt)) {
+		return
+	}
+	assert.ErrorIs(t, repo.MarkRead(bob, items[2].ID, readAt), notification.ErrInboxItemNotFound, "another user's notification")
+	unread, err := repo.Unread(alice)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, unread)
+
+	page, total, err = repo.List(notification.InboxQuery{UserID: alice, UnreadOnly: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []notification.InboxItem{items[1], items[0]}, page)
+
+	marked, err := repo.MarkAllRead(alice, time.Unix(200, 0))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, marked)
+	page, _, err = repo.List(notification.InboxQuery{UserID: alice})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, readAt, *page[0].ReadAt, "read notifications keep their read time")
+
+	unread, err = repo.Unread(bob)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, unread)
+}
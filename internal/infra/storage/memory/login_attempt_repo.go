@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/lockout"
+)
+
+// LoginAttemptRepo keeps failed login attempts keyed by lockout key
+type LoginAttemptRepo struct {
+	mu       sync.RWMutex
+	attempts map[string]lockout.Attempts
+}
+
+func NewLoginAttemptRepo() *LoginAttemptRepo {
+	return &LoginAttemptRepo{
+		attempts: make(map[string]lockout.Attempts),
+	}
+}
+
+func (r *LoginAttemptRepo) Get(key lockout.Key) (lockout.Attempts, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.attempts[key.String()]
+	return a, ok
+}
+
+func (r *LoginAttemptRepo) Save(a lockout.Attempts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[a.Key.String()] = a
+}
+
+func (r *LoginAttemptRepo) Delete(key lockout.Key) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.attempts[key.String()]
+	delete(r.attempts, key.String())
+	return ok
+}
+
+func (r *LoginAttemptRepo) List() []lockout.Attempts {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make([]lockout.Attempts, 0, len(r.attempts))
+	for _, a := range r.attempts {
+		values = append(values, a)
+	}
+	return values
+}
+
+func (r *LoginAttemptRepo) DeleteStale(before time.Time, now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for k, a := range r.attempts {
+		if a.LastFailure.Before(before) && !now.Before(a.BlockedUntil) {
+			delete(r.attempts, k)
+			removed++
+		}
+	}
+	return removed
+}
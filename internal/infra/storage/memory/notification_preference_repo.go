@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferenceRepo keeps the notification preferences in memory, by user
+type NotificationPreferenceRepo struct {
+	mu          sync.RWMutex
+	preferences map[uuid.UUID]notification.Preferences
+}
+
+func NewNotificationPreferenceRepo() *NotificationPreferenceRepo {
+	return &NotificationPreferenceRepo{preferences: make(map[uuid.UUID]notification.Preferences)}
+}
+
+func (r *NotificationPreferenceRepo) Get(userID uuid.UUID) (notification.Preferences, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.preferences[userID]
+	if !ok {
+		return notification.Preferences{}, notification.ErrPreferencesNotFound
+	}
+	return copyPreferences(p), nil
+}
+
+func (r *NotificationPreferenceRepo) Save(p notification.Preferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preferences[p.UserID] = copyPreferences(p)
+	return nil
+}
+
+// copyPreferences keeps callers from sharing the slices and quiet hours with the stored preferences
+func copyPreferences(p notification.Preferences) notification.Preferences {
+	p.Channels = append([]string{}, p.Channels...)
+	p.MutedEvents = append([]string{}, p.MutedEvents...)
+	if p.QuietHours != nil {
+		q := *p.QuietHours
+		p.QuietHours = &q
+	}
+	return p
+}
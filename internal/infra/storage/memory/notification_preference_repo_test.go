@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/notification"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationPreferenceRepo(t *testing.T) {
+	repo := NewNotificationPreferenceRepo()
+	userID := uuid.New()
+	_, err := repo.Get(userID)
+	assert.ErrorIs(t, err, notification.ErrPreferencesNotFound)
+
+	p := notification.DefaultPreferences(userID)
+	p.QuietHours = &notification.QuietHours{Start: "22:00", End: "07:00"}
+	if !assert.NoError(t, repo.Save(p)) {
+		return
+	}
+
+	got, err := repo.Get(userID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, p, got)
+
+	got.Channels[0] = "pigeon"
+	got.QuietHours.Start = "00:00"
+	again, err := repo.Get(userID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, p, again, "the stored preferences are not shared")
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+)
+
+// OIDCStateRepo keeps pending OpenID Connect logins keyed by the keyed hash of the state
+type OIDCStateRepo struct {
+	mu     sync.Mutex
+	hasher helper.TokenHasher
+	states map[string]oidcStateEntry // state hash -> entry
+}
+
+type oidcStateEntry struct {
+	record token.OIDCStateRecord
+}
+
+func NewOIDCStateRepo(hasher helper.TokenHasher) *OIDCStateRepo {
+	return &OIDCStateRepo{
+		hasher: hasher,
+		states: make(map[string]oidcStateEntry),
+	}
+}
+
+func (r *OIDCStateRepo) Save(state string, rec token.OIDCStateRecord) {
+	hash := r.hasher.Hash(state)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[hash] = oidcStateEntry{record: rec}
+}
+
+func (r *OIDCStateRepo) Consume(state string) (token.OIDCStateRecord, bool) {
+	hash := r.hasher.Hash(state)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.states[hash]
+	if !ok {
+		return token.OIDCStateRecord{}, false
+	}
+	delete(r.states, hash)
+	return entry.record, true
+}
+
+func (r *OIDCStateRepo) DeleteExpired(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for hash, entry := range r.states {
+		if now.After(entry.record.Expiry) {
+			delete(r.states, hash)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+)
+
+// OutboxRepo keeps the outbox events in memory, in the order they were appended
+type OutboxRepo struct {
+	mu     sync.RWMutex
+	events []outbox.Event
+}
+
+func NewOutboxRepo() *OutboxRepo {
+	return &OutboxRepo{}
+}
+
+// append adds events under the lock of the writing repository, which makes the write and its events atomic
+func (r *OutboxRepo) append(events []outbox.Event) {
+	if len(events) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+}
+
+func (r *OutboxRepo) Due(now time.Time, limit int) ([]outbox.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due := []outbox.Event{}
+	for _, e := range r.events {
+		if e.PublishedAt != nil || e.NextAttemptAt.After(now) {
+			continue
+		}
+		e.DeliveredTo = append([]string(nil), e.DeliveredTo...)
+		due = append(due, e)
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].OccurredAt.Before(due[j].OccurredAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *OutboxRepo) MarkDelivered(id uuid.UUID, subscriber string) error {
+	return r.update(id, func(e *outbox.Event) {
+		if !e.Delivered(subscriber) {
+			e.DeliveredTo = append(e.DeliveredTo, subscriber)
+		}
+	})
+}
+
+func (r *OutboxRepo) MarkPublished(id uuid.UUID, at time.Time) error {
+	return r.update(id, func(e *outbox.Event) {
+		e.PublishedAt = &at
+		e.LastError = ""
+	})
+}
+
+func (r *OutboxRepo) MarkFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	return r.update(id, func(e *outbox.Event) {
+		e.Attempts++
+		e.LastError = lastError
+		e.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (r *OutboxRepo) DeletePublished(before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if e.PublishedAt == nil || !e.PublishedAt.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(r.events) - len(kept)
+	r.events = kept
+	return removed, nil
+}
+
+func (r *OutboxRepo) update(id uuid.UUID, apply func(e *outbox.Event)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		if r.events[i].ID == id {
+			apply(&r.events[i])
+			return nil
+		}
+	}
+	return outbox.ErrEventNotFound
+}
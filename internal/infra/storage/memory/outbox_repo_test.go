@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingAuditRepo refuses every entry
+type failingAuditRepo struct {
+	*AuditRepo
+}
+
+func (failingAuditRepo) Append(audit.Entry) error {
+	return errors.New("audit log unavailable")
+}
+
+func TestRepo_WritesAuditAndEventsWithTheChange(t *testing.T) {
+	events := NewOutboxRepo()
+	audits := NewAuditRepo()
+	repo := NewRepo(events, audits)
+	userID := uuid.New()
+	fav := favourite.Favorite{ID: uuid.New(), Description: "chart"}
+	now := time.Now().UTC()
+
+	created := outbox.Event{ID: uuid.New(), Type: event.FavoriteAddedName, OccurredAt: now, NextAttemptAt: now}
+	entry := audit.Entry{ID: uuid.New(), Time: now, Action: audit.ActionFavouriteCreate}
+	if !assert.NoError(t, repo.Add(userID, fav, entry, created)) {
+		return
+	}
+
+	// a failing delete leaves no entry nor event behind
+	missing := outbox.Event{ID: uuid.New(), Type: event.FavoriteDeletedName, OccurredAt: now, NextAttemptAt: now}
+	assert.Error(t, repo.Delete(userID, uuid.New(), audit.Entry{ID: uuid.New(), Action: audit.ActionFavouriteDelete}, missing))
+
+	due, err := events.Due(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []outbox.Event{created}, due)
+	entries, _, err := audits.List(audit.Query{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []audit.Entry{entry}, entries)
+}
+
+func TestRepo_NoChangeWithoutItsAuditEntry(t *testing.T) {
+	events := NewOutboxRepo()
+	repo := NewRepo(events, failingAuditRepo{NewAuditRepo()})
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	created := outbox.Event{ID: uuid.New(), Type: event.FavoriteAddedName, OccurredAt: now, NextAttemptAt: now}
+	assert.Error(t, repo.Add(userID, favourite.Favorite{ID: uuid.New()}, audit.Entry{}, created))
+
+	favs, err := repo.GetAll(userID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, favs, "the favourite is not stored")
+	due, err := events.Due(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, due, "nor its event")
+}
+
+func TestOutboxRepo(t *testing.T) {
+	repo := NewOutboxRepo()
+	now := time.Now().UTC()
+	first := outbox.Event{ID: uuid.New(), Type: "first", OccurredAt: now.Add(-time.Minute), NextAttemptAt: now.Add(-time.Minute)}
+	second := outbox.Event{ID: uuid.New(), Type: "second", OccurredAt: now, NextAttemptAt: now}
+	repo.append([]outbox.Event{second, first})
+
+	due, err := repo.Due(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, due, 2) {
+		return
+	}
+	assert.Equal(t, first.ID, due[0].ID, "oldest first")
+
+	due, err = repo.Due(now, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, due, 1)
+
+	// a failed event waits for its next attempt
+	if !assert.NoError(t, repo.MarkDelivered(first.ID, "notifications")) {
+		return
+	}
+	if !assert.NoError(t, repo.MarkFailed(first.ID, "boom", now.Add(time.Minute))) {
+		return
+	}
+	due, err = repo.Due(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []outbox.Event{second}, due)
+
+	due, err = repo.Due(now.Add(time.Minute), 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, due, 2) {
+		return
+	}
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.Equal(t, "boom", due[0].LastError)
+	assert.True(t, due[0].Delivered("notifications"))
+
+	// published events are no longer due, and purged after the retention
+	if !assert.NoError(t, repo.MarkPublished(second.ID, now)) {
+		return
+	}
+	due, err = repo.Due(now.Add(time.Minute), 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, due, 1)
+
+	removed, err := repo.DeletePublished(now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Zero(t, removed)
+	removed, err = repo.DeletePublished(now.Add(time.Second))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, removed)
+
+	assert.ErrorIs(t, repo.MarkPublished(second.ID, now), outbox.ErrEventNotFound)
+}
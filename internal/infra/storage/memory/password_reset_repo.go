@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+)
+
+// ResetRepo keeps password reset tokens keyed by the keyed hash of the token
+type ResetRepo struct {
+	mu     sync.Mutex
+	hasher helper.TokenHasher
+	tokens map[string]resetEntry // token hash -> entry
+}
+
+type resetEntry struct {
+	record token.ResetRecord
+}
+
+func NewResetRepo(hasher helper.TokenHasher) *ResetRepo {
+	return &ResetRepo{
+		hasher: hasher,
+		tokens: make(map[string]resetEntry),
+	}
+}
+
+func (r *ResetRepo) Save(token string, rec token.ResetRecord) {
+	hash := r.hasher.Hash(token)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[hash] = resetEntry{record: rec}
+}
+
+func (r *ResetRepo) Consume(token string) (token.ResetRecord, bool) {
+	hash := r.hasher.Hash(token)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.tokens[hash]
+	if !ok {
+		return entry.record, false
+	}
+	delete(r.tokens, hash)
+	return entry.record, true
+}
+
+func (r *ResetRepo) DeleteByUser(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for hash, entry := range r.tokens {
+		if entry.record.UserID == userID {
+			delete(r.tokens, hash)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (r *ResetRepo) DeleteExpired(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for hash, entry := range r.tokens {
+		if now.After(entry.record.Expiry) {
+			delete(r.tokens, hash)
+			removed++
+		}
+	}
+	return removed
+}
@@ -2,35 +2,127 @@ package memory
 
 import (
 	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
 	"sync"
+	"time"
 )
 
+// RefreshRepo keeps refresh token sessions keyed by the keyed hash of the token.
+// The raw token is never stored. A lookup is a map access by the HMAC digest, which an
+// attacker cannot compute without the key, so its timing reveals nothing useful about stored tokens.
 type RefreshRepo struct {
 	mu     sync.RWMutex
-	tokens map[string]token.RefreshRecord
+	hasher helper.TokenHasher
+	tokens map[string]refreshEntry           // token hash -> entry
+	byUser map[uuid.UUID]map[string]struct{} // userID -> set of token hashes
 }
 
-func NewRefreshRepo() *RefreshRepo {
+type refreshEntry struct {
+	record token.RefreshRecord
+}
+
+func NewRefreshRepo(hasher helper.TokenHasher) *RefreshRepo {
 	return &RefreshRepo{
-		tokens: make(map[string]token.RefreshRecord),
+		hasher: hasher,
+		tokens: make(map[string]refreshEntry),
+		byUser: make(map[uuid.UUID]map[string]struct{}),
 	}
 }
 
 func (r *RefreshRepo) Save(token string, rec token.RefreshRecord) {
+	hash := r.hasher.Hash(token)
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.tokens[token] = rec
+	r.tokens[hash] = refreshEntry{record: rec}
+	if _, ok := r.byUser[rec.UserID]; !ok {
+		r.byUser[rec.UserID] = make(map[string]struct{})
+	}
+	r.byUser[rec.UserID][hash] = struct{}{}
 }
 
 func (r *RefreshRepo) Get(token string) (token.RefreshRecord, bool) {
+	hash := r.hasher.Hash(token)
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	rec, ok := r.tokens[token]
-	return rec, ok
+	entry, ok := r.tokens[hash]
+	if !ok {
+		return entry.record, false
+	}
+	return entry.record, true
 }
 
 func (r *RefreshRepo) Delete(token string) {
+	hash := r.hasher.Hash(token)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delete(hash)
+}
+
+func (r *RefreshRepo) ListByUser(userID uuid.UUID) []token.RefreshRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := make([]token.RefreshRecord, 0, len(r.byUser[userID]))
+	for hash := range r.byUser[userID] {
+		records = append(records, r.tokens[hash].record)
+	}
+	return records
+}
+
+func (r *RefreshRepo) DeleteByID(userID uuid.UUID, sessionID uuid.UUID) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	delete(r.tokens, token)
+
+	for hash := range r.byUser[userID] {
+		if r.tokens[hash].record.ID == sessionID {
+			r.delete(hash)
+			return nil
+		}
+	}
+	return token.ErrSessionNotFound
+}
+
+func (r *RefreshRepo) DeleteByUser(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for hash := range r.byUser[userID] {
+		r.delete(hash)
+		removed++
+	}
+	return removed
+}
+
+func (r *RefreshRepo) DeleteExpired(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for hash, entry := range r.tokens {
+		if now.After(entry.record.Expiry) {
+			r.delete(hash)
+			removed++
+		}
+	}
+	return removed
+}
+
+// delete removes a token hash from both indexes. Caller must hold the write lock.
+func (r *RefreshRepo) delete(hash string) {
+	entry, ok := r.tokens[hash]
+	if !ok {
+		return
+	}
+	delete(r.tokens, hash)
+	if set, ok := r.byUser[entry.record.UserID]; ok {
+		delete(set, hash)
+		if len(set) == 0 {
+			delete(r.byUser, entry.record.UserID)
+		}
+	}
 }
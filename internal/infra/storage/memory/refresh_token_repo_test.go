@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshRepo_StoresOnlyHashes(t *testing.T) {
+	repo := NewRefreshRepo(helper.NewTokenHasher([]byte("key")))
+	rec := token.RefreshRecord{ID: uuid.New(), UserID: uuid.New(), Expiry: time.Now().Add(time.Hour)}
+
+	repo.Save("raw-token", rec)
+
+	_, stored := repo.tokens["raw-token"]
+	assert.False(t, stored, "raw token must not be a storage key")
+
+	got, ok := repo.Get("raw-token")
+	assert.True(t, ok)
+	assert.Equal(t, rec.ID, got.ID)
+
+	_, ok = repo.Get("other-token")
+	assert.False(t, ok)
+
+	// a repository with another key cannot resolve the same token
+	other := NewRefreshRepo(helper.NewTokenHasher([]byte("other-key")))
+	other.tokens = repo.tokens
+	_, ok = other.Get("raw-token")
+	assert.False(t, ok)
+}
+
+func TestRefreshRepo_DeleteExpired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	repo := NewRefreshRepo(helper.NewTokenHasher([]byte("key")))
+
+	repo.Save("expired-1", token.RefreshRecord{ID: uuid.New(), UserID: userID, Expiry: now.Add(-time.Hour)})
+	repo.Save("expired-2", token.RefreshRecord{ID: uuid.New(), UserID: uuid.New(), Expiry: now.Add(-time.Second)})
+	repo.Save("active", token.RefreshRecord{ID: uuid.New(), UserID: userID, Expiry: now.Add(time.Hour)})
+
+	assert.Equal(t, 2, repo.DeleteExpired(now))
+	assert.Equal(t, 0, repo.DeleteExpired(now))
+
+	_, ok := repo.Get("active")
+	assert.True(t, ok)
+	assert.Len(t, repo.ListByUser(userID), 1)
+	assert.Len(t, repo.byUser, 1)
+}
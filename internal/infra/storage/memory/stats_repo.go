@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/stats"
+	"github.com/google/uuid"
+)
+
+// StatsRepo aggregates the in-memory stores in place, reading each under its own lock
+type StatsRepo struct {
+	users      *UserRepo
+	favourites *Repo
+	sessions   *RefreshRepo
+}
+
+func NewStatsRepo(users *UserRepo, favourites *Repo, sessions *RefreshRepo) *StatsRepo {
+	return &StatsRepo{users: users, favourites: favourites, sessions: sessions}
+}
+
+func (r *StatsRepo) Get(query stats.Query) (*stats.Stats, error) {
+	result := &stats.Stats{
+		FavouritesByType: map[favourite.AssetType]int{},
+		TopUsers:         []stats.UserFavourites{},
+		FavouritesPerDay: []stats.DayFavourites{},
+	}
+
+	perUser := r.countFavourites(query, result)
+	r.addTopUsers(query.TopUsers, perUser, result)
+
+	r.sessions.mu.RLock()
+	for _, entry := range r.sessions.tokens {
+		if query.Now.Before(entry.record.Expiry) {
+			result.ActiveSessions++
+		}
+	}
+	r.sessions.mu.RUnlock()
+
+	return result, nil
+}
+
+// countFavourites fills the favourite totals and returns the favourites per user
+func (r *StatsRepo) countFavourites(query stats.Query, result *stats.Stats) []stats.UserFavourites {
+	perDay := map[string]int{}
+
+	r.favourites.mu.RLock()
+	perUser := make([]stats.UserFavourites, 0, len(r.favourites.favourites))
+	for userID, favs := range r.favourites.favourites {
+		if len(favs) == 0 {
+			continue
+		}
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			continue
+		}
+		perUser = append(perUser, stats.UserFavourites{UserID: id, Favourites: len(favs)})
+		result.Favourites += len(favs)
+
+		for _, fav := range favs {
+			result.FavouritesByType[fav.Type]++
+			if !fav.CreatedAt.Before(query.Since) {
+				perDay[fav.CreatedAt.UTC().Format(stats.DayLayout)]++
+			}
+		}
+	}
+	r.favourites.mu.RUnlock()
+
+	for _, day := range query.Days() {
+		result.FavouritesPerDay = append(result.FavouritesPerDay, stats.DayFavourites{Day: day, Favourites: perDay[day]})
+	}
+	return perUser
+}
+
+// addTopUsers counts the users and keeps the top n of perUser, with their usernames
+func (r *StatsRepo) addTopUsers(n int, perUser []stats.UserFavourites, result *stats.Stats) {
+	sort.Slice(perUser, func(i, j int) bool {
+		if perUser[i].Favourites != perUser[j].Favourites {
+			return perUser[i].Favourites > perUser[j].Favourites
+		}
+		return perUser[i].UserID.String() < perUser[j].UserID.String()
+	})
+	if n < len(perUser) {
+		perUser = perUser[:n]
+	}
+
+	top := make(map[uuid.UUID]int, len(perUser))
+	for i, u := range perUser {
+		top[u.UserID] = i
+	}
+
+	r.users.mu.RLock()
+	result.Users = len(r.users.users)
+	for _, u := range r.users.users {
+		if i, ok := top[u.ID]; ok {
+			perUser[i].Username = u.Username
+		}
+	}
+	r.users.mu.RUnlock()
+
+	result.TopUsers = append(result.TopUsers, perUser...)
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/stats"
+	"github.com/akazantzidis/gwi-ass/internal/domain/token"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestStatsRepo_Get(t *testing.T) {
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+	if !assert.NoError(t, err) {
+		return
+	}
+	users := NewUserRepo(hasher)
+	favourites := NewRepo(NewOutboxRepo(), NewAuditRepo())
+	sessions := NewRefreshRepo(helper.NewTokenHasher([]byte("key")))
+	repo := NewStatsRepo(users, favourites, sessions)
+
+	alice, err := users.Add("alice", "long enough password", []string{"user"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	bob, err := users.Add("bob", "long enough password", []string{"user"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = users.Add("carol", "long enough password", []string{"user"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	add := func(owner uuid.UUID, assetType favourite.AssetType, created time.Time) {
+		if !assert.NoError(t, favourites.Add(owner, favourite.Favorite{ID: uuid.New(), Type: assetType, CreatedAt: created}, audit.Entry{})) {
+			return
+		}
+	}
+	add(alice.ID, favourite.AssetChart, now.AddDate(0, 0, -20))
+	add(bob.ID, favourite.AssetChart, now.AddDate(0, 0, -3))
+	add(bob.ID, favourite.AssetChart, now.AddDate(0, 0, -1))
+	add(bob.ID, favourite.AssetInsight, now.AddDate(0, 0, -1))
+	add(bob.ID, favourite.AssetAudience, now)
+
+	sessions.Save("active", token.RefreshRecord{ID: uuid.New(), UserID: bob.ID, Expiry: now.Add(time.Hour)})
+	sessions.Save("expired", token.RefreshRecord{ID: uuid.New(), UserID: alice.ID, Expiry: now.Add(-time.Hour)})
+
+	got, err := repo.Get(stats.Query{Since: time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC), Now: now, TopUsers: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 3, got.Users)
+	assert.Equal(t, 5, got.Favourites)
+	assert.Equal(t, map[favourite.AssetType]int{
+		favourite.AssetChart:    3,
+		favourite.AssetInsight:  1,
+		favourite.AssetAudience: 1,
+	}, got.FavouritesByType)
+	assert.Equal(t, []stats.UserFavourites{{UserID: bob.ID, Username: "bob", Favourites: 4}}, got.TopUsers)
+	assert.Equal(t, []stats.DayFavourites{
+		{Day: "2025-03-06", Favourites: 0},
+		{Day: "2025-03-07", Favourites: 1},
+		{Day: "2025-03-08", Favourites: 0},
+		{Day: "2025-03-09", Favourites: 2},
+		{Day: "2025-03-10", Favourites: 1},
+	}, got.FavouritesPerDay, "one entry per day of the window, gaps included")
+	assert.Equal(t, 1, got.ActiveSessions)
+}
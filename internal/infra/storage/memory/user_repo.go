@@ -1,55 +1,211 @@
 package memory
 
 import (
-	"errors"
 	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+// ErrUserNotFound is kept for callers of this package, it is user.ErrUserNotFound
+var ErrUserNotFound = user.ErrUserNotFound
 
+// UserRepo keeps users in memory. Lookups return copies, so callers never share state with the store.
 type UserRepo struct {
-	mu    sync.RWMutex
-	users map[string]*user.User // key = username
+	mu     sync.RWMutex
+	hasher user.PasswordHasher
+	users  map[string]*user.User // key = normalized username
 }
 
-func NewUserRepo() *UserRepo {
+func NewUserRepo(hasher user.PasswordHasher) *UserRepo {
 	return &UserRepo{
-		users: make(map[string]*user.User),
+		hasher: hasher,
+		users:  make(map[string]*user.User),
 	}
 }
 
 func (r *UserRepo) Add(username, plainPassword string, roles []string) (*user.User, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	username = user.NormalizeUsername(username)
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
+	hashed, err := r.hasher.Hash(plainPassword)
 	if err != nil {
 		return nil, err
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.users[username]; exists {
+		return nil, user.ErrUsernameTaken
+	}
+
 	u := &user.User{
-		ID:       uuid.New(),
-		Username: username,
-		Password: string(hashed),
-		Roles:    roles,
+		ID:        uuid.New(),
+		Username:  username,
+		Password:  hashed,
+		Roles:     roles,
+		Status:    user.StatusActive,
+		CreatedAt: time.Now().UTC(),
 	}
 
 	r.users[username] = u
-	return u, nil
+	c := *u
+	return &c, nil
 }
 
-func (r *UserRepo) GetByID(id uuid.UUID) (*user.User, error) {
+func (r *UserRepo) UpdatePassword(id uuid.UUID, plainPassword string) error {
+	hashed, err := r.hasher.Hash(plainPassword)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.Password = hashed
+	return nil
+}
+
+func (r *UserRepo) ReplacePasswordHash(id uuid.UUID, oldHash, newHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	if u.Password != oldHash {
+		return user.ErrPasswordHashChanged
+	}
+	u.Password = newHash
+	return nil
+}
+
+func (r *UserRepo) UpdateTOTP(id uuid.UUID, totp user.TOTP) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.TOTP = totp
+	return nil
+}
+
+func (r *UserRepo) GetByIdentity(identity user.Identity) (*user.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	if u := r.findByIdentity(identity); u != nil {
+		c := *u
+		return &c, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *UserRepo) LinkIdentity(id uuid.UUID, identity user.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	if linked := r.findByIdentity(identity); linked != nil {
+		if linked.ID == id {
+			return nil
+		}
+		return user.ErrIdentityLinked
+	}
+	// copy on write, copies handed out earlier share the old slice
+	u.Identities = append(append([]user.Identity(nil), u.Identities...), identity)
+	return nil
+}
+
+func (r *UserRepo) UpdateRoles(id uuid.UUID, roles []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.Roles = append([]string(nil), roles...)
+	return nil
+}
+
+func (r *UserRepo) UpdateEmail(id uuid.UUID, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.Email = email
+	return nil
+}
+
+func (r *UserRepo) List(query user.ListQuery) ([]*user.User, int, error) {
+	search := user.NormalizeUsername(query.Search)
+
+	r.mu.RLock()
+	matches := make([]*user.User, 0, len(r.users))
 	for _, u := range r.users {
-		if u.ID == id {
-			return u, nil
+		if search != "" && !strings.Contains(u.Username, search) {
+			continue
+		}
+		if query.Status != "" && u.Status != query.Status {
+			continue
 		}
+		if query.Role != "" && !hasRole(u, query.Role) {
+			continue
+		}
+		c := *u
+		matches = append(matches, &c)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Username < matches[j].Username })
+
+	total := len(matches)
+	if query.Offset >= total {
+		return []*user.User{}, total, nil
+	}
+	end := total
+	if query.Limit > 0 && query.Offset+query.Limit < total {
+		end = query.Offset + query.Limit
+	}
+	return matches[query.Offset:end], total, nil
+}
+
+func (r *UserRepo) SetStatus(id uuid.UUID, status user.Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.findByID(id)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.Status = status
+	return nil
+}
+
+func (r *UserRepo) GetByID(id uuid.UUID) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if u := r.findByID(id); u != nil {
+		c := *u
+		return &c, nil
 	}
 
 	return nil, ErrUserNotFound
@@ -59,9 +215,41 @@ func (r *UserRepo) GetByUsername(username string) (*user.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	u, ok := r.users[username]
+	u, ok := r.users[user.NormalizeUsername(username)]
 	if !ok {
 		return nil, ErrUserNotFound
 	}
-	return u, nil
+	c := *u
+	return &c, nil
+}
+
+// findByID scans the users for the given id. Caller must hold the lock.
+func (r *UserRepo) findByID(id uuid.UUID) *user.User {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u
+		}
+	}
+	return nil
+}
+
+// findByIdentity scans the users for the given external identity. Caller must hold the lock.
+func (r *UserRepo) findByIdentity(identity user.Identity) *user.User {
+	for _, u := range r.users {
+		for _, i := range u.Identities {
+			if i == identity {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+func hasRole(u *user.User, role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
 }
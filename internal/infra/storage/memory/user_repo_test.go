@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserRepo_ListFiltersAndPaginates(t *testing.T) {
+	hasher, err := passwordhash.New(passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+	if !assert.NoError(t, err) {
+		return
+	}
+	repo := NewUserRepo(hasher)
+
+	for _, name := range []string{"dave", "alice", "carol", "bob"} {
+		_, err := repo.Add(name, "long enough password", []string{"user"})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+	carol, err := repo.GetByUsername("carol")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, repo.UpdateRoles(carol.ID, []string{"admin", "user"})) {
+		return
+	}
+	if !assert.NoError(t, repo.SetStatus(carol.ID, user.StatusDisabled)) {
+		return
+	}
+
+	page, total, err := repo.List(user.ListQuery{Offset: 1, Limit: 2})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{"bob", "carol"}, usernames(page))
+
+	page, total, err = repo.List(user.ListQuery{Search: " AR"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []string{"carol"}, usernames(page))
+
+	page, _, err = repo.List(user.ListQuery{Role: "admin", Status: user.StatusDisabled})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"carol"}, usernames(page))
+
+	page, total, err = repo.List(user.ListQuery{Offset: 10})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 4, total)
+	assert.Empty(t, page)
+
+	assert.ErrorIs(t, repo.SetStatus(uuid.New(), user.StatusActive), user.ErrUserNotFound)
+}
+
+func usernames(users []*user.User) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	return names
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/google/uuid"
+)
+
+// WebhookRepo keeps the webhook subscriptions and their deliveries in memory
+type WebhookRepo struct {
+	mu            sync.RWMutex
+	subscriptions []webhook.Subscription
+	deliveries    []webhook.Delivery
+}
+
+func NewWebhookRepo() *WebhookRepo {
+	return &WebhookRepo{}
+}
+
+func (r *WebhookRepo) Add(s webhook.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions = append(r.subscriptions, copySubscription(s))
+	return nil
+}
+
+func (r *WebhookRepo) Get(id uuid.UUID) (webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.subscriptions {
+		if s.ID == id {
+			return copySubscription(s), nil
+		}
+	}
+	return webhook.Subscription{}, webhook.ErrSubscriptionNotFound
+}
+
+func (r *WebhookRepo) List() ([]webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subscriptions := make([]webhook.Subscription, 0, len(r.subscriptions))
+	for _, s := range r.subscriptions {
+		subscriptions = append(subscriptions, copySubscription(s))
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookRepo) Update(s webhook.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.subscriptions {
+		if r.subscriptions[i].ID == s.ID {
+			r.subscriptions[i] = copySubscription(s)
+			return nil
+		}
+	}
+	return webhook.ErrSubscriptionNotFound
+}
+
+func (r *WebhookRepo) RecordAttempt(id uuid.UUID, ok bool, disableAfter int, lastError string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.subscriptions {
+		if r.subscriptions[i].ID == id {
+			return r.subscriptions[i].RecordAttempt(ok, disableAfter, lastError, at), nil
+		}
+	}
+	return false, webhook.ErrSubscriptionNotFound
+}
+
+func (r *WebhookRepo) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.subscriptions {
+		if s.ID == id {
+			r.subscriptions = append(r.subscriptions[:i:i], r.subscriptions[i+1:]...)
+			kept := r.deliveries[:0]
+			for _, d := range r.deliveries {
+				if d.SubscriptionID != id {
+					kept = append(kept, d)
+				}
+			}
+			r.deliveries = kept
+			return nil
+		}
+	}
+	return webhook.ErrSubscriptionNotFound
+}
+
+func (r *WebhookRepo) Enqueue(d webhook.Delivery) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.deliveries {
+		if existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID {
+			return false, nil
+		}
+	}
+	r.deliveries = append(r.deliveries, d)
+	return true, nil
+}
+
+func (r *WebhookRepo) DueDeliveries(now time.Time, limit int) ([]webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due := []webhook.Delivery{}
+	for _, d := range r.deliveries {
+		if d.Status == webhook.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *WebhookRepo) UpdateDelivery(d webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == d.ID {
+			r.deliveries[i] = d
+			return nil
+		}
+	}
+	return webhook.ErrDeliveryNotFound
+}
+
+func (r *WebhookRepo) Deliveries(subscriptionID uuid.UUID, limit int) ([]webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []webhook.Delivery{}
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, r.deliveries[i])
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) DeleteCompletedDeliveries(before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.deliveries[:0]
+	for _, d := range r.deliveries {
+		if d.CompletedAt == nil || !d.CompletedAt.Before(before) {
+			kept = append(kept, d)
+		}
+	}
+	removed := len(r.deliveries) - len(kept)
+	r.deliveries = kept
+	return removed, nil
+}
+
+// copySubscription keeps callers from sharing the event filter with the stored subscription
+func copySubscription(s webhook.Subscription) webhook.Subscription {
+	s.Events = append([]string(nil), s.Events...)
+	return s
+}
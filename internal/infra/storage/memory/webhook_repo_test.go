@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRepo_Subscriptions(t *testing.T) {
+	repo := NewWebhookRepo()
+	s := webhook.Subscription{ID: uuid.New(), URL: "https://example.com/hook", Events: []string{"favourite.created"}, Status: webhook.StatusActive}
+	if !assert.NoError(t, repo.Add(s)) {
+		return
+	}
+
+	got, err := repo.Get(s.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	got.Events[0] = "changed"
+	got, err = repo.Get(s.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, s, got, "the stored filter is not shared")
+
+	s.Status = webhook.StatusDisabled
+	if !assert.NoError(t, repo.Update(s)) {
+		return
+	}
+	list, err := repo.List()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []webhook.Subscription{s}, list)
+
+	assert.ErrorIs(t, repo.Update(webhook.Subscription{ID: uuid.New()}), webhook.ErrSubscriptionNotFound)
+	_, err = repo.Get(uuid.New())
+	assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+}
+
+func TestWebhookRepo_RecordAttempt(t *testing.T) {
+	repo := NewWebhookRepo()
+	s := webhook.Subscription{ID: uuid.New(), URL: "https://example.com/hook", Status: webhook.StatusActive}
+	if !assert.NoError(t, repo.Add(s)) {
+		return
+	}
+
+	// attempts and edits running concurrently neither lose a failure nor revert an edit
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := repo.RecordAttempt(s.ID, false, 0, "boom", time.Now())
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.Update(webhook.Subscription{ID: s.ID, URL: "https://example.com/moved", Status: webhook.StatusActive}))
+		}()
+	}
+	wg.Wait()
+	got, err := repo.Get(s.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "https://example.com/moved", got.URL)
+
+	if !assert.NoError(t, repo.Update(s)) {
+		return
+	}
+	disabled, err := repo.RecordAttempt(s.ID, false, 2, "boom", time.Now())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, disabled)
+	disabled, err = repo.RecordAttempt(s.ID, false, 2, "boom", time.Now())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, disabled)
+	got, _ = repo.Get(s.ID)
+	assert.Equal(t, webhook.StatusDisabled, got.Status)
+	assert.Equal(t, "2 consecutive failed deliveries, last: boom", got.DisabledReason)
+
+	_, err = repo.RecordAttempt(s.ID, true, 2, "", time.Now())
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, _ = repo.Get(s.ID)
+	assert.Zero(t, got.ConsecutiveFailures)
+	assert.Equal(t, webhook.StatusDisabled, got.Status, "only an admin enables it again")
+
+	_, err = repo.RecordAttempt(uuid.New(), true, 0, "", time.Now())
+	assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+}
+
+func TestWebhookRepo_Deliveries(t *testing.T) {
+	repo := NewWebhookRepo()
+	subID, eventID := uuid.New(), uuid.New()
+	now := time.Now().UTC()
+	if !assert.NoError(t, repo.Add(webhook.Subscription{ID: subID})) {
+		return
+	}
+
+	first := webhook.Delivery{ID: uuid.New(), SubscriptionID: subID, EventID: eventID, Status: webhook.DeliveryPending, CreatedAt: now.Add(-time.Minute), NextAttemptAt: now}
+	second := webhook.Delivery{ID: uuid.New(), SubscriptionID: subID, EventID: uuid.New(), Status: webhook.DeliveryPending, CreatedAt: now, NextAttemptAt: now.Add(time.Minute)}
+	for _, d := range []webhook.Delivery{first, second} {
+		stored, err := repo.Enqueue(d)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, stored)
+	}
+	stored, err := repo.Enqueue(webhook.Delivery{ID: uuid.New(), SubscriptionID: subID, EventID: eventID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, stored, "an event is delivered once per subscription")
+
+	due, err := repo.DueDeliveries(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []webhook.Delivery{first}, due)
+
+	log, err := repo.Deliveries(subID, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, log, 2) {
+		return
+	}
+	assert.Equal(t, second.ID, log[0].ID, "newest first")
+
+	completed := now.Add(-time.Hour)
+	first.Status, first.CompletedAt = webhook.DeliverySucceeded, &completed
+	if !assert.NoError(t, repo.UpdateDelivery(first)) {
+		return
+	}
+	assert.ErrorIs(t, repo.UpdateDelivery(webhook.Delivery{ID: uuid.New()}), webhook.ErrDeliveryNotFound)
+
+	removed, err := repo.DeleteCompletedDeliveries(now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, removed)
+
+	if !assert.NoError(t, repo.Delete(subID)) {
+		return
+	}
+	log, err = repo.Deliveries(subID, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, log, "deleting a subscription deletes its deliveries")
+	assert.ErrorIs(t, repo.Delete(subID), webhook.ErrSubscriptionNotFound)
+}
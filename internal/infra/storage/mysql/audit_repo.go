@@ -0,0 +1,118 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+)
+
+// AuditRepo keeps the audit log in the audit_entries table. The filtered fields have their own
+// columns, the entry itself is stored as JSON.
+type AuditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+// appendEntry inserts the entry, within the transaction of the change it describes when given one
+func appendEntry(tx execer, entry audit.Entry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO audit_entries (id, time, actor_id, action, target_type, target_id, request_id, entry) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.ID[:], entry.Time, entry.Actor.ID, entry.Action, entry.Target.Type, entry.Target.ID, entry.RequestID, body)
+	return err
+}
+
+func (r *AuditRepo) Append(entry audit.Entry) error {
+	return appendEntry(r.db, entry)
+}
+
+func (r *AuditRepo) List(query audit.Query) ([]audit.Entry, int, error) {
+	where, args := auditFilters(query)
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM audit_entries"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := " ORDER BY seq DESC"
+	if query.OldestFirst {
+		order = " ORDER BY seq"
+	}
+	// MySQL has no OFFSET without LIMIT, the largest value stands for no limit
+	bounds := " LIMIT 18446744073709551615 OFFSET ?"
+	if query.Limit > 0 {
+		bounds = " LIMIT ? OFFSET ?"
+		args = append(args, query.Limit)
+	}
+	rows, err := r.db.Query("SELECT entry FROM audit_entries"+where+order+bounds, append(args, query.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	page := []audit.Entry{}
+	for rows.Next() {
+		var body []byte
+		if err := rows.Scan(&body); err != nil {
+			return nil, 0, err
+		}
+		var e audit.Entry
+		if err := json.Unmarshal(body, &e); err != nil {
+			return nil, 0, err
+		}
+		page = append(page, e)
+	}
+	return page, total, rows.Err()
+}
+
+// auditFilters translates the filters of audit.Query.Matches into a WHERE clause
+func auditFilters(query audit.Query) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if query.ActorID != "" {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, query.ActorID)
+	}
+	if query.Action != "" {
+		conditions = append(conditions, `(action = ? OR action LIKE ? ESCAPE '\\')`)
+		args = append(args, query.Action, escapeLike(query.Action)+".%")
+	}
+	if query.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, query.TargetType)
+	}
+	if query.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, query.TargetID)
+	}
+	if query.RequestID != "" {
+		conditions = append(conditions, "request_id = ?")
+		args = append(args, query.RequestID)
+	}
+	if !query.Since.IsZero() {
+		conditions = append(conditions, "time >= ?")
+		args = append(args, query.Since)
+	}
+	if !query.Until.IsZero() {
+		conditions = append(conditions, "time < ?")
+		args = append(args, query.Until)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, "_" being common in actions
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// statement is a statement run on the fake database, transaction boundaries included
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDB is a database/sql driver recording the statements it is given. Queries answer the rows
+// set for them and statements containing failOn fail.
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []statement
+	rows       map[string][][]driver.Value
+	columns    map[string][]string
+	affected   int64
+	failOn     string
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{rows: map[string][][]driver.Value{}, columns: map[string][]string{}, affected: 1}
+	return f, sql.OpenDB(f)
+}
+
+// answer sets the rows of the queries starting with prefix
+func (f *fakeDB) answer(prefix string, columns []string, rows ...[]driver.Value) {
+	f.columns[prefix] = columns
+	f.rows[prefix] = rows
+}
+
+// queries returns the recorded statements, without their arguments
+func (f *fakeDB) queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	queries := make([]string, 0, len(f.statements))
+	for _, s := range f.statements {
+		queries = append(queries, strings.TrimSpace(strings.Fields(s.query)[0]+" "+tableOf(s.query)))
+	}
+	return queries
+}
+
+// tableOf names the table a statement works on, empty for the transaction boundaries
+func tableOf(query string) string {
+	fields := strings.Fields(query)
+	for i, field := range fields {
+		if (field == "INTO" || field == "FROM" || field == "UPDATE") && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.statements = append(f.statements, statement{query: query, args: values})
+	if f.failOn != "" && strings.Contains(query, f.failOn) {
+		return errors.New("statement failed")
+	}
+	return nil
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, c.db.record("BEGIN", nil)
+}
+
+func (c *fakeConn) Commit() error   { return c.db.record("COMMIT", nil) }
+func (c *fakeConn) Rollback() error { return c.db.record("ROLLBACK", nil) }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.db.record(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(c.db.affected), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.db.record(query, args); err != nil {
+		return nil, err
+	}
+	for prefix, rows := range c.db.rows {
+		if strings.HasPrefix(query, prefix) {
+			return &fakeRows{columns: c.db.columns[prefix], rows: rows}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
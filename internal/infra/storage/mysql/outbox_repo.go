@@ -0,0 +1,178 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+)
+
+// OutboxRepo keeps the outbox events in the outbox_events table
+type OutboxRepo struct {
+	db *sql.DB
+	// claimFor is how long the events returned by Due are hidden from the other relays
+	claimFor time.Duration
+}
+
+// NewOutboxRepo constructor. claimFor must exceed the time a relay takes to publish a batch: an
+// event still unpublished once its claim ran out is due again.
+func NewOutboxRepo(db *sql.DB, claimFor time.Duration) *OutboxRepo {
+	return &OutboxRepo{db: db, claimFor: claimFor}
+}
+
+const eventColumns = "id, type, aggregate_id, user_id, payload, occurred_at, attempts, next_attempt_at, last_error, delivered_to, published_at"
+
+// appendEvent inserts the event within the transaction of the change it describes
+func appendEvent(tx execer, e outbox.Event) error {
+	deliveredTo, err := json.Marshal(e.DeliveredTo)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO outbox_events ("+eventColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		e.ID[:], e.Type, e.AggregateID[:], e.UserID[:], []byte(e.Payload), e.OccurredAt, e.Attempts, e.NextAttemptAt,
+		nullString(e.LastError), deliveredTo, e.PublishedAt)
+	return err
+}
+
+// Due locks the due events, skipping the ones another relay holds, and pushes their next attempt
+// past the claim before committing, so the other relays leave them alone once the locks are released
+func (r *OutboxRepo) Due(now time.Time, limit int) ([]outbox.Event, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + eventColumns + " FROM outbox_events WHERE published_at IS NULL AND next_attempt_at <= ? ORDER BY occurred_at"
+	args := []any{now}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := tx.Query(query+" FOR UPDATE SKIP LOCKED", args...)
+	if err != nil {
+		return nil, err
+	}
+	due := []outbox.Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(due) == 0 {
+		return due, nil
+	}
+
+	ids := make([]any, 0, len(due)+1)
+	ids = append(ids, now.Add(r.claimFor))
+	for _, e := range due {
+		ids = append(ids, e.ID[:])
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(due)), ", ")
+	if _, err := tx.Exec("UPDATE outbox_events SET next_attempt_at = ? WHERE id IN ("+placeholders+")", ids...); err != nil {
+		return nil, err
+	}
+	return due, tx.Commit()
+}
+
+func (r *OutboxRepo) MarkDelivered(id uuid.UUID, subscriber string) error {
+	return r.update(id, func(e *outbox.Event) {
+		if !e.Delivered(subscriber) {
+			e.DeliveredTo = append(e.DeliveredTo, subscriber)
+		}
+	})
+}
+
+func (r *OutboxRepo) MarkPublished(id uuid.UUID, at time.Time) error {
+	return r.update(id, func(e *outbox.Event) {
+		e.PublishedAt = &at
+		e.LastError = ""
+	})
+}
+
+func (r *OutboxRepo) MarkFailed(id uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	return r.update(id, func(e *outbox.Event) {
+		e.Attempts++
+		e.LastError = lastError
+		e.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (r *OutboxRepo) DeletePublished(before time.Time) (int, error) {
+	result, err := r.db.Exec("DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// update applies the change to the locked event and writes its publishing state back
+func (r *OutboxRepo) update(id uuid.UUID, apply func(e *outbox.Event)) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	e, err := scanEvent(tx.QueryRow("SELECT "+eventColumns+" FROM outbox_events WHERE id = ? FOR UPDATE", id[:]))
+	if errors.Is(err, sql.ErrNoRows) {
+		return outbox.ErrEventNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	apply(&e)
+	deliveredTo, err := json.Marshal(e.DeliveredTo)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE outbox_events SET attempts = ?, next_attempt_at = ?, last_error = ?, delivered_to = ?, published_at = ? WHERE id = ?",
+		e.Attempts, e.NextAttemptAt, nullString(e.LastError), deliveredTo, e.PublishedAt, id[:]); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanEvent(row interface{ Scan(dest ...any) error }) (outbox.Event, error) {
+	var (
+		e           outbox.Event
+		payload     []byte
+		lastError   sql.NullString
+		deliveredTo []byte
+		publishedAt sql.NullTime
+	)
+	if err := row.Scan(&e.ID, &e.Type, &e.AggregateID, &e.UserID, &payload, &e.OccurredAt, &e.Attempts,
+		&e.NextAttemptAt, &lastError, &deliveredTo, &publishedAt); err != nil {
+		return outbox.Event{}, err
+	}
+	e.Payload = json.RawMessage(payload)
+	e.LastError = lastError.String
+	if len(deliveredTo) > 0 {
+		if err := json.Unmarshal(deliveredTo, &e.DeliveredTo); err != nil {
+			return outbox.Event{}, err
+		}
+	}
+	if publishedAt.Valid {
+		at := publishedAt.Time
+		e.PublishedAt = &at
+	}
+	return e, nil
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
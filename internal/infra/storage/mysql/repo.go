@@ -1,2 +1,199 @@
-// Package mysql contains the implementation of the repository interface for the MySQL database.
+// Package mysql contains the implementation of the repository interfaces for the MySQL database.
+//
+// The repositories work on a *sql.DB whose driver is registered by the binary opening it, with a
+// DSN setting parseTime=true. Schema creates their tables.
+//
+// The favourite repository writes the audit entry and the outbox events it is given in the same
+// transaction as the favourite change, so none of them is committed without the others. The outbox
+// repository claims the events it returns with FOR UPDATE SKIP LOCKED, so relays running in several
+// instances never publish the same event at once.
 package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+)
+
+// execer runs statements on the database or within a transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Repo keeps the favourites in the favourites table
+type Repo struct {
+	db *sql.DB
+}
+
+func NewRepo(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+const favouriteColumns = "id, type, description, data, position, created_at, updated_at"
+
+func (r *Repo) GetByID(userID uuid.UUID, favoriteID uuid.UUID) (*favourite.Favorite, error) {
+	row := r.db.QueryRow("SELECT "+favouriteColumns+" FROM favourites WHERE user_id = ? AND id = ?", userID[:], favoriteID[:])
+	fav, err := scanFavourite(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fav, nil
+}
+
+func (r *Repo) GetAll(userID uuid.UUID) ([]favourite.Favorite, error) {
+	rows, err := r.db.Query("SELECT "+favouriteColumns+" FROM favourites WHERE user_id = ? ORDER BY position, created_at, id", userID[:])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []favourite.Favorite{}
+	for rows.Next() {
+		fav, err := scanFavourite(rows)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, fav)
+	}
+	return values, rows.Err()
+}
+
+func (r *Repo) Add(userID uuid.UUID, favorite favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
+	return r.write(entry, events, func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO favourites (user_id, "+favouriteColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			userID[:], favorite.ID[:], string(favorite.Type), favorite.Description, jsonValue(favorite.Data), favorite.Position, favorite.CreatedAt, favorite.UpdatedAt)
+		return err
+	})
+}
+
+func (r *Repo) Update(userID uuid.UUID, favorite favourite.Favorite, entry audit.Entry, events ...outbox.Event) error {
+	return r.write(entry, events, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE favourites SET type = ?, description = ?, data = ?, position = ?, updated_at = ? WHERE user_id = ? AND id = ?",
+			string(favorite.Type), favorite.Description, jsonValue(favorite.Data), favorite.Position, favorite.UpdatedAt, userID[:], favorite.ID[:])
+		return err
+	})
+}
+
+func (r *Repo) Delete(userID uuid.UUID, favoriteID uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
+	return r.write(entry, events, func(tx *sql.Tx) error {
+		result, err := tx.Exec("DELETE FROM favourites WHERE user_id = ? AND id = ?", userID[:], favoriteID[:])
+		if err != nil {
+			return err
+		}
+		return expectRow(result, favoriteID)
+	})
+}
+
+func (r *Repo) Reorder(userID uuid.UUID, order []uuid.UUID, entry audit.Entry, events ...outbox.Event) error {
+	return r.write(entry, events, func(tx *sql.Tx) error {
+		if err := lockedOrder(tx, userID, order); err != nil {
+			return err
+		}
+		for position, id := range order {
+			if _, err := tx.Exec("UPDATE favourites SET position = ? WHERE user_id = ? AND id = ?", position, userID[:], id[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockedOrder locks the favourites of the user, checking that order lists each of them once
+func lockedOrder(tx *sql.Tx, userID uuid.UUID, order []uuid.UUID) error {
+	rows, err := tx.Query("SELECT id FROM favourites WHERE user_id = ? FOR UPDATE", userID[:])
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	remaining := make(map[uuid.UUID]bool, len(order))
+	for _, id := range order {
+		remaining[id] = true
+	}
+	stored := 0
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if !remaining[id] {
+			return favourite.ErrInvalidOrder
+		}
+		delete(remaining, id)
+		stored++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if stored != len(order) {
+		return favourite.ErrInvalidOrder
+	}
+	return nil
+}
+
+// write applies change, appends the audit entry and the outbox events in one transaction
+func (r *Repo) write(entry audit.Entry, events []outbox.Event, change func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	if err := appendEntry(tx, entry); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := appendEvent(tx, e); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// expectRow fails when the statement changed no favourite
+func expectRow(result sql.Result, favoriteID uuid.UUID) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("favorite %v not found", favoriteID.String())
+	}
+	return nil
+}
+
+func scanFavourite(row interface{ Scan(dest ...any) error }) (favourite.Favorite, error) {
+	var (
+		fav       favourite.Favorite
+		assetType string
+		data      []byte
+	)
+	if err := row.Scan(&fav.ID, &assetType, &fav.Description, &data, &fav.Position, &fav.CreatedAt, &fav.UpdatedAt); err != nil {
+		return favourite.Favorite{}, err
+	}
+	fav.Type = favourite.AssetType(assetType)
+	if len(data) > 0 {
+		fav.Data = json.RawMessage(data)
+	}
+	return fav, nil
+}
+
+// jsonValue stores an absent JSON document as NULL
+func jsonValue(data json.RawMessage) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return []byte(data)
+}
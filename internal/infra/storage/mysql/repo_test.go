@@ -0,0 +1,231 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	"github.com/akazantzidis/gwi-ass/internal/domain/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepo_WritesAuditAndEventsInTheTransaction(t *testing.T) {
+	userID := uuid.New()
+	fav := favourite.Favorite{ID: uuid.New(), Type: favourite.AssetChart, Data: json.RawMessage(`{"x":1}`)}
+	entry := audit.Entry{ID: uuid.New(), Action: audit.ActionFavouriteCreate}
+	created := outbox.Event{ID: uuid.New(), Type: event.FavoriteAddedName, AggregateID: fav.ID, UserID: userID, Payload: json.RawMessage(`{}`)}
+
+	tests := []struct {
+		name     string
+		failOn   string
+		missing  bool
+		write    func(r *Repo) error
+		expected []string
+	}{
+		{
+			name:     "add",
+			write:    func(r *Repo) error { return r.Add(userID, fav, entry, created) },
+			expected: []string{"BEGIN", "INSERT favourites", "INSERT audit_entries", "INSERT outbox_events", "COMMIT"},
+		},
+		{
+			name:     "update",
+			write:    func(r *Repo) error { return r.Update(userID, fav, entry, created) },
+			expected: []string{"BEGIN", "UPDATE favourites", "INSERT audit_entries", "INSERT outbox_events", "COMMIT"},
+		},
+		{
+			name:     "delete",
+			write:    func(r *Repo) error { return r.Delete(userID, fav.ID, entry, created) },
+			expected: []string{"BEGIN", "DELETE favourites", "INSERT audit_entries", "INSERT outbox_events", "COMMIT"},
+		},
+		{
+			name:     "a failing audit entry leaves the favourite unchanged",
+			failOn:   "INSERT INTO audit_entries",
+			write:    func(r *Repo) error { return r.Add(userID, fav, entry, created) },
+			expected: []string{"BEGIN", "INSERT favourites", "INSERT audit_entries", "ROLLBACK"},
+		},
+		{
+			name:     "a failing event leaves the favourite unchanged",
+			failOn:   "INSERT INTO outbox_events",
+			write:    func(r *Repo) error { return r.Update(userID, fav, entry, created) },
+			expected: []string{"BEGIN", "UPDATE favourites", "INSERT audit_entries", "INSERT outbox_events", "ROLLBACK"},
+		},
+		{
+			name:     "deleting a missing favourite records nothing",
+			missing:  true,
+			write:    func(r *Repo) error { return r.Delete(userID, uuid.New(), entry, created) },
+			expected: []string{"BEGIN", "DELETE favourites", "ROLLBACK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB()
+			fake.failOn = tt.failOn
+			if tt.missing {
+				fake.affected = 0
+			}
+
+			err := tt.write(NewRepo(db))
+			if tt.failOn != "" || tt.missing {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, fake.queries())
+		})
+	}
+}
+
+func TestRepo_GetAll(t *testing.T) {
+	fake, db := newFakeDB()
+	created := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	fake.answer("SELECT", strings.Split(favouriteColumns, ", "),
+		[]driver.Value{id[:], "chart", "sales", []byte(`{"x":1}`), int64(0), created, created},
+		[]driver.Value{uuid.New().String(), "insight", "", nil, int64(1), created, created},
+	)
+
+	favs, err := NewRepo(db).GetAll(uuid.New())
+	if !assert.NoError(t, err) || !assert.Len(t, favs, 2) {
+		return
+	}
+	assert.Equal(t, favourite.Favorite{ID: id, Type: favourite.AssetChart, Description: "sales", Data: json.RawMessage(`{"x":1}`), CreatedAt: created, UpdatedAt: created}, favs[0])
+	assert.Nil(t, favs[1].Data, "no data")
+	assert.Equal(t, 1, favs[1].Position)
+	assert.Contains(t, fake.statements[0].query, "ORDER BY position")
+}
+
+func TestOutboxRepo_DueClaimsTheEvents(t *testing.T) {
+	fake, db := newFakeDB()
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	due := outbox.Event{
+		ID:            uuid.New(),
+		Type:          event.FavoriteAddedName,
+		AggregateID:   uuid.New(),
+		UserID:        uuid.New(),
+		Payload:       json.RawMessage(`{}`),
+		OccurredAt:    now.Add(-time.Minute),
+		Attempts:      1,
+		NextAttemptAt: now.Add(-time.Second),
+		LastError:     "boom",
+		DeliveredTo:   []string{"notifications"},
+	}
+	fake.answer("SELECT", strings.Split(eventColumns, ", "), []driver.Value{
+		due.ID[:], due.Type, due.AggregateID[:], due.UserID[:], []byte(due.Payload), due.OccurredAt, int64(due.Attempts),
+		due.NextAttemptAt, due.LastError, []byte(`["notifications"]`), nil,
+	})
+
+	events, err := NewOutboxRepo(db, time.Minute).Due(now, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []outbox.Event{due}, events)
+	assert.Equal(t, []string{"BEGIN", "SELECT outbox_events", "UPDATE outbox_events", "COMMIT"}, fake.queries())
+
+	selected, claimed := fake.statements[1], fake.statements[2]
+	assert.True(t, strings.HasSuffix(selected.query, "LIMIT ? FOR UPDATE SKIP LOCKED"), "locks the events, skipping the claimed ones")
+	assert.Equal(t, []driver.Value{now, int64(10)}, selected.args)
+	assert.Equal(t, []driver.Value{now.Add(time.Minute), due.ID[:]}, claimed.args, "hidden from the other relays for the claim")
+}
+
+func TestOutboxRepo_NothingDue(t *testing.T) {
+	fake, db := newFakeDB()
+
+	events, err := NewOutboxRepo(db, time.Minute).Due(time.Now(), 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, events)
+	assert.Equal(t, []string{"BEGIN", "SELECT outbox_events", "ROLLBACK"}, fake.queries(), "nothing to claim")
+	assert.NotContains(t, fake.statements[1].query, "LIMIT", "no limit")
+}
+
+func TestOutboxRepo_MarkFailed(t *testing.T) {
+	fake, db := newFakeDB()
+	id := uuid.New()
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	fake.answer("SELECT", strings.Split(eventColumns, ", "), []driver.Value{
+		id[:], event.FavoriteAddedName, id[:], id[:], []byte(`{}`), now, int64(0), now, nil, nil, nil,
+	})
+
+	if !assert.NoError(t, NewOutboxRepo(db, time.Minute).MarkFailed(id, "boom", now.Add(time.Hour))) {
+		return
+	}
+	assert.Equal(t, []string{"BEGIN", "SELECT outbox_events", "UPDATE outbox_events", "COMMIT"}, fake.queries())
+	assert.Contains(t, fake.statements[1].query, "FOR UPDATE")
+	assert.Equal(t, []driver.Value{int64(1), now.Add(time.Hour), "boom", []byte("null"), nil, id[:]}, fake.statements[2].args)
+
+	_, db = newFakeDB()
+	assert.ErrorIs(t, NewOutboxRepo(db, time.Minute).MarkFailed(uuid.New(), "boom", now), outbox.ErrEventNotFound)
+}
+
+func TestAuditFilters(t *testing.T) {
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	where, args := auditFilters(audit.Query{ActorID: "admin", Action: "auth.logout_all", Since: since})
+	assert.Equal(t, ` WHERE actor_id = ? AND (action = ? OR action LIKE ? ESCAPE '\\') AND time >= ?`, where)
+	assert.Equal(t, []any{"admin", "auth.logout_all", `auth.logout\_all.%`, since}, args)
+
+	where, args = auditFilters(audit.Query{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+}
+
+func TestRepo_Reorder(t *testing.T) {
+	userID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	entry := audit.Entry{ID: uuid.New(), Action: audit.ActionFavouriteReorder}
+
+	tests := []struct {
+		name     string
+		order    []uuid.UUID
+		invalid  bool
+		expected []string
+	}{
+		{
+			name:     "every favourite once",
+			order:    []uuid.UUID{second, first},
+			expected: []string{"BEGIN", "SELECT favourites", "UPDATE favourites", "UPDATE favourites", "INSERT audit_entries", "INSERT outbox_events", "COMMIT"},
+		},
+		{
+			name:     "a missing favourite",
+			order:    []uuid.UUID{second},
+			invalid:  true,
+			expected: []string{"BEGIN", "SELECT favourites", "ROLLBACK"},
+		},
+		{
+			name:     "a repeated favourite",
+			order:    []uuid.UUID{second, first, first},
+			invalid:  true,
+			expected: []string{"BEGIN", "SELECT favourites", "ROLLBACK"},
+		},
+		{
+			name:     "another favourite",
+			order:    []uuid.UUID{second, uuid.New()},
+			invalid:  true,
+			expected: []string{"BEGIN", "SELECT favourites", "ROLLBACK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB()
+			fake.answer("SELECT id", []string{"id"}, []driver.Value{first[:]}, []driver.Value{second[:]})
+
+			err := NewRepo(db).Reorder(userID, tt.order, entry, outbox.Event{ID: uuid.New(), Type: event.FavoritesReorderedName})
+			if tt.invalid {
+				assert.ErrorIs(t, err, favourite.ErrInvalidOrder)
+			} else {
+				assert.NoError(t, err)
+				assert.Contains(t, fake.statements[1].query, "FOR UPDATE", "the favourites are locked while reordered")
+				assert.Equal(t, []driver.Value{int64(0), userID[:], second[:]}, fake.statements[2].args)
+			}
+			assert.Equal(t, tt.expected, fake.queries())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package mysql
+
+// Schema creates the tables of the repositories. Ids are stored as the 16 bytes of the UUID.
+const Schema = `
+CREATE TABLE IF NOT EXISTS favourites (
+    user_id     BINARY(16)   NOT NULL,
+    id          BINARY(16)   NOT NULL,
+    type        VARCHAR(32)  NOT NULL,
+    description TEXT         NOT NULL,
+    data        JSON         NULL,
+    position    INT          NOT NULL DEFAULT 0,
+    created_at  DATETIME(6)  NOT NULL,
+    updated_at  DATETIME(6)  NOT NULL,
+    PRIMARY KEY (user_id, id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+    seq         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    id          BINARY(16)   NOT NULL UNIQUE,
+    time        DATETIME(6)  NOT NULL,
+    actor_id    VARCHAR(64)  NOT NULL,
+    action      VARCHAR(64)  NOT NULL,
+    target_type VARCHAR(32)  NOT NULL,
+    target_id   VARCHAR(64)  NOT NULL,
+    request_id  VARCHAR(64)  NOT NULL,
+    entry       JSON         NOT NULL,
+    INDEX audit_entries_actor (actor_id),
+    INDEX audit_entries_target (target_type, target_id),
+    INDEX audit_entries_time (time)
+);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BINARY(16)   NOT NULL PRIMARY KEY,
+    type            VARCHAR(64)  NOT NULL,
+    aggregate_id    BINARY(16)   NOT NULL,
+    user_id         BINARY(16)   NOT NULL,
+    payload         JSON         NOT NULL,
+    occurred_at     DATETIME(6)  NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6)  NOT NULL,
+    last_error      TEXT         NULL,
+    delivered_to    JSON         NULL,
+    published_at    DATETIME(6)  NULL,
+    INDEX outbox_events_due (published_at, next_attempt_at, occurred_at)
+);
+`
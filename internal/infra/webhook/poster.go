@@ -0,0 +1,53 @@
+// Package webhook POSTs the webhook deliveries over HTTP.
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxDrain is how much of a response body is read so the connection can be reused
+const maxDrain = 64 << 10
+
+// Poster POSTs deliveries with a timeout, never following redirects so an endpoint cannot
+// send the signed payload elsewhere
+type Poster struct {
+	client *http.Client
+}
+
+// NewPoster constructor
+func NewPoster(timeout time.Duration) *Poster {
+	return &Poster{client: &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Post sends the body and returns the status code of the response, a redirect being a failure like any non 2xx status
+func (p *Poster) Post(url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("User-Agent", "gwi-favorites-webhooks/1.0")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		var urlErr interface{ Timeout() bool }
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return 0, errors.New("request timed out")
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,156 @@
+package webhook_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/app/eventbus"
+	"github.com/akazantzidis/gwi-ass/internal/app/webhooks"
+	"github.com/akazantzidis/gwi-ass/internal/domain/event"
+	"github.com/akazantzidis/gwi-ass/internal/domain/favourite"
+	domainwebhook "github.com/akazantzidis/gwi-ass/internal/domain/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/infra/storage/memory"
+	"github.com/akazantzidis/gwi-ass/internal/infra/webhook"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	timeprovider "github.com/akazantzidis/gwi-ass/internal/pkg/time"
+	uuidprovider "github.com/akazantzidis/gwi-ass/internal/pkg/uuid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "0123456789abcdef"
+
+// receiver is an endpoint verifying the signature of what it receives, answering with status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	received []string
+	events   []string
+	errors   []error
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if err := domainwebhook.Verify(secret, r.Header.Get(domainwebhook.SignatureHeader), body, time.Now(), time.Minute); err != nil {
+		rc.errors = append(rc.errors, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	rc.received = append(rc.received, r.Header.Get(domainwebhook.IDHeader))
+	rc.events = append(rc.events, r.Header.Get(domainwebhook.EventHeader))
+	w.WriteHeader(rc.status)
+}
+
+func TestPoster_Post(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	status, err := webhook.NewPoster(time.Second).Post(server.URL, map[string]string{"Content-Type": "application/json"}, []byte(`{}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+}
+
+func TestPoster_DoesNotFollowRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the redirect was followed")
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	status, err := webhook.NewPoster(time.Second).Post(server.URL, nil, []byte(`{}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusTemporaryRedirect, status)
+}
+
+func TestPoster_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, err := webhook.NewPoster(20*time.Millisecond).Post(server.URL, nil, []byte(`{}`))
+	assert.EqualError(t, err, "request timed out")
+}
+
+// TestDeliverer_DeliversToReceiver runs the favourite events through the webhook subscription of
+// the bus and the deliverer to a receiver verifying their signatures
+func TestDeliverer_DeliversToReceiver(t *testing.T) {
+	rc := &receiver{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	cipher, err := helper.NewSecretCipher([]byte(strings.Repeat("k", 32)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	encrypted, err := cipher.Encrypt(secret)
+	if !assert.NoError(t, err) {
+		return
+	}
+	repo := memory.NewWebhookRepo()
+	subscription := domainwebhook.Subscription{ID: uuid.New(), URL: server.URL, Secret: encrypted, Status: domainwebhook.StatusActive}
+	if !assert.NoError(t, repo.Add(subscription)) {
+		return
+	}
+
+	up, tp := uuidprovider.NewUUIDProvider(), timeprovider.NewTimeProvider()
+	bus := eventbus.NewBus(up, tp)
+	bus.Subscribe("webhooks", webhooks.NewEventHandler(repo, up, tp), webhooks.Events...)
+	bus.Publish(event.FavoriteAdded{UserID: uuid.New(), Favorite: favourite.Favorite{Description: "chart"}})
+	bus.Publish(event.UserLoggedIn{UserID: uuid.New()})
+
+	deliverer := webhooks.NewDeliverer(repo, webhook.NewPoster(time.Second), cipher, webhooks.Config{BatchSize: 10, MaxAttempts: 3}, tp)
+	sent, err := deliverer.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 0, sent, "the receiver is unavailable")
+
+	rc.mu.Lock()
+	rc.status = http.StatusNoContent
+	rc.mu.Unlock()
+	sent, err = deliverer.Deliver()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, sent)
+
+	assert.Empty(t, rc.errors)
+	if !assert.Len(t, rc.received, 2) {
+		return
+	}
+	assert.Equal(t, rc.received[0], rc.received[1], "every attempt carries the event ID")
+	assert.Equal(t, []string{event.FavoriteAddedName, event.FavoriteAddedName}, rc.events)
+
+	log, err := repo.Deliveries(subscription.ID, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, log, 1) {
+		return
+	}
+	assert.Equal(t, domainwebhook.DeliverySucceeded, log[0].Status)
+	assert.Equal(t, 2, log[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, log[0].LastStatusCode)
+}
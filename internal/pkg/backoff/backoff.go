@@ -0,0 +1,24 @@
+// Package backoff computes the delays between the attempts of retried work.
+package backoff
+
+import "time"
+
+// Exponential is a delay doubling after every failed attempt
+type Exponential struct {
+	// Base is the delay after the first failed attempt
+	Base time.Duration
+	// Max caps the delay, it is unbounded when 0
+	Max time.Duration
+}
+
+// Delay returns the delay after the failed attempt, counted from 1
+func (e Exponential) Delay(attempt int) time.Duration {
+	delay := e.Base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if e.Max > 0 && delay >= e.Max {
+			return e.Max
+		}
+	}
+	return delay
+}
@@ -0,0 +1,23 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/backoff"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponential_Delay(t *testing.T) {
+	capped := backoff.Exponential{Base: time.Second, Max: 5 * time.Second}
+	assert.Equal(t, time.Second, capped.Delay(1))
+	assert.Equal(t, 2*time.Second, capped.Delay(2))
+	assert.Equal(t, 4*time.Second, capped.Delay(3))
+	assert.Equal(t, 5*time.Second, capped.Delay(4))
+	assert.Equal(t, 5*time.Second, capped.Delay(100), "does not overflow")
+
+	unbounded := backoff.Exponential{Base: time.Second}
+	assert.Equal(t, 8*time.Second, unbounded.Delay(4))
+	assert.Equal(t, time.Second, unbounded.Delay(0))
+}
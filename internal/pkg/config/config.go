@@ -0,0 +1,314 @@
+// Package config loads the runtime configuration of the service from environment variables.
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+)
+
+// Config contains the runtime configuration of the service
+type Config struct {
+	// TokenHashKey is the key used to hash refresh and reset tokens at rest
+	TokenHashKey []byte
+	// JanitorInterval is how often expired records are purged
+	JanitorInterval time.Duration
+	// CookieSecure restricts browser session cookies to HTTPS
+	CookieSecure bool
+
+	// PasswordMinLength is the minimum number of characters of a new password
+	PasswordMinLength int
+	// PasswordMaxLength is the maximum number of bytes of a new password
+	PasswordMaxLength int
+	// BreachedPasswordsFile is an optional local file of breached passwords, one per line
+	BreachedPasswordsFile string
+	// PasswordResetTTL is how long a password reset token stays valid
+	PasswordResetTTL time.Duration
+
+	// PasswordHashAlgorithm is the algorithm of new password hashes, argon2id or bcrypt
+	PasswordHashAlgorithm string
+	// Argon2Memory (KiB), Argon2Iterations and Argon2Parallelism are the argon2id cost parameters
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	// BcryptCost is the bcrypt cost factor
+	BcryptCost int
+
+	// LoginFreeAttempts is the number of failed logins per username before backoff kicks in
+	LoginFreeAttempts int
+	// LoginLockoutThreshold is the number of failed logins per username that locks it
+	LoginLockoutThreshold int
+	// LoginIPFreeAttempts is the number of failed logins per client IP before backoff kicks in
+	LoginIPFreeAttempts int
+	// LoginIPLockoutThreshold is the number of failed logins per client IP that blocks it
+	LoginIPLockoutThreshold int
+	// LoginBackoffBase is the first backoff delay, doubled on every further failure
+	LoginBackoffBase time.Duration
+	// LoginBackoffMax caps the backoff delay
+	LoginBackoffMax time.Duration
+	// LoginLockoutDuration is how long a locked username or IP stays locked
+	LoginLockoutDuration time.Duration
+	// LoginAttemptsResetAfter is how long failed logins are remembered
+	LoginAttemptsResetAfter time.Duration
+
+	// TOTPEncryptionKey is the AES key (16, 24 or 32 bytes) encrypting TOTP secrets at rest
+	TOTPEncryptionKey []byte
+	// TOTPIssuer is the issuer shown in authenticator apps
+	TOTPIssuer string
+
+	// OIDCIssuerURL is the OpenID Connect provider, federated login is disabled when empty
+	OIDCIssuerURL string
+	// OIDCClientID and OIDCClientSecret are the registration of the service at the provider
+	OIDCClientID     string
+	OIDCClientSecret string
+	// OIDCRedirectURL is the callback URL registered at the provider
+	OIDCRedirectURL string
+	// OIDCGroupsClaim is the ID token claim listing the groups of the user
+	OIDCGroupsClaim string
+	// OIDCRoleMapping maps provider groups to local roles
+	OIDCRoleMapping map[string][]string
+	// OIDCStateTTL is how long a started login waits for the provider callback
+	OIDCStateTTL time.Duration
+
+	// NotificationChannel is where notifications are delivered, console or email
+	NotificationChannel string
+	// NotificationQueueSize is how many notifications may wait for delivery
+	NotificationQueueSize int
+	// NotificationWorkers is how many notifications are delivered concurrently
+	NotificationWorkers int
+	// NotificationMaxAttempts is how many times a notification is tried before it is dead-lettered
+	NotificationMaxAttempts int
+	// NotificationRetryBase is the first retry delay, doubled on every further retry
+	NotificationRetryBase time.Duration
+	// NotificationRetryMax caps the retry delay
+	NotificationRetryMax time.Duration
+
+	// SMTPHost and SMTPPort are the mail server of the email channel
+	SMTPHost string
+	SMTPPort int
+	// SMTPUsername and SMTPPassword authenticate with the mail server when the username is set
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPFrom is the sender of the emails
+	SMTPFrom string
+	// SMTPTLS secures the connection to the mail server: starttls, tls or none
+	SMTPTLS string
+	// SMTPTimeout bounds the delivery of one email
+	SMTPTimeout time.Duration
+
+	// OutboxPollInterval is how often the outbox is polled for events to publish
+	OutboxPollInterval time.Duration
+	// OutboxBatchSize is how many events are published per poll
+	OutboxBatchSize int
+	// OutboxRetryBase is the first retry delay of an event, doubled on every further retry
+	OutboxRetryBase time.Duration
+	// OutboxRetryMax caps the retry delay of an event
+	OutboxRetryMax time.Duration
+	// OutboxRetention is how long published events are kept
+	OutboxRetention time.Duration
+
+	// WebhookPollInterval is how often the due webhook deliveries are sent
+	WebhookPollInterval time.Duration
+	// WebhookBatchSize is how many webhook deliveries are sent per poll
+	WebhookBatchSize int
+	// WebhookTimeout bounds one POST to a webhook endpoint
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts is how many times a webhook delivery is attempted before it fails
+	WebhookMaxAttempts int
+	// WebhookRetryBase is the first retry delay of a delivery, doubled on every further retry
+	WebhookRetryBase time.Duration
+	// WebhookRetryMax caps the retry delay of a delivery
+	WebhookRetryMax time.Duration
+	// WebhookDisableAfter is how many consecutive failed attempts disable a subscription, never when 0
+	WebhookDisableAfter int
+	// WebhookDeliveryRetention is how long completed deliveries are kept in the delivery log
+	WebhookDeliveryRetention time.Duration
+
+	// DigestInterval is how often the digests of the ended periods are sent
+	DigestInterval time.Duration
+	// DigestRetention is how long sent digests are remembered, so they are not sent twice
+	DigestRetention time.Duration
+
+	// StreamBufferSize is how many of the latest favourite events of each user are kept for resuming streams
+	StreamBufferSize int
+	// StreamHeartbeat is how often an idle favourite stream is written to
+	StreamHeartbeat time.Duration
+	// StreamBufferRetention is how long the resume buffer of a user without streams outlives their latest event
+	StreamBufferRetention time.Duration
+
+	// SocketAuthTimeout is how long a WebSocket connection may take to authenticate
+	SocketAuthTimeout time.Duration
+	// SocketReauthWarning is how long before its token expires a WebSocket connection is asked for a new one
+	SocketReauthWarning time.Duration
+	// SocketPingInterval is how often WebSocket connections are pinged
+	SocketPingInterval time.Duration
+	// SocketWriteTimeout bounds every write to a WebSocket client
+	SocketWriteTimeout time.Duration
+	// SocketSendQueue is how many messages may wait for a WebSocket client
+	SocketSendQueue int
+	// SocketMaxMessageSize is the largest message accepted from a WebSocket client, in bytes
+	SocketMaxMessageSize int
+}
+
+// Load reads the configuration from the environment, falling back to defaults
+func Load() Config {
+	return Config{
+		TokenHashKey:    keyFromEnv("TOKEN_HASH_KEY"),
+		JanitorInterval: durationFromEnv("JANITOR_INTERVAL", time.Minute*10),
+		CookieSecure:    boolFromEnv("COOKIE_SECURE", true),
+
+		PasswordMinLength:     intFromEnv("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:     intFromEnv("PASSWORD_MAX_LENGTH", 72),
+		BreachedPasswordsFile: os.Getenv("BREACHED_PASSWORDS_FILE"),
+		PasswordResetTTL:      durationFromEnv("PASSWORD_RESET_TTL", time.Minute*15),
+
+		PasswordHashAlgorithm: stringFromEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		Argon2Memory:          intFromEnv("ARGON2_MEMORY", 19*1024),
+		Argon2Iterations:      intFromEnv("ARGON2_ITERATIONS", 2),
+		Argon2Parallelism:     intFromEnv("ARGON2_PARALLELISM", 1),
+		BcryptCost:            intFromEnv("BCRYPT_COST", 12),
+
+		LoginFreeAttempts:       intFromEnv("LOGIN_FREE_ATTEMPTS", 3),
+		LoginLockoutThreshold:   intFromEnv("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginIPFreeAttempts:     intFromEnv("LOGIN_IP_FREE_ATTEMPTS", 10),
+		LoginIPLockoutThreshold: intFromEnv("LOGIN_IP_LOCKOUT_THRESHOLD", 50),
+		LoginBackoffBase:        durationFromEnv("LOGIN_BACKOFF_BASE", time.Second),
+		LoginBackoffMax:         durationFromEnv("LOGIN_BACKOFF_MAX", time.Minute),
+		LoginLockoutDuration:    durationFromEnv("LOGIN_LOCKOUT_DURATION", time.Minute*15),
+		LoginAttemptsResetAfter: durationFromEnv("LOGIN_ATTEMPTS_RESET_AFTER", time.Hour),
+
+		TOTPEncryptionKey: keyFromEnv("TOTP_ENCRYPTION_KEY"),
+		TOTPIssuer:        stringFromEnv("TOTP_ISSUER", "GWI Favorites"),
+
+		OIDCIssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  stringFromEnv("OIDC_REDIRECT_URL", "http://localhost:8080/oidc/callback"),
+		OIDCGroupsClaim:  stringFromEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCRoleMapping:  roleMappingFromEnv("OIDC_ROLE_MAPPING"),
+		OIDCStateTTL:     durationFromEnv("OIDC_STATE_TTL", time.Minute*10),
+
+		NotificationChannel:     stringFromEnv("NOTIFICATION_CHANNEL", "console"),
+		NotificationQueueSize:   intFromEnv("NOTIFICATION_QUEUE_SIZE", 1000),
+		NotificationWorkers:     intFromEnv("NOTIFICATION_WORKERS", 4),
+		NotificationMaxAttempts: intFromEnv("NOTIFICATION_MAX_ATTEMPTS", 5),
+		NotificationRetryBase:   durationFromEnv("NOTIFICATION_RETRY_BASE", time.Second),
+		NotificationRetryMax:    durationFromEnv("NOTIFICATION_RETRY_MAX", time.Minute),
+
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     intFromEnv("SMTP_PORT", 587),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     stringFromEnv("SMTP_FROM", "GWI Favorites <no-reply@localhost>"),
+		SMTPTLS:      stringFromEnv("SMTP_TLS", "starttls"),
+		SMTPTimeout:  durationFromEnv("SMTP_TIMEOUT", time.Second*10),
+
+		OutboxPollInterval: durationFromEnv("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:    intFromEnv("OUTBOX_BATCH_SIZE", 100),
+		OutboxRetryBase:    durationFromEnv("OUTBOX_RETRY_BASE", time.Second),
+		OutboxRetryMax:     durationFromEnv("OUTBOX_RETRY_MAX", time.Minute*5),
+		OutboxRetention:    durationFromEnv("OUTBOX_RETENTION", time.Hour*24),
+
+		WebhookPollInterval:      durationFromEnv("WEBHOOK_POLL_INTERVAL", time.Second),
+		WebhookBatchSize:         intFromEnv("WEBHOOK_BATCH_SIZE", 100),
+		WebhookTimeout:           durationFromEnv("WEBHOOK_TIMEOUT", time.Second*10),
+		WebhookMaxAttempts:       intFromEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:         durationFromEnv("WEBHOOK_RETRY_BASE", time.Second*10),
+		WebhookRetryMax:          durationFromEnv("WEBHOOK_RETRY_MAX", time.Hour),
+		WebhookDisableAfter:      intFromEnv("WEBHOOK_DISABLE_AFTER", 20),
+		WebhookDeliveryRetention: durationFromEnv("WEBHOOK_DELIVERY_RETENTION", time.Hour*24*7),
+
+		DigestInterval:  durationFromEnv("DIGEST_INTERVAL", time.Minute),
+		DigestRetention: durationFromEnv("DIGEST_RETENTION", time.Hour*24*30),
+
+		StreamBufferSize:      intFromEnv("STREAM_BUFFER_SIZE", 100),
+		StreamHeartbeat:       durationFromEnv("STREAM_HEARTBEAT", time.Second*15),
+		StreamBufferRetention: durationFromEnv("STREAM_BUFFER_RETENTION", time.Minute*10),
+
+		SocketAuthTimeout:    durationFromEnv("WS_AUTH_TIMEOUT", time.Second*10),
+		SocketReauthWarning:  durationFromEnv("WS_REAUTH_WARNING", time.Minute),
+		SocketPingInterval:   durationFromEnv("WS_PING_INTERVAL", time.Second*30),
+		SocketWriteTimeout:   durationFromEnv("WS_WRITE_TIMEOUT", time.Second*10),
+		SocketSendQueue:      intFromEnv("WS_SEND_QUEUE", 64),
+		SocketMaxMessageSize: intFromEnv("WS_MAX_MESSAGE_SIZE", 64<<10),
+	}
+}
+
+// keyFromEnv reads a secret key, generating a random one when unset.
+// A random key is fine for the in-memory storages since nothing outlives the process.
+func keyFromEnv(name string) []byte {
+	if v := os.Getenv(name); v != "" {
+		return []byte(v)
+	}
+	key, err := helper.NewRandomKey(32)
+	if err != nil {
+		log.Fatalf("failed to generate %s: %v", name, err)
+	}
+	return key
+}
+
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", name, v, err)
+	}
+	return d
+}
+
+func intFromEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", name, v, err)
+	}
+	return i
+}
+
+func boolFromEnv(name string, def bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", name, v, err)
+	}
+	return b
+}
+
+// stringFromEnv reads a string, falling back to def when unset
+func stringFromEnv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// roleMappingFromEnv reads a comma separated list of group:role pairs. A group may be listed
+// several times to map it to several roles.
+func roleMappingFromEnv(name string) map[string][]string {
+	mapping := make(map[string][]string)
+	v := os.Getenv(name)
+	if v == "" {
+		return mapping
+	}
+	for _, pair := range strings.Split(v, ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || group == "" || role == "" {
+			log.Fatalf("invalid %s %q: expected group:role pairs", name, v)
+		}
+		mapping[group] = append(mapping[group], role)
+	}
+	return mapping
+}
@@ -0,0 +1,55 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretCipher encrypts small secrets at rest with AES-GCM
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher constructor, the key must be 16, 24 or 32 bytes
+func NewSecretCipher(key []byte) (SecretCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return SecretCipher{}, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return SecretCipher{}, err
+	}
+	return SecretCipher{aead: aead}, nil
+}
+
+// Encrypt returns the base64 encoded nonce and ciphertext of the plaintext
+func (c SecretCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt
+func (c SecretCipher) Decrypt(encrypted string) (string, error) {
+	sealed, err := base64.RawStdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("invalid ciphertext: too short")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
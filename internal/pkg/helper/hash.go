@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// TokenHasher computes keyed hashes (HMAC-SHA256) of opaque tokens, so that
+// storages only ever hold a digest that is useless without the server key.
+type TokenHasher struct {
+	key []byte
+}
+
+// NewTokenHasher constructor
+func NewTokenHasher(key []byte) TokenHasher {
+	return TokenHasher{key: key}
+}
+
+// Hash returns the hex encoded keyed hash of the token
+func (h TokenHasher) Hash(token string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Equal compares two hashes in constant time
+func (h TokenHasher) Equal(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// NewRandomKey returns a random key of n bytes
+func NewRandomKey(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,21 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// GeneratePKCE returns a RFC 7636 code verifier and its S256 code challenge
+func GeneratePKCE() (string, string, error) {
+	verifier, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, PKCEChallenge(verifier), nil
+}
+
+// PKCEChallenge returns the S256 code challenge of a code verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -12,23 +12,63 @@ import (
 var (
 	jwtKey               = []byte("secret")
 	AccessTokenTTL       = time.Minute * 15
-	refreshTokenTTL      = time.Hour * 24 * 7
+	MFATokenTTL          = time.Minute * 5
+	ImpersonationTTL     = time.Minute * 10
+	RefreshTokenTTL      = time.Hour * 24 * 7
 	ErrInvalidRefresh    = errors.New("invalid refresh token")
 	ErrRefreshExpired    = errors.New("refresh token expired")
 	ErrInvalidCredential = errors.New("invalid credentials")
 )
 
+const (
+	// TokenUseAccess marks access tokens. Tokens issued before the claim existed carry no value.
+	TokenUseAccess = "access"
+	// TokenUseMFA marks the short-lived challenge token of a login waiting for its second factor
+	TokenUseMFA = "mfa"
+	// TokenUseAPIKey marks claims resolved from an API key. They are never signed into a JWT.
+	TokenUseAPIKey = "api_key"
+	// TokenUseClient marks access tokens issued to an OAuth2 client with the client_credentials grant
+	TokenUseClient = "client"
+	// TokenUseImpersonation marks the access token of an admin acting as another user, see ActClaim
+	TokenUseImpersonation = "impersonation"
+
+	// APIKeyPrefix starts every API key, so they are told apart from JWTs and easy to spot in leaked text
+	APIKeyPrefix = "gwi_"
+	// apiKeyDisplayLength is how many characters of an API key are kept to identify it
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8
+)
+
 type CustomClaims struct {
-	UserID string   `json:"user_id"`
-	Roles  []string `json:"roles"`
+	UserID   string   `json:"user_id"`
+	Roles    []string `json:"roles"`
+	TokenUse string   `json:"token_use,omitempty"`
+	// Scopes limits what the request may do. Only API keys carry scopes, access tokens may do everything.
+	Scopes []string `json:"scopes,omitempty"`
+	// ClientID is set on tokens of OAuth2 clients, which carry no user
+	ClientID string `json:"client_id,omitempty"`
+	// Act is set on impersonation tokens and names the admin acting as UserID
+	Act *ActClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ActClaim is the RFC 8693 actor claim: the party actually acting on behalf of the subject
+type ActClaim struct {
+	Subject string `json:"sub"`
+}
+
+// MFAClaims are the claims of an MFA challenge token. It deliberately has no user_id
+// or roles, so it can never pass as an access token.
+type MFAClaims struct {
+	TokenUse string `json:"token_use"`
 	jwt.RegisteredClaims
 }
 
 func GenerateAccessToken(userID string, roles []string) (string, error) {
 	now := time.Now()
 	claims := &CustomClaims{
-		UserID: userID,
-		Roles:  roles,
+		UserID:   userID,
+		Roles:    roles,
+		TokenUse: TokenUseAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
@@ -42,15 +82,77 @@ func GenerateAccessToken(userID string, roles []string) (string, error) {
 }
 
 func GenerateRefreshToken() (string, time.Time, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
+	token, err := GenerateOpaqueToken()
+	if err != nil {
 		return "", time.Time{}, err
 	}
-	token := base64.RawURLEncoding.EncodeToString(b)
-	exp := time.Now().Add(refreshTokenTTL)
+	exp := time.Now().Add(RefreshTokenTTL)
 	return token, exp, nil
 }
 
+// GenerateOpaqueToken returns a random 256 bit url-safe token
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateClientToken issues the access token of an OAuth2 client, limited to scopes
+func GenerateClientToken(clientID string, scopes []string) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(AccessTokenTTL)
+	claims := &CustomClaims{
+		TokenUse: TokenUseClient,
+		Scopes:   scopes,
+		ClientID: clientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Subject:   clientID,
+			ID:        generateJTI(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(jwtKey)
+	return signed, exp, err
+}
+
+// GenerateImpersonationToken issues the short-lived token of the admin actorID acting as userID.
+// It carries no roles, so it never grants more than the scopes.
+func GenerateImpersonationToken(userID, actorID string, scopes []string) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(ImpersonationTTL)
+	claims := &CustomClaims{
+		UserID:   userID,
+		TokenUse: TokenUseImpersonation,
+		Scopes:   scopes,
+		Act:      &ActClaim{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Subject:   userID,
+			ID:        generateJTI(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(jwtKey)
+	return signed, exp, err
+}
+
+// GenerateAPIKey returns a new API key and the prefix identifying it
+func GenerateAPIKey() (string, string, error) {
+	t, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	key := APIKeyPrefix + t
+	return key, key[:apiKeyDisplayLength], nil
+}
+
 func ParseAndValidateToken(tokenStr string) (*CustomClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
 		// ensure HMAC
@@ -63,11 +165,57 @@ func ParseAndValidateToken(tokenStr string) (*CustomClaims, error) {
 		return nil, err
 	}
 	if claims, ok := token.Claims.(*CustomClaims); ok && token.Valid {
+		switch claims.TokenUse {
+		case "", TokenUseAccess, TokenUseClient:
+		case TokenUseImpersonation:
+			if claims.Act == nil || claims.Act.Subject == "" {
+				return nil, fmt.Errorf("impersonation token without actor")
+			}
+		default:
+			return nil, fmt.Errorf("not an access token")
+		}
 		return claims, nil
 	}
 	return nil, fmt.Errorf("invalid token")
 }
 
+// GenerateMFAToken issues the challenge token of a login that still needs its second factor
+func GenerateMFAToken(userID string) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(MFATokenTTL)
+	claims := &MFAClaims{
+		TokenUse: TokenUseMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Subject:   userID,
+			ID:        generateJTI(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(jwtKey)
+	return signed, exp, err
+}
+
+// ParseMFAToken validates an MFA challenge token and returns the user id it was issued for
+func ParseMFAToken(tokenStr string) (string, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &MFAClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return jwtKey, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, ok := token.Claims.(*MFAClaims)
+	if !ok || !token.Valid || claims.TokenUse != TokenUseMFA {
+		return "", fmt.Errorf("invalid mfa token")
+	}
+	return claims.Subject, nil
+}
+
 func generateJTI() string {
 	b := make([]byte, 16)
 	_, _ = rand.Read(b)
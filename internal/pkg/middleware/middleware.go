@@ -2,41 +2,187 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+	"log"
 	"net/http"
 	"strings"
 )
 
 type contextKey string
 
-const ContextUserKey contextKey = "auth_user"
+const (
+	ContextUserKey   contextKey = "auth_user"
+	ContextClaimsKey contextKey = "auth_claims"
+)
 
-func JWTMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
+// ErrNoCredentials is returned by an Authenticator when the request carries no credentials it understands
+var ErrNoCredentials = errors.New("missing credentials")
+
+// APIKeyHeader is the header carrying an API key, as an alternative to the Authorization header
+const APIKeyHeader = "X-API-Key"
+
+// Cookies and header of browser sessions. The CSRF cookie is readable by scripts, which echo it
+// in CSRFHeader on state-changing requests (double-submit).
+const (
+	AccessCookie  = "gwi_access"
+	RefreshCookie = "gwi_refresh"
+	CSRFCookie    = "gwi_csrf"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// ErrCSRFTokenMismatch is returned when a cookie authenticated request changing state lacks a matching CSRF token
+var ErrCSRFTokenMismatch = errors.New("missing or invalid CSRF token")
+
+// Authenticator resolves the credentials of a request into claims
+type Authenticator interface {
+	Authenticate(r *http.Request) (*helper.CustomClaims, error)
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator
+type AuthenticatorFunc func(r *http.Request) (*helper.CustomClaims, error)
+
+// Authenticate calls f(r)
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*helper.CustomClaims, error) {
+	return f(r)
+}
+
+// Authenticate tries each authenticator in turn and stores the claims of the first one
+// recognising the credentials in the request context. An authenticator that recognises
+// the credentials but rejects them ends the chain with 401.
+func Authenticate(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				claims, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if errors.Is(err, ErrCSRFTokenMismatch) {
+					http.Error(w, err.Error(), http.StatusForbidden)
+					return
+				}
+				if err != nil {
+					http.Error(w, "invalid token: "+err.Error(), http.StatusUnauthorized)
+					return
+				}
+
+				if claims.Act != nil {
+					log.Printf("impersonation: admin %s acting as user %s: %s %s request_id=%s",
+						claims.Act.Subject, claims.UserID, r.Method, r.URL.Path, RequestIDFromContext(r.Context()))
+				}
+
+				ctx := context.WithValue(r.Context(), ContextUserKey, claims.UserID)
+				ctx = context.WithValue(ctx, ContextClaimsKey, claims)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
 			http.Error(w, "missing Authorization header", http.StatusUnauthorized)
-			return
+		})
+	}
+}
+
+// JWTAuthenticator accepts access tokens of users and clients sent as "Authorization: Bearer <jwt>"
+func JWTAuthenticator() Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*helper.CustomClaims, error) {
+		tokenStr, err := bearerToken(r)
+		if err != nil {
+			return nil, err
 		}
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "invalid Authorization format", http.StatusUnauthorized)
-			return
+		if strings.HasPrefix(tokenStr, helper.APIKeyPrefix) {
+			return nil, ErrNoCredentials
 		}
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+		return helper.ParseAndValidateToken(tokenStr)
+	})
+}
 
-		claims, err := helper.ParseAndValidateToken(tokenStr)
-		if err != nil {
-			http.Error(w, "invalid token: "+err.Error(), http.StatusUnauthorized)
-			return
+// APIKeyAuthenticator accepts API keys sent in the X-API-Key header or as a bearer token,
+// resolving them with lookup
+func APIKeyAuthenticator(lookup func(key string) (*helper.CustomClaims, error)) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*helper.CustomClaims, error) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			tokenStr, err := bearerToken(r)
+			if err != nil {
+				return nil, err
+			}
+			key = tokenStr
 		}
+		if !strings.HasPrefix(key, helper.APIKeyPrefix) {
+			return nil, ErrNoCredentials
+		}
+		return lookup(key)
+	})
+}
 
-		ctx := context.WithValue(r.Context(), ContextUserKey, claims.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// CookieAuthenticator accepts access tokens of browser sessions sent in the access cookie.
+// Being sent by the browser on its own, the cookie is only trusted for state-changing
+// requests carrying the CSRF token.
+func CookieAuthenticator() Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*helper.CustomClaims, error) {
+		cookie, err := r.Cookie(AccessCookie)
+		if err != nil || cookie.Value == "" {
+			return nil, ErrNoCredentials
+		}
+		if err := CheckCSRF(r); err != nil {
+			return nil, err
+		}
+		return helper.ParseAndValidateToken(cookie.Value)
 	})
 }
 
+// CheckCSRF requires the CSRF header of state-changing requests to match the CSRF cookie
+func CheckCSRF(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return ErrCSRFTokenMismatch
+	}
+	header := r.Header.Get(CSRFHeader)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+		return ErrCSRFTokenMismatch
+	}
+	return nil
+}
+
+// bearerToken returns the token of the Authorization header
+func bearerToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", ErrNoCredentials
+	}
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", fmt.Errorf("invalid Authorization format")
+	}
+	return strings.TrimPrefix(authHeader, "Bearer "), nil
+}
+
+// JWTMiddleware only accepts access tokens
+func JWTMiddleware(next http.Handler) http.Handler {
+	return Authenticate(JWTAuthenticator())(next)
+}
+
+// UserIDFromContext returns the authenticated user id stored by JWTMiddleware
+func UserIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	userIDStr, ok := ctx.Value(ContextUserKey).(string)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("missing user in token")
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID format in token")
+	}
+	return userID, nil
+}
+
 func ClaimsFromContext(ctx context.Context) *helper.CustomClaims {
-	if v := ctx.Value(ContextUserKey); v != nil {
+	if v := ctx.Value(ContextClaimsKey); v != nil {
 		if c, ok := v.(*helper.CustomClaims); ok {
 			return c
 		}
@@ -68,3 +214,51 @@ func RequireRole(role string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// RequireScope rejects requests whose claims are limited to scopes not including scope.
+// Access tokens of users carry no scopes and are never limited.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := ClaimsFromContext(r.Context())
+			if claims == nil || !HasScope(claims, scope) {
+				http.Error(w, "insufficient scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession only lets interactive user sessions through, so API keys and
+// service clients cannot manage credentials or administer the service
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := ClaimsFromContext(r.Context())
+		if claims == nil || !isSession(claims) {
+			http.Error(w, "forbidden for api keys and clients", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func HasScope(c *helper.CustomClaims, scope string) bool {
+	if c == nil {
+		return false
+	}
+	if isSession(c) {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// isSession reports whether the claims belong to an access token of a logged in user
+func isSession(c *helper.CustomClaims) bool {
+	return c.TokenUse == "" || c.TokenUse == helper.TokenUseAccess
+}
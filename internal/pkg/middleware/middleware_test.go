@@ -0,0 +1,138 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate_Cookies(t *testing.T) {
+	access, err := helper.GenerateAccessToken("0b3b0a36-52a4-4c9e-9b47-2f2fd0cfb0a1", []string{"user"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		bearer     string
+		cookies    map[string]string
+		csrfHeader string
+		wantStatus int
+	}{
+		{name: "no credentials", method: http.MethodGet, wantStatus: http.StatusUnauthorized},
+		{name: "cookie on a safe method", method: http.MethodGet, cookies: map[string]string{middleware.AccessCookie: access}, wantStatus: http.StatusOK},
+		{
+			name: "cookie with matching csrf token", method: http.MethodPost,
+			cookies:    map[string]string{middleware.AccessCookie: access, middleware.CSRFCookie: "csrf"},
+			csrfHeader: "csrf", wantStatus: http.StatusOK,
+		},
+		{
+			name: "cookie without csrf header", method: http.MethodPost,
+			cookies:    map[string]string{middleware.AccessCookie: access, middleware.CSRFCookie: "csrf"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "cookie with mismatching csrf header", method: http.MethodDelete,
+			cookies:    map[string]string{middleware.AccessCookie: access, middleware.CSRFCookie: "csrf"},
+			csrfHeader: "other", wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "csrf header without csrf cookie", method: http.MethodPut,
+			cookies:    map[string]string{middleware.AccessCookie: access},
+			csrfHeader: "csrf", wantStatus: http.StatusForbidden,
+		},
+		{name: "invalid cookie", method: http.MethodGet, cookies: map[string]string{middleware.AccessCookie: "garbage"}, wantStatus: http.StatusUnauthorized},
+		{
+			name: "bearer token needs no csrf token", method: http.MethodPost, bearer: access,
+			cookies:    map[string]string{middleware.AccessCookie: "garbage"},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	handler := middleware.Authenticate(middleware.JWTAuthenticator(), middleware.CookieAuthenticator())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NotNil(t, middleware.ClaimsFromContext(r.Context()))
+		}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/me/sessions", nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			for name, value := range tt.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(middleware.CSRFHeader, tt.csrfHeader)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "generated when missing", incoming: ""},
+		{name: "valid id is kept", incoming: "abc-123_x.y", keep: true},
+		{name: "unsafe id is replaced", incoming: "abc\r\ninjected"},
+		{name: "long id is replaced", incoming: strings.Repeat("a", 65)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = middleware.RequestIDFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rec.Header().Get(middleware.RequestIDHeader))
+			if tt.keep {
+				assert.Equal(t, tt.incoming, seen)
+			} else {
+				assert.NotEqual(t, tt.incoming, seen)
+			}
+		})
+	}
+}
+
+func TestAuditSource_Impersonation(t *testing.T) {
+	admin, target := "0b3b0a36-52a4-4c9e-9b47-2f2fd0cfb0a1", "6f1d7c1e-0d7a-4c55-9a37-2b5c3f1f8e11"
+	impersonation, _, err := helper.GenerateImpersonationToken(target, admin, []string{"favorites:read"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var source audit.Source
+	h := middleware.RequestID(middleware.JWTMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source = middleware.AuditSource(r)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+impersonation)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, audit.Actor{Kind: audit.ActorImpersonation, ID: admin, OnBehalfOf: target}, source.Actor)
+	assert.NotEmpty(t, source.RequestID)
+}
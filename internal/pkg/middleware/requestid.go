@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/audit"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/helper"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the id of a request. A valid id sent by the caller is kept, so a
+// request can be followed across services, otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// ContextRequestIDKey is the context key of the request id
+const ContextRequestIDKey contextKey = "request_id"
+
+const requestIDMaxLength = 64
+
+// RequestID stores the id of the request in its context and echoes it in the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ContextRequestIDKey, id)))
+	})
+}
+
+// RequestIDFromContext returns the id stored by RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextRequestIDKey).(string)
+	return id
+}
+
+// AuditSource describes the authenticated principal and the request for the audit log
+func AuditSource(r *http.Request) audit.Source {
+	source := audit.Source{
+		Actor:     audit.Actor{Kind: audit.ActorAnonymous},
+		RequestID: RequestIDFromContext(r.Context()),
+		IP:        helper.ClientIP(r),
+	}
+	claims := ClaimsFromContext(r.Context())
+	switch {
+	case claims == nil:
+	case claims.TokenUse == helper.TokenUseClient:
+		source.Actor = audit.Actor{Kind: audit.ActorClient, ID: claims.ClientID}
+	case claims.TokenUse == helper.TokenUseImpersonation:
+		source.Actor = audit.Actor{Kind: audit.ActorImpersonation, ID: claims.Act.Subject, OnBehalfOf: claims.UserID}
+	case claims.TokenUse == helper.TokenUseAPIKey:
+		source.Actor = audit.Actor{Kind: audit.ActorAPIKey, ID: claims.UserID}
+	default:
+		source.Actor = audit.Actor{Kind: audit.ActorUser, ID: claims.UserID}
+	}
+	return source
+}
+
+// validRequestID accepts short ids of letters, digits, '-', '_' and '.', which are safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > requestIDMaxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
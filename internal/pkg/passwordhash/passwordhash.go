@@ -0,0 +1,244 @@
+// Package passwordhash hashes passwords with argon2id or bcrypt into PHC string format
+// (https://github.com/P-H-C/phc-string-format), e.g.
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+//	$bcrypt$r=12$<bcrypt salt and hash>
+//
+// Hashes of either algorithm verify whatever the configured one is, and NeedsRehash tells
+// whether a hash should be upgraded to the configured algorithm and parameters.
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Argon2id is the argon2id algorithm of RFC 9106, the default
+	Argon2id = "argon2id"
+	// Bcrypt is the bcrypt algorithm
+	Bcrypt = "bcrypt"
+)
+
+// ErrUnsupportedHash is returned when a stored hash is malformed or of an unknown algorithm
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Argon2idParams are the cost parameters of argon2id
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the OWASP password storage recommendation
+var DefaultArgon2idParams = Argon2idParams{Memory: 19 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// Config selects the algorithm and parameters of new hashes
+type Config struct {
+	Algorithm  string
+	Argon2id   Argon2idParams
+	BcryptCost int
+}
+
+// Hasher implements user.PasswordHasher
+type Hasher struct {
+	cfg Config
+	// dummies holds a hash of every algorithm, verified against by VerifyUniform
+	dummies map[string]string
+}
+
+// algorithms lists the supported algorithms
+var algorithms = []string{Argon2id, Bcrypt}
+
+var _ user.PasswordHasher = (*Hasher)(nil)
+
+// New constructor, validating the configuration
+func New(cfg Config) (*Hasher, error) {
+	switch cfg.Algorithm {
+	case Argon2id:
+		p := cfg.Argon2id
+		if p.Memory < 8*uint32(p.Parallelism) || p.Iterations < 1 || p.Parallelism < 1 || p.SaltLength < 8 || p.KeyLength < 16 {
+			return nil, fmt.Errorf("invalid argon2id parameters m=%d,t=%d,p=%d", p.Memory, p.Iterations, p.Parallelism)
+		}
+	case Bcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost %d", cfg.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+
+	h := &Hasher{cfg: cfg, dummies: make(map[string]string, len(algorithms))}
+	// the dummy of the other algorithm uses its configured parameters when valid, the defaults otherwise
+	dummy := Config{Argon2id: cfg.Argon2id, BcryptCost: cfg.BcryptCost}
+	if dummy.Argon2id.Iterations < 1 || dummy.Argon2id.Parallelism < 1 || dummy.Argon2id.SaltLength < 8 || dummy.Argon2id.KeyLength < 16 {
+		dummy.Argon2id = DefaultArgon2idParams
+	}
+	if dummy.BcryptCost < bcrypt.MinCost || dummy.BcryptCost > bcrypt.MaxCost {
+		dummy.BcryptCost = bcrypt.DefaultCost
+	}
+	for _, algorithm := range algorithms {
+		dummy.Algorithm = algorithm
+		hash, err := (&Hasher{cfg: dummy}).Hash("dummy password")
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash the %s dummy: %w", algorithm, err)
+		}
+		h.dummies[algorithm] = hash
+	}
+	return h, nil
+}
+
+// Hash hashes a password with the configured algorithm
+func (h *Hasher) Hash(plainPassword string) (string, error) {
+	if h.cfg.Algorithm == Bcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(plainPassword), h.cfg.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		// $2a$12$<salt and hash> -> $bcrypt$r=12$<salt and hash>
+		parts := strings.Split(string(hashed), "$")
+		return fmt.Sprintf("$%s$r=%d$%s", Bcrypt, h.cfg.BcryptCost, parts[3]), nil
+	}
+
+	p := h.cfg.Argon2id
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(plainPassword), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", Argon2id, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify checks a password against a hash of any supported algorithm
+func (h *Hasher) Verify(hash, plainPassword string) error {
+	parsed, err := parse(hash)
+	if err != nil {
+		return err
+	}
+
+	switch parsed.algorithm {
+	case Bcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(parsed.bcrypt), []byte(plainPassword))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return user.ErrPasswordMismatch
+		}
+		return err
+	default:
+		p := parsed.argon2id
+		key := argon2.IDKey([]byte(plainPassword), parsed.salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(parsed.key)))
+		if subtle.ConstantTimeCompare(key, parsed.key) != 1 {
+			return user.ErrPasswordMismatch
+		}
+		return nil
+	}
+}
+
+// VerifyUniform verifies the password against hash, then against the dummy of every other algorithm.
+// Every call costs one verification per algorithm, so a login against a legacy bcrypt hash, an argon2id
+// hash or an unknown user (an empty hash) takes the same time.
+func (h *Hasher) VerifyUniform(hash, plainPassword string) error {
+	err, verified := user.ErrPasswordMismatch, ""
+	if hash != "" {
+		err = h.Verify(hash, plainPassword)
+		if parsed, parseErr := parse(hash); parseErr == nil {
+			verified = parsed.algorithm
+		}
+	}
+	for _, algorithm := range algorithms {
+		if algorithm != verified {
+			_ = h.Verify(h.dummies[algorithm], plainPassword)
+		}
+	}
+	return err
+}
+
+// NeedsRehash reports whether hash differs from what Hash would produce in algorithm or parameters
+func (h *Hasher) NeedsRehash(hash string) bool {
+	parsed, err := parse(hash)
+	if err != nil || parsed.algorithm != h.cfg.Algorithm {
+		return true
+	}
+	if parsed.algorithm == Bcrypt {
+		return parsed.bcryptCost != h.cfg.BcryptCost
+	}
+	p, want := parsed.argon2id, h.cfg.Argon2id
+	return p.Memory != want.Memory || p.Iterations != want.Iterations || p.Parallelism != want.Parallelism ||
+		len(parsed.salt) != int(want.SaltLength) || len(parsed.key) != int(want.KeyLength)
+}
+
+// b64 is the PHC encoding: standard base64 without padding
+var b64 = base64.RawStdEncoding
+
+type parsedHash struct {
+	algorithm string
+
+	argon2id  Argon2idParams
+	salt, key []byte
+
+	bcrypt     string // in the native $2a$ format
+	bcryptCost int
+}
+
+// parse decodes a PHC hash. Native bcrypt hashes ($2a$, $2b$, $2y$) are accepted as well.
+func parse(hash string) (parsedHash, error) {
+	fields := strings.Split(hash, "$")
+	if len(fields) < 4 || fields[0] != "" {
+		return parsedHash{}, ErrUnsupportedHash
+	}
+
+	switch fields[1] {
+	case "2a", "2b", "2y":
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		return parsedHash{algorithm: Bcrypt, bcrypt: hash, bcryptCost: cost}, nil
+
+	case Bcrypt:
+		cost, err := strconv.Atoi(strings.TrimPrefix(fields[2], "r="))
+		if len(fields) != 4 || !strings.HasPrefix(fields[2], "r=") || err != nil {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		native := fmt.Sprintf("$2a$%02d$%s", cost, fields[3])
+		if _, err := bcrypt.Cost([]byte(native)); err != nil {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		return parsedHash{algorithm: Bcrypt, bcrypt: native, bcryptCost: cost}, nil
+
+	case Argon2id:
+		var version int
+		var p Argon2idParams
+		if len(fields) != 6 {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		salt, err := b64.DecodeString(fields[4])
+		if err != nil {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		key, err := b64.DecodeString(fields[5])
+		if err != nil || len(key) == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+			return parsedHash{}, ErrUnsupportedHash
+		}
+		p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+		return parsedHash{algorithm: Argon2id, argon2id: p, salt: salt, key: key}, nil
+	}
+	return parsedHash{}, ErrUnsupportedHash
+}
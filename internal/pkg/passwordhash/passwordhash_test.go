@@ -0,0 +1,154 @@
+package passwordhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akazantzidis/gwi-ass/internal/domain/user"
+	"github.com/akazantzidis/gwi-ass/internal/pkg/passwordhash"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id keeps the tests fast, production uses DefaultArgon2idParams
+var testArgon2id = passwordhash.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newHasher(t *testing.T, cfg passwordhash.Config) *passwordhash.Hasher {
+	h, err := passwordhash.New(cfg)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return h
+}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    passwordhash.Config
+		prefix string
+	}{
+		{name: "argon2id", cfg: passwordhash.Config{Algorithm: passwordhash.Argon2id, Argon2id: testArgon2id}, prefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+		{name: "bcrypt", cfg: passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost}, prefix: "$bcrypt$r=4$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHasher(t, tt.cfg)
+
+			hash, err := h.Hash("correct horse")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+			assert.False(t, h.NeedsRehash(hash))
+
+			assert.NoError(t, h.Verify(hash, "correct horse"))
+			assert.ErrorIs(t, h.Verify(hash, "wrong horse"), user.ErrPasswordMismatch)
+
+			other, err := h.Hash("correct horse")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NotEqual(t, hash, other, "hashes must be salted")
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	argon := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Argon2id, Argon2id: testArgon2id})
+	stronger := testArgon2id
+	stronger.Iterations = 2
+	strongerArgon := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Argon2id, Argon2id: stronger})
+	weakBcrypt := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+	strongBcrypt := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost + 1})
+
+	argonHash, err := argon.Hash("pw")
+	if !assert.NoError(t, err) {
+		return
+	}
+	bcryptHash, err := weakBcrypt.Hash("pw")
+	if !assert.NoError(t, err) {
+		return
+	}
+	native, err := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name   string
+		hasher *passwordhash.Hasher
+		hash   string
+		want   bool
+	}{
+		{name: "same argon2id parameters", hasher: argon, hash: argonHash, want: false},
+		{name: "weaker argon2id parameters", hasher: strongerArgon, hash: argonHash, want: true},
+		{name: "bcrypt hash with argon2id configured", hasher: argon, hash: bcryptHash, want: true},
+		{name: "argon2id hash with bcrypt configured", hasher: weakBcrypt, hash: argonHash, want: true},
+		{name: "lower bcrypt cost", hasher: strongBcrypt, hash: bcryptHash, want: true},
+		{name: "native bcrypt hash of the same cost", hasher: weakBcrypt, hash: string(native), want: false},
+		{name: "malformed hash", hasher: argon, hash: "plain", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hasher.NeedsRehash(tt.hash))
+		})
+	}
+
+	// every hasher verifies hashes of every supported algorithm
+	for _, h := range []*passwordhash.Hasher{argon, strongBcrypt} {
+		for _, hash := range []string{argonHash, bcryptHash, string(native)} {
+			assert.NoError(t, h.Verify(hash, "pw"))
+		}
+	}
+}
+
+func TestHasher_VerifyUniform(t *testing.T) {
+	h := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Argon2id, Argon2id: testArgon2id, BcryptCost: bcrypt.MinCost})
+	legacy := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Bcrypt, BcryptCost: bcrypt.MinCost})
+
+	argonHash, err := h.Hash("pw")
+	if !assert.NoError(t, err) {
+		return
+	}
+	bcryptHash, err := legacy.Hash("pw")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, hash := range []string{argonHash, bcryptHash} {
+		assert.NoError(t, h.VerifyUniform(hash, "pw"))
+		assert.ErrorIs(t, h.VerifyUniform(hash, "wrong"), user.ErrPasswordMismatch)
+	}
+	assert.ErrorIs(t, h.VerifyUniform("", "dummy password"), user.ErrPasswordMismatch, "no hash never matches, not even the dummies' password")
+	assert.ErrorIs(t, h.VerifyUniform("plain", "pw"), passwordhash.ErrUnsupportedHash)
+}
+
+func TestHasher_VerifyUnsupportedHash(t *testing.T) {
+	h := newHasher(t, passwordhash.Config{Algorithm: passwordhash.Argon2id, Argon2id: testArgon2id})
+
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$md5$abc$def",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+		"$bcrypt$r=x$abc",
+	} {
+		assert.ErrorIs(t, h.Verify(hash, "pw"), passwordhash.ErrUnsupportedHash, hash)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	for _, cfg := range []passwordhash.Config{
+		{Algorithm: "md5"},
+		{Algorithm: passwordhash.Bcrypt, BcryptCost: 3},
+		{Algorithm: passwordhash.Argon2id, Argon2id: passwordhash.Argon2idParams{Memory: 64, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32}},
+	} {
+		_, err := passwordhash.New(cfg)
+		assert.Error(t, err, cfg.Algorithm)
+	}
+}
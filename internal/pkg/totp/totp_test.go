@@ -0,0 +1,56 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// the RFC lists 8 digit codes, the 6 digit codes are their last 6 digits
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := Code(rfcSecret, Step(now))
+
+	step, ok := Validate(rfcSecret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// one step of drift is accepted, two are not
+	_, ok = Validate(rfcSecret, code, now.Add(Period), 1)
+	assert.True(t, ok)
+	_, ok = Validate(rfcSecret, code, now.Add(2*Period), 1)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "000000", now, 1)
+	assert.False(t, ok)
+	_, ok = Validate(rfcSecret, "123", now, 1)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("GWI Favorites", "alice", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/GWI%20Favorites:alice?algorithm=SHA1&digits=6&issuer=GWI+Favorites&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
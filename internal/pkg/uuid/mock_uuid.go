@@ -11,7 +11,7 @@ type MockProvider struct {
 }
 
 // NewUUID returns the mocked uuid
-func (m MockProvider) NewUUID() uuid.UUID {
+func (m *MockProvider) NewUUID() uuid.UUID {
 	args := m.Called()
 	return args.Get(0).(uuid.UUID)
 }
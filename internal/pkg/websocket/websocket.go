@@ -0,0 +1,354 @@
+// Package websocket is a small server side implementation of the WebSocket protocol (RFC 6455): the
+// opening handshake, text and binary messages, and the control frames. Extensions and subprotocols
+// are not negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// acceptGUID is appended to the key of the client to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Message types, the opcodes of the frames starting them
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+
+	opContinuation = 0
+	opClose        = 8
+	opPing         = 9
+	opPong         = 10
+)
+
+// Close codes (RFC 6455 section 7.4.1). Applications use the codes from 4000 to 4999.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+	CloseTryAgainLater   = 1013
+)
+
+// maxControlPayload is the largest payload of a control frame
+const maxControlPayload = 125
+
+var (
+	// ErrClosed is returned when writing to a connection whose closing handshake started
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrMessageTooLarge is returned when a client sends a message larger than the limit of the connection
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+	// ErrProtocol is returned when a client breaks the protocol, the connection is then closed
+	ErrProtocol = errors.New("websocket: protocol error")
+)
+
+// CloseError is returned by ReadMessage when the client closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by the client with %d %s", e.Code, e.Reason)
+}
+
+// Conn is an upgraded connection. Reads must come from a single goroutine, writes are safe from any.
+type Conn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	maxMessageSize int64
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+
+	wmu       sync.Mutex
+	closeSent bool
+}
+
+// Upgrade answers the opening handshake of a WebSocket client and takes over the connection. Messages
+// larger than maxMessageSize are refused. When the request is no handshake it answers with an error
+// status and returns an error.
+func Upgrade(w http.ResponseWriter, r *http.Request, maxMessageSize int64) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("websocket: handshake with method %s", r.Method)
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket handshake expected", http.StatusBadRequest)
+		return nil, errors.New("websocket: not a handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: invalid key")
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	// the deadlines of the server were meant for the request
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	fmt.Fprintf(brw.Writer, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", AcceptKey(key))
+	if err := brw.Writer.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	return &Conn{conn: conn, br: brw.Reader, maxMessageSize: maxMessageSize}, nil
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept answering the Sec-WebSocket-Key of a client
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether a comma separated header has the token, case-insensitively
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReadTimeout closes reads waiting longer than d for a frame, zero waits forever. Control frames count,
+// so a client answering pings is not timed out.
+func (c *Conn) SetReadTimeout(d time.Duration) {
+	c.readTimeout = d
+}
+
+// SetWriteTimeout fails writes taking longer than d, zero waits forever
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.writeTimeout = d
+}
+
+// RemoteAddr returns the address of the client
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage returns the next message of the client. Pings are answered and pongs skipped on the way.
+// When the client closes the connection it returns a *CloseError, after answering the close. A client
+// breaking the protocol or sending a message too large gets a close frame and an error.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil && !errors.Is(err, ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, c.closed(payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "message started within a message", ErrProtocol)
+			}
+			messageType = op
+		case opContinuation:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation without a message", ErrProtocol)
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode", ErrProtocol)
+		}
+
+		if c.maxMessageSize > 0 && int64(len(message)+len(payload)) > c.maxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too large", ErrMessageTooLarge)
+		}
+		message = append(message, payload...)
+		if !fin {
+			continue
+		}
+		if messageType == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8", ErrProtocol)
+		}
+		return messageType, message, nil
+	}
+}
+
+// readFrame reads a frame of the client and unmasks its payload
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	if c.readTimeout > 0 {
+		if err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	op := int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set", ErrProtocol)
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "unmasked frame", ErrProtocol)
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= opClose && (!fin || length > maxControlPayload) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame", ErrProtocol)
+	}
+	// a frame larger than the limit is refused before it is read
+	if c.maxMessageSize > 0 && length > uint64(c.maxMessageSize) {
+		return false, 0, nil, c.fail(CloseMessageTooBig, "message too large", ErrMessageTooLarge)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// closed answers the close frame of the client with its code
+func (c *Conn) closed(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close frame", ErrProtocol)
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !utf8.ValidString(closeErr.Reason) {
+			return c.fail(CloseProtocolError, "invalid close reason", ErrProtocol)
+		}
+	}
+	code := closeErr.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	c.WriteClose(code, "")
+	return closeErr
+}
+
+// fail closes the connection with code after a broken frame, returning err
+func (c *Conn) fail(code int, reason string, err error) error {
+	c.WriteClose(code, reason)
+	return err
+}
+
+// WriteMessage sends a message in a single frame
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// Ping sends a ping, the client answers with a pong
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// WriteClose starts the closing handshake with code and reason. Later writes fail with ErrClosed.
+func (c *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	return c.writeFrame(opClose, payload)
+}
+
+// writeFrame sends an unmasked, final frame
+func (c *Conn) writeFrame(op int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+
+	header := []byte{0x80 | byte(op)}
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if c.writeTimeout > 0 {
+		if err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return err
+		}
+	}
+	buffers := net.Buffers{header, payload}
+	_, err := buffers.WriteTo(c.conn)
+	return err
+}
+
+// Close closes the connection, without a closing handshake when none was started
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,278 @@
+package websocket_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akazantzidis/gwi-ass/internal/pkg/websocket"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const clientKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// client speaks the client side of the protocol over a raw connection
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dial(t *testing.T, url string) *client {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", clientKey)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode) {
+		t.FailNow()
+	}
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return &client{t: t, conn: conn, br: br}
+}
+
+func (c *client) write(fin bool, op byte, payload []byte, masked bool) {
+	first := op
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if masked {
+		mask := []byte{1, 2, 3, 4}
+		frame = append(frame, mask...)
+		for i, b := range payload {
+			frame = append(frame, b^mask[i%4])
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+	_, err := c.conn.Write(frame)
+	if !assert.NoError(c.t, err) {
+		return
+	}
+}
+
+func (c *client) read() (byte, []byte) {
+	var header [2]byte
+	_, err := io.ReadFull(c.br, header[:])
+	if !assert.NoError(c.t, err) {
+		c.t.FailNow()
+	}
+	if !assert.NotZero(c.t, header[0]&0x80, "the server sends final frames") {
+		c.t.FailNow()
+	}
+	if !assert.Zero(c.t, header[1]&0x80, "the server does not mask") {
+		c.t.FailNow()
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		_, err = io.ReadFull(c.br, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, err = io.ReadFull(c.br, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if !assert.NoError(c.t, err) {
+		c.t.FailNow()
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.br, payload)
+	if !assert.NoError(c.t, err) {
+		c.t.FailNow()
+	}
+	return header[0] & 0x0f, payload
+}
+
+func (c *client) readClose() int {
+	op, payload := c.read()
+	if !assert.Equal(c.t, byte(8), op) {
+		c.t.FailNow()
+	}
+	if !assert.GreaterOrEqual(c.t, len(payload), 2) {
+		c.t.FailNow()
+	}
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+// echo upgrades the connection and echoes the messages, reporting the error ending the connection
+func echo(errs chan<- error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, 1024)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := conn.WriteMessage(messageType, message); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}
+}
+
+func TestConn_Messages(t *testing.T) {
+	errs := make(chan error, 1)
+	server := httptest.NewServer(echo(errs))
+	defer server.Close()
+	c := dial(t, server.URL)
+
+	c.write(true, websocket.TextMessage, []byte(`{"type":"ping"}`), true)
+	op, payload := c.read()
+	assert.Equal(t, byte(websocket.TextMessage), op)
+	assert.Equal(t, `{"type":"ping"}`, string(payload))
+
+	// a fragmented message with a ping in between
+	c.write(false, websocket.TextMessage, []byte("hel"), true)
+	c.write(true, 9, []byte("are you there"), true)
+	c.write(true, 0, []byte("lo"), true)
+	op, payload = c.read()
+	assert.Equal(t, byte(10), op, "pong")
+	assert.Equal(t, "are you there", string(payload))
+	op, payload = c.read()
+	assert.Equal(t, byte(websocket.TextMessage), op)
+	assert.Equal(t, "hello", string(payload))
+
+	long := []byte(strings.Repeat("a", 300))
+	c.write(true, websocket.BinaryMessage, long, true)
+	op, payload = c.read()
+	assert.Equal(t, byte(websocket.BinaryMessage), op)
+	assert.Equal(t, long, payload)
+
+	c.write(true, 8, binary.BigEndian.AppendUint16(nil, websocket.CloseGoingAway), true)
+	assert.Equal(t, websocket.CloseGoingAway, c.readClose())
+	var closeErr *websocket.CloseError
+	if !assert.ErrorAs(t, <-errs, &closeErr) {
+		return
+	}
+	assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+}
+
+func TestConn_Violations(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(c *client)
+		code int
+		err  error
+	}{
+		{
+			name: "unmasked frame",
+			send: func(c *client) { c.write(true, websocket.TextMessage, []byte("hi"), false) },
+			code: websocket.CloseProtocolError,
+			err:  websocket.ErrProtocol,
+		},
+		{
+			name: "message too large",
+			send: func(c *client) { c.write(true, websocket.TextMessage, make([]byte, 2000), true) },
+			code: websocket.CloseMessageTooBig,
+			err:  websocket.ErrMessageTooLarge,
+		},
+		{
+			name: "fragments too large",
+			send: func(c *client) {
+				c.write(false, websocket.TextMessage, make([]byte, 600), true)
+				c.write(true, 0, make([]byte, 600), true)
+			},
+			code: websocket.CloseMessageTooBig,
+			err:  websocket.ErrMessageTooLarge,
+		},
+		{
+			name: "continuation without a message",
+			send: func(c *client) { c.write(true, 0, []byte("hi"), true) },
+			code: websocket.CloseProtocolError,
+			err:  websocket.ErrProtocol,
+		},
+		{
+			name: "invalid UTF-8",
+			send: func(c *client) { c.write(true, websocket.TextMessage, []byte{0xff, 0xfe}, true) },
+			code: websocket.CloseInvalidPayload,
+			err:  websocket.ErrProtocol,
+		},
+		{
+			name: "fragmented control frame",
+			send: func(c *client) { c.write(false, 9, nil, true) },
+			code: websocket.CloseProtocolError,
+			err:  websocket.ErrProtocol,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := make(chan error, 1)
+			server := httptest.NewServer(echo(errs))
+			defer server.Close()
+			c := dial(t, server.URL)
+
+			tt.send(c)
+			assert.Equal(t, tt.code, c.readClose())
+			assert.True(t, errors.Is(<-errs, tt.err))
+		})
+	}
+}
+
+func TestUpgrade_Rejected(t *testing.T) {
+	errs := make(chan error, 1)
+	server := httptest.NewServer(echo(errs))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Error(t, <-errs)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", clientKey)
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	resp, err = http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get("Sec-WebSocket-Version"))
+	assert.Error(t, <-errs)
+}